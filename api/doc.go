@@ -1,2 +1,2 @@
-//Package api contains the trasnport layer of the application.
+// Package api contains the trasnport layer of the application.
 package api
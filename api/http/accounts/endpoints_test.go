@@ -73,7 +73,7 @@ func newAuthenticator() auth.Service {
 func newService() accounts.Service {
 	repo := mocks.NewRepository()
 	idp := mocks.NewIdentityProvider()
-	opts := &accounts.Options{Repository: repo, IDP: idp}
+	opts := &accounts.Options{Repository: repo, Fees: mocks.NewFeeRepository(), Penalties: mocks.NewPenaltyRepository(), IDP: idp}
 	return accounts.New(opts)
 }
 
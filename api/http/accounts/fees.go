@@ -0,0 +1,84 @@
+package accounts
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// SetFeeRule handles fee rule changes of an account
+func SetFeeRule(lgger log.Entry, svc accounts.Service) http.Handler {
+	const op errors.Op = "api/http/accounts.SetFeeRule"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		var rule accounts.FeeRule
+
+		err := Decode(r, &rule)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+		defer r.Body.Close()
+
+		vars := mux.Vars(r)
+		rule.Account = vars["id"]
+
+		res, err := svc.SetFeeRule(r.Context(), rule)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// RetrieveFeeRule handles fee rule retrieval, only developers may look
+// at the fee rule of another account
+func RetrieveFeeRule(lgger log.Entry, svc accounts.Service) http.Handler {
+	const op errors.Op = "api/http/accounts.RetrieveFeeRule"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var id = vars["id"]
+
+		creds := auth.CredentialsFromContext(r.Context())
+		if creds == nil || (creds.Role != auth.Dev && creds.Account != id) {
+			err := errors.E(op, "access denied: insufficient privileges", errors.KindAccessDenied)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		res, err := svc.FeeRule(r.Context(), id)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
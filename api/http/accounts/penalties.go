@@ -0,0 +1,93 @@
+package accounts
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// SetPenaltyPolicy handles late payment penalty changes, sector managers
+// only change the policy of their own account
+func SetPenaltyPolicy(lgger log.Entry, svc accounts.Service) http.Handler {
+	const op errors.Op = "api/http/accounts.SetPenaltyPolicy"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		var policy accounts.PenaltyPolicy
+
+		err := Decode(r, &policy)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+		defer r.Body.Close()
+
+		vars := mux.Vars(r)
+		policy.Account = vars["id"]
+
+		creds := auth.CredentialsFromContext(r.Context())
+		if creds == nil || (creds.Role != auth.Dev && creds.Account != policy.Account) {
+			err := errors.E(op, "access denied: insufficient privileges", errors.KindAccessDenied)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		res, err := svc.SetPenaltyPolicy(r.Context(), policy)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// RetrievePenaltyPolicy handles penalty policy retrieval, only developers
+// may look at the penalty policy of another account
+func RetrievePenaltyPolicy(lgger log.Entry, svc accounts.Service) http.Handler {
+	const op errors.Op = "api/http/accounts.RetrievePenaltyPolicy"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var id = vars["id"]
+
+		creds := auth.CredentialsFromContext(r.Context())
+		if creds == nil || (creds.Role != auth.Dev && creds.Account != id) {
+			err := errors.E(op, "access denied: insufficient privileges", errors.KindAccessDenied)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		res, err := svc.PenaltyPolicy(r.Context(), id)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
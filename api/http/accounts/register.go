@@ -55,4 +55,22 @@ func RegisterHandlers(r *mux.Router, opts *HandlerOpts) {
 	r.Handle(ListAccountsRoute, authenticator(LogEntryHandler(List, opts))).
 		Methods(http.MethodGet).
 		Queries("offset", "{offset}", "limit", "{limit}")
+
+	// fees are the platform commission, only developers may change them
+	devs := middleware.Authorize(opts.Logger, auth.Dev)
+
+	r.Handle(FeeRuleRoute, authenticator(devs(LogEntryHandler(SetFeeRule, opts)))).
+		Methods(http.MethodPut)
+
+	r.Handle(FeeRuleRoute, authenticator(LogEntryHandler(RetrieveFeeRule, opts))).
+		Methods(http.MethodGet)
+
+	// late payment penalties are a sector decision
+	managers := middleware.Authorize(opts.Logger, auth.Basic, auth.Admin, auth.Dev)
+
+	r.Handle(PenaltyPolicyRoute, authenticator(managers(LogEntryHandler(SetPenaltyPolicy, opts)))).
+		Methods(http.MethodPut)
+
+	r.Handle(PenaltyPolicyRoute, authenticator(LogEntryHandler(RetrievePenaltyPolicy, opts))).
+		Methods(http.MethodGet)
 }
@@ -7,4 +7,6 @@ const (
 	UpdateAccountRoute     = "/accounts/{id}"
 	DeactivateAccountRoute = "/accounts/{id}"
 	ListAccountsRoute      = "/accounts"
+	FeeRuleRoute           = "/accounts/{id}/fees"
+	PenaltyPolicyRoute     = "/accounts/{id}/penalties"
 )
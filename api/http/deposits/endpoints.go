@@ -0,0 +1,197 @@
+package deposits
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// Import handles the upload of a csv bank statement, the request
+// body is the statement itself.
+func Import(logger log.Entry, svc deposits.Service) http.Handler {
+	const op errors.Op = "api/http/deposits/Import"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		statement := deposits.Statement{
+			Namespace:  namespace(r, query.Get("namespace")),
+			Filename:   query.Get("filename"),
+			UploadedBy: username(r),
+		}
+
+		body := http.MaxBytesReader(w, r.Body, deposits.MaxStatementSize)
+		defer body.Close()
+
+		res, err := svc.Import(r.Context(), statement, body)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusCreated, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Lines lists bank statement lines starting with the most recent
+func Lines(logger log.Entry, svc deposits.Service) http.Handler {
+	const op errors.Op = "api/http/deposits/Lines"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		query := r.URL.Query()
+
+		flts := &deposits.Filters{
+			Namespace: cast.StringPointer(namespace(r, query.Get("namespace"))),
+			Status:    cast.StringPointer(query.Get("status")),
+			Statement: cast.StringPointer(query.Get("statement")),
+			Offset:    offset,
+			Limit:     limit,
+		}
+
+		res, err := svc.Lines(r.Context(), flts)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Resolve settles a line sent for review with the house in the body
+func Resolve(logger log.Entry, svc deposits.Service) http.Handler {
+	const op errors.Op = "api/http/deposits/Resolve"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var body struct {
+			Property string `json:"property"`
+		}
+
+		if err := encoding.Decode(r, &body); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if body.Property == "" {
+			err := errors.E(op, "missing house code", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		res, err := svc.Resolve(r.Context(), vars["id"], namespace(r, ""), body.Property, username(r))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Dismiss closes a line sent for review, the body may give a reason
+func Dismiss(logger log.Entry, svc deposits.Service) http.Handler {
+	const op errors.Op = "api/http/deposits/Dismiss"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var body struct {
+			Reason string `json:"reason,omitempty"`
+		}
+
+		if r.ContentLength != 0 {
+			if err := encoding.Decode(r, &body); err != nil {
+				err = errors.E(op, err)
+				logger.SystemErr(err)
+				encoding.EncodeError(w, errors.Kind(err), err)
+				return
+			}
+		}
+
+		res, err := svc.Dismiss(r.Context(), vars["id"], namespace(r, ""), username(r), body.Reason)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+func namespace(r *http.Request, requested string) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil || creds.Role == auth.Dev {
+		return requested
+	}
+	return creds.Account
+}
+
+func username(r *http.Request) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil {
+		return ""
+	}
+	return creds.Username
+}
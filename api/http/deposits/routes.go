@@ -0,0 +1,9 @@
+package deposits
+
+// bank statement routes
+const (
+	StatementsRoute  = "/payment/statements"
+	LinesRoute       = "/payment/statements/lines"
+	ResolveLineRoute = "/payment/statements/lines/{id}/resolve"
+	DismissLineRoute = "/payment/statements/lines/{id}/dismiss"
+)
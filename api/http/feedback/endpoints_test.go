@@ -105,7 +105,8 @@ func TestRecord(t *testing.T) {
 
 	validData := toJSON(msg)
 
-	res := toJSON(feedback.Message{ID: "1", Title: "title", Body: "body", Creator: "0784677882"})
+	// creators are stored in E.164 format
+	res := toJSON(feedback.Message{ID: "1", Title: "title", Body: "body", Creator: "+250784677882"})
 
 	cases := []struct {
 		desc        string
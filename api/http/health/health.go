@@ -1,13 +1,53 @@
 package health
 
-import "net/http"
+import (
+	"context"
+	"net/http"
 
-// Health indicates the health of the server
-func Health(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+)
+
+// server states reported by the health check
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// Report is the body of the health check
+type Report struct {
+	Status   string                  `json:"status"`
+	Payments []payment.BackendHealth `json:"payment_backends,omitempty"`
+}
+
+// Backends reports the health of the payment backends
+type Backends interface {
+	Backends(ctx context.Context) ([]payment.BackendHealth, error)
+}
+
+// Health indicates the health of the server along with the circuit breakers
+// of the payment backends. An open breaker degrades the server but the check
+// still succeeds, restarting the server wouldn't bring the gateway back.
+func Health(backends Backends) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := Report{Status: StatusOK}
+
+		// clients that don't report their health leave the report empty
+		if health, err := backends.Backends(r.Context()); err == nil {
+			report.Payments = health
+		}
+
+		for _, backend := range report.Payments {
+			if backend.Breaker == payment.BreakerOpen || backend.Breaker == payment.BreakerHalfOpen {
+				report.Status = StatusDegraded
+			}
+		}
+
+		encoding.Encode(w, http.StatusOK, report)
+	}
 }
 
-//Panic simulates panic
+// Panic simulates panic
 func Panic(w http.ResponseWriter, r *http.Request) {
 	panic("panic simulation")
 }
@@ -145,3 +145,28 @@ func MRetrievePayed(lgger log.Entry, svc invoices.Service) http.Handler {
 
 	return http.HandlerFunc(f)
 }
+
+// RetrieveCredit handles retrieval of the credit a property has accumulated
+func RetrieveCredit(lgger log.Entry, svc invoices.Service) http.Handler {
+	const op errors.Op = "api/http/invoices/RetrieveCredit"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.RetrieveCredit(r.Context(), vars["property"])
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+	}
+
+	return http.HandlerFunc(f)
+}
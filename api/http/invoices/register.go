@@ -47,6 +47,10 @@ func RegisterHandlers(r *mux.Router, opts *HandlerOpts) {
 		Methods(http.MethodGet).
 		Queries("property", "{property}", "months", "{months}")
 
+	r.Handle(RetrieveCreditRoute, authenticator(LogEntryHandler(RetrieveCredit, opts))).
+		Methods(http.MethodGet).
+		Queries("property", "{property}")
+
 	r.Handle(MRetrieveAllInvoiceRoute, LogEntryHandler(MRetrieveAll, opts)).Methods(http.MethodGet).
 		Queries("property", "{property}", "months", "{months}")
 
@@ -3,6 +3,7 @@ package invoices
 // invoices routes
 const (
 	RetrieveInvoicesRoute        = "/billing/invoices"
+	RetrieveCreditRoute          = "/billing/credit"
 	MRetrieveAllInvoiceRoute     = "/mobile/billing/invoices"
 	MRetrievePendingInvoiceRoute = "/mobile/billing/invoices/pending"
 	MRetrievePayedInvoiceRoute   = "/mobile/billing/invoices/payed"
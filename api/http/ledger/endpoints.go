@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// TrialBalance handles trial balance requests
+func TrialBalance(lgger log.Entry, svc ledger.Service) http.Handler {
+	const op errors.Op = "api/http/ledger/TrialBalance"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		res, err := svc.TrialBalance(r.Context(), namespace(r))
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Statement handles account statement requests
+func Statement(lgger log.Entry, svc ledger.Service) http.Handler {
+	const op errors.Op = "api/http/ledger/Statement"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 64)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 64)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		res, err := svc.Statement(r.Context(), vars["code"], namespace(r), offset, limit)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// namespace limits the ledger to the account of the caller, developers
+// see every account unless they ask for one
+func namespace(r *http.Request) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds.Role == auth.Dev {
+		return r.URL.Query().Get("namespace")
+	}
+	return creds.Account
+}
@@ -0,0 +1,7 @@
+package ledger
+
+// ledger routes
+const (
+	TrialBalanceRoute = "/ledger/trial-balance"
+	StatementRoute    = "/ledger/accounts/{code}/statement"
+)
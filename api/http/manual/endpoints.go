@@ -0,0 +1,280 @@
+package manual
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// Record handles cash and bank payments recorded by agents
+func Record(logger log.Entry, svc manual.Service) http.Handler {
+	const op errors.Op = "api/http/manual/Record"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		var m manual.Payment
+
+		err := encoding.Decode(r, &m)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		m.RecordedBy = username(r)
+		m.Namespace = namespace(r, m.Namespace)
+
+		res, err := svc.Record(r.Context(), m)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusCreated, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Approve settles the invoices of a pending manual payment
+func Approve(logger log.Entry, svc manual.Service) http.Handler {
+	const op errors.Op = "api/http/manual/Approve"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.Approve(r.Context(), vars["id"], namespace(r, ""), username(r))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Reject closes a pending manual payment, the body may give a reason
+func Reject(logger log.Entry, svc manual.Service) http.Handler {
+	const op errors.Op = "api/http/manual/Reject"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var body struct {
+			Reason string `json:"reason,omitempty"`
+		}
+
+		if r.ContentLength != 0 {
+			if err := encoding.Decode(r, &body); err != nil {
+				err = errors.E(op, err)
+				logger.SystemErr(err)
+				encoding.EncodeError(w, errors.Kind(err), err)
+				return
+			}
+		}
+
+		res, err := svc.Reject(r.Context(), vars["id"], namespace(r, ""), username(r), body.Reason)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Retrieve returns a manual payment with its slip photo
+func Retrieve(logger log.Entry, svc manual.Service) http.Handler {
+	const op errors.Op = "api/http/manual/Retrieve"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.Retrieve(r.Context(), vars["id"], namespace(r, ""))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// List lists manual payments starting with the most recent
+func List(logger log.Entry, svc manual.Service) http.Handler {
+	const op errors.Op = "api/http/manual/List"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		flts := filters(r)
+		flts.Offset = offset
+		flts.Limit = limit
+
+		res, err := svc.List(r.Context(), flts)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Remit records cash an agent handed over to the sector
+func Remit(logger log.Entry, svc manual.Service) http.Handler {
+	const op errors.Op = "api/http/manual/Remit"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		var remittance manual.Remittance
+
+		err := encoding.Decode(r, &remittance)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		remittance.ReceivedBy = username(r)
+		remittance.Namespace = namespace(r, remittance.Namespace)
+
+		res, err := svc.Remit(r.Context(), remittance)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusCreated, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// AgentCash reports the cash each agent still owes to the sector
+func AgentCash(logger log.Entry, svc manual.Service) http.Handler {
+	const op errors.Op = "api/http/manual/AgentCash"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		ns := namespace(r, r.URL.Query().Get("namespace"))
+
+		res, err := svc.AgentCash(r.Context(), ns)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// filters reads the optional filters of the query string. Agents only
+// see what they recorded and only developers look across namespaces.
+func filters(r *http.Request) *manual.Filters {
+	query := r.URL.Query()
+
+	flts := &manual.Filters{
+		Status:     cast.StringPointer(query.Get("status")),
+		RecordedBy: cast.StringPointer(query.Get("agent")),
+		Namespace:  cast.StringPointer(namespace(r, query.Get("namespace"))),
+	}
+
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds != nil && creds.Role == auth.Min {
+		flts.RecordedBy = &creds.Username
+	}
+	return flts
+}
+
+// namespace returns the account of the caller, developers may pick any.
+func namespace(r *http.Request, requested string) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil || creds.Role == auth.Dev {
+		return requested
+	}
+	return creds.Account
+}
+
+// username returns the name of the authenticated caller if any
+func username(r *http.Request) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil {
+		return ""
+	}
+	return creds.Username
+}
@@ -0,0 +1,64 @@
+package manual
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/middleware"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// ProtocolHandler adapts the manual payments service into an http.handler
+type ProtocolHandler func(logger log.Entry, svc manual.Service) http.Handler
+
+// HandlerOpts are the generic options
+// for a ProtocolHandler
+type HandlerOpts struct {
+	Logger        *log.Logger
+	Service       manual.Service
+	Authenticator auth.Service
+}
+
+// LogEntryHandler pulls a log entry from the request context. Thanks to the
+// LogEntryMiddleware, we should have a log entry stored in the context for each
+// request with request-specific fields. This will grab the entry and pass it to
+// the protocol handlers
+func LogEntryHandler(ph ProtocolHandler, opts *HandlerOpts) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		ent := log.EntryFromContext(r.Context())
+		handler := ph(ent, opts.Service)
+		handler.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(f)
+}
+
+// RegisterHandlers ...
+func RegisterHandlers(r *mux.Router, opts *HandlerOpts) {
+	// If true, this would only panic at boot time, static nil checks anyone?
+	if opts == nil || opts.Service == nil || opts.Logger == nil {
+		panic("absolutely unacceptable handler opts")
+	}
+
+	authenticator := middleware.Authenticate(opts.Logger, opts.Authenticator)
+
+	// agents record cash and bank payments that managers of their sector
+	// then review
+	managers := middleware.Authorize(opts.Logger, auth.Basic, auth.Admin, auth.Dev)
+
+	r.Handle(ManualPaymentsRoute, authenticator(LogEntryHandler(Record, opts))).Methods(http.MethodPost)
+
+	r.Handle(ManualPaymentsRoute, authenticator(LogEntryHandler(List, opts))).Methods(http.MethodGet).
+		Queries("offset", "{offset}", "limit", "{limit}")
+
+	r.Handle(AgentCashRoute, authenticator(managers(LogEntryHandler(AgentCash, opts)))).Methods(http.MethodGet)
+
+	r.Handle(RemittancesRoute, authenticator(managers(LogEntryHandler(Remit, opts)))).Methods(http.MethodPost)
+
+	r.Handle(ManualPaymentRoute, authenticator(LogEntryHandler(Retrieve, opts))).Methods(http.MethodGet)
+
+	r.Handle(ApproveManualRoute, authenticator(managers(LogEntryHandler(Approve, opts)))).Methods(http.MethodPost)
+
+	r.Handle(RejectManualRoute, authenticator(managers(LogEntryHandler(Reject, opts)))).Methods(http.MethodPost)
+}
@@ -0,0 +1,11 @@
+package manual
+
+// manual payment routes
+const (
+	ManualPaymentsRoute = "/payment/manual"
+	ManualPaymentRoute  = "/payment/manual/{id}"
+	ApproveManualRoute  = "/payment/manual/{id}/approve"
+	RejectManualRoute   = "/payment/manual/{id}/reject"
+	RemittancesRoute    = "/payment/manual/remittances"
+	AgentCashRoute      = "/payment/manual/cash"
+)
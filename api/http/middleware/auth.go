@@ -47,3 +47,34 @@ func Authenticate(lgger log.Entry, svc auth.Service) mux.MiddlewareFunc {
 		return http.HandlerFunc(f)
 	}
 }
+
+// Authorize only lets through authenticated users holding one of the given roles,
+// it must be chained after Authenticate.
+func Authorize(lgger log.Entry, roles ...string) mux.MiddlewareFunc {
+	const op errors.Op = "api/http/middleware/Authorize"
+
+	return func(h http.Handler) http.Handler {
+		f := func(w http.ResponseWriter, r *http.Request) {
+			creds := auth.CredentialsFromContext(r.Context())
+			if creds == nil {
+				err := errors.E(op, "access denied: missing credentials", errors.KindAccessDenied)
+				lgger.SystemErr(err)
+				encodeErr(w, errors.Kind(err), err)
+				return
+			}
+
+			for _, role := range roles {
+				if creds.Role == role {
+					h.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			err := errors.E(op, "access denied: insufficient privileges", errors.KindAccessDenied)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+		}
+
+		return http.HandlerFunc(f)
+	}
+}
@@ -51,3 +51,46 @@ func TestAuthenticate(t *testing.T) {
 
 	assert.Equal(t, expected, got, fmt.Sprintf("expected: '%d' got '%d'", expected, got))
 }
+
+func TestAuthorize(t *testing.T) {
+	svc := newService()
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	cases := []struct {
+		desc   string
+		roles  []string
+		status int
+	}{
+		{
+			desc:   "authorize user holding an allowed role",
+			roles:  []string{auth.Admin, auth.Dev},
+			status: http.StatusOK,
+		},
+		{
+			desc:   "authorize user without an allowed role",
+			roles:  []string{auth.Admin},
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	token, err := svc.Login(context.Background(), user)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	for _, tc := range cases {
+		r := mux.NewRouter()
+		r.HandleFunc("/test", h)
+
+		r.Use(Authenticate(log.NoOpLogger(), svc), Authorize(log.NoOpLogger(), tc.roles...))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		r.ServeHTTP(w, req)
+
+		got := w.Result().StatusCode
+		assert.Equal(t, tc.status, got, fmt.Sprintf("%s: expected: '%d' got '%d'", tc.desc, tc.status, got))
+	}
+}
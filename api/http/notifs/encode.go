@@ -18,7 +18,7 @@ func encode(w http.ResponseWriter, code int, response interface{}) error {
 	return json.NewEncoder(w).Encode(response)
 }
 
-//encodeError encodes the application error to the http api
+// encodeError encodes the application error to the http api
 func encodeErr(w http.ResponseWriter, code int, err error) {
 	w.Header().Set("Content-Type", "application/json")
 
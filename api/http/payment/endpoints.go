@@ -1,10 +1,13 @@
 package payment
 
 import (
-	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 
+	"github.com/gorilla/mux"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
 	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
@@ -26,6 +29,13 @@ func Pull(logger log.Entry, svc payment.Service) http.Handler {
 			return
 		}
 
+		tx.IdempotencyKey = r.Header.Get(payment.IdempotencyHeader)
+
+		// the method follows the operator of the number when it isn't given
+		if tx.Method == "" {
+			tx.Method = payment.SelectMethod(tx.MSISDN)
+		}
+
 		res, err := svc.Pull(r.Context(), tx)
 		if err != nil {
 			err = errors.E(op, err)
@@ -53,24 +63,30 @@ func ProcessCallBack(logger log.Entry, svc payment.Service) http.Handler {
 	const op errors.Op = "api/http/payment/ProcessCallBack"
 
 	f := func(w http.ResponseWriter, r *http.Request) {
-
-		callback := new(payment.Callback)
-
-		dec := json.NewDecoder(r.Body)
-		dec.UseNumber()
-		if err := dec.Decode(callback); err != nil {
+		signed, err := readSignedCallback(r, ProcessDebitRoute)
+		if err != nil {
 			err = errors.E(op, err, errors.KindBadRequest)
 			logger.SystemErr(err)
 			encoding.EncodeError(w, errors.Kind(err), err)
 			return
 		}
-		defer r.Body.Close()
 
-		err := svc.ProcessHook(r.Context(), *callback)
+		callback, err := svc.VerifyCallback(r.Context(), signed)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		err = svc.ProcessHook(r.Context(), callback)
 		if err != nil {
 			out := msg{
 				Message: err.Error(),
 			}
+			if rerr := svc.ReleaseCallback(r.Context(), callback); rerr != nil {
+				logger.SystemErr(errors.E(op, rerr))
+			}
 			err = errors.E(op, err)
 			logger.SystemErr(errors.E(op, err))
 			encoding.Encode(w, errors.Kind(err), out)
@@ -107,6 +123,18 @@ func Push(logger log.Entry, svc payment.Service) http.Handler {
 			return
 		}
 
+		tx.IdempotencyKey = r.Header.Get(payment.IdempotencyHeader)
+
+		// the method follows the operator of the number when it isn't given
+		if tx.Method == "" {
+			tx.Method = payment.SelectMethod(tx.MSISDN)
+		}
+
+		// payouts are booked against the account of the caller
+		if creds := auth.CredentialsFromContext(r.Context()); creds != nil {
+			tx.Namespace = creds.Account
+		}
+
 		res, err := svc.Push(r.Context(), tx)
 		if err != nil {
 			err = errors.E(op, err)
@@ -129,18 +157,27 @@ func ConfirmPush(logger log.Entry, svc payment.Service) http.Handler {
 	const op errors.Op = "api/http/payment/ConfirmPush"
 
 	f := func(w http.ResponseWriter, r *http.Request) {
+		signed, err := readSignedCallback(r, ProcessCreditRoute)
+		if err != nil {
+			err = errors.E(op, err, errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
 
-		var callback payment.Callback
-
-		if err := encoding.Decode(r, &callback); err != nil {
+		callback, err := svc.VerifyCallback(r.Context(), signed)
+		if err != nil {
 			err = errors.E(op, err)
 			logger.SystemErr(err)
 			encoding.EncodeError(w, errors.Kind(err), err)
 			return
 		}
 
-		err := svc.ConfirmPush(r.Context(), callback)
+		err = svc.ConfirmPush(r.Context(), callback)
 		if err != nil {
+			if rerr := svc.ReleaseCallback(r.Context(), callback); rerr != nil {
+				logger.SystemErr(errors.E(op, rerr))
+			}
 			err = errors.E(op, err)
 			logger.SystemErr(err)
 			encoding.EncodeError(w, errors.Kind(err), err)
@@ -150,3 +187,160 @@ func ConfirmPush(logger log.Entry, svc payment.Service) http.Handler {
 	}
 	return http.HandlerFunc(f)
 }
+
+// RejectedCallbacks lists callbacks that failed signature verification
+func RejectedCallbacks(logger log.Entry, svc payment.Service) http.Handler {
+	const op errors.Op = "api/http/payment/RejectedCallbacks"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encodeErr(w, err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encodeErr(w, err)
+			return
+		}
+
+		res, err := svc.RejectedCallbacks(r.Context(), offset, limit)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(errors.E(op, err))
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Discrepancies lists pending payments that reconciliation couldn't settle
+func Discrepancies(logger log.Entry, svc payment.Service) http.Handler {
+	const op errors.Op = "api/http/payment/Discrepancies"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encodeErr(w, err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encodeErr(w, err)
+			return
+		}
+
+		res, err := svc.Discrepancies(r.Context(), offset, limit)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(errors.E(op, err))
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Backends reports the health of every payment backend
+func Backends(logger log.Entry, svc payment.Service) http.Handler {
+	const op errors.Op = "api/http/payment/Backends"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		res, err := svc.Backends(r.Context())
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(errors.E(op, err))
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Timeline returns the lifecycle events of a payment
+func Timeline(logger log.Entry, svc payment.Service) http.Handler {
+	const op errors.Op = "api/http/payment/Timeline"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.Timeline(r.Context(), vars["ref"], namespace(r, ""))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(errors.E(op, err))
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// readSignedCallback reads the raw callback body along with its signature headers
+func readSignedCallback(r *http.Request, route string) (*payment.SignedCallback, error) {
+	defer r.Body.Close()
+
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := &payment.SignedCallback{
+		Route:     route,
+		Payload:   payload,
+		Signature: r.Header.Get(payment.SignatureHeader),
+		Timestamp: r.Header.Get(payment.TimestampHeader),
+	}
+	return signed, nil
+}
+
+// namespace returns the account of the caller, developers may pick any.
+func namespace(r *http.Request, requested string) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil || creds.Role == auth.Dev {
+		return requested
+	}
+	return creds.Account
+}
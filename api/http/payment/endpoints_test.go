@@ -14,6 +14,8 @@ import (
 
 	"github.com/gorilla/mux"
 	endpoints "github.com/nshimiyimanaamani/paypack-backend/api/http/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/backends/fake"
+	amocks "github.com/nshimiyimanaamani/paypack-backend/core/accounts/mocks"
 	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
 	"github.com/nshimiyimanaamani/paypack-backend/core/nanoid"
 	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
@@ -28,6 +30,7 @@ import (
 
 const (
 	contentType = "application/json"
+	secret      = "callback-secret"
 )
 
 type testRequest struct {
@@ -106,7 +109,7 @@ func TestPull(t *testing.T) {
 			}),
 			status:      http.StatusBadRequest,
 			contentType: contentType,
-			res:         toJSON(map[string]string{"error": "amount doesn't match invoice"}),
+			res:         toJSON(map[string]string{"error": "amount must be greater than zero"}),
 		},
 		{
 			desc: "missing house code",
@@ -204,22 +207,90 @@ func TestConfirm(t *testing.T) {
 	}
 }
 
+func TestFakeGateway(t *testing.T) {
+	cases := []struct {
+		desc    string
+		outcome string
+	}{
+		{desc: "settle successful payment", outcome: fake.Successful},
+		{desc: "settle failed payment", outcome: fake.Failed},
+	}
+
+	for _, tc := range cases {
+		owners, owner := newOwnersStore()
+		properties, property := newPropertiesStore(owner)
+		invoices, invoice := newInvoiceStore(property)
+
+		router := mux.NewRouter()
+		srv := httptest.NewServer(router)
+
+		gateway, err := fake.New(&fake.Options{URL: srv.URL, Secret: secret, Outcome: tc.outcome, Delay: 20 * time.Millisecond})
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		repo := mocks.NewPaymentRepository()
+		svc := newGatewayService(gateway, repo, owners, properties, invoices)
+		endpoints.RegisterHandlers(router, &endpoints.HandlerOpts{Service: svc, Logger: log.NoOpLogger()})
+
+		req := testRequest{
+			client:      srv.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s%s", srv.URL, endpoints.DebitRoute),
+			contentType: contentType,
+			body: strings.NewReader(toJSON(payment.TxRequest{
+				Code:   property.ID,
+				Amount: invoice.Amount,
+				MSISDN: "0785780891",
+				Method: payment.MTN,
+			})),
+		}
+
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, http.StatusOK, res.StatusCode))
+
+		var tx payment.TxResponse
+		err = json.NewDecoder(res.Body).Decode(&tx)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		gateway.Wait()
+		srv.Close()
+
+		payments, err := repo.Find(context.Background(), tx.TxID)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Len(t, payments, 1, tc.desc)
+		assert.Equal(t, tc.outcome, payments[0].Status, fmt.Sprintf("%s: expected status %s got %s", tc.desc, tc.outcome, payments[0].Status))
+	}
+}
+
 func toJSON(data interface{}) string {
 	jsonData, _ := json.Marshal(data)
 	return string(jsonData)
 }
 
 func newService(ws owners.Repository, ps properties.Repository, vc invoices.Repository) payment.Service {
+	return newGatewayService(mocks.NewBackend(), mocks.NewPaymentRepository(), ws, ps, vc)
+}
+
+func newGatewayService(backend payment.Client, repo payment.Repository, ws owners.Repository, ps properties.Repository, vc invoices.Repository) payment.Service {
 	var opts payment.Options
 	opts.Owners = ws
 	opts.Properties = ps
 	opts.Invoices = vc
-	opts.Repository = mocks.NewPaymentRepository()
+	opts.Repository = repo
 	opts.SMS = newSMSService()
 	opts.Idp = mocks.NewIdentityProvider()
-	opts.Backend = mocks.NewBackend()
+	opts.Backend = backend
 	opts.Queue = mocks.NewQueue()
 	opts.Transactions = mocks.NewTransactionsRepository()
+	opts.Callbacks = mocks.NewCallbackRepository()
+	opts.Replays = mocks.NewReplayCache()
+	opts.Discrepancy = mocks.NewDiscrepancyRepository()
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Reversals = mocks.NewReversals()
+	opts.Events = mocks.NewEventRepository()
+	opts.Payouts = mocks.NewPayoutRepository()
+	opts.Fees = amocks.NewFeeRepository()
+	opts.Secret = secret
 	return payment.New(&opts)
 }
 
@@ -76,6 +76,22 @@ func RegisterHandlers(r *mux.Router, opts *HandlerOpts) {
 	r.Handle(TodaySummaryRoute, authenticator(RepoLogEntryHandler(TodaySummary, opts))).Methods(http.MethodGet).
 		Queries("sector", "{sector}", "cell", "{cell}", "village", "{village}", "date", "{date}")
 
+	// raw gateway payloads are only handled by admins
+	admins := middleware.Authorize(opts.Logger, auth.Admin, auth.Dev)
+
+	r.Handle(RejectedCallbacksRoute, authenticator(admins(LogEntryHandler(RejectedCallbacks, opts)))).Methods(http.MethodGet).
+		Queries("offset", "{offset}", "limit", "{limit}")
+
+	r.Handle(DiscrepanciesRoute, authenticator(admins(LogEntryHandler(Discrepancies, opts)))).Methods(http.MethodGet).
+		Queries("offset", "{offset}", "limit", "{limit}")
+
+	r.Handle(BackendsRoute, authenticator(admins(LogEntryHandler(Backends, opts)))).Methods(http.MethodGet)
+
+	// managers follow the payments of their sector
+	managers := middleware.Authorize(opts.Logger, auth.Basic, auth.Admin, auth.Dev)
+
+	r.Handle(TimelineRoute, authenticator(managers(LogEntryHandler(Timeline, opts)))).Methods(http.MethodGet)
+
 	r.Handle(UnpaidHousesRoute, authenticator(RepoLogEntryHandler(UnpaidHouses, opts))).Methods(http.MethodGet).
 		Queries("limit", "{limit}", "offset", "{offset}", "month", "{month}")
 }
@@ -11,5 +11,9 @@ const (
 	TodayTransactionRoutes  = "/payment/reports/today"
 	DailyTransactionsRoutes = "/payment/reports/daily"
 	TodaySummaryRoute       = "/payment/summary/today"
-	UnpaidHousesRoute      	="/payment/unpaid"
+	UnpaidHousesRoute       = "/payment/unpaid"
+	RejectedCallbacksRoute  = "/payment/callbacks/rejected"
+	DiscrepanciesRoute      = "/payment/discrepancies"
+	BackendsRoute           = "/payment/backends"
+	TimelineRoute           = "/payment/{ref}/timeline"
 )
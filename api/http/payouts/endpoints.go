@@ -0,0 +1,106 @@
+package payouts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payouts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
+)
+
+// List lists the payouts of the caller's namespace starting with the most recent
+func List(logger log.Entry, svc payouts.Service) http.Handler {
+	const op errors.Op = "api/http/payouts/List"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		flts := filters(r)
+		flts.Offset = offset
+		flts.Limit = limit
+
+		res, err := svc.List(r.Context(), flts)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Totals sums the payouts of the caller's namespace by status
+func Totals(logger log.Entry, svc payouts.Service) http.Handler {
+	const op errors.Op = "api/http/payouts/Totals"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		res, err := svc.Totals(r.Context(), filters(r))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// filters reads the optional filters of the query string, the namespace
+// is always the caller's unless they are a developer.
+func filters(r *http.Request) *payouts.Filters {
+	query := r.URL.Query()
+
+	return &payouts.Filters{
+		Namespace: cast.StringPointer(namespace(r, query.Get("namespace"))),
+		Status:    cast.StringPointer(query.Get("status")),
+		MSISDN:    cast.StringPointer(msisdn.Normalize(query.Get("phone"))),
+		From:      cast.StringPointer(query.Get("from")),
+		To:        cast.StringPointer(query.Get("to")),
+	}
+}
+
+// namespace returns the account of the caller, developers may pick any.
+func namespace(r *http.Request, requested string) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil || creds.Role == auth.Dev {
+		return requested
+	}
+	return creds.Account
+}
@@ -0,0 +1,7 @@
+package payouts
+
+// payout routes
+const (
+	PayoutsRoute      = "/payment/payouts"
+	PayoutTotalsRoute = "/payment/payouts/totals"
+)
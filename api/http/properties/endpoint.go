@@ -11,6 +11,7 @@ import (
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 )
 
 // Register handles property registration
@@ -193,7 +194,7 @@ func ListBySector(lgger log.Entry, svc properties.Service) http.Handler {
 
 		vars := mux.Vars(r)
 		names := cast.StringPointer(vars["names"])
-		phone := cast.StringPointer(vars["phone"])
+		phone := cast.StringPointer(msisdn.Normalize(vars["phone"]))
 		sector := cast.StringPointer(vars["sector"])
 
 		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
@@ -127,6 +127,9 @@ func TestRegister(t *testing.T) {
 	//fake id
 	property.ID = "1"
 
+	// the billing defaults are filled in on registration
+	property.Billing = properties.DefaultBilling
+
 	cases := []struct {
 		desc        string
 		req         string
@@ -248,9 +251,10 @@ func TestUpdate(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
 
 	res := properties.Property{
-		ID:    saved.ID,
-		Owner: owner,
-		Due:   saved.Due,
+		ID:      saved.ID,
+		Owner:   owner,
+		Due:     saved.Due,
+		Billing: saved.Billing,
 		Address: properties.Address{
 			Sector:  saved.Address.Sector,
 			Cell:    saved.Address.Cell,
@@ -395,9 +399,10 @@ func TestRetrieve(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
 
 	res := properties.Property{
-		ID:    saved.ID,
-		Owner: properties.Owner{ID: saved.Owner.ID},
-		Due:   saved.Due,
+		ID:      saved.ID,
+		Owner:   properties.Owner{ID: saved.Owner.ID},
+		Due:     saved.Due,
+		Billing: saved.Billing,
 		Address: properties.Address{
 			Sector:  saved.Address.Sector,
 			Cell:    saved.Address.Cell,
@@ -591,9 +596,10 @@ func TestListByOwner(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
 
 		res := properties.Property{
-			ID:    saved.ID,
-			Owner: saved.Owner,
-			Due:   saved.Due,
+			ID:      saved.ID,
+			Owner:   saved.Owner,
+			Due:     saved.Due,
+			Billing: saved.Billing,
 			Address: properties.Address{
 				Sector:  saved.Address.Sector,
 				Cell:    saved.Address.Cell,
@@ -700,9 +706,10 @@ func TestListByCell(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
 
 		res := properties.Property{
-			ID:    saved.ID,
-			Owner: owner,
-			Due:   saved.Due,
+			ID:      saved.ID,
+			Owner:   owner,
+			Due:     saved.Due,
+			Billing: saved.Billing,
 			Address: properties.Address{
 				Sector:  saved.Address.Sector,
 				Cell:    saved.Address.Cell,
@@ -808,9 +815,10 @@ func TestListBySector(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
 
 		res := properties.Property{
-			ID:    saved.ID,
-			Owner: owner,
-			Due:   saved.Due,
+			ID:      saved.ID,
+			Owner:   owner,
+			Due:     saved.Due,
+			Billing: saved.Billing,
 			Address: properties.Address{
 				Sector:  saved.Address.Sector,
 				Cell:    saved.Address.Cell,
@@ -917,9 +925,10 @@ func TestListByVillage(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
 
 		res := properties.Property{
-			ID:    saved.ID,
-			Owner: owner,
-			Due:   saved.Due,
+			ID:      saved.ID,
+			Owner:   owner,
+			Due:     saved.Due,
+			Billing: saved.Billing,
 			Address: properties.Address{
 				Sector:  saved.Address.Sector,
 				Cell:    saved.Address.Cell,
@@ -1026,9 +1035,10 @@ func TestListByRecorder(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
 
 		res := properties.Property{
-			ID:    saved.ID,
-			Owner: owner,
-			Due:   saved.Due,
+			ID:      saved.ID,
+			Owner:   owner,
+			Due:     saved.Due,
+			Billing: saved.Billing,
 			Address: properties.Address{
 				Sector:  saved.Address.Sector,
 				Cell:    saved.Address.Cell,
@@ -8,6 +8,7 @@ import (
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 )
 
 // PageMetadata ...
@@ -148,7 +149,7 @@ func MListPropertyBySector(lgger log.Entry, svc properties.Service) http.Handler
 		ctx := r.Context()
 		vars := mux.Vars(r)
 		names := cast.StringPointer(vars["names"])
-		phone := cast.StringPointer(vars["phone"])
+		phone := cast.StringPointer(msisdn.Normalize(vars["phone"]))
 		sector := cast.StringPointer(vars["sector"])
 
 		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
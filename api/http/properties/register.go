@@ -43,6 +43,9 @@ func RegisterHandlers(r *mux.Router, opts *HandlerOpts) {
 
 	authenticator := middleware.Authenticate(opts.Logger, opts.Authenticator)
 
+	// due changes adjust the open invoices of the house
+	managers := middleware.Authorize(opts.Logger, auth.Basic, auth.Admin, auth.Dev)
+
 	r.Handle(RegisterPRoute, authenticator(LogEntryHandler(Register, opts))).
 		Methods(http.MethodPost)
 
@@ -52,6 +55,9 @@ func RegisterHandlers(r *mux.Router, opts *HandlerOpts) {
 	r.Handle(UpdatePRoute, authenticator(LogEntryHandler(Update, opts))).
 		Methods(http.MethodPut)
 
+	r.Handle(ChangeDueRoute, authenticator(managers(LogEntryHandler(ChangeDue, opts)))).
+		Methods(http.MethodPut)
+
 	r.Handle(DeletePRoute, authenticator(LogEntryHandler(Delete, opts))).
 		Methods(http.MethodDelete)
 
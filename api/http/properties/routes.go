@@ -7,6 +7,7 @@ const (
 	UpdatePRoute   = "/properties/{id}"
 	DeletePRoute   = "/properties/{id}"
 	ListPRoute     = "/properties"
+	ChangeDueRoute = "/properties/{id}/due"
 
 	// mobile routes/ temp
 	MRetrievePRoute = "/mobile/properties/{id}"
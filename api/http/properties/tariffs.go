@@ -0,0 +1,74 @@
+package properties
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// tariffRequest is the body of a due change, a missing effective day
+// means the change takes effect today
+type tariffRequest struct {
+	Due           float64 `json:"due"`
+	EffectiveFrom string  `json:"effective_from,omitempty"`
+}
+
+// ChangeDue handles effective-dated changes of the due of a property
+func ChangeDue(lgger log.Entry, svc properties.Service) http.Handler {
+	const op errors.Op = "api/http/properties/ChangeDue"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+
+		var req tariffRequest
+
+		err := Decode(r, &req)
+		if err != nil {
+			err = parseErr(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, err)
+			return
+		}
+
+		effective := time.Now().UTC()
+		if req.EffectiveFrom != "" {
+			effective, err = time.Parse(properties.DayLayout, req.EffectiveFrom)
+			if err != nil {
+				err = errors.E(op, err, "invalid effective day", errors.KindBadRequest)
+				lgger.SystemErr(err)
+				encodeErr(w, err)
+				return
+			}
+		}
+
+		change := properties.TariffChange{
+			Property:      mux.Vars(r)["id"],
+			Due:           req.Due,
+			EffectiveFrom: effective,
+		}
+		if creds := auth.CredentialsFromContext(r.Context()); creds != nil {
+			change.ChangedBy = creds.Username
+		}
+
+		res, err := svc.ChangeDue(r.Context(), change)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, err)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, err)
+			return
+		}
+	}
+
+	return http.HandlerFunc(f)
+}
@@ -0,0 +1,168 @@
+package refunds
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// Request handles refund requests for a completed payment
+func Request(logger log.Entry, svc refunds.Service) http.Handler {
+	const op errors.Op = "api/http/refunds/Request"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		var refund refunds.Refund
+
+		err := encoding.Decode(r, &refund)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		refund.Namespace = namespace(r, "")
+		refund.RequestedBy = username(r)
+
+		res, err := svc.Request(r.Context(), refund)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusCreated, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Approve pays out a pending refund
+func Approve(logger log.Entry, svc refunds.Service) http.Handler {
+	const op errors.Op = "api/http/refunds/Approve"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.Approve(r.Context(), vars["id"], namespace(r, ""), username(r))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Reject closes a pending refund without paying it out
+func Reject(logger log.Entry, svc refunds.Service) http.Handler {
+	const op errors.Op = "api/http/refunds/Reject"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.Reject(r.Context(), vars["id"], namespace(r, ""), username(r))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// List lists the refunds of the caller's namespace starting with the most recent
+func List(logger log.Entry, svc refunds.Service) http.Handler {
+	const op errors.Op = "api/http/refunds/List"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		query := r.URL.Query()
+
+		flts := &refunds.Filters{
+			Namespace: cast.StringPointer(namespace(r, query.Get("namespace"))),
+			Status:    cast.StringPointer(query.Get("status")),
+			Offset:    offset,
+			Limit:     limit,
+		}
+
+		res, err := svc.List(r.Context(), flts)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// namespace returns the account of the caller, developers may pick any.
+func namespace(r *http.Request, requested string) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil || creds.Role == auth.Dev {
+		return requested
+	}
+	return creds.Account
+}
+
+// username returns the name of the authenticated caller if any
+func username(r *http.Request) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil {
+		return ""
+	}
+	return creds.Username
+}
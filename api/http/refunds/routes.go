@@ -0,0 +1,8 @@
+package refunds
+
+// refund routes
+const (
+	RefundsRoute       = "/payment/refunds"
+	ApproveRefundRoute = "/payment/refunds/{id}/approve"
+	RejectRefundRoute  = "/payment/refunds/{id}/reject"
+)
@@ -0,0 +1,156 @@
+package settlements
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// List lists daily settlements starting with the most recent day
+func List(logger log.Entry, svc settlements.Service) http.Handler {
+	const op errors.Op = "api/http/settlements/List"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		query := r.URL.Query()
+
+		for _, key := range []string{"from", "to"} {
+			if day := query.Get(key); day != "" {
+				if _, err := time.Parse(settlements.DayLayout, day); err != nil {
+					err = errors.E(op, err, "invalid "+key+" day", errors.KindBadRequest)
+					logger.SystemErr(err)
+					encoding.EncodeError(w, errors.Kind(err), err)
+					return
+				}
+			}
+		}
+
+		flts := &settlements.Filters{
+			Namespace: cast.StringPointer(namespace(r, query.Get("namespace"))),
+			Status:    cast.StringPointer(query.Get("status")),
+			From:      cast.StringPointer(query.Get("from")),
+			To:        cast.StringPointer(query.Get("to")),
+			Offset:    offset,
+			Limit:     limit,
+		}
+
+		res, err := svc.List(r.Context(), flts)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Retrieve a single settlement once its signature is checked
+func Retrieve(logger log.Entry, svc settlements.Service) http.Handler {
+	const op errors.Op = "api/http/settlements/Retrieve"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.Retrieve(r.Context(), vars["id"])
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		// settlements of other namespaces are as good as missing
+		if res.Namespace != namespace(r, res.Namespace) {
+			err := errors.E(op, "settlement not found", errors.KindNotFound)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Settle computes the settlements of the day given in the query again,
+// yesterday is used when no day is given
+func Settle(logger log.Entry, svc settlements.Service) http.Handler {
+	const op errors.Op = "api/http/settlements/Settle"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		day := time.Now().UTC().AddDate(0, 0, -1)
+
+		if value := r.URL.Query().Get("day"); value != "" {
+			parsed, err := time.Parse(settlements.DayLayout, value)
+			if err != nil {
+				err = errors.E(op, err, "invalid settlement day", errors.KindBadRequest)
+				logger.SystemErr(err)
+				encoding.EncodeError(w, errors.Kind(err), err)
+				return
+			}
+			day = parsed
+		}
+
+		res, err := svc.Settle(r.Context(), day)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+func namespace(r *http.Request, requested string) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil || creds.Role == auth.Dev {
+		return requested
+	}
+	return creds.Account
+}
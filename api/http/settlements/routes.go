@@ -0,0 +1,7 @@
+package settlements
+
+// settlement routes
+const (
+	SettlementsRoute = "/payment/settlements"
+	SettlementRoute  = "/payment/settlements/{id}"
+)
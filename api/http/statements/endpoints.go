@@ -0,0 +1,123 @@
+package statements
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/pdf"
+)
+
+// statement formats
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatPDF  = "pdf"
+)
+
+// Statement handles property statement requests. The period defaults to
+// the current year up to today, ?format= picks json, csv or pdf.
+func Statement(lgger log.Entry, svc statements.Service) http.Handler {
+	const op errors.Op = "api/http/statements/Statement"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		query := r.URL.Query()
+
+		today := time.Now().UTC()
+		from := time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		to := today
+
+		var err error
+
+		if v := query.Get("from"); v != "" {
+			if from, err = time.Parse(statements.DayLayout, v); err != nil {
+				err = errors.E(op, err, "invalid from day", errors.KindBadRequest)
+				lgger.SystemErr(err)
+				encodeErr(w, errors.Kind(err), err)
+				return
+			}
+		}
+
+		if v := query.Get("to"); v != "" {
+			if to, err = time.Parse(statements.DayLayout, v); err != nil {
+				err = errors.E(op, err, "invalid to day", errors.KindBadRequest)
+				lgger.SystemErr(err)
+				encodeErr(w, errors.Kind(err), err)
+				return
+			}
+		}
+
+		format := query.Get("format")
+		switch format {
+		case "":
+			format = formatJSON
+		case formatJSON, formatCSV, formatPDF:
+		default:
+			err = errors.E(op, "unknown statement format", errors.KindBadRequest)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		st, err := svc.Statement(r.Context(), vars["id"], namespace(r), from, to)
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		if format == formatJSON {
+			if err := encode(w, http.StatusOK, st); err != nil {
+				err = errors.E(op, err)
+				lgger.SystemErr(err)
+				encodeErr(w, errors.Kind(err), err)
+			}
+			return
+		}
+
+		var buf bytes.Buffer
+
+		contentType := csvContentType
+		if format == formatPDF {
+			contentType = pdf.ContentType
+			err = renderPDF(&buf, st)
+		} else {
+			err = renderCSV(&buf, st)
+		}
+		if err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		filename := fmt.Sprintf("statement-%s-%s-%s.%s", st.Property,
+			st.From.Format(statements.DayLayout), st.To.Format(statements.DayLayout), format)
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+	return http.HandlerFunc(f)
+}
+
+// namespace limits statements to the houses of the account of the
+// caller, developers see every house
+func namespace(r *http.Request) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds.Role == auth.Dev {
+		return ""
+	}
+	return creds.Account
+}
@@ -0,0 +1,166 @@
+package statements_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	endpoints "github.com/nshimiyimanaamani/paypack-backend/api/http/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	authmocks "github.com/nshimiyimanaamani/paypack-backend/core/auth/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/encrypt"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	token    = "rugwiro.account.dev"
+	property = "1000-4433-3343"
+)
+
+func newAuthenticator() auth.Service {
+	user := auth.Credentials{
+		Username: "username",
+		Password: "password",
+		Role:     auth.Dev,
+		Account:  "account",
+	}
+	opts := &auth.Options{
+		Hasher:    authmocks.NewHasher(),
+		Encrypter: encrypt.None(),
+		Repo:      authmocks.NewRepository(user),
+		JWT:       authmocks.NewJWTProvider(),
+	}
+	return auth.New(opts)
+}
+
+func newServer() *httptest.Server {
+	now := time.Now()
+
+	account := statements.Statement{
+		Property:  property,
+		Owner:     "rugwiro james",
+		Namespace: "account",
+		Due:       1000,
+		Items: []statements.Item{
+			{Kind: statements.Invoice, Ref: "1", Description: "invoice 1", Debit: 1000, CreatedAt: now},
+			{Kind: statements.Payment, Ref: "a5b1c8f2-6a4b-4a64-8b6c-2f1a6a5e0d11", Description: "payment", Credit: 1500, CreatedAt: now},
+		},
+	}
+	svc := statements.New(&statements.Options{Repository: mocks.NewRepository(account)})
+
+	mux := mux.NewRouter()
+	opts := &endpoints.HandlerOpts{
+		Service:       svc,
+		Logger:        log.NoOpLogger(),
+		Authenticator: newAuthenticator(),
+	}
+	endpoints.RegisterHandlers(mux, opts)
+	return httptest.NewServer(mux)
+}
+
+func TestStatement(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+	client := ts.Client()
+
+	cases := []struct {
+		desc        string
+		property    string
+		query       string
+		token       string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{
+			desc:        "retrieve statement of the current year",
+			property:    property,
+			token:       token,
+			status:      http.StatusOK,
+			contentType: "application/json",
+			contains:    `"closing_balance":-500`,
+		},
+		{
+			desc:        "retrieve statement as csv",
+			property:    property,
+			query:       "?format=csv",
+			token:       token,
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			contains:    "payment,a5b1c8f2-6a4b-4a64-8b6c-2f1a6a5e0d11,payment,0.00,1500.00,-500.00",
+		},
+		{
+			desc:        "retrieve statement as pdf",
+			property:    property,
+			query:       "?format=pdf",
+			token:       token,
+			status:      http.StatusOK,
+			contentType: "application/pdf",
+			contains:    "%PDF-",
+		},
+		{
+			desc:        "retrieve statement in an unknown format",
+			property:    property,
+			query:       "?format=xls",
+			token:       token,
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+		},
+		{
+			desc:        "retrieve statement with invalid day",
+			property:    property,
+			query:       "?from=01-01-2021",
+			token:       token,
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+		},
+		{
+			desc:        "retrieve statement of a period ending before it starts",
+			property:    property,
+			query:       "?from=2021-03-01&to=2021-01-01",
+			token:       token,
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+		},
+		{
+			desc:        "retrieve statement of non-existent property",
+			property:    "invalid",
+			token:       token,
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+		},
+		{
+			desc:        "retrieve statement with empty token",
+			property:    property,
+			status:      http.StatusUnauthorized,
+			contentType: "application/json",
+		},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/properties/%s/statement%s", ts.URL, tc.property, tc.query), nil)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		if tc.token != "" {
+			req.Header.Set("Authorization", tc.token)
+		}
+
+		res, err := client.Do(req)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		body, err := ioutil.ReadAll(res.Body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		res.Body.Close()
+
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.contentType, res.Header.Get("Content-Type"), fmt.Sprintf("%s: expected content type %s got %s", tc.desc, tc.contentType, res.Header.Get("Content-Type")))
+		assert.True(t, strings.Contains(string(body), tc.contains), fmt.Sprintf("%s: expected body to contain %s got %s", tc.desc, tc.contains, body))
+	}
+}
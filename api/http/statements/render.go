@@ -0,0 +1,109 @@
+package statements
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/pdf"
+)
+
+const csvContentType = "text/csv"
+
+// renderCSV writes a row per item between the opening and closing
+// balances, amounts keep their cents so that spreadsheets add them up
+func renderCSV(buf *bytes.Buffer, st statements.Statement) error {
+	const op errors.Op = "api/http/statements/renderCSV"
+
+	amount := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	w := csv.NewWriter(buf)
+
+	w.Write([]string{"date", "kind", "ref", "description", "debit", "credit", "balance"})
+	w.Write([]string{st.From.Format(statements.DayLayout), "opening", "", "opening balance", "", "", amount(st.Opening)})
+	for _, item := range st.Items {
+		w.Write([]string{
+			item.CreatedAt.Format(statements.DayLayout),
+			string(item.Kind),
+			item.Ref,
+			item.Description,
+			amount(item.Debit),
+			amount(item.Credit),
+			amount(item.Balance),
+		})
+	}
+	w.Write([]string{st.To.Format(statements.DayLayout), "closing", "", "closing balance", amount(st.Charged), amount(st.Settled), amount(st.Closing)})
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+// renderPDF lays the statement out for printing
+func renderPDF(buf *bytes.Buffer, st statements.Statement) error {
+	period := fmt.Sprintf("%s - %s", st.From.Format("02 Jan 2006"), st.To.Format("02 Jan 2006"))
+
+	doc := pdf.New("Account statement", period)
+
+	doc.Section("Property")
+	doc.Field("Property code", st.Property)
+	doc.Field("Owner", st.Owner)
+	doc.Field("Address", strings.Join([]string{st.Village, st.Cell, st.Sector}, ", "))
+	doc.Field("Monthly due", pdf.Money(st.Due))
+
+	doc.Section("Summary")
+	doc.Field("Opening balance", pdf.Money(st.Opening))
+	doc.Field("Charged", pdf.Money(st.Charged))
+	doc.Field("Paid and waived", pdf.Money(st.Settled))
+	doc.Field("Closing balance", pdf.Money(st.Closing))
+
+	doc.Section("Movements")
+	rows := make([][]string, len(st.Items))
+	for i, item := range st.Items {
+		var debit, credit string
+		if item.Debit > 0 {
+			debit = pdf.Money(item.Debit)
+		}
+		if item.Credit > 0 {
+			credit = pdf.Money(item.Credit)
+		}
+		rows[i] = []string{
+			item.CreatedAt.Format("02 Jan 2006"),
+			string(item.Kind),
+			short(item.Ref),
+			debit,
+			credit,
+			pdf.Money(item.Balance),
+		}
+	}
+	doc.Table([]pdf.Column{
+		{Header: "Date", Width: 25, Align: "L"},
+		{Header: "Kind", Width: 22, Align: "L"},
+		{Header: "Reference", Width: 40, Align: "L"},
+		{Header: "Charged", Width: 28, Align: "R"},
+		{Header: "Paid", Width: 28, Align: "R"},
+		{Header: "Balance", Width: 27, Align: "R"},
+	}, rows)
+
+	if st.Closing < 0 {
+		doc.Text("A negative balance is credit, it settles the invoices to come.")
+	}
+	return doc.Write(buf)
+}
+
+// short cuts long references such as payment ids down to what fits in a
+// table cell
+func short(ref string) string {
+	if len(ref) <= 16 {
+		return ref
+	}
+	return ref[:13] + "..."
+}
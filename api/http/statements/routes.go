@@ -0,0 +1,6 @@
+package statements
+
+// statement routes
+const (
+	StatementRoute = "/properties/{id}/statement"
+)
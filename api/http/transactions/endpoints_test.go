@@ -25,10 +25,11 @@ import (
 )
 
 const (
-	email   = "user@gmail.com"
-	token   = "rugwiro.account.dev"
-	wrong   = "wrong"
-	wrongID = 0
+	email          = "user@gmail.com"
+	token          = "rugwiro.account.dev"
+	wrong          = "wrong"
+	wrongID        = 0
+	receiptsSecret = "secret"
 )
 
 var (
@@ -79,8 +80,10 @@ func newService(tokens map[string]string) transactions.Service {
 	repo := mocks.NewRepository()
 	idp := mocks.NewIdentityProvider()
 	opts := &transactions.Options{
-		Repo: repo,
-		Idp:  idp,
+		Repo:           repo,
+		Idp:            idp,
+		ReceiptsURL:    "https://paypack.rw/api",
+		ReceiptsSecret: receiptsSecret,
 	}
 	return transactions.New(opts)
 }
@@ -478,3 +481,71 @@ func TestMListByProperty(t *testing.T) {
 		assert.ElementsMatch(t, tc.res, data, fmt.Sprintf("%s: expected body '%v' got '%v'", tc.desc, tc.res, data))
 	}
 }
+
+func TestReceipt(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+
+	defer ts.Close()
+	client := ts.Client()
+
+	ctx := context.Background()
+	saved, err := svc.Record(ctx, transaction)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	missing := strconv.FormatUint(wrongID, 10)
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		status      int
+		contentType string
+	}{
+		{
+			desc:        "download receipt of existing transaction",
+			id:          saved.ID,
+			token:       transactions.ReceiptToken(receiptsSecret, saved.ID),
+			status:      http.StatusOK,
+			contentType: "application/pdf",
+		},
+		{
+			desc:        "download receipt with invalid token",
+			id:          saved.ID,
+			token:       wrong,
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+		},
+		{
+			desc:        "download receipt without token",
+			id:          saved.ID,
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+		},
+		{
+			desc:        "download receipt of non-existent transaction",
+			id:          missing,
+			token:       transactions.ReceiptToken(receiptsSecret, missing),
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: client,
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/transactions/%s/receipt?token=%s", ts.URL, tc.id, tc.token),
+		}
+
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.contentType, res.Header.Get("Content-Type"), tc.desc)
+		if tc.status == http.StatusOK {
+			assert.True(t, strings.HasPrefix(string(body), "%PDF-"), fmt.Sprintf("%s: expected a pdf document", tc.desc))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package transactions
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/core/transactions"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/pdf"
+)
+
+// Receipt renders the pdf receipt of a transaction, it's linked in the
+// payment sms so it doesn't require authentication but the token the link
+// was signed with.
+func Receipt(lgger log.Entry, svc transactions.Service) http.Handler {
+	const op errors.Op = "api/http/transactions.Receipt"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		receipt, err := svc.Receipt(r.Context(), vars["id"], r.URL.Query().Get("token"))
+		if err != nil {
+			lgger.SystemErr(errors.E(op, err))
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := renderReceipt(&buf, receipt); err != nil {
+			err = errors.E(op, err)
+			lgger.SystemErr(err)
+			encodeErr(w, errors.Kind(err), err)
+			return
+		}
+
+		w.Header().Set("Content-Type", pdf.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=receipt-%s.pdf", receipt.ID))
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+	return http.HandlerFunc(f)
+}
+
+func renderReceipt(buf *bytes.Buffer, receipt transactions.Receipt) error {
+	doc := pdf.New("Payment receipt", fmt.Sprintf("Receipt %s", receipt.ID))
+
+	doc.Section("Property")
+	doc.Field("Property code", receipt.Property)
+	doc.Field("Owner", receipt.Owner)
+	doc.Field("Address", strings.Join([]string{receipt.Village, receipt.Cell, receipt.Sector}, ", "))
+
+	doc.Section("Payment")
+	doc.Field("Amount", pdf.Money(receipt.Amount))
+	doc.Field("Method", receipt.Method)
+	if receipt.MSISDN != "" {
+		doc.Field("Paid from", receipt.MSISDN)
+	}
+	doc.Field("Reference", receipt.Ref)
+	doc.Field("Date", receipt.PaidAt.Format("02 Jan 2006 15:04"))
+
+	doc.Section("Invoices")
+	rows := make([][]string, len(receipt.Items))
+	for i, item := range receipt.Items {
+		rows[i] = []string{
+			strconv.FormatUint(item.Invoice, 10),
+			item.Month.Format("January 2006"),
+			pdf.Money(item.Amount),
+		}
+	}
+	doc.Table([]pdf.Column{
+		{Header: "Invoice", Width: 40, Align: "L"},
+		{Header: "Month", Width: 70, Align: "L"},
+		{Header: "Amount", Width: 60, Align: "R"},
+	}, rows)
+
+	// scanning the code shows the copy held by the server
+	if receipt.URL != "" {
+		if err := doc.QR(receipt.URL, "Scan to verify this receipt with paypack", 35); err != nil {
+			return err
+		}
+	}
+	return doc.Write(buf)
+}
@@ -64,4 +64,6 @@ func RegisterHandlers(r *mux.Router, opts *HandlerOpts) {
 	r.Handle(MListTransactionsRoute, LogEntryHandler(MListByProperty, opts)).Methods(http.MethodGet).
 		Queries("property", "{property}")
 
+	r.Handle(ReceiptRoute, LogEntryHandler(Receipt, opts)).Methods(http.MethodGet)
+
 }
@@ -6,4 +6,5 @@ const (
 	RetrieveTransactionRoute = "/transactions/{id}"
 	ListTransactionsRoute    = "/transactions"
 	MListTransactionsRoute   = "/mobile/transactions"
+	ReceiptRoute             = "/transactions/{id}/receipt"
 )
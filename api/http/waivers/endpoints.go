@@ -0,0 +1,275 @@
+package waivers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// request is the body of a waiver request, days are written as 2006-01-02
+type request struct {
+	Property  string  `json:"property"`
+	Namespace string  `json:"namespace,omitempty"`
+	Percent   float64 `json:"percent"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Reason    string  `json:"reason"`
+}
+
+// Request handles waiver requests
+func Request(logger log.Entry, svc waivers.Service) http.Handler {
+	const op errors.Op = "api/http/waivers/Request"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		var req request
+
+		err := encoding.Decode(r, &req)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		from, err := time.Parse(waivers.DayLayout, req.From)
+		if err != nil {
+			err = errors.E(op, err, "invalid from day", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		to, err := time.Parse(waivers.DayLayout, req.To)
+		if err != nil {
+			err = errors.E(op, err, "invalid to day", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		waiver := waivers.Waiver{
+			Property:    req.Property,
+			Namespace:   namespace(r, req.Namespace),
+			Percent:     req.Percent,
+			From:        from,
+			To:          to,
+			Reason:      req.Reason,
+			RequestedBy: username(r),
+		}
+
+		res, err := svc.Request(r.Context(), waiver)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusCreated, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Approve applies a pending waiver
+func Approve(logger log.Entry, svc waivers.Service) http.Handler {
+	const op errors.Op = "api/http/waivers/Approve"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if err := authorize(r, svc, vars["id"]); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		res, err := svc.Approve(r.Context(), vars["id"], username(r))
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Reject closes a pending waiver, the body may give a note
+func Reject(logger log.Entry, svc waivers.Service) http.Handler {
+	const op errors.Op = "api/http/waivers/Reject"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var body struct {
+			Note string `json:"note,omitempty"`
+		}
+
+		if r.ContentLength != 0 {
+			if err := encoding.Decode(r, &body); err != nil {
+				err = errors.E(op, err)
+				logger.SystemErr(err)
+				encoding.EncodeError(w, errors.Kind(err), err)
+				return
+			}
+		}
+
+		if err := authorize(r, svc, vars["id"]); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		res, err := svc.Reject(r.Context(), vars["id"], username(r), body.Note)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// Retrieve handles waiver retrieval
+func Retrieve(logger log.Entry, svc waivers.Service) http.Handler {
+	const op errors.Op = "api/http/waivers/Retrieve"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := svc.Retrieve(r.Context(), vars["id"])
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if ns := namespace(r, res.Namespace); ns != res.Namespace {
+			err = errors.E(op, "waiver not found", errors.KindNotFound)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// List lists waivers starting with the most recent
+func List(logger log.Entry, svc waivers.Service) http.Handler {
+	const op errors.Op = "api/http/waivers/List"
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		offset, err := strconv.ParseUint(vars["offset"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid offset value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		limit, err := strconv.ParseUint(vars["limit"], 10, 32)
+		if err != nil {
+			err = errors.E(op, err, "invalid limit value", errors.KindBadRequest)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		query := r.URL.Query()
+
+		flts := &waivers.Filters{
+			Namespace: cast.StringPointer(namespace(r, query.Get("namespace"))),
+			Property:  cast.StringPointer(query.Get("property")),
+			Status:    cast.StringPointer(query.Get("status")),
+			Offset:    offset,
+			Limit:     limit,
+		}
+
+		res, err := svc.List(r.Context(), flts)
+		if err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+
+		if err := encoding.Encode(w, http.StatusOK, res); err != nil {
+			err = errors.E(op, err)
+			logger.SystemErr(err)
+			encoding.EncodeError(w, errors.Kind(err), err)
+			return
+		}
+	}
+	return http.HandlerFunc(f)
+}
+
+// authorize hides the waivers of other namespaces from reviewers
+func authorize(r *http.Request, svc waivers.Service, id string) error {
+	const op errors.Op = "api/http/waivers/authorize"
+
+	waiver, err := svc.Retrieve(r.Context(), id)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if namespace(r, waiver.Namespace) != waiver.Namespace {
+		return errors.E(op, "waiver not found", errors.KindNotFound)
+	}
+	return nil
+}
+
+// namespace returns the account of the caller, developers may pick any.
+func namespace(r *http.Request, requested string) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil || creds.Role == auth.Dev {
+		return requested
+	}
+	return creds.Account
+}
+
+func username(r *http.Request) string {
+	creds := auth.CredentialsFromContext(r.Context())
+	if creds == nil {
+		return ""
+	}
+	return creds.Username
+}
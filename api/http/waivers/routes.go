@@ -0,0 +1,9 @@
+package waivers
+
+// waiver routes
+const (
+	WaiversRoute       = "/waivers"
+	WaiverRoute        = "/waivers/{id}"
+	ApproveWaiverRoute = "/waivers/{id}/approve"
+	RejectWaiverRoute  = "/waivers/{id}/reject"
+)
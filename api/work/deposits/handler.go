@@ -0,0 +1,38 @@
+package deposits
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// StatementsHandler matches imported bank statement lines with houses
+func StatementsHandler(lgger log.Entry, svc deposits.Service) asynq.Handler {
+	const op errors.Op = "api/work/StatementsHandler"
+
+	f := func(ctx context.Context, task *asynq.Task) error {
+		var payload = task.Payload
+
+		batch, err := payload.GetInt("batch")
+		if err != nil {
+			err := errors.E(op, err, errors.KindBadRequest)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		report, err := svc.Match(ctx, uint64(batch))
+		if err != nil {
+			err := errors.E(op, err)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		lgger.Infof("checked %d statement lines: %d matched, %d sent for review", report.Checked, report.Matched, report.Review)
+		return nil
+	}
+
+	return asynq.HandlerFunc(f)
+}
@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// DispatchHandler delivers the receipts waiting in the outbox
+func DispatchHandler(lgger log.Entry, svc outbox.Service) asynq.Handler {
+	const op errors.Op = "api/work/DispatchHandler"
+
+	f := func(ctx context.Context, task *asynq.Task) error {
+		var payload = task.Payload
+
+		batch, err := payload.GetInt("batch")
+		if err != nil {
+			err := errors.E(op, err, errors.KindBadRequest)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		sent, err := svc.Dispatch(ctx, batch)
+		if err != nil {
+			err := errors.E(op, err)
+			lgger.SystemErr(err)
+			return err
+		}
+		lgger.Infof("%d receipts sent", sent)
+		return nil
+	}
+
+	return asynq.HandlerFunc(f)
+}
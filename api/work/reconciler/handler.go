@@ -0,0 +1,84 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// ReconcileHandler settles stale pending payments against the gateway
+func ReconcileHandler(lgger log.Entry, svc payment.Service) asynq.Handler {
+	const op errors.Op = "api/work/ReconcileHandler"
+
+	f := func(ctx context.Context, task *asynq.Task) error {
+		var payload = task.Payload
+
+		age, err := payload.GetInt("age")
+		if err != nil {
+			err := errors.E(op, err, errors.KindBadRequest)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		batch, err := payload.GetInt("batch")
+		if err != nil {
+			err := errors.E(op, err, errors.KindBadRequest)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		report, err := svc.Reconcile(ctx, time.Duration(age)*time.Minute, uint64(batch))
+		if err != nil {
+			err := errors.E(op, err)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		lgger.Infof(
+			"reconciled %d payments: %d successful, %d failed, %d still pending, %d discrepancies, %d not checked",
+			report.Checked, report.Successful, report.Failed, report.Pending, len(report.Discrepancies), len(report.Failures),
+		)
+		for _, d := range report.Discrepancies {
+			lgger.Warnf("payment %s: %s (local %s %.2f, gateway %s %.2f)",
+				d.Ref, d.Reason, d.LocalStatus, d.LocalAmount, d.RemoteStatus, d.RemoteAmount)
+		}
+		for _, f := range report.Failures {
+			lgger.Warnf("payment %s could not be reconciled: %s", f.Ref, f.Reason)
+		}
+		return nil
+	}
+
+	return asynq.HandlerFunc(f)
+}
+
+// ExpireHandler gives up on payments that stayed pending for too long
+func ExpireHandler(lgger log.Entry, svc payment.Service) asynq.Handler {
+	const op errors.Op = "api/work/ExpireHandler"
+
+	f := func(ctx context.Context, task *asynq.Task) error {
+		var payload = task.Payload
+
+		age, err := payload.GetInt("age")
+		if err != nil {
+			err := errors.E(op, err, errors.KindBadRequest)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		refs, err := svc.Expire(ctx, time.Duration(age)*time.Minute)
+		if err != nil {
+			err := errors.E(op, err)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		lgger.Infof("expired %d payments pending for more than %d minutes", len(refs), age)
+		return nil
+	}
+
+	return asynq.HandlerFunc(f)
+}
@@ -0,0 +1,53 @@
+package settlements
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
+)
+
+// SettlementsHandler computes the settlements of the day in the task payload
+func SettlementsHandler(lgger log.Entry, svc settlements.Service) asynq.Handler {
+	const op errors.Op = "api/work/SettlementsHandler"
+
+	f := func(ctx context.Context, task *asynq.Task) error {
+		var payload = task.Payload
+
+		value, err := payload.GetString("day")
+		if err != nil {
+			err := errors.E(op, err, errors.KindBadRequest)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		day, err := time.Parse(settlements.DayLayout, value)
+		if err != nil {
+			err := errors.E(op, err, errors.KindBadRequest)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		settled, err := svc.Settle(ctx, day)
+		if err != nil {
+			err := errors.E(op, err)
+			lgger.SystemErr(err)
+			return err
+		}
+
+		var unbalanced int
+		for _, s := range settled {
+			if s.Status == settlements.Unbalanced {
+				unbalanced++
+			}
+		}
+
+		lgger.Infof("settled %d namespaces for %s: %d unbalanced", len(settled), value, unbalanced)
+		return nil
+	}
+
+	return asynq.HandlerFunc(f)
+}
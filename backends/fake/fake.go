@@ -0,0 +1,208 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Callback paths of the api receiving the gateway notifications
+const (
+	DebitCallbackPath  = "/payment/confirm"
+	CreditCallbackPath = "/payment/credit/confirm"
+)
+
+// Outcomes of a fake transaction
+const (
+	Successful = "successful"
+	Failed     = "failed"
+	// Silent transactions stay pending and never call back
+	Silent = "silent"
+)
+
+// Options configures the fake gateway
+type Options struct {
+	// URL is the base url of the api receiving callbacks, e.g. http://localhost:8080
+	URL string
+
+	// Secret signs callbacks the same way the real gateway does
+	Secret string
+
+	// Outcome of every transaction: successful, failed or silent
+	Outcome string
+
+	// Delay before a callback is delivered, it should leave the api
+	// enough time to save the payment
+	Delay time.Duration
+
+	// Client delivers callbacks, http.DefaultClient is used if nil
+	Client *http.Client
+}
+
+// Gateway is an in memory payment.Client that settles transactions
+// by calling back the api, it never moves real money.
+type Gateway struct {
+	url     string
+	secret  string
+	outcome string
+	delay   time.Duration
+	client  *http.Client
+
+	mu  sync.Mutex
+	txs map[string]*payment.Data
+	wg  sync.WaitGroup
+}
+
+// New creates a fake gateway
+func New(opts *Options) (*Gateway, error) {
+	const op errors.Op = "backends/fake/New"
+
+	outcome := opts.Outcome
+	if outcome == "" {
+		outcome = Successful
+	}
+
+	switch outcome {
+	case Successful, Failed, Silent:
+	default:
+		return nil, errors.E(op, fmt.Sprintf("unknown fake outcome %s", outcome), errors.KindUnexpected)
+	}
+
+	if opts.URL == "" && outcome != Silent {
+		return nil, errors.E(op, "missing callback url", errors.KindUnexpected)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Gateway{
+		url:     strings.TrimSuffix(opts.URL, "/"),
+		secret:  opts.Secret,
+		outcome: outcome,
+		delay:   opts.Delay,
+		client:  client,
+		txs:     make(map[string]*payment.Data),
+	}, nil
+}
+
+// Pull debits the payer and calls back the debit endpoint
+func (g *Gateway) Pull(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	return g.accept(tx, "CASHIN", DebitCallbackPath)
+}
+
+// Push credits the payee and calls back the credit endpoint
+func (g *Gateway) Push(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	return g.accept(tx, "CASHOUT", CreditCallbackPath)
+}
+
+// Status returns the transaction as the gateway knows it
+func (g *Gateway) Status(ctx context.Context, ref string) (*payment.Data, error) {
+	const op errors.Op = "backends/fake/Gateway.Status"
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	data, ok := g.txs[ref]
+	if !ok {
+		return nil, errors.E(op, fmt.Sprintf("transaction %s not found", ref), errors.KindNotFound)
+	}
+	out := *data
+	return &out, nil
+}
+
+// Wait blocks until every pending callback was delivered
+func (g *Gateway) Wait() {
+	g.wg.Wait()
+}
+
+func (g *Gateway) accept(tx *payment.TxRequest, kind, path string) (*payment.TxResponse, error) {
+	const op errors.Op = "backends/fake/Gateway.accept"
+
+	if tx.MSISDN == "" || tx.Amount <= 0 {
+		return nil, errors.E(op, "invalid transaction", errors.KindBadRequest)
+	}
+
+	now := time.Now()
+	data := &payment.Data{
+		Ref:     uuid.NewV4().String(),
+		Kind:    kind,
+		Client:  tx.MSISDN,
+		Amount:  tx.Amount,
+		Status:  "pending",
+		Created: &now,
+	}
+
+	res := &payment.TxResponse{
+		TxID:    data.Ref,
+		Status:  data.Status,
+		Message: data.Status,
+		TxState: payment.ToTxState[data.Status],
+	}
+
+	g.mu.Lock()
+	g.txs[data.Ref] = data
+	g.mu.Unlock()
+
+	if g.outcome != Silent {
+		g.wg.Add(1)
+		go g.settle(data.Ref, path)
+	}
+	return res, nil
+}
+
+// settle applies the configured outcome once the delay elapsed and
+// delivers a signed callback. Delivery errors are dropped, payments that
+// never get a callback are picked up by reconciliation.
+func (g *Gateway) settle(ref, path string) {
+	defer g.wg.Done()
+
+	time.Sleep(g.delay)
+
+	now := time.Now()
+
+	g.mu.Lock()
+	data := g.txs[ref]
+	data.Status = g.outcome
+	data.Processed = &now
+	cb := payment.Callback{
+		EventID: uuid.NewV4().String(),
+		Kind:    payment.ProcessedEvent,
+		Data:    *data,
+	}
+	g.mu.Unlock()
+
+	payload, err := json.Marshal(cb)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.url+path, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payment.TimestampHeader, timestamp)
+	req.Header.Set(payment.SignatureHeader, payment.Sign(g.secret, timestamp, payload))
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
+
+var _ payment.Client = (*Gateway)(nil)
@@ -0,0 +1,94 @@
+package fake_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/backends/fake"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "callback-secret"
+
+func TestCallbacks(t *testing.T) {
+	received := make(chan *payment.SignedCallback, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := ioutil.ReadAll(r.Body)
+		received <- &payment.SignedCallback{
+			Route:     r.URL.Path,
+			Payload:   payload,
+			Signature: r.Header.Get(payment.SignatureHeader),
+			Timestamp: r.Header.Get(payment.TimestampHeader),
+		}
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		desc    string
+		outcome string
+		push    bool
+		route   string
+	}{
+		{desc: "call back successful debit", outcome: fake.Successful, route: fake.DebitCallbackPath},
+		{desc: "call back failed debit", outcome: fake.Failed, route: fake.DebitCallbackPath},
+		{desc: "call back successful credit", outcome: fake.Successful, push: true, route: fake.CreditCallbackPath},
+	}
+
+	for _, tc := range cases {
+		gateway, err := fake.New(&fake.Options{URL: srv.URL, Secret: secret, Outcome: tc.outcome})
+		require.Nil(t, err, tc.desc)
+
+		tx := &payment.TxRequest{MSISDN: "0785780891", Amount: 1000, Method: payment.MTN}
+
+		call := gateway.Pull
+		if tc.push {
+			call = gateway.Push
+		}
+
+		res, err := call(context.Background(), tx)
+		require.Nil(t, err, tc.desc)
+		assert.Equal(t, payment.Pending, res.TxState, tc.desc)
+
+		gateway.Wait()
+		signed := <-received
+
+		assert.Equal(t, tc.route, signed.Route, tc.desc)
+		assert.Nil(t, signed.Verify(secret, time.Minute, time.Now()), tc.desc)
+
+		var cb payment.Callback
+		require.Nil(t, json.Unmarshal(signed.Payload, &cb), tc.desc)
+		assert.Equal(t, res.TxID, cb.Data.Ref, tc.desc)
+		assert.Equal(t, tc.outcome, cb.Data.Status, tc.desc)
+
+		data, err := gateway.Status(context.Background(), res.TxID)
+		require.Nil(t, err, tc.desc)
+		assert.Equal(t, tc.outcome, data.Status, tc.desc)
+	}
+}
+
+func TestSilent(t *testing.T) {
+	gateway, err := fake.New(&fake.Options{Outcome: fake.Silent})
+	require.Nil(t, err)
+
+	res, err := gateway.Pull(context.Background(), &payment.TxRequest{MSISDN: "0785780891", Amount: 1000})
+	require.Nil(t, err)
+
+	data, err := gateway.Status(context.Background(), res.TxID)
+	require.Nil(t, err)
+	assert.Equal(t, "pending", data.Status)
+
+	_, err = gateway.Status(context.Background(), "unknown")
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err))
+
+	_, err = gateway.Pull(context.Background(), &payment.TxRequest{MSISDN: "0785780891"})
+	assert.Equal(t, errors.KindBadRequest, errors.Kind(err))
+}
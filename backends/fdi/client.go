@@ -2,9 +2,12 @@ package fdi
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 	"github.com/quarksgroup/paypack-go/paypack"
 	"github.com/quarksgroup/paypack-go/paypack/api"
 )
@@ -14,25 +17,31 @@ const Timeout = 30 * time.Second
 
 type Service struct {
 	client      *api.Client
-	token       *payment.PaymentTk
+	tokens      *tokenSource
 	WebhookMode string
 }
 
+// New logs in to the gateway and keeps the token renewed until Close is called
 func New(cli *api.Client, id, secret, mode string) (srv *Service, err error) {
 
 	srv = &Service{
 		client:      cli,
+		tokens:      newTokenSource(cli, id, secret),
 		WebhookMode: mode,
 	}
 
-	srv.token, err = srv.Login(context.Background(), id, secret)
-	if err != nil {
+	if err := srv.tokens.start(context.Background()); err != nil {
 		return nil, err
 	}
 
 	return srv, nil
 }
 
+// Close stops renewing the gateway token
+func (srv *Service) Close() {
+	srv.tokens.close()
+}
+
 func (srv *Service) Login(ctx context.Context, client_id, client_secret string) (*payment.PaymentTk, error) {
 
 	token, err := srv.client.Login(ctx, client_id, client_secret)
@@ -40,11 +49,7 @@ func (srv *Service) Login(ctx context.Context, client_id, client_secret string)
 	if err != nil {
 		return nil, err
 	}
-	return &payment.PaymentTk{
-		Access:  token.Access,
-		Refresh: token.Refresh,
-		Expires: token.Expires,
-	}, nil
+	return convertToken(token), nil
 }
 
 func (s *Service) Refresh(ctx context.Context, refresh_token string) (*payment.PaymentTk, error) {
@@ -59,29 +64,19 @@ func (s *Service) Refresh(ctx context.Context, refresh_token string) (*payment.P
 		return nil, err
 	}
 
-	return &payment.PaymentTk{
-		Access:  token.Access,
-		Refresh: token.Refresh,
-		Expires: token.Expires,
-	}, nil
+	return convertToken(token), nil
 }
 
 func (srv *Service) Push(ctx context.Context, txn *payment.TxRequest) (out *payment.TxResponse, err error) {
 
-	if !time.Unix(srv.token.Expires, 0).After(time.Now().UTC().Add(-time.Minute)) {
-		srv.token, err = srv.Refresh(ctx, srv.token.Refresh)
-		if err != nil {
-			return nil, err
-		}
+	ctx, err = srv.authorize(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx = context.WithValue(ctx, paypack.TokenKey{}, &paypack.Token{
-		Access: srv.token.Access,
-	})
-
 	tx := &paypack.TransactionRequest{
 		Amount: txn.Amount,
-		Number: txn.MSISDN,
+		Number: local(txn.MSISDN),
 		Mode:   srv.WebhookMode,
 	}
 
@@ -103,20 +98,14 @@ func (srv *Service) Push(ctx context.Context, txn *payment.TxRequest) (out *paym
 
 func (srv *Service) Pull(ctx context.Context, txn *payment.TxRequest) (out *payment.TxResponse, err error) {
 
-	if !time.Unix(srv.token.Expires, 0).After(time.Now().UTC().Add(-time.Minute)) {
-		srv.token, err = srv.Refresh(ctx, srv.token.Refresh)
-		if err != nil {
-			return nil, err
-		}
+	ctx, err = srv.authorize(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx = context.WithValue(ctx, paypack.TokenKey{}, &paypack.Token{
-		Access: srv.token.Access,
-	})
-
 	tx := &paypack.TransactionRequest{
 		Amount: txn.Amount,
-		Number: txn.MSISDN,
+		Number: local(txn.MSISDN),
 		Mode:   srv.WebhookMode,
 	}
 
@@ -136,4 +125,67 @@ func (srv *Service) Pull(ctx context.Context, txn *payment.TxRequest) (out *paym
 	return out, err
 }
 
+// Status looks up the processed event of a transaction, a transaction
+// without one is still pending on the gateway.
+func (srv *Service) Status(ctx context.Context, ref string) (out *payment.Data, err error) {
+	const op errors.Op = "backends/fdi/Service.Status"
+
+	ctx, err = srv.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := srv.client.ListEvents(ctx, paypack.Option(fmt.Sprintf("ref=%s", ref)))
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if len(res.Transactions) == 0 {
+		return nil, errors.E(op, fmt.Sprintf("transaction %s not found", ref), errors.KindNotFound)
+	}
+
+	event := res.Transactions[0]
+	for _, ev := range res.Transactions {
+		if ev.Kind == payment.ProcessedEvent {
+			event = ev
+			break
+		}
+	}
+
+	out = &payment.Data{
+		Ref:       event.Data.Ref,
+		Kind:      event.Data.Kind,
+		Fee:       event.Data.Fee,
+		Client:    event.Data.Client,
+		Amount:    event.Data.Amount,
+		Status:    event.Data.Status,
+		Processed: event.Data.Processed,
+		Commited:  event.Data.Commited,
+	}
+
+	if event.Kind != payment.ProcessedEvent {
+		out.Status = "pending"
+	}
+	return out, nil
+}
+
+// authorize puts a valid access token in ctx for the gateway transport
+func (srv *Service) authorize(ctx context.Context) (context.Context, error) {
+	token, err := srv.tokens.Token(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	return paypack.WithContext(ctx, &paypack.Token{Access: token.Access}), nil
+}
+
 var _ payment.Client = (*Service)(nil)
+
+// local returns the number the way the gateway expects it, numbers that
+// don't parse are passed on as they are
+func local(phone string) string {
+	number, err := msisdn.Parse(phone)
+	if err != nil {
+		return phone
+	}
+	return number.Local()
+}
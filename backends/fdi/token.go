@@ -0,0 +1,234 @@
+package fdi
+
+import (
+	"context"
+	goerrors "errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/quarksgroup/paypack-go/paypack"
+)
+
+const (
+	// Leeway is how long before it expires a token is renewed
+	Leeway = time.Minute
+
+	// RetryInterval is the pause after a background renewal failed
+	RetryInterval = 5 * time.Second
+)
+
+// authenticator issues the tokens authorizing calls to the gateway
+type authenticator interface {
+	Login(ctx context.Context, id, secret string) (*paypack.Token, error)
+	Refresh(ctx context.Context, token *paypack.Token) (*paypack.Token, error)
+}
+
+// tokenSource hands out gateway tokens to concurrent callers.
+//
+// A token is renewed in the background shortly before it expires, callers
+// finding it due renew it themselves. Either way a single renewal is in
+// flight at a time and everyone waiting shares its outcome.
+type tokenSource struct {
+	auth   authenticator
+	id     string
+	secret string
+
+	leeway time.Duration
+	retry  time.Duration
+
+	mu      sync.Mutex
+	token   *payment.PaymentTk
+	pending *renewal
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// renewal is a token request shared by every caller waiting on it
+type renewal struct {
+	done  chan struct{}
+	token *payment.PaymentTk
+	err   error
+}
+
+func newTokenSource(auth authenticator, id, secret string) *tokenSource {
+	return &tokenSource{
+		auth:   auth,
+		id:     id,
+		secret: secret,
+		leeway: Leeway,
+		retry:  RetryInterval,
+		stop:   make(chan struct{}),
+	}
+}
+
+// start logs in and keeps the token renewed until close is called
+func (s *tokenSource) start(ctx context.Context) error {
+	const op errors.Op = "backends/fdi/tokenSource.start"
+
+	if _, err := s.wait(ctx, s.renew()); err != nil {
+		return errors.E(op, err)
+	}
+	go s.run()
+	return nil
+}
+
+// Token returns a token that isn't about to expire
+func (s *tokenSource) Token(ctx context.Context) (*payment.PaymentTk, error) {
+	const op errors.Op = "backends/fdi/tokenSource.Token"
+
+	s.mu.Lock()
+	current := s.token
+	if current != nil && !s.due(current) {
+		s.mu.Unlock()
+		return current, nil
+	}
+	r := s.renewLocked()
+	s.mu.Unlock()
+
+	token, err := s.wait(ctx, r)
+	if err != nil {
+		// the old token is still good for a little while
+		if current != nil && !expired(current) {
+			return current, nil
+		}
+		return nil, errors.E(op, err)
+	}
+	return token, nil
+}
+
+func (s *tokenSource) close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func (s *tokenSource) run() {
+	for {
+		timer := time.NewTimer(s.next())
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		r := s.renew()
+		select {
+		case <-s.stop:
+			return
+		case <-r.done:
+		}
+	}
+}
+
+// next is the pause until the token becomes due, never shorter than the
+// retry interval so that a failing gateway isn't flooded
+func (s *tokenSource) next() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil {
+		return s.retry
+	}
+
+	d := time.Until(time.Unix(s.token.Expires, 0).Add(-s.leeway))
+	if d < s.retry {
+		return s.retry
+	}
+	return d
+}
+
+func (s *tokenSource) renew() *renewal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.renewLocked()
+}
+
+// renewLocked joins the renewal in flight or starts one, s.mu must be held
+func (s *tokenSource) renewLocked() *renewal {
+	if s.pending != nil {
+		return s.pending
+	}
+
+	r := &renewal{done: make(chan struct{})}
+	s.pending = r
+	current := s.token
+
+	// the renewal outlives callers giving up on it, others may be waiting
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+
+		token, err := s.fetch(ctx, current)
+
+		s.mu.Lock()
+		if err == nil {
+			s.token = token
+		}
+		s.pending = nil
+		s.mu.Unlock()
+
+		r.token, r.err = token, err
+		close(r.done)
+	}()
+	return r
+}
+
+// fetch refreshes current and logs in again when the gateway rejects its
+// refresh token
+func (s *tokenSource) fetch(ctx context.Context, current *payment.PaymentTk) (*payment.PaymentTk, error) {
+	const op errors.Op = "backends/fdi/tokenSource.fetch"
+
+	if current != nil && current.Refresh != "" {
+		token, err := s.auth.Refresh(ctx, &paypack.Token{Refresh: current.Refresh})
+		if err == nil {
+			return convertToken(token), nil
+		}
+		if !rejected(err) {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+	}
+
+	token, err := s.auth.Login(ctx, s.id, s.secret)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	return convertToken(token), nil
+}
+
+func (s *tokenSource) wait(ctx context.Context, r *renewal) (*payment.PaymentTk, error) {
+	select {
+	case <-r.done:
+		return r.token, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *tokenSource) due(token *payment.PaymentTk) bool {
+	return !time.Unix(token.Expires, 0).After(time.Now().Add(s.leeway))
+}
+
+func expired(token *payment.PaymentTk) bool {
+	return !time.Unix(token.Expires, 0).After(time.Now())
+}
+
+// rejected tells whether the gateway refused the credentials it was given
+func rejected(err error) bool {
+	var gwErr *paypack.Error
+	if !goerrors.As(err, &gwErr) {
+		return false
+	}
+	return gwErr.Code >= http.StatusBadRequest && gwErr.Code < http.StatusInternalServerError
+}
+
+func convertToken(token *paypack.Token) *payment.PaymentTk {
+	return &payment.PaymentTk{
+		Access:  token.Access,
+		Refresh: token.Refresh,
+		Expires: token.Expires,
+	}
+}
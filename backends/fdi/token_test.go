@@ -0,0 +1,166 @@
+package fdi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quarksgroup/paypack-go/paypack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuth issues numbered tokens valid for ttl
+type fakeAuth struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	delay     time.Duration
+	reject    bool
+	logins    int
+	refreshes int
+	issued    int
+}
+
+func (a *fakeAuth) Login(ctx context.Context, id, secret string) (*paypack.Token, error) {
+	a.mu.Lock()
+	a.logins++
+	a.mu.Unlock()
+
+	return a.issue(), nil
+}
+
+func (a *fakeAuth) Refresh(ctx context.Context, token *paypack.Token) (*paypack.Token, error) {
+	a.mu.Lock()
+	a.refreshes++
+	reject := a.reject
+	a.mu.Unlock()
+
+	if reject {
+		return nil, &paypack.Error{Code: 401, Message: "invalid refresh token"}
+	}
+	return a.issue(), nil
+}
+
+func (a *fakeAuth) issue() *paypack.Token {
+	time.Sleep(a.delay)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.issued++
+	return &paypack.Token{
+		Access:  fmt.Sprintf("access-%d", a.issued),
+		Refresh: fmt.Sprintf("refresh-%d", a.issued),
+		Expires: time.Now().Add(a.ttl).Unix(),
+	}
+}
+
+func (a *fakeAuth) counts() (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.logins, a.refreshes
+}
+
+func (a *fakeAuth) set(ttl time.Duration, reject bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ttl, a.reject = ttl, reject
+}
+
+// hammer asks for a token from n goroutines at once
+func hammer(t *testing.T, src *tokenSource, n int) []string {
+	var wg sync.WaitGroup
+	tokens := make([]string, n)
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := src.Token(context.Background())
+			if err == nil {
+				tokens[i] = token.Access
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+	return tokens
+}
+
+func TestTokenSingleFlight(t *testing.T) {
+	cases := []struct {
+		desc      string
+		reject    bool
+		logins    int
+		refreshes int
+	}{
+		{
+			desc:      "refresh a due token once for all callers",
+			logins:    1,
+			refreshes: 1,
+		},
+		{
+			desc:      "login again once when the refresh token is rejected",
+			reject:    true,
+			logins:    2,
+			refreshes: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		// tokens issued at login are due right away
+		auth := &fakeAuth{ttl: 30 * time.Second, delay: 20 * time.Millisecond}
+
+		src := newTokenSource(auth, "id", "secret")
+		src.retry = time.Hour
+
+		require.Nil(t, src.start(context.Background()), fmt.Sprintf("%s: unexpected login error", tc.desc))
+
+		auth.set(time.Hour, tc.reject)
+
+		tokens := hammer(t, src, 100)
+		for _, token := range tokens {
+			assert.Equal(t, "access-2", token, fmt.Sprintf("%s: expected every caller to get the renewed token", tc.desc))
+		}
+
+		logins, refreshes := auth.counts()
+		assert.Equal(t, tc.logins, logins, fmt.Sprintf("%s: expected %d logins got %d", tc.desc, tc.logins, logins))
+		assert.Equal(t, tc.refreshes, refreshes, fmt.Sprintf("%s: expected %d refreshes got %d", tc.desc, tc.refreshes, refreshes))
+
+		src.close()
+	}
+}
+
+func TestTokenRenewedInBackground(t *testing.T) {
+	auth := &fakeAuth{ttl: 30 * time.Second}
+
+	src := newTokenSource(auth, "id", "secret")
+	src.retry = 10 * time.Millisecond
+	defer src.close()
+
+	require.Nil(t, src.start(context.Background()), "unexpected login error")
+
+	auth.set(time.Hour, false)
+
+	// nobody asks for a token while it is renewed
+	time.Sleep(50 * time.Millisecond)
+
+	logins, refreshes := auth.counts()
+	assert.Equal(t, 1, logins, fmt.Sprintf("expected %d logins got %d", 1, logins))
+	assert.Equal(t, 1, refreshes, fmt.Sprintf("expected %d refreshes got %d", 1, refreshes))
+
+	// callers hammering a fresh token never hit the gateway
+	for _, token := range hammer(t, src, 100) {
+		assert.Equal(t, "access-2", token, "expected the token renewed in background")
+	}
+
+	logins, refreshes = auth.counts()
+	assert.Equal(t, 2, logins+refreshes, fmt.Sprintf("expected %d token requests got %d", 2, logins+refreshes))
+}
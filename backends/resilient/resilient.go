@@ -0,0 +1,372 @@
+package resilient
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/quarksgroup/paypack-go/paypack"
+)
+
+// defaults used when an option is left empty
+const (
+	DefaultTimeout   = 10 * time.Second
+	DefaultRetries   = 2
+	DefaultBackoff   = 200 * time.Millisecond
+	DefaultThreshold = 5
+	DefaultCooldown  = 30 * time.Second
+)
+
+// Options configures the resilient client
+type Options struct {
+	// Name of the backend used in errors
+	Name string
+
+	// Timeout bounds every attempt made to the backend
+	Timeout time.Duration
+
+	// Retries is the number of attempts made after the first one failed,
+	// a negative value turns retries off
+	Retries int
+
+	// Backoff is the pause before the first retry, it doubles on every retry
+	Backoff time.Duration
+
+	// Threshold is the number of consecutive failures opening the breaker
+	Threshold int
+
+	// Cooldown is how long the breaker stays open before a probe goes through
+	Cooldown time.Duration
+}
+
+// Client is a payment.Client guarding another one with per attempt
+// deadlines, bounded retries and a circuit breaker.
+//
+// Debits and credits are retried only when the gateway was never reached
+// so that a payer can't be charged twice, status lookups are read only
+// and are retried on any transient error.
+type Client struct {
+	next payment.Client
+	name string
+
+	timeout   time.Duration
+	retries   int
+	backoff   time.Duration
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    payment.BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New wraps next in a resilient client
+func New(next payment.Client, opts *Options) *Client {
+	c := &Client{
+		next:      next,
+		name:      opts.Name,
+		timeout:   opts.Timeout,
+		retries:   opts.Retries,
+		backoff:   opts.Backoff,
+		threshold: opts.Threshold,
+		cooldown:  opts.Cooldown,
+		state:     payment.BreakerClosed,
+	}
+	if c.timeout == 0 {
+		c.timeout = DefaultTimeout
+	}
+	if c.retries == 0 {
+		c.retries = DefaultRetries
+	}
+	if c.retries < 0 {
+		c.retries = 0
+	}
+	if c.backoff == 0 {
+		c.backoff = DefaultBackoff
+	}
+	if c.threshold == 0 {
+		c.threshold = DefaultThreshold
+	}
+	if c.cooldown == 0 {
+		c.cooldown = DefaultCooldown
+	}
+	return c
+}
+
+// Pull debits the payer, it is retried only when the gateway wasn't reached
+func (c *Client) Pull(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	const op errors.Op = "backends/resilient/Client.Pull"
+
+	var res *payment.TxResponse
+
+	err := c.do(ctx, Unreachable, func(ctx context.Context) (err error) {
+		res, err = c.next.Pull(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return res, nil
+}
+
+// Push credits the payee, it is retried only when the gateway wasn't reached
+func (c *Client) Push(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	const op errors.Op = "backends/resilient/Client.Push"
+
+	var res *payment.TxResponse
+
+	err := c.do(ctx, Unreachable, func(ctx context.Context) (err error) {
+		res, err = c.next.Push(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return res, nil
+}
+
+// Status looks up a transaction, it is retried on any transient error
+func (c *Client) Status(ctx context.Context, ref string) (*payment.Data, error) {
+	const op errors.Op = "backends/resilient/Client.Status"
+
+	var data *payment.Data
+
+	err := c.do(ctx, transient, func(ctx context.Context) (err error) {
+		data, err = c.next.Status(ctx, ref)
+		return err
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return data, nil
+}
+
+// Breaker reports the state of the circuit breaker
+func (c *Client) Breaker() payment.BreakerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == payment.BreakerOpen && time.Since(c.openedAt) >= c.cooldown {
+		return payment.BreakerHalfOpen
+	}
+	return c.state
+}
+
+func (c *Client) do(ctx context.Context, retryable func(error) bool, call func(context.Context) error) error {
+	const op errors.Op = "backends/resilient/Client.do"
+
+	probe, err := c.acquire()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// a probe is a single attempt, the breaker decides on its outcome
+	retries := c.retries
+	if probe {
+		retries = 0
+	}
+
+	backoff := c.backoff
+
+	for attempt := 0; ; attempt++ {
+		actx, cancel := context.WithTimeout(ctx, c.timeout)
+		err = call(actx)
+		slow := actx.Err() == context.DeadlineExceeded
+		cancel()
+
+		// the caller gave up, that says nothing about the backend
+		if ctx.Err() != nil {
+			c.abandon(probe)
+			return errors.E(op, ctx.Err(), errors.KindUnexpected)
+		}
+
+		if err == nil {
+			c.succeeded()
+			return nil
+		}
+
+		if slow {
+			err = errors.E(op, timeoutError(fmt.Sprintf("%s didn't answer within %s", c.backend(), c.timeout)), errors.KindUnexpected)
+		}
+
+		if attempt >= retries || !retryable(err) {
+			break
+		}
+
+		if !sleep(ctx, backoff) {
+			c.abandon(probe)
+			return errors.E(op, ctx.Err(), errors.KindUnexpected)
+		}
+		backoff *= 2
+	}
+
+	if transient(err) {
+		c.failed()
+	} else {
+		// the gateway answered, it is up even if it refused the call
+		c.succeeded()
+	}
+	return err
+}
+
+// acquire checks whether a call may go through and whether it is the probe
+// of a half open breaker
+func (c *Client) acquire() (bool, error) {
+	const op errors.Op = "backends/resilient/Client.acquire"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case payment.BreakerOpen:
+		if time.Since(c.openedAt) < c.cooldown {
+			return false, errors.E(op, breakerError(fmt.Sprintf("%s is unavailable, retry later", c.backend())), errors.KindUnexpected)
+		}
+		c.state = payment.BreakerHalfOpen
+		c.probing = true
+		return true, nil
+	case payment.BreakerHalfOpen:
+		if c.probing {
+			return false, errors.E(op, breakerError(fmt.Sprintf("%s is being probed, retry later", c.backend())), errors.KindUnexpected)
+		}
+		c.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+func (c *Client) succeeded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = payment.BreakerClosed
+	c.failures = 0
+	c.probing = false
+}
+
+func (c *Client) failed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures++
+	c.probing = false
+
+	if c.state == payment.BreakerHalfOpen || c.failures >= c.threshold {
+		c.state = payment.BreakerOpen
+		c.openedAt = time.Now()
+	}
+}
+
+// abandon lets another call probe the backend when the probe was cancelled
+func (c *Client) abandon(probe bool) {
+	if !probe {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probing = false
+}
+
+func (c *Client) backend() string {
+	if c.name == "" {
+		return "payment backend"
+	}
+	return fmt.Sprintf("payment backend %s", c.name)
+}
+
+// Unreachable tells whether err happened before the gateway got the request,
+// retrying it or sending it to another backend can't charge a payer twice
+func Unreachable(err error) bool {
+	err = cause(err)
+
+	var brErr breakerError
+	if goerrors.As(err, &brErr) {
+		return true
+	}
+
+	var gwErr *paypack.Error
+	if goerrors.As(err, &gwErr) {
+		return gwErr.Code == http.StatusTooManyRequests || gwErr.Code == http.StatusServiceUnavailable
+	}
+
+	var dnsErr *net.DNSError
+	if goerrors.As(err, &dnsErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return goerrors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// transient errors are expected to go away on their own
+func transient(err error) bool {
+	if Unreachable(err) || timedOut(err) {
+		return true
+	}
+
+	var gwErr *paypack.Error
+	if goerrors.As(cause(err), &gwErr) {
+		return gwErr.Code >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return goerrors.As(cause(err), &netErr)
+}
+
+func timedOut(err error) bool {
+	if goerrors.Is(cause(err), context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return goerrors.As(cause(err), &netErr) && netErr.Timeout()
+}
+
+// timeoutError is returned when an attempt runs out of time, the gateway
+// may have processed it all the same
+type timeoutError string
+
+func (e timeoutError) Error() string   { return string(e) }
+func (e timeoutError) Timeout() bool   { return true }
+func (e timeoutError) Temporary() bool { return true }
+
+// breakerError is returned when the breaker holds a call back, the
+// gateway never saw it
+type breakerError string
+
+func (e breakerError) Error() string { return string(e) }
+
+// cause digs the original error out of the system errors wrapping it
+func cause(err error) error {
+	for {
+		e, ok := err.(errors.Error)
+		if !ok || e.Err == nil {
+			return err
+		}
+		err = e.Err
+	}
+}
+
+// sleep pauses for d unless ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+var _ payment.Client = (*Client)(nil)
+var _ payment.BreakerReporter = (*Client)(nil)
@@ -0,0 +1,230 @@
+package resilient_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/backends/resilient"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/quarksgroup/paypack-go/paypack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubClient fails with errs in turn and succeeds once they run out
+type stubClient struct {
+	mu    sync.Mutex
+	errs  []error
+	delay time.Duration
+	calls int
+}
+
+func (c *stubClient) Pull(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	if err := c.call(ctx); err != nil {
+		return nil, err
+	}
+	return &payment.TxResponse{TxID: "ref", TxState: "success"}, nil
+}
+
+func (c *stubClient) Push(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	if err := c.call(ctx); err != nil {
+		return nil, err
+	}
+	return &payment.TxResponse{TxID: "ref", TxState: "success"}, nil
+}
+
+func (c *stubClient) Status(ctx context.Context, ref string) (*payment.Data, error) {
+	if err := c.call(ctx); err != nil {
+		return nil, err
+	}
+	return &payment.Data{Ref: ref, Status: "successful"}, nil
+}
+
+func (c *stubClient) call(ctx context.Context) error {
+	c.mu.Lock()
+	c.calls++
+	var err error
+	if len(c.errs) > 0 {
+		err, c.errs = c.errs[0], c.errs[1:]
+	}
+	c.mu.Unlock()
+
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func (c *stubClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+var (
+	errDial     = &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}
+	errBusy     = &paypack.Error{Code: 503, Message: "service unavailable"}
+	errDown     = &paypack.Error{Code: 500, Message: "Something went wrong"}
+	errRejected = &paypack.Error{Code: 400, Message: "invalid number"}
+)
+
+func newClient(stub *stubClient) *resilient.Client {
+	return resilient.New(stub, &resilient.Options{
+		Name:      "stub",
+		Timeout:   50 * time.Millisecond,
+		Retries:   2,
+		Backoff:   time.Millisecond,
+		Threshold: 3,
+		Cooldown:  50 * time.Millisecond,
+	})
+}
+
+func TestRetries(t *testing.T) {
+	cases := []struct {
+		desc   string
+		errs   []error
+		delay  time.Duration
+		status bool
+		calls  int
+		failed bool
+	}{
+		{
+			desc:  "retry debit that didn't reach the gateway",
+			errs:  []error{errDial, errBusy},
+			calls: 3,
+		},
+		{
+			desc:   "give up debit after bounded retries",
+			errs:   []error{errDial, errDial, errDial, errDial},
+			calls:  3,
+			failed: true,
+		},
+		{
+			desc:   "don't retry debit the gateway failed on",
+			errs:   []error{errDown},
+			calls:  1,
+			failed: true,
+		},
+		{
+			desc:   "don't retry debit that timed out",
+			delay:  100 * time.Millisecond,
+			calls:  1,
+			failed: true,
+		},
+		{
+			desc:   "don't retry rejected debit",
+			errs:   []error{errRejected},
+			calls:  1,
+			failed: true,
+		},
+		{
+			desc:   "retry status lookup the gateway failed on",
+			errs:   []error{errDown, errDown},
+			status: true,
+			calls:  3,
+		},
+	}
+
+	for _, tc := range cases {
+		stub := &stubClient{errs: tc.errs, delay: tc.delay}
+		client := newClient(stub)
+
+		var err error
+		if tc.status {
+			_, err = client.Status(context.Background(), "ref")
+		} else {
+			_, err = client.Pull(context.Background(), &payment.TxRequest{})
+		}
+
+		assert.Equal(t, tc.failed, err != nil, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.calls, stub.count(), fmt.Sprintf("%s: expected %d calls got %d", tc.desc, tc.calls, stub.count()))
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	stub := &stubClient{errs: []error{errDown, errDown, errDown, errDown}}
+	client := newClient(stub)
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Pull(ctx, &payment.TxRequest{})
+		require.NotNil(t, err, "expected gateway error")
+	}
+	assert.Equal(t, payment.BreakerOpen, client.Breaker(), "expected breaker to open")
+
+	// an open breaker doesn't bother the gateway
+	_, err := client.Pull(ctx, &payment.TxRequest{})
+	assert.Equal(t, errors.KindUnexpected, errors.Kind(err), fmt.Sprintf("expected kind %d got err: '%v'", errors.KindUnexpected, err))
+	assert.Equal(t, 3, stub.count(), fmt.Sprintf("expected %d calls got %d", 3, stub.count()))
+	assert.True(t, resilient.Unreachable(err), "expected a call held back by the breaker to be unreachable")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, payment.BreakerHalfOpen, client.Breaker(), "expected breaker to be half open")
+
+	// the probe fails and opens the breaker again
+	_, err = client.Pull(ctx, &payment.TxRequest{})
+	require.NotNil(t, err, "expected gateway error")
+	assert.Equal(t, payment.BreakerOpen, client.Breaker(), "expected breaker to open again")
+
+	time.Sleep(60 * time.Millisecond)
+
+	// a single probe goes through while the breaker is half open
+	stub.delay = 20 * time.Millisecond
+
+	var wg sync.WaitGroup
+	results := make([]error, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results[i] = client.Pull(ctx, &payment.TxRequest{})
+		}(i)
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	assert.Nil(t, results[0], fmt.Sprintf("unexpected probe error: '%v'", results[0]))
+	assert.NotNil(t, results[1], "expected call during probe to be rejected")
+	assert.Equal(t, 5, stub.count(), fmt.Sprintf("expected %d calls got %d", 5, stub.count()))
+	assert.Equal(t, payment.BreakerClosed, client.Breaker(), "expected breaker to close")
+}
+
+func TestBreakerIgnoresRejections(t *testing.T) {
+	stub := &stubClient{errs: []error{errRejected, errRejected, errRejected, errRejected}}
+	client := newClient(stub)
+
+	for i := 0; i < 4; i++ {
+		_, err := client.Push(context.Background(), &payment.TxRequest{})
+		require.NotNil(t, err, "expected rejection")
+	}
+	assert.Equal(t, payment.BreakerClosed, client.Breaker(), "expected breaker to stay closed")
+}
+
+func TestUnreachable(t *testing.T) {
+	cases := []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{desc: "dial error", err: errDial, want: true},
+		{desc: "busy gateway", err: errBusy, want: true},
+		{desc: "gateway error", err: errDown, want: false},
+		{desc: "rejected request", err: errRejected, want: false},
+		{desc: "timeout", err: context.DeadlineExceeded, want: false},
+		{desc: "wrapped dial error", err: errors.E(errors.Op("wrapper"), errDial, errors.KindUnexpected), want: true},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, resilient.Unreachable(tc.err), tc.desc)
+	}
+}
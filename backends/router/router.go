@@ -0,0 +1,295 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/backends/resilient"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// DefaultTimeout bounds a single call to a backend
+const DefaultTimeout = 30 * time.Second
+
+// AnyMethod is a route method matching every payment method without a route of its own
+const AnyMethod payment.Method = "*"
+
+// Route maps a payment method, optionally scoped to a namespace, to the
+// backends serving it in order of preference.
+type Route struct {
+	Method    payment.Method
+	Namespace string
+	Backends  []string
+}
+
+// Options configures the router
+type Options struct {
+	// Backends are the payment clients known by name
+	Backends map[string]payment.Client
+
+	// Routes pick the backends of every method
+	Routes []Route
+
+	// Timeout bounds a single call to a backend
+	Timeout time.Duration
+}
+
+// Router is a payment.Client that dispatches every transaction to the
+// backends configured for its method and namespace, falling back to the
+// next backend when one couldn't be reached.
+type Router struct {
+	backends map[string]payment.Client
+	routes   map[string][]string
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	health map[string]*payment.BackendHealth
+}
+
+// New creates a router and checks that every route refers to a known backend
+func New(opts *Options) (*Router, error) {
+	const op errors.Op = "backends/router/New"
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	r := &Router{
+		backends: opts.Backends,
+		routes:   make(map[string][]string),
+		timeout:  timeout,
+		health:   make(map[string]*payment.BackendHealth),
+	}
+
+	for name := range opts.Backends {
+		r.health[name] = &payment.BackendHealth{Name: name, Healthy: true}
+	}
+
+	for _, route := range opts.Routes {
+		if len(route.Backends) == 0 {
+			return nil, errors.E(op, fmt.Sprintf("route %s has no backends", route.Method), errors.KindUnexpected)
+		}
+		for _, name := range route.Backends {
+			if _, ok := opts.Backends[name]; !ok {
+				return nil, errors.E(op, fmt.Sprintf("route %s refers to unknown backend %s", route.Method, name), errors.KindUnexpected)
+			}
+		}
+		r.routes[key(route.Method, route.Namespace)] = route.Backends
+	}
+	return r, nil
+}
+
+// ParseRoutes reads routes written as "method[@namespace]=primary[|secondary...]"
+// and separated by commas, e.g. "momo-mtn-rw=fdi|fake,momo-airtel-rw@kigali=fdi,*=fdi".
+func ParseRoutes(s string) ([]Route, error) {
+	const op errors.Op = "backends/router/ParseRoutes"
+
+	var routes []Route
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.E(op, fmt.Sprintf("invalid route %q", entry), errors.KindBadRequest)
+		}
+
+		route := Route{Method: payment.Method(parts[0])}
+		if i := strings.Index(parts[0], "@"); i >= 0 {
+			route.Method = payment.Method(parts[0][:i])
+			route.Namespace = parts[0][i+1:]
+		}
+
+		for _, name := range strings.Split(parts[1], "|") {
+			if name = strings.TrimSpace(name); name != "" {
+				route.Backends = append(route.Backends, name)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// Pull debits the payer through the first backend that accepts the transaction
+func (r *Router) Pull(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	const op errors.Op = "backends/router/Router.Pull"
+
+	res, err := r.dispatch(ctx, tx, func(ctx context.Context, cli payment.Client) (*payment.TxResponse, error) {
+		return cli.Pull(ctx, tx)
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return res, nil
+}
+
+// Push credits the payee through the first backend that accepts the transaction
+func (r *Router) Push(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	const op errors.Op = "backends/router/Router.Push"
+
+	res, err := r.dispatch(ctx, tx, func(ctx context.Context, cli payment.Client) (*payment.TxResponse, error) {
+		return cli.Push(ctx, tx)
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return res, nil
+}
+
+// Status asks every backend for the transaction since the ref alone
+// doesn't tell which one processed it.
+func (r *Router) Status(ctx context.Context, ref string) (*payment.Data, error) {
+	const op errors.Op = "backends/router/Router.Status"
+
+	var lastErr error
+	for _, name := range r.names() {
+		cctx, cancel := context.WithTimeout(ctx, r.timeout)
+		data, err := r.backends[name].Status(cctx, ref)
+		cancel()
+
+		if err == nil {
+			r.succeeded(name)
+			return data, nil
+		}
+		if errors.Kind(err) != errors.KindNotFound {
+			r.failed(name, err)
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, errors.E(op, "no payment backend configured", errors.KindUnexpected)
+	}
+	return nil, errors.E(op, lastErr)
+}
+
+// Health reports the state of every backend sorted by name
+func (r *Router) Health() []payment.BackendHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	health := make([]payment.BackendHealth, 0, len(r.health))
+	for name, h := range r.health {
+		report := *h
+		if breaker, ok := r.backends[name].(payment.BreakerReporter); ok {
+			report.Breaker = breaker.Breaker()
+		}
+		health = append(health, report)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Name < health[j].Name })
+	return health
+}
+
+func (r *Router) dispatch(ctx context.Context, tx *payment.TxRequest, call func(context.Context, payment.Client) (*payment.TxResponse, error)) (*payment.TxResponse, error) {
+	const op errors.Op = "backends/router/Router.dispatch"
+
+	backends, err := r.resolve(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, name := range backends {
+		cctx, cancel := context.WithTimeout(ctx, r.timeout)
+		res, err := call(cctx, r.backends[name])
+		cancel()
+
+		if err == nil {
+			r.succeeded(name)
+			return res, nil
+		}
+
+		// a rejected request would be rejected by every backend
+		if errors.Kind(err) == errors.KindBadRequest {
+			return nil, err
+		}
+		r.failed(name, err)
+		lastErr = err
+
+		// a backend that got the request may have processed it, sending it
+		// to the next one could charge the payer twice
+		if !resilient.Unreachable(err) {
+			break
+		}
+
+		// the caller gave up, don't bother the next backend
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.E(op, lastErr, errors.KindUnexpected)
+}
+
+// resolve prefers a route scoped to the namespace over the method default
+func (r *Router) resolve(tx *payment.TxRequest) ([]string, error) {
+	const op errors.Op = "backends/router/Router.resolve"
+
+	if tx.Namespace != "" {
+		if backends, ok := r.routes[key(tx.Method, tx.Namespace)]; ok {
+			return backends, nil
+		}
+	}
+	if backends, ok := r.routes[key(tx.Method, "")]; ok {
+		return backends, nil
+	}
+	if backends, ok := r.routes[key(AnyMethod, "")]; ok {
+		return backends, nil
+	}
+	return nil, errors.E(op, fmt.Sprintf("payment method %s is not supported", tx.Method), errors.KindBadRequest)
+}
+
+// names lists the backends with the healthy ones first
+func (r *Router) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.health))
+	for name := range r.health {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		hi, hj := r.health[names[i]], r.health[names[j]]
+		if hi.Healthy != hj.Healthy {
+			return hi.Healthy
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func (r *Router) succeeded(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.health[name]
+	h.Healthy = true
+	h.Failures = 0
+	h.LastSuccess = time.Now()
+}
+
+func (r *Router) failed(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.health[name]
+	h.Healthy = false
+	h.Failures++
+	h.LastError = err.Error()
+	h.LastFailure = time.Now()
+}
+
+func key(method payment.Method, namespace string) string {
+	return fmt.Sprintf("%s@%s", method, namespace)
+}
+
+var _ payment.Client = (*Router)(nil)
+var _ payment.HealthReporter = (*Router)(nil)
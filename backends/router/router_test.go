@@ -0,0 +1,235 @@
+package router_test
+
+import (
+	"context"
+	goerrors "errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/backends/router"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubClient struct {
+	name  string
+	err   error
+	delay time.Duration
+}
+
+func (c *stubClient) Pull(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	return c.call(ctx)
+}
+
+func (c *stubClient) Push(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	return c.call(ctx)
+}
+
+func (c *stubClient) Status(ctx context.Context, ref string) (*payment.Data, error) {
+	const op errors.Op = "backends/router_test/stubClient.Status"
+
+	if ref != c.name {
+		return nil, errors.E(op, "transaction not found", errors.KindNotFound)
+	}
+	return &payment.Data{Ref: ref, Status: "successful"}, nil
+}
+
+func (c *stubClient) call(ctx context.Context) (*payment.TxResponse, error) {
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &payment.TxResponse{TxID: c.name, TxState: "success"}, nil
+}
+
+func TestParseRoutes(t *testing.T) {
+	cases := []struct {
+		desc   string
+		routes string
+		want   []router.Route
+		err    bool
+	}{
+		{
+			desc:   "parse method routes",
+			routes: "momo-mtn-rw=fdi|fake, momo-airtel-rw@kigali=fake",
+			want: []router.Route{
+				{Method: payment.MTN, Backends: []string{"fdi", "fake"}},
+				{Method: payment.AIRTEL, Namespace: "kigali", Backends: []string{"fake"}},
+			},
+		},
+		{
+			desc:   "parse empty routes",
+			routes: "",
+		},
+		{
+			desc:   "parse route without backends",
+			routes: "momo-mtn-rw=",
+			err:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		routes, err := router.ParseRoutes(tc.routes)
+		assert.Equal(t, tc.err, err != nil, tc.desc)
+		assert.Equal(t, tc.want, routes, tc.desc)
+	}
+}
+
+func TestRouting(t *testing.T) {
+	primary := &stubClient{name: "primary"}
+	secondary := &stubClient{name: "secondary"}
+
+	r, err := router.New(&router.Options{
+		Backends: map[string]payment.Client{"primary": primary, "secondary": secondary},
+		Routes: []router.Route{
+			{Method: payment.MTN, Backends: []string{"primary"}},
+			{Method: payment.MTN, Namespace: "kigali", Backends: []string{"secondary"}},
+		},
+	})
+	require.Nil(t, err)
+
+	cases := []struct {
+		desc string
+		tx   *payment.TxRequest
+		want string
+		kind int
+	}{
+		{
+			desc: "route by method",
+			tx:   &payment.TxRequest{Method: payment.MTN},
+			want: "primary",
+		},
+		{
+			desc: "route by method and namespace",
+			tx:   &payment.TxRequest{Method: payment.MTN, Namespace: "kigali"},
+			want: "secondary",
+		},
+		{
+			desc: "route unknown namespace to the method default",
+			tx:   &payment.TxRequest{Method: payment.MTN, Namespace: "huye"},
+			want: "primary",
+		},
+		{
+			desc: "route unsupported method",
+			tx:   &payment.TxRequest{Method: payment.AIRTEL},
+			kind: errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := r.Pull(context.Background(), tc.tx)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), tc.desc)
+			continue
+		}
+		require.Nil(t, err, tc.desc)
+		assert.Equal(t, tc.want, res.TxID, tc.desc)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	const op errors.Op = "backends/router_test/TestFailover"
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: goerrors.New("connection refused")}
+
+	cases := []struct {
+		desc      string
+		primary   *stubClient
+		secondary *stubClient
+		want      string
+		kind      int
+		healthy   bool
+	}{
+		{
+			desc:      "fail over when the primary can't be reached",
+			primary:   &stubClient{name: "primary", err: errors.E(op, dialErr, errors.KindUnexpected)},
+			secondary: &stubClient{name: "secondary"},
+			want:      "secondary",
+		},
+		{
+			desc:      "don't fail over when the primary errors after getting the request",
+			primary:   &stubClient{name: "primary", err: errors.E(op, "gateway down", errors.KindUnexpected)},
+			secondary: &stubClient{name: "secondary"},
+			kind:      errors.KindUnexpected,
+		},
+		{
+			desc:      "don't fail over when the primary times out",
+			primary:   &stubClient{name: "primary", delay: time.Second},
+			secondary: &stubClient{name: "secondary"},
+			kind:      errors.KindUnexpected,
+		},
+		{
+			desc:      "don't fail over rejected requests",
+			primary:   &stubClient{name: "primary", err: errors.E(op, "invalid phone", errors.KindBadRequest)},
+			secondary: &stubClient{name: "secondary"},
+			kind:      errors.KindBadRequest,
+			healthy:   true,
+		},
+		{
+			desc:      "fail when every backend is unreachable",
+			primary:   &stubClient{name: "primary", err: errors.E(op, dialErr, errors.KindUnexpected)},
+			secondary: &stubClient{name: "secondary", err: errors.E(op, dialErr, errors.KindUnexpected)},
+			kind:      errors.KindUnexpected,
+		},
+	}
+
+	for _, tc := range cases {
+		r, err := router.New(&router.Options{
+			Backends: map[string]payment.Client{"primary": tc.primary, "secondary": tc.secondary},
+			Routes:   []router.Route{{Method: router.AnyMethod, Backends: []string{"primary", "secondary"}}},
+			Timeout:  50 * time.Millisecond,
+		})
+		require.Nil(t, err, tc.desc)
+
+		res, err := r.Push(context.Background(), &payment.TxRequest{Method: payment.MTN})
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), tc.desc)
+		} else {
+			require.Nil(t, err, tc.desc)
+			assert.Equal(t, tc.want, res.TxID, tc.desc)
+		}
+
+		health := r.Health()
+		require.Len(t, health, 2, tc.desc)
+		assert.Equal(t, "primary", health[0].Name, tc.desc)
+		assert.Equal(t, tc.healthy, health[0].Healthy, tc.desc)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	r, err := router.New(&router.Options{
+		Backends: map[string]payment.Client{
+			"primary":   &stubClient{name: "primary"},
+			"secondary": &stubClient{name: "secondary"},
+		},
+	})
+	require.Nil(t, err)
+
+	data, err := r.Status(context.Background(), "secondary")
+	require.Nil(t, err)
+	assert.Equal(t, "secondary", data.Ref)
+
+	_, err = r.Status(context.Background(), "unknown")
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err))
+
+	for _, h := range r.Health() {
+		assert.True(t, h.Healthy, h.Name)
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := router.New(&router.Options{
+		Backends: map[string]payment.Client{"fdi": &stubClient{}},
+		Routes:   []router.Route{{Method: payment.MTN, Backends: []string{"nova"}}},
+	})
+	assert.NotNil(t, err)
+}
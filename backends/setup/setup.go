@@ -0,0 +1,131 @@
+// Package setup builds the payment and sms backends from the configuration,
+// it is shared by the api server and the worker.
+package setup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nshimiyimanaamani/paypack-backend/backends/fake"
+	"github.com/nshimiyimanaamani/paypack-backend/backends/fdi"
+	"github.com/nshimiyimanaamani/paypack-backend/backends/resilient"
+	"github.com/nshimiyimanaamani/paypack-backend/backends/router"
+	"github.com/nshimiyimanaamani/paypack-backend/backends/sms"
+	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/config"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/quarksgroup/paypack-go/paypack/api"
+	"github.com/quarksgroup/paypack-go/paypack/transport/oauth"
+)
+
+// Development is the environment the fake payment backend is allowed in
+const Development = "development"
+
+// PaymentClient initialises the payment backends and routes payments between them
+func PaymentClient(ctx context.Context, cfg *config.Config) (payment.Client, error) {
+	const op errors.Op = "backends/setup/PaymentClient"
+
+	backends := make(map[string]payment.Client)
+	var names []string
+
+	for _, name := range strings.Split(cfg.Payment.Backends, ",") {
+		name = strings.TrimSpace(name)
+
+		var (
+			cli payment.Client
+			err error
+		)
+
+		switch name {
+		case "":
+			continue
+		case "fdi":
+			cli, err = initFDI(cfg)
+		case "fake":
+			// the fake backend approves payments without moving any money
+			if cfg.GoEnv != Development {
+				return nil, errors.E(op, "the fake payment backend is only available in development", errors.KindUnexpected)
+			}
+			cli, err = fake.New(&fake.Options{
+				URL:     cfg.Payment.FakeURL,
+				Secret:  cfg.Payment.CallbackSecret,
+				Outcome: cfg.Payment.FakeOutcome,
+				Delay:   cfg.Payment.FakeDelay,
+			})
+		default:
+			err = errors.E(op, fmt.Sprintf("unknown payment backend %s", name), errors.KindUnexpected)
+		}
+		if err != nil {
+			return nil, err
+		}
+		backends[name] = resilient.New(cli, &resilient.Options{
+			Name:      name,
+			Timeout:   cfg.Payment.CallTimeout,
+			Retries:   cfg.Payment.CallRetries,
+			Threshold: cfg.Payment.BreakerThreshold,
+			Cooldown:  cfg.Payment.BreakerCooldown,
+		})
+		// the fake backend is only used by the routes that name it
+		if name != "fake" {
+			names = append(names, name)
+		}
+	}
+
+	if len(backends) == 0 {
+		return nil, errors.E(op, "no payment backend configured", errors.KindUnexpected)
+	}
+
+	routes, err := router.ParseRoutes(cfg.Payment.Routes)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		if len(names) == 0 {
+			return nil, errors.E(op, "the fake payment backend must be routed explicitly with PAYMENT_ROUTES", errors.KindUnexpected)
+		}
+		routes = []router.Route{{Method: router.AnyMethod, Backends: names}}
+	}
+
+	return router.New(&router.Options{
+		Backends: backends,
+		Routes:   routes,
+		Timeout:  cfg.Payment.RouteTimeout,
+	})
+}
+
+func initFDI(cfg *config.Config) (payment.Client, error) {
+	const op errors.Op = "backends/setup/initFDI"
+
+	if cfg.Payment.PaymentURL == "" || cfg.Payment.AppID == "" || cfg.Payment.Secret == "" {
+		return nil, errors.E(op, "fdi backend requires PAYMENT_BASE_URL, PAYMENT_CLIENT_ID and PAYMENT_CLIENT_SECRET", errors.KindUnexpected)
+	}
+
+	tr := &http.Client{
+		Transport: &oauth.Transport{
+			Scheme: oauth.SchemeBearer,
+			Source: oauth.ContextTokenSource(),
+			Base:   http.DefaultTransport,
+		},
+	}
+
+	cli, err := api.New(cfg.Payment.PaymentURL, tr.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	return fdi.New(cli, cfg.Payment.AppID, cfg.Payment.Secret, cfg.GoEnv)
+}
+
+// SMSBackend initialises the sms gateway client
+func SMSBackend(ctx context.Context, cfg *config.SMSConfig) (notifs.Backend, error) {
+	opts := &sms.Options{
+		URL:       cfg.SmsURL,
+		SenderID:  cfg.SenderID,
+		AppID:     cfg.AppID,
+		AppSecret: cfg.Secret,
+	}
+	return sms.New(opts)
+}
@@ -0,0 +1,49 @@
+package setup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/backends/setup"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentClient(t *testing.T) {
+	cases := []struct {
+		desc   string
+		env    string
+		routes string
+		err    bool
+	}{
+		{
+			desc: "start fake backend outside development",
+			env:  "production",
+			err:  true,
+		},
+		{
+			desc: "start fake backend without routing it",
+			env:  setup.Development,
+			err:  true,
+		},
+		{
+			desc:   "start fake backend routed explicitly",
+			env:    setup.Development,
+			routes: "*=fake",
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &config.Config{
+			GoEnv: tc.env,
+			Payment: &config.PaymentConfig{
+				Backends:    "fake",
+				Routes:      tc.routes,
+				FakeURL:     "http://localhost:8080",
+				FakeOutcome: "successful",
+			},
+		}
+		_, err := setup.PaymentClient(context.Background(), cfg)
+		assert.Equal(t, tc.err, err != nil, tc.desc)
+	}
+}
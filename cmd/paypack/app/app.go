@@ -8,6 +8,8 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	mw "github.com/nshimiyimanaamani/paypack-backend/api/http/middleware"
+	"github.com/nshimiyimanaamani/paypack-backend/backends/setup"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/config"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
 	"github.com/nshimiyimanaamani/paypack-backend/web"
@@ -40,14 +42,14 @@ func Bootstrap(conf *config.Config) (http.Handler, error) {
 	lggr := log.New(conf.CloudRuntime, logLvl)
 
 	//init payment backend
-	pb, err := InitPaymentClient(ctx, conf)
+	pb, err := setup.PaymentClient(ctx, conf)
 	if err != nil {
 		lggr.Errorf("error connecting to payment backend (%s)", err)
 		return nil, err
 	}
 
 	//init sms backend
-	sms, err := InitSMSBackend(ctx, conf.SMS)
+	sms, err := setup.SMSBackend(ctx, conf.SMS)
 	if err != nil {
 		lggr.Errorf("error connecting to sms backend (%s)", err)
 		return nil, err
@@ -60,7 +62,7 @@ func Bootstrap(conf *config.Config) (http.Handler, error) {
 	}
 
 	//create protocol
-	services := Init(db, rclient, queue, pb, sms, conf.Secret, namespace, conf.USSD.Prefix)
+	services := Init(db, rclient, queue, pb, conf.Payment, sms, conf.Secret, namespace, conf.USSD.Prefix)
 
 	handlerOpts := NewHandlerOptions(services, lggr)
 
@@ -81,7 +83,7 @@ func Bootstrap(conf *config.Config) (http.Handler, error) {
 	cors := handlers.CORS(
 		handlers.AllowedOrigins([]string{"*"}),
 		handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", payment.IdempotencyHeader}),
 	)
 	return cors(router), nil
 }
@@ -6,19 +6,27 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/accounts"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/deposits"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/feedback"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/health"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/manual"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/metrics"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/notifs"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/owners"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/payouts"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/refunds"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/scheduler"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/statements"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/transactions"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/users"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/ussd"
 	"github.com/nshimiyimanaamani/paypack-backend/api/http/version"
+	"github.com/nshimiyimanaamani/paypack-backend/api/http/waivers"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
 )
 
@@ -34,6 +42,14 @@ type HandlerOptions struct {
 	TransOptions     *transactions.HandlerOpts
 	UsersOptions     *users.HandlerOpts
 	InvoiceOptions   *invoices.HandlerOpts
+	LedgerOptions    *ledger.HandlerOpts
+	WaiverOptions    *waivers.HandlerOpts
+	RefundOptions    *refunds.HandlerOpts
+	PayoutOptions    *payouts.HandlerOpts
+	ManualOptions    *manual.HandlerOpts
+	StatementOptions *statements.HandlerOpts
+	DepositOptions   *deposits.HandlerOpts
+	SettleOptions    *settlements.HandlerOpts
 	StatsOptions     *metrics.HandlerOpts
 	SchedulerOptions *scheduler.HandlerOpts
 	USSDOptions      *ussd.HandlerOpts
@@ -91,6 +107,46 @@ func NewHandlerOptions(services *Services, lggr *log.Logger) *HandlerOptions {
 		Service:       services.Invoices,
 		Authenticator: services.Auth,
 	}
+	ledgerOpts := &ledger.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Ledger,
+		Authenticator: services.Auth,
+	}
+	waiverOpts := &waivers.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Waivers,
+		Authenticator: services.Auth,
+	}
+	refundOpts := &refunds.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Refunds,
+		Authenticator: services.Auth,
+	}
+	payoutOpts := &payouts.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Payouts,
+		Authenticator: services.Auth,
+	}
+	manualOpts := &manual.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Manual,
+		Authenticator: services.Auth,
+	}
+	statementOpts := &statements.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Statements,
+		Authenticator: services.Auth,
+	}
+	depositOpts := &deposits.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Deposits,
+		Authenticator: services.Auth,
+	}
+	settleOpts := &settlements.HandlerOpts{
+		Logger:        lggr,
+		Service:       services.Settlements,
+		Authenticator: services.Auth,
+	}
 	statsOpts := &metrics.HandlerOpts{
 		Logger:        lggr,
 		Service:       services.Stats,
@@ -121,6 +177,14 @@ func NewHandlerOptions(services *Services, lggr *log.Logger) *HandlerOptions {
 		TransOptions:     transOpts,
 		UsersOptions:     usersOpts,
 		InvoiceOptions:   invOpts,
+		LedgerOptions:    ledgerOpts,
+		WaiverOptions:    waiverOpts,
+		RefundOptions:    refundOpts,
+		PayoutOptions:    payoutOpts,
+		ManualOptions:    manualOpts,
+		StatementOptions: statementOpts,
+		DepositOptions:   depositOpts,
+		SettleOptions:    settleOpts,
 		StatsOptions:     statsOpts,
 		NotifOptions:     notifOpts,
 		SchedulerOptions: scOptions,
@@ -135,7 +199,7 @@ func Register(mux *mux.Router, opts *HandlerOptions) {
 		panic("absolutely unacceptable start server opts")
 	}
 
-	mux.HandleFunc("/healthz", health.Health).Methods(http.MethodGet)
+	mux.HandleFunc("/healthz", health.Health(opts.PayOptions.Service)).Methods(http.MethodGet)
 	mux.HandleFunc("/version", version.Build).Methods(http.MethodGet)
 	mux.HandleFunc("/panic", health.Panic).Methods(http.MethodGet)
 
@@ -157,6 +221,22 @@ func Register(mux *mux.Router, opts *HandlerOptions) {
 
 	invoices.RegisterHandlers(mux, opts.InvoiceOptions)
 
+	ledger.RegisterHandlers(mux, opts.LedgerOptions)
+
+	waivers.RegisterHandlers(mux, opts.WaiverOptions)
+
+	refunds.RegisterHandlers(mux, opts.RefundOptions)
+
+	payouts.RegisterHandlers(mux, opts.PayoutOptions)
+
+	manual.RegisterHandlers(mux, opts.ManualOptions)
+
+	statements.RegisterHandlers(mux, opts.StatementOptions)
+
+	deposits.RegisterHandlers(mux, opts.DepositOptions)
+
+	settlements.RegisterHandlers(mux, opts.SettleOptions)
+
 	metrics.RegisterHandlers(mux, opts.StatsOptions)
 
 	notifs.RegisterHandlers(mux, opts.NotifOptions)
@@ -6,19 +6,28 @@ import (
 	"github.com/go-redis/redis/v7"
 	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
 	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
 	"github.com/nshimiyimanaamani/paypack-backend/core/feedback"
 	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
 	"github.com/nshimiyimanaamani/paypack-backend/core/metrics"
 	"github.com/nshimiyimanaamani/paypack-backend/core/nanoid"
 	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
 	"github.com/nshimiyimanaamani/paypack-backend/core/owners"
 	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payouts"
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds"
 	"github.com/nshimiyimanaamani/paypack-backend/core/scheduler"
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
 	"github.com/nshimiyimanaamani/paypack-backend/core/transactions"
 	"github.com/nshimiyimanaamani/paypack-backend/core/users"
 	"github.com/nshimiyimanaamani/paypack-backend/core/ussd"
 	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/config"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/encrypt"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/passwords/bcrypt"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/passwords/rand"
@@ -40,6 +49,14 @@ type Services struct {
 	Transactions  transactions.Service
 	Users         users.Service
 	Invoices      invoices.Service
+	Ledger        ledger.Service
+	Waivers       waivers.Service
+	Refunds       refunds.Service
+	Payouts       payouts.Service
+	Manual        manual.Service
+	Statements    statements.Service
+	Deposits      deposits.Service
+	Settlements   settlements.Service
 	Stats         metrics.Service
 	USSD          ussd.Service
 	Scheduler     scheduler.Service
@@ -52,27 +69,37 @@ func Init(
 	rclient *redis.Client,
 	queue *queue.Queue,
 	pclient payment.Client,
+	pconf *config.PaymentConfig,
 	sms notifs.Backend,
 	secret string,
 	namespace string,
 	prefix string,
 ) *Services {
 	notifs := bootNotifService(db, sms)
+	payment := bootPaymentService(db, rclient, sms, pclient, pconf)
 	services := &Services{
 		Accounts:      bootAccountsService(db),
 		Feedback:      bootFeedbackService(db),
 		Notifications: notifs,
 		Owners:        bootOwnersService(db),
-		Payment:       bootPaymentService(db, rclient, sms, pclient),
+		Payment:       payment,
 		Properties:    bootPropertiesService(db),
-		Transactions:  bootTransactionsService(db),
+		Transactions:  bootTransactionsService(db, pconf),
 		Users:         bootUserService(db, secret),
 		Auth:          bootAuthService(db, secret),
 		Invoices:      bootInvoiceService(db),
+		Ledger:        bootLedgerService(db),
+		Waivers:       bootWaiverService(db),
+		Refunds:       bootRefundService(db, pconf, payment),
+		Payouts:       bootPayoutService(db),
+		Manual:        bootManualService(db, pconf),
+		Statements:    bootStatementService(db),
+		Deposits:      bootDepositService(db, pconf),
+		Settlements:   bootSettlementService(db, pconf),
 		Stats:         bootStatsService(db),
-		Scheduler:     bootScheduler(db, queue),
-		USSD:          bootUSSDService(prefix, db, rclient, sms, pclient),
-		PaymentStore:  postgres.NewPaymentRepository(db, notifs),
+		Scheduler:     bootScheduler(db, queue, pconf),
+		USSD:          bootUSSDService(prefix, db, rclient, sms, pclient, pconf),
+		PaymentStore:  postgres.NewPaymentRepository(db, pconf.ReceiptsURL, pconf.ReceiptsSecret),
 	}
 	return services
 }
@@ -113,10 +140,15 @@ func bootOwnersService(db *sql.DB) owners.Service {
 }
 
 // bootTransactionsService configures the transactions service
-func bootTransactionsService(db *sql.DB) transactions.Service {
+func bootTransactionsService(db *sql.DB, pconf *config.PaymentConfig) transactions.Service {
 	repo := postgres.NewTransactionRepository(db)
 	idp := uuid.New()
-	opts := &transactions.Options{Repo: repo, Idp: idp}
+	opts := &transactions.Options{
+		Repo:           repo,
+		Idp:            idp,
+		ReceiptsURL:    pconf.ReceiptsURL,
+		ReceiptsSecret: pconf.ReceiptsSecret,
+	}
 	return transactions.New(opts)
 }
 
@@ -128,12 +160,22 @@ func bootFeedbackService(db *sql.DB) feedback.Service {
 	return feedback.New(opts)
 }
 
-func bootPaymentService(db *sql.DB, rclient *redis.Client, nclient notifs.Backend, pclient payment.Client) payment.Service {
+func bootPaymentService(db *sql.DB, rclient *redis.Client, nclient notifs.Backend, pclient payment.Client, pconf *config.PaymentConfig) payment.Service {
 	var opts payment.Options
 	opts.Backend = pclient
+	opts.Secret = pconf.CallbackSecret
+	opts.Tolerance = pconf.CallbackTolerance
+	opts.Callbacks = postgres.NewCallbackRepository(db)
+	opts.Replays = rstore.NewReplayCache(rclient)
+	opts.Discrepancy = postgres.NewDiscrepancyRepository(db)
+	opts.Idempotency = rstore.NewIdempotencyStore(rclient)
+	opts.Reversals = refunds.NewReversals(postgres.NewRefundRepository(db))
+	opts.Events = postgres.NewEventRepository(db)
+	opts.Payouts = postgres.NewPayoutRepository(db)
+	opts.Fees = postgres.NewFeeRepository(db)
 	opts.Idp = uuid.New()
 	opts.SMS = bootNotifService(db, nclient)
-	opts.Repository = postgres.NewPaymentRepository(db, opts.SMS)
+	opts.Repository = postgres.NewPaymentRepository(db, pconf.ReceiptsURL, pconf.ReceiptsSecret)
 	opts.Queue = rstore.NewQueue(rclient)
 	opts.Properties = postgres.NewPropertyStore(db)
 	opts.Owners = postgres.NewOwnerRepo(db)
@@ -145,7 +187,7 @@ func bootPaymentService(db *sql.DB, rclient *redis.Client, nclient notifs.Backen
 func bootAccountsService(db *sql.DB) accounts.Service {
 	repo := postgres.NewAccountRepository(db)
 	idp := uuid.New()
-	opts := &accounts.Options{Repository: repo, IDP: idp}
+	opts := &accounts.Options{Repository: repo, Fees: postgres.NewFeeRepository(db), Penalties: postgres.NewPenaltyRepository(db), IDP: idp}
 	return accounts.New(opts)
 }
 
@@ -155,6 +197,77 @@ func bootInvoiceService(db *sql.DB) invoices.Service {
 	return invoices.New(opts)
 }
 
+func bootLedgerService(db *sql.DB) ledger.Service {
+	repo := postgres.NewLedgerRepository(db)
+	opts := &ledger.Options{Repository: repo}
+	return ledger.New(opts)
+}
+
+func bootWaiverService(db *sql.DB) waivers.Service {
+	opts := &waivers.Options{
+		Idp:        uuid.New(),
+		Repository: postgres.NewWaiverRepository(db),
+		Properties: postgres.NewPropertyStore(db),
+	}
+	return waivers.New(opts)
+}
+
+// bootRefundService pays refunds out through the payment service
+func bootRefundService(db *sql.DB, pconf *config.PaymentConfig, gateway payment.Service) refunds.Service {
+	opts := &refunds.Options{
+		Idp:        uuid.New(),
+		Repository: postgres.NewRefundRepository(db),
+		Properties: postgres.NewPropertyStore(db),
+		Payments:   postgres.NewPaymentRepository(db, pconf.ReceiptsURL, pconf.ReceiptsSecret),
+		Gateway:    gateway,
+	}
+	return refunds.New(opts)
+}
+
+func bootPayoutService(db *sql.DB) payouts.Service {
+	opts := &payouts.Options{
+		Repository: postgres.NewPayoutReportRepository(db),
+	}
+	return payouts.New(opts)
+}
+
+// bootManualService settles approved cash and bank payments like gateway ones
+func bootManualService(db *sql.DB, pconf *config.PaymentConfig) manual.Service {
+	opts := &manual.Options{
+		Idp:        uuid.New(),
+		Repository: postgres.NewManualRepository(db),
+		Properties: postgres.NewPropertyStore(db),
+		Invoices:   postgres.NewInvoiceRepository(db),
+		Payments:   postgres.NewPaymentRepository(db, pconf.ReceiptsURL, pconf.ReceiptsSecret),
+	}
+	return manual.New(opts)
+}
+
+func bootDepositService(db *sql.DB, pconf *config.PaymentConfig) deposits.Service {
+	opts := &deposits.Options{
+		Idp:        uuid.New(),
+		Repository: postgres.NewDepositRepository(db),
+		Properties: postgres.NewPropertyStore(db),
+		Invoices:   postgres.NewInvoiceRepository(db),
+		Payments:   postgres.NewPaymentRepository(db, pconf.ReceiptsURL, pconf.ReceiptsSecret),
+	}
+	return deposits.New(opts)
+}
+
+func bootSettlementService(db *sql.DB, pconf *config.PaymentConfig) settlements.Service {
+	opts := &settlements.Options{
+		Idp:        uuid.New(),
+		Repository: postgres.NewSettlementRepository(db),
+		Secret:     pconf.SettlementSecret,
+	}
+	return settlements.New(opts)
+}
+
+func bootStatementService(db *sql.DB) statements.Service {
+	opts := &statements.Options{Repository: postgres.NewPropertyStatementRepository(db)}
+	return statements.New(opts)
+}
+
 func bootStatsService(db *sql.DB) metrics.Service {
 	repo := postgres.NewStatsRepository(db)
 	opts := &metrics.Options{Repo: repo}
@@ -169,11 +282,11 @@ func bootNotifService(db *sql.DB, client notifs.Backend) notifs.Service {
 	return notifs.New(&opts)
 }
 
-func bootUSSDService(prefix string, db *sql.DB, rclient *redis.Client, sms notifs.Backend, pclient payment.Client) ussd.Service {
+func bootUSSDService(prefix string, db *sql.DB, rclient *redis.Client, sms notifs.Backend, pclient payment.Client, pconf *config.PaymentConfig) ussd.Service {
 	idp := uuid.New()
 	properties := postgres.NewPropertyStore(db)
 	owners := postgres.NewOwnerRepo(db)
-	payment := bootPaymentService(db, rclient, sms, pclient)
+	payment := bootPaymentService(db, rclient, sms, pclient, pconf)
 	agents := postgres.NewUserRepository(db)
 	invoice := postgres.NewInvoiceRepository(db)
 	opts := &ussd.Options{
@@ -188,8 +301,10 @@ func bootUSSDService(prefix string, db *sql.DB, rclient *redis.Client, sms notif
 	return ussd.New(opts)
 }
 
-func bootScheduler(db *sql.DB, queue *queue.Queue) scheduler.Service {
+func bootScheduler(db *sql.DB, queue *queue.Queue, pconf *config.PaymentConfig) scheduler.Service {
 	var opts scheduler.Options
+	opts.ReconcileAfter = pconf.ReconcileAfter
+	opts.ExpireAfter = pconf.ExpireAfter
 	opts.Queue = queue
 	opts.Counter = postgres.NewAuditableCounter(db)
 	opts.Invoices = postgres.NewInvoiceRepository(db)
@@ -1,9 +1,11 @@
 package app
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/hibiken/asynq"
+	"github.com/nshimiyimanaamani/paypack-backend/backends/setup"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/config"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
 	"github.com/sirupsen/logrus"
@@ -13,6 +15,8 @@ const namespace = "paypack"
 
 // Bootstrap worker pool
 func Bootstrap(conf *config.Config) (*asynq.ServeMux, error) {
+	ctx := context.Background()
+
 	db, err := PostgresConnect(conf.Postgres)
 	if err != nil {
 		err = fmt.Errorf("error connecting to postgres (%s)", err)
@@ -25,7 +29,19 @@ func Bootstrap(conf *config.Config) (*asynq.ServeMux, error) {
 	}
 	lggr := log.New(conf.CloudRuntime, logLvl)
 
-	services := ProvideServices(db)
+	sms, err := setup.SMSBackend(ctx, conf.SMS)
+	if err != nil {
+		lggr.Errorf("error connecting to sms backend (%s)", err)
+		return nil, err
+	}
+
+	pclient, err := setup.PaymentClient(ctx, conf)
+	if err != nil {
+		lggr.Errorf("error connecting to payment backend (%s)", err)
+		return nil, err
+	}
+
+	services := ProvideServices(db, sms, pclient, conf.Payment)
 
 	handlerOpts := ProvideHandlerOptions(services, lggr)
 
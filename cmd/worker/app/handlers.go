@@ -6,12 +6,20 @@ import (
 	"github.com/hibiken/asynq"
 	"github.com/nshimiyimanaamani/paypack-backend/api/work/archiver"
 	"github.com/nshimiyimanaamani/paypack-backend/api/work/auditor"
+	"github.com/nshimiyimanaamani/paypack-backend/api/work/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/api/work/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/api/work/reconciler"
+	"github.com/nshimiyimanaamani/paypack-backend/api/work/settlements"
 )
 
 // HandlerOptions ...
 type HandlerOptions struct {
-	ArchiveOptions *archiver.HandlerOpts
-	AuditOptions   *auditor.HandlerOpts
+	ArchiveOptions   *archiver.HandlerOpts
+	AuditOptions     *auditor.HandlerOpts
+	DepositOptions   *deposits.HandlerOpts
+	OutboxOptions    *outbox.HandlerOpts
+	ReconcileOptions *reconciler.HandlerOpts
+	SettleOptions    *settlements.HandlerOpts
 }
 
 // ProvideHandlerOptions ...
@@ -24,19 +32,43 @@ func ProvideHandlerOptions(services *Services, lggr *log.Logger) *HandlerOptions
 		Logger:  lggr,
 		Service: services.Archiver,
 	}
+	statements := &deposits.HandlerOpts{
+		Logger:  lggr,
+		Service: services.Deposits,
+	}
+	receipts := &outbox.HandlerOpts{
+		Logger:  lggr,
+		Service: services.Outbox,
+	}
+	reconcile := &reconciler.HandlerOpts{
+		Logger:  lggr,
+		Service: services.Payment,
+	}
+	settle := &settlements.HandlerOpts{
+		Logger:  lggr,
+		Service: services.Settlements,
+	}
 
 	return &HandlerOptions{
-		ArchiveOptions: archive,
-		AuditOptions:   audit,
+		ArchiveOptions:   archive,
+		AuditOptions:     audit,
+		DepositOptions:   statements,
+		OutboxOptions:    receipts,
+		ReconcileOptions: reconcile,
+		SettleOptions:    settle,
 	}
 }
 
 // Register registers all handlers
 func Register(mux *asynq.ServeMux, opts *HandlerOptions) {
-	if opts.AuditOptions == nil || opts.ArchiveOptions == nil {
+	if opts.AuditOptions == nil || opts.ArchiveOptions == nil || opts.DepositOptions == nil || opts.OutboxOptions == nil || opts.ReconcileOptions == nil || opts.SettleOptions == nil {
 		panic("absolutely unacceptable start server opts")
 	}
 
 	archiver.RegisterHandlers(mux, opts.ArchiveOptions)
 	auditor.RegisterHandlers(mux, opts.AuditOptions)
+	deposits.RegisterHandlers(mux, opts.DepositOptions)
+	outbox.RegisterHandlers(mux, opts.OutboxOptions)
+	reconciler.RegisterHandlers(mux, opts.ReconcileOptions)
+	settlements.RegisterHandlers(mux, opts.SettleOptions)
 }
@@ -5,20 +5,35 @@ import (
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/archiver"
 	"github.com/nshimiyimanaamani/paypack-backend/core/auditor"
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/config"
 	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
 )
 
 // Services ....
 type Services struct {
-	Auditor  auditor.Service
-	Archiver archiver.Service
+	Auditor     auditor.Service
+	Archiver    archiver.Service
+	Deposits    deposits.Service
+	Outbox      outbox.Service
+	Payment     payment.Service
+	Settlements settlements.Service
 }
 
 // ProvideServices ...
-func ProvideServices(db *sql.DB) *Services {
+func ProvideServices(db *sql.DB, sms notifs.Backend, pclient payment.Client, pconf *config.PaymentConfig) *Services {
 	return &Services{
-		Auditor:  bootAuditor(db),
-		Archiver: bootArchiver(db),
+		Auditor:     bootAuditor(db),
+		Archiver:    bootArchiver(db),
+		Deposits:    bootDeposits(db, pconf),
+		Outbox:      bootOutbox(db, sms),
+		Payment:     bootPayment(db, pclient, pconf),
+		Settlements: bootSettlements(db, pconf),
 	}
 }
 
@@ -33,3 +48,52 @@ func bootArchiver(db *sql.DB) archiver.Service {
 	opts := &archiver.Options{Executor: exec}
 	return archiver.New(opts)
 }
+
+// bootDeposits settles the invoices of matched statement lines like a
+// payment callback does
+func bootDeposits(db *sql.DB, pconf *config.PaymentConfig) deposits.Service {
+	opts := &deposits.Options{
+		Idp:        uuid.New(),
+		Repository: postgres.NewDepositRepository(db),
+		Properties: postgres.NewPropertyStore(db),
+		Invoices:   postgres.NewInvoiceRepository(db),
+		Payments:   postgres.NewPaymentRepository(db, pconf.ReceiptsURL, pconf.ReceiptsSecret),
+	}
+	return deposits.New(opts)
+}
+
+func bootOutbox(db *sql.DB, client notifs.Backend) outbox.Service {
+	var nopts notifs.Options
+	nopts.IDP = uuid.New()
+	nopts.Backend = client
+	nopts.Store = postgres.NewNotifsRepository(db)
+
+	opts := &outbox.Options{
+		Repository: postgres.NewOutboxRepository(db),
+		SMS:        notifs.New(&nopts),
+	}
+	return outbox.New(opts)
+}
+
+// bootPayment only wires what the reconciliation job needs
+func bootPayment(db *sql.DB, pclient payment.Client, pconf *config.PaymentConfig) payment.Service {
+	var opts payment.Options
+	opts.Backend = pclient
+	opts.Repository = postgres.NewPaymentRepository(db, pconf.ReceiptsURL, pconf.ReceiptsSecret)
+	opts.Discrepancy = postgres.NewDiscrepancyRepository(db)
+	opts.Events = postgres.NewEventRepository(db)
+	opts.Payouts = postgres.NewPayoutRepository(db)
+	opts.Properties = postgres.NewPropertyStore(db)
+	opts.Fees = postgres.NewFeeRepository(db)
+	return payment.New(&opts)
+}
+
+// bootSettlements signs settlements with the same key the api verifies them with
+func bootSettlements(db *sql.DB, pconf *config.PaymentConfig) settlements.Service {
+	opts := &settlements.Options{
+		Idp:        uuid.New(),
+		Repository: postgres.NewSettlementRepository(db),
+		Secret:     pconf.SettlementSecret,
+	}
+	return settlements.New(opts)
+}
@@ -0,0 +1,157 @@
+package accounts
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// FeeType tells how the fee of a transaction is computed
+type FeeType string
+
+const (
+	// FlatFee charges the same amount on every transaction
+	FlatFee FeeType = "flat"
+	// PercentageFee charges a share of the transaction amount
+	PercentageFee FeeType = "percentage"
+	// TieredFee picks a flat or percentage fee by transaction amount
+	TieredFee FeeType = "tiered"
+)
+
+// FeeBearer tells who pays the fee of a transaction
+type FeeBearer string
+
+const (
+	// Payee fees are deducted from the money collected for the account
+	Payee FeeBearer = "payee"
+	// Payer fees are pulled from the payer on top of the amount they pay
+	Payer FeeBearer = "payer"
+)
+
+// FeeTier applies to transactions up to an amount, a zero UpTo has no upper bound
+type FeeTier struct {
+	UpTo  float64 `json:"up_to,omitempty"`
+	Type  FeeType `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// FeeRule is the commission model of an account
+type FeeRule struct {
+	Account   string    `json:"account,omitempty"`
+	Type      FeeType   `json:"type,omitempty"`
+	Value     float64   `json:"value,omitempty"`
+	Tiers     []FeeTier `json:"tiers,omitempty"`
+	Bearer    FeeBearer `json:"bearer,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// NoFeeRule is applied to accounts that never configured a fee rule, their
+// transactions aren't charged
+func NoFeeRule(account string) FeeRule {
+	return FeeRule{
+		Account: account,
+		Type:    FlatFee,
+		Bearer:  Payee,
+	}
+}
+
+// Validate the fee rule before it's saved
+func (rule *FeeRule) Validate() error {
+	const op errors.Op = "app/accounts/feeRule.Validate"
+
+	if rule.Account == "" {
+		return errors.E(op, "invalid fee rule: missing account", errors.KindBadRequest)
+	}
+
+	if rule.Bearer != Payee && rule.Bearer != Payer {
+		return errors.E(op, "invalid fee rule: unknown bearer", errors.KindBadRequest)
+	}
+
+	switch rule.Type {
+	case FlatFee, PercentageFee:
+		return validateFee(op, rule.Type, rule.Value)
+	case TieredFee:
+		if len(rule.Tiers) == 0 {
+			return errors.E(op, "invalid fee rule: missing tiers", errors.KindBadRequest)
+		}
+		for i, tier := range rule.Tiers {
+			if tier.Type == TieredFee {
+				return errors.E(op, "invalid fee rule: tiers can't be nested", errors.KindBadRequest)
+			}
+			if err := validateFee(op, tier.Type, tier.Value); err != nil {
+				return err
+			}
+			last := i == len(rule.Tiers)-1
+			if tier.UpTo == 0 && !last {
+				return errors.E(op, "invalid fee rule: only the last tier can be unbounded", errors.KindBadRequest)
+			}
+			if i > 0 && tier.UpTo != 0 && tier.UpTo <= rule.Tiers[i-1].UpTo {
+				return errors.E(op, "invalid fee rule: tiers must be sorted by amount", errors.KindBadRequest)
+			}
+		}
+		return nil
+	default:
+		return errors.E(op, "invalid fee rule: unknown type", errors.KindBadRequest)
+	}
+}
+
+// Compute the fee of a transaction amount rounded to the cent. Amounts
+// above the last bounded tier use that tier.
+func (rule FeeRule) Compute(amount float64) float64 {
+	typ, value := rule.Type, rule.Value
+
+	if typ == TieredFee {
+		if len(rule.Tiers) == 0 {
+			return 0
+		}
+		tier := rule.Tiers[len(rule.Tiers)-1]
+		for _, t := range rule.Tiers {
+			if t.UpTo == 0 || amount <= t.UpTo {
+				tier = t
+				break
+			}
+		}
+		typ, value = tier.Type, tier.Value
+	}
+
+	var fee float64
+	switch typ {
+	case FlatFee:
+		fee = value
+	case PercentageFee:
+		fee = amount * value
+	}
+	return math.Round(fee*100) / 100
+}
+
+// Net is what the account keeps of an amount once the fee is paid
+func Net(amount, fee float64, bearer FeeBearer) float64 {
+	if bearer == Payer {
+		return amount
+	}
+	return amount - fee
+}
+
+// FeeRepository stores the fee rules of accounts
+type FeeRepository interface {
+	// SaveFeeRule creates or replaces the fee rule of an account
+	SaveFeeRule(ctx context.Context, rule FeeRule) (FeeRule, error)
+
+	// RetrieveFeeRule returns the fee rule of an account
+	RetrieveFeeRule(ctx context.Context, account string) (FeeRule, error)
+}
+
+func validateFee(op errors.Op, typ FeeType, value float64) error {
+	switch {
+	case typ != FlatFee && typ != PercentageFee:
+		return errors.E(op, "invalid fee rule: unknown type", errors.KindBadRequest)
+	case value < 0:
+		return errors.E(op, "invalid fee rule: negative fee", errors.KindBadRequest)
+	case typ == PercentageFee && value >= 1:
+		return errors.E(op, "invalid fee rule: percentage must be a fraction below 1", errors.KindBadRequest)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package accounts_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFeeRule(t *testing.T) {
+	cases := []struct {
+		desc string
+		rule accounts.FeeRule
+		kind int
+	}{
+		{
+			desc: "validate percentage fee",
+			rule: accounts.FeeRule{Account: "kigali", Type: accounts.PercentageFee, Value: 0.02, Bearer: accounts.Payee},
+		},
+		{
+			desc: "validate tiered fee",
+			rule: accounts.FeeRule{Account: "kigali", Type: accounts.TieredFee, Bearer: accounts.Payer, Tiers: []accounts.FeeTier{
+				{UpTo: 1000, Type: accounts.FlatFee, Value: 50},
+				{Type: accounts.PercentageFee, Value: 0.03},
+			}},
+		},
+		{
+			desc: "validate fee without account",
+			rule: accounts.FeeRule{Type: accounts.FlatFee, Value: 100, Bearer: accounts.Payee},
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "validate fee with unknown bearer",
+			rule: accounts.FeeRule{Account: "kigali", Type: accounts.FlatFee, Value: 100, Bearer: "bank"},
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "validate percentage above 100%",
+			rule: accounts.FeeRule{Account: "kigali", Type: accounts.PercentageFee, Value: 5, Bearer: accounts.Payee},
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "validate tiers out of order",
+			rule: accounts.FeeRule{Account: "kigali", Type: accounts.TieredFee, Bearer: accounts.Payee, Tiers: []accounts.FeeTier{
+				{UpTo: 5000, Type: accounts.FlatFee, Value: 100},
+				{UpTo: 1000, Type: accounts.FlatFee, Value: 50},
+			}},
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "validate unbounded tier before the last one",
+			rule: accounts.FeeRule{Account: "kigali", Type: accounts.TieredFee, Bearer: accounts.Payee, Tiers: []accounts.FeeTier{
+				{Type: accounts.FlatFee, Value: 50},
+				{UpTo: 1000, Type: accounts.FlatFee, Value: 100},
+			}},
+			kind: errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.rule.Validate()
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
+
+func TestComputeFee(t *testing.T) {
+	tiered := accounts.FeeRule{Type: accounts.TieredFee, Tiers: []accounts.FeeTier{
+		{UpTo: 1000, Type: accounts.FlatFee, Value: 50},
+		{UpTo: 10000, Type: accounts.PercentageFee, Value: 0.03},
+	}}
+
+	cases := []struct {
+		desc   string
+		rule   accounts.FeeRule
+		amount float64
+		fee    float64
+	}{
+		{
+			desc:   "compute flat fee",
+			rule:   accounts.FeeRule{Type: accounts.FlatFee, Value: 100},
+			amount: 2000,
+			fee:    100,
+		},
+		{
+			desc:   "compute percentage fee",
+			rule:   accounts.FeeRule{Type: accounts.PercentageFee, Value: 0.015},
+			amount: 1333,
+			fee:    20,
+		},
+		{
+			desc:   "compute fee without a rule",
+			rule:   accounts.NoFeeRule("kigali"),
+			amount: 2000,
+			fee:    0,
+		},
+		{
+			desc:   "compute first tier fee",
+			rule:   tiered,
+			amount: 1000,
+			fee:    50,
+		},
+		{
+			desc:   "compute second tier fee",
+			rule:   tiered,
+			amount: 5000,
+			fee:    150,
+		},
+		{
+			desc:   "compute fee above the last tier",
+			rule:   tiered,
+			amount: 20000,
+			fee:    600,
+		},
+	}
+
+	for _, tc := range cases {
+		fee := tc.rule.Compute(tc.amount)
+		assert.Equal(t, tc.fee, fee, tc.desc)
+	}
+
+	assert.Equal(t, float64(1900), accounts.Net(2000, 100, accounts.Payee))
+	assert.Equal(t, float64(2000), accounts.Net(2000, 100, accounts.Payer))
+}
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (accounts.FeeRepository) = (*feesMock)(nil)
+
+type feesMock struct {
+	mu    sync.Mutex
+	rules map[string]accounts.FeeRule
+}
+
+// NewFeeRepository initiates a mock fee rules repository
+func NewFeeRepository() accounts.FeeRepository {
+	return &feesMock{
+		rules: make(map[string]accounts.FeeRule),
+	}
+}
+
+func (repo *feesMock) SaveFeeRule(ctx context.Context, rule accounts.FeeRule) (accounts.FeeRule, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+
+	rule.CreatedAt, rule.UpdatedAt = now, now
+	if saved, ok := repo.rules[rule.Account]; ok {
+		rule.CreatedAt = saved.CreatedAt
+	}
+	repo.rules[rule.Account] = rule
+
+	return rule, nil
+}
+
+func (repo *feesMock) RetrieveFeeRule(ctx context.Context, account string) (accounts.FeeRule, error) {
+	const op errors.Op = "accounts/repository.RetrieveFeeRule"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	rule, ok := repo.rules[account]
+	if !ok {
+		return accounts.FeeRule{}, errors.E(op, "fee rule not found", errors.KindNotFound)
+	}
+	return rule, nil
+}
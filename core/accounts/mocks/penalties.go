@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (accounts.PenaltyRepository) = (*penaltiesMock)(nil)
+
+type penaltiesMock struct {
+	mu       sync.Mutex
+	policies map[string]accounts.PenaltyPolicy
+}
+
+// NewPenaltyRepository initiates a mock penalty policies repository
+func NewPenaltyRepository() accounts.PenaltyRepository {
+	return &penaltiesMock{
+		policies: make(map[string]accounts.PenaltyPolicy),
+	}
+}
+
+func (repo *penaltiesMock) SavePenaltyPolicy(ctx context.Context, policy accounts.PenaltyPolicy) (accounts.PenaltyPolicy, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+
+	policy.CreatedAt, policy.UpdatedAt = now, now
+	if saved, ok := repo.policies[policy.Account]; ok {
+		policy.CreatedAt = saved.CreatedAt
+	}
+	repo.policies[policy.Account] = policy
+
+	return policy, nil
+}
+
+func (repo *penaltiesMock) RetrievePenaltyPolicy(ctx context.Context, account string) (accounts.PenaltyPolicy, error) {
+	const op errors.Op = "accounts/repository.RetrievePenaltyPolicy"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	policy, ok := repo.policies[account]
+	if !ok {
+		return accounts.PenaltyPolicy{}, errors.E(op, "penalty policy not found", errors.KindNotFound)
+	}
+	return policy, nil
+}
@@ -0,0 +1,94 @@
+package accounts
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// PenaltyType tells how the late payment penalty of an invoice is computed
+type PenaltyType string
+
+const (
+	// FixedPenalty charges the same amount on every overdue invoice
+	FixedPenalty PenaltyType = "fixed"
+	// PercentagePenalty charges a share of what is still owed on the invoice
+	PercentagePenalty PenaltyType = "percentage"
+)
+
+// PenaltyPolicy is the late payment penalty of an account. Once an invoice
+// is still owed GraceDays after its period ended it gets a single penalty
+// invoice linked to it, a zero Cap doesn't bound the penalty.
+type PenaltyPolicy struct {
+	Account   string      `json:"account,omitempty"`
+	Type      PenaltyType `json:"type,omitempty"`
+	Value     float64     `json:"value,omitempty"`
+	Cap       float64     `json:"cap,omitempty"`
+	GraceDays int         `json:"grace_days,omitempty"`
+	Enabled   bool        `json:"enabled"`
+	CreatedAt time.Time   `json:"created_at,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at,omitempty"`
+}
+
+// DefaultPenaltyPolicy is applied to accounts that never configured one,
+// their invoices are never penalized
+func DefaultPenaltyPolicy(account string) PenaltyPolicy {
+	return PenaltyPolicy{Account: account, Type: FixedPenalty}
+}
+
+// Validate the penalty policy before it's saved
+func (policy *PenaltyPolicy) Validate() error {
+	const op errors.Op = "app/accounts/penaltyPolicy.Validate"
+
+	if policy.Account == "" {
+		return errors.E(op, "invalid penalty policy: missing account", errors.KindBadRequest)
+	}
+
+	switch {
+	case policy.Type != FixedPenalty && policy.Type != PercentagePenalty:
+		return errors.E(op, "invalid penalty policy: unknown type", errors.KindBadRequest)
+	case policy.Value < 0:
+		return errors.E(op, "invalid penalty policy: negative penalty", errors.KindBadRequest)
+	case policy.Type == PercentagePenalty && policy.Value >= 1:
+		return errors.E(op, "invalid penalty policy: percentage must be a fraction below 1", errors.KindBadRequest)
+	case policy.Cap < 0:
+		return errors.E(op, "invalid penalty policy: negative cap", errors.KindBadRequest)
+	case policy.GraceDays < 0:
+		return errors.E(op, "invalid penalty policy: negative grace period", errors.KindBadRequest)
+	case policy.Enabled && policy.Value == 0:
+		return errors.E(op, "invalid penalty policy: missing penalty", errors.KindBadRequest)
+	}
+	return nil
+}
+
+// Compute the penalty of an invoice that still owes an amount, rounded to
+// the cent and bounded by the cap
+func (policy PenaltyPolicy) Compute(owed float64) float64 {
+	if !policy.Enabled || owed <= 0 {
+		return 0
+	}
+
+	var penalty float64
+	switch policy.Type {
+	case FixedPenalty:
+		penalty = policy.Value
+	case PercentagePenalty:
+		penalty = owed * policy.Value
+	}
+
+	if policy.Cap > 0 && penalty > policy.Cap {
+		penalty = policy.Cap
+	}
+	return math.Round(penalty*100) / 100
+}
+
+// PenaltyRepository stores the penalty policies of accounts
+type PenaltyRepository interface {
+	// SavePenaltyPolicy creates or replaces the penalty policy of an account
+	SavePenaltyPolicy(ctx context.Context, policy PenaltyPolicy) (PenaltyPolicy, error)
+
+	// RetrievePenaltyPolicy returns the penalty policy of an account
+	RetrievePenaltyPolicy(ctx context.Context, account string) (PenaltyPolicy, error)
+}
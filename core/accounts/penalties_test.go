@@ -0,0 +1,115 @@
+package accounts_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePenaltyPolicy(t *testing.T) {
+	cases := []struct {
+		desc   string
+		policy accounts.PenaltyPolicy
+		kind   int
+	}{
+		{
+			desc:   "validate fixed penalty",
+			policy: accounts.PenaltyPolicy{Account: "kigali", Type: accounts.FixedPenalty, Value: 500, GraceDays: 10, Enabled: true},
+		},
+		{
+			desc:   "validate capped percentage penalty",
+			policy: accounts.PenaltyPolicy{Account: "kigali", Type: accounts.PercentagePenalty, Value: 0.1, Cap: 1000, Enabled: true},
+		},
+		{
+			desc:   "validate default policy",
+			policy: accounts.DefaultPenaltyPolicy("kigali"),
+		},
+		{
+			desc:   "validate penalty without account",
+			policy: accounts.PenaltyPolicy{Type: accounts.FixedPenalty, Value: 500, Enabled: true},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate penalty with unknown type",
+			policy: accounts.PenaltyPolicy{Account: "kigali", Type: "daily", Value: 500, Enabled: true},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate percentage above 100%",
+			policy: accounts.PenaltyPolicy{Account: "kigali", Type: accounts.PercentagePenalty, Value: 10, Enabled: true},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate negative cap",
+			policy: accounts.PenaltyPolicy{Account: "kigali", Type: accounts.FixedPenalty, Value: 500, Cap: -1, Enabled: true},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate negative grace period",
+			policy: accounts.PenaltyPolicy{Account: "kigali", Type: accounts.FixedPenalty, Value: 500, GraceDays: -1, Enabled: true},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate enabled policy without penalty",
+			policy: accounts.PenaltyPolicy{Account: "kigali", Type: accounts.FixedPenalty, Enabled: true},
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.policy.Validate()
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
+
+func TestComputePenalty(t *testing.T) {
+	cases := []struct {
+		desc    string
+		policy  accounts.PenaltyPolicy
+		owed    float64
+		penalty float64
+	}{
+		{
+			desc:    "compute fixed penalty",
+			policy:  accounts.PenaltyPolicy{Type: accounts.FixedPenalty, Value: 500, Enabled: true},
+			owed:    2000,
+			penalty: 500,
+		},
+		{
+			desc:    "compute percentage penalty",
+			policy:  accounts.PenaltyPolicy{Type: accounts.PercentagePenalty, Value: 0.015, Enabled: true},
+			owed:    1333,
+			penalty: 20,
+		},
+		{
+			desc:    "compute capped penalty",
+			policy:  accounts.PenaltyPolicy{Type: accounts.PercentagePenalty, Value: 0.5, Cap: 300, Enabled: true},
+			owed:    2000,
+			penalty: 300,
+		},
+		{
+			desc:    "compute disabled penalty",
+			policy:  accounts.PenaltyPolicy{Type: accounts.FixedPenalty, Value: 500},
+			owed:    2000,
+			penalty: 0,
+		},
+		{
+			desc:    "compute penalty of a paid invoice",
+			policy:  accounts.PenaltyPolicy{Type: accounts.FixedPenalty, Value: 500, Enabled: true},
+			owed:    0,
+			penalty: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		penalty := tc.policy.Compute(tc.owed)
+		assert.Equal(t, tc.penalty, penalty, tc.desc)
+	}
+}
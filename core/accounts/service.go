@@ -13,23 +13,41 @@ type Service interface {
 	Update(ctx context.Context, acc Account) error
 	Retrieve(ctx context.Context, id string) (Account, error)
 	List(ctx context.Context, offset, limit uint64) (AccountPage, error)
+
+	// SetFeeRule replaces the fee rule of an account
+	SetFeeRule(ctx context.Context, rule FeeRule) (FeeRule, error)
+
+	// FeeRule returns the fee rule of an account or the default one
+	FeeRule(ctx context.Context, account string) (FeeRule, error)
+
+	// SetPenaltyPolicy replaces the late payment penalty policy of an account
+	SetPenaltyPolicy(ctx context.Context, policy PenaltyPolicy) (PenaltyPolicy, error)
+
+	// PenaltyPolicy returns the penalty policy of an account or a disabled one
+	PenaltyPolicy(ctx context.Context, account string) (PenaltyPolicy, error)
 }
 
 // Options contains accounts.Service creation options
 type Options struct {
 	Repository Repository
+	Fees       FeeRepository
+	Penalties  PenaltyRepository
 	IDP        identity.Provider
 }
 type service struct {
-	repo Repository
-	idp  identity.Provider
+	repo      Repository
+	fees      FeeRepository
+	penalties PenaltyRepository
+	idp       identity.Provider
 }
 
 // New instantiates the accounts.Service
 func New(opts *Options) Service {
 	return &service{
-		repo: opts.Repository,
-		idp:  opts.IDP,
+		repo:      opts.Repository,
+		fees:      opts.Fees,
+		penalties: opts.Penalties,
+		idp:       opts.IDP,
 	}
 }
 
@@ -74,3 +92,77 @@ func (svc *service) List(ctx context.Context, offset, limit uint64) (AccountPage
 	}
 	return page, nil
 }
+
+func (svc *service) SetFeeRule(ctx context.Context, rule FeeRule) (FeeRule, error) {
+	const op errors.Op = "app/accounts/service.SetFeeRule"
+
+	if rule.Bearer == "" {
+		rule.Bearer = Payee
+	}
+
+	if err := rule.Validate(); err != nil {
+		return FeeRule{}, errors.E(op, err)
+	}
+
+	if _, err := svc.repo.Retrieve(ctx, rule.Account); err != nil {
+		return FeeRule{}, errors.E(op, err)
+	}
+
+	saved, err := svc.fees.SaveFeeRule(ctx, rule)
+	if err != nil {
+		return FeeRule{}, errors.E(op, err)
+	}
+	return saved, nil
+}
+
+func (svc *service) FeeRule(ctx context.Context, account string) (FeeRule, error) {
+	const op errors.Op = "app/accounts/service.FeeRule"
+
+	rule, err := svc.fees.RetrieveFeeRule(ctx, account)
+	if err == nil {
+		return rule, nil
+	}
+	if errors.Kind(err) != errors.KindNotFound {
+		return FeeRule{}, errors.E(op, err)
+	}
+
+	if _, err := svc.repo.Retrieve(ctx, account); err != nil {
+		return FeeRule{}, errors.E(op, err)
+	}
+	return NoFeeRule(account), nil
+}
+
+func (svc *service) SetPenaltyPolicy(ctx context.Context, policy PenaltyPolicy) (PenaltyPolicy, error) {
+	const op errors.Op = "app/accounts/service.SetPenaltyPolicy"
+
+	if err := policy.Validate(); err != nil {
+		return PenaltyPolicy{}, errors.E(op, err)
+	}
+
+	if _, err := svc.repo.Retrieve(ctx, policy.Account); err != nil {
+		return PenaltyPolicy{}, errors.E(op, err)
+	}
+
+	saved, err := svc.penalties.SavePenaltyPolicy(ctx, policy)
+	if err != nil {
+		return PenaltyPolicy{}, errors.E(op, err)
+	}
+	return saved, nil
+}
+
+func (svc *service) PenaltyPolicy(ctx context.Context, account string) (PenaltyPolicy, error) {
+	const op errors.Op = "app/accounts/service.PenaltyPolicy"
+
+	policy, err := svc.penalties.RetrievePenaltyPolicy(ctx, account)
+	if err == nil {
+		return policy, nil
+	}
+	if errors.Kind(err) != errors.KindNotFound {
+		return PenaltyPolicy{}, errors.E(op, err)
+	}
+
+	if _, err := svc.repo.Retrieve(ctx, account); err != nil {
+		return PenaltyPolicy{}, errors.E(op, err)
+	}
+	return DefaultPenaltyPolicy(account), nil
+}
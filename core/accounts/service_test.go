@@ -15,7 +15,7 @@ import (
 func newService() accounts.Service {
 	repo := mocks.NewRepository()
 	idp := mocks.NewIdentityProvider()
-	opts := &accounts.Options{Repository: repo, IDP: idp}
+	opts := &accounts.Options{Repository: repo, Fees: mocks.NewFeeRepository(), Penalties: mocks.NewPenaltyRepository(), IDP: idp}
 	return accounts.New(opts)
 }
 
@@ -141,6 +141,58 @@ func TestRetrieve(t *testing.T) {
 
 }
 
+func TestFeeRule(t *testing.T) {
+	svc := newService()
+
+	ctx := context.Background()
+
+	account := accounts.Account{ID: "paypack.developers", Name: "developers", NumberOfSeats: 10, Type: accounts.Devs}
+	saved, err := svc.Create(ctx, account)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	rule, err := svc.FeeRule(ctx, saved.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, accounts.NoFeeRule(saved.ID), rule, "expected no fee without a rule")
+
+	cases := []struct {
+		desc string
+		rule accounts.FeeRule
+		kind int
+	}{
+		{
+			desc: "set valid fee rule",
+			rule: accounts.FeeRule{Account: saved.ID, Type: accounts.FlatFee, Value: 100},
+		},
+		{
+			desc: "set invalid fee rule",
+			rule: accounts.FeeRule{Account: saved.ID, Type: accounts.FlatFee, Value: -1},
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "set fee rule of unknown account",
+			rule: accounts.FeeRule{Account: "invalid", Type: accounts.FlatFee, Value: 100},
+			kind: errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.SetFeeRule(ctx, tc.rule)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+
+	rule, err = svc.FeeRule(ctx, saved.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, accounts.FlatFee, rule.Type)
+	assert.Equal(t, accounts.Payee, rule.Bearer, "expected the payee to bear fees by default")
+
+	_, err = svc.FeeRule(ctx, "invalid")
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err))
+}
+
 func TestList(t *testing.T) {
 	svc := newService()
 
@@ -201,3 +253,55 @@ func TestList(t *testing.T) {
 	}
 
 }
+
+func TestPenaltyPolicy(t *testing.T) {
+	svc := newService()
+
+	ctx := context.Background()
+
+	account := accounts.Account{ID: "paypack.developers", Name: "developers", NumberOfSeats: 10, Type: accounts.Devs}
+	saved, err := svc.Create(ctx, account)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	policy, err := svc.PenaltyPolicy(ctx, saved.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.False(t, policy.Enabled, "expected penalties to be disabled by default")
+
+	cases := []struct {
+		desc   string
+		policy accounts.PenaltyPolicy
+		kind   int
+	}{
+		{
+			desc:   "set valid penalty policy",
+			policy: accounts.PenaltyPolicy{Account: saved.ID, Type: accounts.PercentagePenalty, Value: 0.1, Cap: 1000, GraceDays: 15, Enabled: true},
+		},
+		{
+			desc:   "set invalid penalty policy",
+			policy: accounts.PenaltyPolicy{Account: saved.ID, Type: accounts.FixedPenalty, Value: -1, Enabled: true},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "set penalty policy of unknown account",
+			policy: accounts.PenaltyPolicy{Account: "invalid", Type: accounts.FixedPenalty, Value: 500, Enabled: true},
+			kind:   errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.SetPenaltyPolicy(ctx, tc.policy)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+
+	policy, err = svc.PenaltyPolicy(ctx, saved.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.True(t, policy.Enabled, "expected penalties to be enabled")
+	assert.Equal(t, 15, policy.GraceDays)
+
+	_, err = svc.PenaltyPolicy(ctx, "invalid")
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err))
+}
@@ -5,4 +5,7 @@ import "context"
 // Executor ...
 type Executor interface {
 	ArchiveFunc(ctx context.Context, offset, limit int) (int, error)
+
+	// PenaltyFunc charges late payment penalties on overdue invoices
+	PenaltyFunc(ctx context.Context) (int, error)
 }
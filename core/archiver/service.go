@@ -40,5 +40,17 @@ func (svc *service) Schedule(ctx context.Context, count, bsize int) error {
 			break
 		}
 	}
+
+	// overdue invoices are penalized once archived
+	for {
+		charged, err := svc.exec.PenaltyFunc(ctx)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		if charged == 0 {
+			break
+		}
+	}
 	return nil
 }
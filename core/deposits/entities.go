@@ -0,0 +1,241 @@
+package deposits
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// MaxStatementSize is the largest bank statement that can be uploaded, in bytes
+const MaxStatementSize = 5 << 20
+
+// LineStatus is the state of a statement line
+type LineStatus string
+
+// possible statement line states
+const (
+	// Pending lines wait for the matching task
+	Pending LineStatus = "pending"
+	// Matched lines settled the invoices of a house
+	Matched LineStatus = "matched"
+	// Review lines couldn't be matched with confidence
+	Review LineStatus = "review"
+	// Ignored lines were dismissed by a reviewer
+	Ignored LineStatus = "ignored"
+)
+
+// Statement is a bank statement uploaded by a sector
+type Statement struct {
+	ID         string    `json:"id,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Filename   string    `json:"filename,omitempty"`
+	UploadedBy string    `json:"uploaded_by,omitempty"`
+	Lines      int       `json:"lines"`
+	Duplicates int       `json:"duplicates"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+}
+
+// Line is a deposit listed in a bank statement
+type Line struct {
+	ID         string     `json:"id,omitempty"`
+	Statement  string     `json:"statement,omitempty"`
+	Namespace  string     `json:"namespace,omitempty"`
+	Date       time.Time  `json:"date,omitempty"`
+	Reference  string     `json:"reference,omitempty"`
+	Narrative  string     `json:"narrative,omitempty"`
+	Amount     float64    `json:"amount,omitempty"`
+	Status     LineStatus `json:"status,omitempty"`
+	Property   string     `json:"property,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+// Filters narrows down listed statement lines, nil filters are ignored
+type Filters struct {
+	Namespace *string
+	Status    *string
+	Statement *string
+	Offset    uint64
+	Limit     uint64
+}
+
+// PageMetadata ...
+type PageMetadata struct {
+	Total  uint64
+	Amount float64 `json:"amount,omitempty"`
+	Offset uint64
+	Limit  uint64
+}
+
+// Page is a list of statement lines
+type Page struct {
+	PageMetadata
+	Lines []Line `json:"lines"`
+}
+
+// Report summarises a run of the matching task
+type Report struct {
+	Checked int `json:"checked"`
+	Matched int `json:"matched"`
+	Review  int `json:"review"`
+}
+
+// statement column headers, compared in lower case
+var (
+	dateHeaders      = []string{"date", "value date", "transaction date", "posting date", "booking date"}
+	narrativeHeaders = []string{"narrative", "description", "details", "particulars", "remarks"}
+	referenceHeaders = []string{"reference", "ref", "transaction reference", "transaction id"}
+	amountHeaders    = []string{"amount", "credit", "credit amount", "deposit"}
+)
+
+var dateLayouts = []string{"2006-01-02", "02/01/2006", "02-01-2006", "2006/01/02", "02-Jan-2006", "02 Jan 2006"}
+
+// Parse reads the deposits of a csv bank statement. The first row
+// names the columns, withdrawals and rows without an amount are skipped.
+func Parse(r io.Reader) ([]Line, error) {
+	const op errors.Op = "core/deposits/Parse"
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.E(op, "statement is empty or not csv", errors.KindBadRequest)
+	}
+
+	var (
+		date      = column(header, dateHeaders)
+		narrative = column(header, narrativeHeaders)
+		reference = column(header, referenceHeaders)
+		amount    = column(header, amountHeaders)
+	)
+
+	if amount < 0 || narrative < 0 && reference < 0 {
+		return nil, errors.E(op, "statement must have an amount and a description column", errors.KindBadRequest)
+	}
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	lines := []Line{}
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.E(op, fmt.Sprintf("row %d: %v", row, err), errors.KindBadRequest)
+		}
+
+		value, err := parseAmount(field(record, amount))
+		if err != nil {
+			return nil, errors.E(op, fmt.Sprintf("row %d: invalid amount", row), errors.KindBadRequest)
+		}
+		if value <= 0 {
+			continue
+		}
+
+		line := Line{
+			Narrative: field(record, narrative),
+			Reference: field(record, reference),
+			Amount:    value,
+			Status:    Pending,
+		}
+
+		if raw := field(record, date); raw != "" {
+			if line.Date, err = parseDate(raw); err != nil {
+				return nil, errors.E(op, fmt.Sprintf("row %d: invalid date", row), errors.KindBadRequest)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// ExtractCodes finds the house codes mentioned in free text. Any word made
+// of exactly properties.Length characters of properties.Alphabet is a
+// candidate, dates and account numbers are weeded out by looking them up.
+func ExtractCodes(text string) []string {
+	isCode := func(r rune) bool {
+		return strings.ContainsRune(properties.Alphabet, r)
+	}
+
+	seen := make(map[string]bool)
+	codes := []string{}
+
+	words := strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !isCode(r)
+	})
+	for _, word := range words {
+		if len(word) != properties.Length || seen[word] {
+			continue
+		}
+		seen[word] = true
+		codes = append(codes, word)
+	}
+	return codes
+}
+
+// MatchInvoices tells whether an amount pays off the oldest outstanding
+// invoices exactly, a deposit for a whole number of months is a confident
+// match.
+func MatchInvoices(amount float64, outstanding []invoices.Invoice) bool {
+	var due float64
+	for _, vc := range outstanding {
+		due += vc.Balance()
+		if math.Abs(amount-due) < 0.01 {
+			return true
+		}
+		if due > amount {
+			return false
+		}
+	}
+	return false
+}
+
+func column(header []string, names []string) int {
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		for _, name := range names {
+			if h == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseAmount(raw string) (float64, error) {
+	raw = strings.NewReplacer(",", "", " ", "", "RWF", "", "rwf", "").Replace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+func parseDate(raw string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,126 @@
+package deposits_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		desc      string
+		statement string
+		lines     int
+		kind      int
+	}{
+		{
+			desc: "parse statement with deposits and withdrawals",
+			statement: "Date,Narrative,Reference,Credit\n" +
+				"2020-10-01,DEPOSIT 22C95179 OCT,FT2027501,\"1,000.00\"\n" +
+				"2020-10-02,BANK CHARGES,FT2027502,\n" +
+				"02/10/2020,ISUKU 0A1B2C3D,FT2027503,2000\n",
+			lines: 2,
+		},
+		{
+			desc:      "parse statement without amount column",
+			statement: "Date,Narrative\n2020-10-01,DEPOSIT 22C95179\n",
+			kind:      errors.KindBadRequest,
+		},
+		{
+			desc:      "parse statement with invalid amount",
+			statement: "Narrative,Amount\nDEPOSIT 22C95179,one thousand\n",
+			kind:      errors.KindBadRequest,
+		},
+		{
+			desc:      "parse statement with invalid date",
+			statement: "Date,Narrative,Amount\nyesterday,DEPOSIT 22C95179,1000\n",
+			kind:      errors.KindBadRequest,
+		},
+		{
+			desc:      "parse empty statement",
+			statement: "",
+			kind:      errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		lines, err := deposits.Parse(strings.NewReader(tc.statement))
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Len(t, lines, tc.lines, fmt.Sprintf("%s: expected %d lines got %d", tc.desc, tc.lines, len(lines)))
+	}
+
+	lines, err := deposits.Parse(strings.NewReader(cases[0].statement))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 1000.0, lines[0].Amount, fmt.Sprintf("expected %v got %v", 1000.0, lines[0].Amount))
+	assert.Equal(t, time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC), lines[1].Date, "expected day first dates")
+}
+
+func TestExtractCodes(t *testing.T) {
+	cases := []struct {
+		desc  string
+		text  string
+		codes []string
+	}{
+		{
+			desc:  "extract code from narrative",
+			text:  "DEPOSIT BY KAMANZI FOR 22C95179 OCT",
+			codes: []string{"22C95179"},
+		},
+		{
+			desc:  "extract lower case code",
+			text:  "isuku 0a1b2c3d",
+			codes: []string{"0A1B2C3D"},
+		},
+		{
+			desc:  "extract several codes once",
+			text:  "22C95179/0A1B2C3D 22C95179",
+			codes: []string{"22C95179", "0A1B2C3D"},
+		},
+		{
+			desc:  "ignore words of another length",
+			text:  "REF 22C9517 2020100155",
+			codes: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		codes := deposits.ExtractCodes(tc.text)
+		assert.Equal(t, tc.codes, codes, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.codes, codes))
+	}
+}
+
+func TestMatchInvoices(t *testing.T) {
+	outstanding := []invoices.Invoice{
+		{ID: 1, Amount: 1000, Paid: 400},
+		{ID: 2, Amount: 1000},
+		{ID: 3, Amount: 1000},
+	}
+
+	cases := []struct {
+		desc   string
+		amount float64
+		match  bool
+	}{
+		{desc: "match balance of the oldest invoice", amount: 600, match: true},
+		{desc: "match balance of two invoices", amount: 1600, match: true},
+		{desc: "match balance of every invoice", amount: 2600, match: true},
+		{desc: "match a part of an invoice", amount: 1000, match: false},
+		{desc: "match more than is owed", amount: 3600, match: false},
+	}
+
+	for _, tc := range cases {
+		match := deposits.MatchInvoices(tc.amount, outstanding)
+		assert.Equal(t, tc.match, match, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.match, match))
+	}
+}
@@ -0,0 +1,163 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (deposits.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu         sync.Mutex
+	statements map[string]deposits.Statement
+	lines      map[string]deposits.Line
+}
+
+// NewRepository creates an in memory mock of deposits.Repository
+func NewRepository() deposits.Repository {
+	return &repositoryMock{
+		statements: make(map[string]deposits.Statement),
+		lines:      make(map[string]deposits.Line),
+	}
+}
+
+func (repo *repositoryMock) Save(ctx context.Context, s *deposits.Statement, lines []deposits.Line) error {
+	const op errors.Op = "core/deposits/mocks/repositoryMock.Save"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.statements[s.ID]; ok {
+		return errors.E(op, "statement already exists", errors.KindAlreadyExists)
+	}
+
+	s.CreatedAt = time.Now()
+	s.Lines, s.Duplicates = 0, 0
+
+	for _, line := range lines {
+		if repo.duplicate(line) {
+			s.Duplicates++
+			continue
+		}
+		line.CreatedAt = s.CreatedAt
+		line.UpdatedAt = s.CreatedAt
+		repo.lines[line.ID] = line
+		s.Lines++
+	}
+	repo.statements[s.ID] = *s
+	return nil
+}
+
+func (repo *repositoryMock) Pending(ctx context.Context, batch uint64) ([]deposits.Line, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	lines := make([]deposits.Line, 0)
+	for _, line := range repo.lines {
+		if line.Status == deposits.Pending {
+			lines = append(lines, line)
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].CreatedAt.Before(lines[j].CreatedAt)
+	})
+
+	if uint64(len(lines)) > batch {
+		lines = lines[:batch]
+	}
+	return lines, nil
+}
+
+func (repo *repositoryMock) Line(ctx context.Context, id string) (deposits.Line, error) {
+	const op errors.Op = "core/deposits/mocks/repositoryMock.Line"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	line, ok := repo.lines[id]
+	if !ok {
+		return deposits.Line{}, errors.E(op, "statement line not found", errors.KindNotFound)
+	}
+	return line, nil
+}
+
+func (repo *repositoryMock) Lines(ctx context.Context, flts *deposits.Filters) (deposits.Page, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	items := make([]deposits.Line, 0)
+	for _, line := range repo.lines {
+		if flts.Namespace != nil && line.Namespace != *flts.Namespace {
+			continue
+		}
+		if flts.Status != nil && string(line.Status) != *flts.Status {
+			continue
+		}
+		if flts.Statement != nil && line.Statement != *flts.Statement {
+			continue
+		}
+		items = append(items, line)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	page := deposits.Page{
+		PageMetadata: deposits.PageMetadata{
+			Total:  uint64(len(items)),
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+		},
+		Lines: []deposits.Line{},
+	}
+
+	for i, line := range items {
+		page.Amount += line.Amount
+		if uint64(i) >= flts.Offset && uint64(i) < flts.Offset+flts.Limit {
+			page.Lines = append(page.Lines, line)
+		}
+	}
+	return page, nil
+}
+
+func (repo *repositoryMock) Transition(ctx context.Context, line deposits.Line, from deposits.LineStatus) error {
+	const op errors.Op = "core/deposits/mocks/repositoryMock.Transition"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	prev, ok := repo.lines[line.ID]
+	if !ok {
+		return errors.E(op, "statement line not found", errors.KindNotFound)
+	}
+	if prev.Status != from {
+		return errors.E(op, "statement line was already resolved", errors.KindAlreadyExists)
+	}
+
+	prev.Status = line.Status
+	prev.Property = line.Property
+	prev.Reason = line.Reason
+	prev.ReviewedBy = line.ReviewedBy
+	prev.UpdatedAt = time.Now()
+	repo.lines[line.ID] = prev
+	return nil
+}
+
+func (repo *repositoryMock) duplicate(line deposits.Line) bool {
+	if line.Reference == "" {
+		return false
+	}
+	for _, prev := range repo.lines {
+		if prev.Namespace == line.Namespace && prev.Reference == line.Reference && prev.Amount == line.Amount && prev.Date.Equal(line.Date) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package deposits
+
+import "context"
+
+// Repository stores bank statements and their lines
+type Repository interface {
+	// Save a statement with its lines. Deposits already imported by an
+	// earlier statement are skipped and counted as duplicates.
+	Save(ctx context.Context, s *Statement, lines []Line) error
+
+	// Pending retrieves up to batch lines awaiting the matching task, the
+	// oldest first
+	Pending(ctx context.Context, batch uint64) ([]Line, error)
+
+	// Line retrieves a statement line by id
+	Line(ctx context.Context, id string) (Line, error)
+
+	// Lines lists statement lines starting with the most recent
+	Lines(ctx context.Context, flts *Filters) (Page, error)
+
+	// Transition moves a line from the given status to the status of line.
+	// It fails if the line moved in the meantime so that it never settles
+	// twice.
+	Transition(ctx context.Context, line Line, from LineStatus) error
+}
@@ -0,0 +1,353 @@
+package deposits
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity"
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the bank deposit usecases
+type Service interface {
+	// Import parses a csv bank statement and queues its deposits for the
+	// matching task
+	Import(ctx context.Context, s Statement, r io.Reader) (Statement, error)
+
+	// Match settles the invoices of houses whose code and dues match
+	// pending statement lines, the others are sent for review
+	Match(ctx context.Context, batch uint64) (Report, error)
+
+	// Lines lists bank statement lines
+	Lines(ctx context.Context, flts *Filters) (Page, error)
+
+	// Resolve settles a line of namespace sent for review with the
+	// invoices of the house picked by the reviewer
+	Resolve(ctx context.Context, id, namespace, property, reviewer string) (Line, error)
+
+	// Dismiss closes a line of namespace sent for review without settling it
+	Dismiss(ctx context.Context, id, namespace, reviewer, reason string) (Line, error)
+}
+
+// Options contains deposits.Service creation options
+type Options struct {
+	Idp        identity.Provider
+	Repository Repository
+	Properties properties.Repository
+	Invoices   invoices.Repository
+	Payments   payment.Repository
+}
+
+type service struct {
+	idp        identity.Provider
+	repo       Repository
+	properties properties.Repository
+	invoices   invoices.Repository
+	payments   payment.Repository
+}
+
+// New instantiates the deposits.Service
+func New(opts *Options) Service {
+	return &service{
+		idp:        opts.Idp,
+		repo:       opts.Repository,
+		properties: opts.Properties,
+		invoices:   opts.Invoices,
+		payments:   opts.Payments,
+	}
+}
+
+func (svc *service) Import(ctx context.Context, s Statement, r io.Reader) (Statement, error) {
+	const op errors.Op = "core/deposits/service.Import"
+
+	if s.Namespace == "" {
+		return Statement{}, errors.E(op, "missing namespace", errors.KindBadRequest)
+	}
+
+	lines, err := Parse(r)
+	if err != nil {
+		return Statement{}, errors.E(op, err)
+	}
+	if len(lines) == 0 {
+		return Statement{}, errors.E(op, "statement has no deposits", errors.KindBadRequest)
+	}
+
+	s.ID = svc.idp.ID()
+	for i := range lines {
+		lines[i].ID = svc.idp.ID()
+		lines[i].Statement = s.ID
+		lines[i].Namespace = s.Namespace
+	}
+
+	if err := svc.repo.Save(ctx, &s, lines); err != nil {
+		return Statement{}, errors.E(op, err)
+	}
+	return s, nil
+}
+
+func (svc *service) Match(ctx context.Context, batch uint64) (Report, error) {
+	const op errors.Op = "core/deposits/service.Match"
+
+	var report Report
+
+	lines, err := svc.repo.Pending(ctx, batch)
+	if err != nil {
+		return report, errors.E(op, err)
+	}
+
+	for _, line := range lines {
+		status, err := svc.match(ctx, line)
+		if err != nil {
+			return report, errors.E(op, err)
+		}
+
+		report.Checked++
+		switch status {
+		case Matched:
+			report.Matched++
+		case Review:
+			report.Review++
+		}
+	}
+	return report, nil
+}
+
+func (svc *service) Lines(ctx context.Context, flts *Filters) (Page, error) {
+	const op errors.Op = "core/deposits/service.Lines"
+
+	page, err := svc.repo.Lines(ctx, flts)
+	if err != nil {
+		return Page{}, errors.E(op, err)
+	}
+	return page, nil
+}
+
+func (svc *service) Resolve(ctx context.Context, id, namespace, property, reviewer string) (Line, error) {
+	const op errors.Op = "core/deposits/service.Resolve"
+
+	line, err := svc.reviewable(ctx, id, namespace)
+	if err != nil {
+		return Line{}, errors.E(op, err)
+	}
+
+	house, err := svc.properties.RetrieveByID(ctx, property)
+	if err != nil {
+		return Line{}, errors.E(op, err)
+	}
+	if house.Namespace != line.Namespace {
+		return Line{}, errors.E(op, "property not found", errors.KindNotFound)
+	}
+
+	line.ReviewedBy = reviewer
+	line, err = svc.claim(ctx, line, house)
+	if err != nil {
+		return line, errors.E(op, err)
+	}
+	return line, nil
+}
+
+func (svc *service) Dismiss(ctx context.Context, id, namespace, reviewer, reason string) (Line, error) {
+	const op errors.Op = "core/deposits/service.Dismiss"
+
+	line, err := svc.reviewable(ctx, id, namespace)
+	if err != nil {
+		return Line{}, errors.E(op, err)
+	}
+
+	from := line.Status
+	line.Status = Ignored
+	line.ReviewedBy = reviewer
+	line.Reason = reason
+	if err := svc.repo.Transition(ctx, line, from); err != nil {
+		return Line{}, errors.E(op, err)
+	}
+	return line, nil
+}
+
+// months is how far back outstanding invoices are matched
+const months = 12
+
+// match settles a pending line when it names a single house of its
+// namespace and pays off a whole number of its outstanding invoices.
+func (svc *service) match(ctx context.Context, line Line) (LineStatus, error) {
+	const op errors.Op = "core/deposits/service.match"
+
+	house, reason, err := svc.identify(ctx, line)
+	if err != nil {
+		return "", errors.E(op, err)
+	}
+
+	if reason != "" {
+		line.Status = Review
+		line.Reason = reason
+		if err = svc.repo.Transition(ctx, line, Pending); err == nil {
+			return Review, nil
+		}
+	} else {
+		// a line that failed to settle was sent for review
+		if line, err = svc.claim(ctx, line, house); err == nil || line.Status == Review {
+			return line.Status, nil
+		}
+	}
+
+	if errors.Kind(err) == errors.KindAlreadyExists {
+		// another run got to the line first
+		return "", nil
+	}
+	return "", errors.E(op, err)
+}
+
+// identify finds the house a deposit pays for, the reason tells why none
+// could be picked with confidence.
+func (svc *service) identify(ctx context.Context, line Line) (properties.Property, string, error) {
+	const op errors.Op = "core/deposits/service.identify"
+
+	var found []properties.Property
+
+	for _, code := range ExtractCodes(line.Narrative + " " + line.Reference) {
+		house, err := svc.properties.RetrieveByID(ctx, code)
+		if err != nil {
+			if errors.Kind(err) != errors.KindNotFound {
+				return properties.Property{}, "", errors.E(op, err)
+			}
+			continue
+		}
+		if house.Namespace == line.Namespace {
+			found = append(found, house)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return properties.Property{}, "no house code found", nil
+	case 1:
+	default:
+		return properties.Property{}, "several house codes found", nil
+	}
+
+	house := found[0]
+
+	outstanding, err := svc.outstanding(ctx, house)
+	if err != nil {
+		if errors.Kind(err) != errors.KindNotFound {
+			return properties.Property{}, "", errors.E(op, err)
+		}
+		return house, "no outstanding invoices", nil
+	}
+
+	if !MatchInvoices(line.Amount, outstanding) {
+		return house, "amount doesn't match the outstanding invoices", nil
+	}
+	return house, "", nil
+}
+
+// claim moves a line to a house before settling its invoices so that
+// concurrent runs never settle it twice. A line that fails to settle is
+// sent for review with the error as reason.
+func (svc *service) claim(ctx context.Context, line Line, house properties.Property) (Line, error) {
+	const op errors.Op = "core/deposits/service.claim"
+
+	from := line.Status
+	line.Status = Matched
+	line.Property = house.ID
+	line.Reason = ""
+	if err := svc.repo.Transition(ctx, line, from); err != nil {
+		return Line{}, errors.E(op, err)
+	}
+
+	if err := svc.settle(ctx, line, house); err != nil {
+		line.Status = Review
+		line.Reason = err.Error()
+		if terr := svc.repo.Transition(ctx, line, Matched); terr != nil {
+			return Line{}, errors.E(op, terr)
+		}
+		return line, errors.E(op, err)
+	}
+	return line, nil
+}
+
+// outstanding retrieves the outstanding invoices of a house, oldest first
+func (svc *service) outstanding(ctx context.Context, house properties.Property) ([]invoices.Invoice, error) {
+	const op errors.Op = "core/deposits/service.outstanding"
+
+	page, err := svc.invoices.Pending(ctx, house.ID, months)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	outstanding := page.Invoices
+	sort.SliceStable(outstanding, func(i, j int) bool {
+		return outstanding[i].CreatedAt.Before(outstanding[j].CreatedAt)
+	})
+	return outstanding, nil
+}
+
+// settle records the deposit as successful bank payments of the outstanding
+// invoices of the house, it pays their balance oldest first and the last one
+// takes whatever is left.
+func (svc *service) settle(ctx context.Context, line Line, house properties.Property) error {
+	const op errors.Op = "core/deposits/service.settle"
+
+	outstanding, err := svc.outstanding(ctx, house)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	payments := make([]*payment.TxRequest, 0)
+
+	left := line.Amount
+	for i, invoice := range outstanding {
+		paid := invoice.Balance()
+		if paid > left || i == len(outstanding)-1 {
+			paid = left
+		}
+		if paid <= 0 {
+			break
+		}
+		if err := invoice.Verify(paid); err != nil {
+			return errors.E(op, err)
+		}
+		payments = append(payments, &payment.TxRequest{
+			ID:        svc.idp.ID(),
+			Code:      house.ID,
+			Ref:       line.ID,
+			Amount:    paid,
+			Invoice:   invoice.ID,
+			Method:    payment.Bank,
+			MSISDN:    house.Owner.Phone,
+			Namespace: house.Namespace,
+		})
+		left -= paid
+	}
+
+	if len(payments) == 0 {
+		return errors.E(op, "no invoice to settle", errors.KindBadRequest)
+	}
+
+	if err := svc.payments.Settle(ctx, payments); err != nil {
+		return errors.E(op, err)
+	}
+	return nil
+}
+
+// reviewable retrieves a line a reviewer may still act upon, managers only
+// review the lines of their own sector.
+func (svc *service) reviewable(ctx context.Context, id, namespace string) (Line, error) {
+	const op errors.Op = "core/deposits/service.reviewable"
+
+	line, err := svc.repo.Line(ctx, id)
+	if err != nil {
+		return Line{}, errors.E(op, err)
+	}
+	if namespace != "" && namespace != line.Namespace {
+		return Line{}, errors.E(op, "statement line not found", errors.KindNotFound)
+	}
+	if line.Status != Review && line.Status != Pending {
+		return Line{}, errors.E(op, "statement line was already resolved", errors.KindAlreadyExists)
+	}
+	return line, nil
+}
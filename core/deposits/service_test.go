@@ -0,0 +1,152 @@
+package deposits_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	pmocks "github.com/nshimiyimanaamani/paypack-backend/core/payment/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	namespace = "remera"
+	other     = "kimironko"
+)
+
+func newService(t *testing.T) (deposits.Service, properties.Property) {
+	props := pmocks.NewPropertyRepository()
+
+	property, err := props.Save(context.Background(), properties.Property{
+		ID:        "22C95179",
+		Due:       1000,
+		Namespace: namespace,
+		Owner:     properties.Owner{ID: "owner", Phone: "0787205106"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	creation := time.Now()
+	invs := pmocks.NewInvoiceRepository(map[string]invoices.Invoice{
+		property.ID: {ID: 1, Amount: 1000, CreatedAt: creation, UpdatedAt: creation},
+	})
+
+	svc := deposits.New(&deposits.Options{
+		Idp:        mocks.NewIdentityProvider(),
+		Repository: mocks.NewRepository(),
+		Properties: props,
+		Invoices:   invs,
+		Payments:   pmocks.NewPaymentRepository(),
+	})
+	return svc, property
+}
+
+func TestMatch(t *testing.T) {
+	svc, property := newService(t)
+
+	ctx := context.Background()
+
+	statement := "Date,Narrative,Reference,Amount\n" +
+		"2020-10-01,DEPOSIT 22C95179,FT01,1000\n" +
+		"2020-10-01,DEPOSIT 22c95179 two months,FT02,1500\n" +
+		"2020-10-01,CASH DEPOSIT,FT03,1000\n" +
+		"2020-10-01,CASH DEPOSIT,FT03,1000\n"
+
+	imported, err := svc.Import(ctx, deposits.Statement{Namespace: namespace}, strings.NewReader(statement))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 3, imported.Lines, fmt.Sprintf("expected %d lines got %d", 3, imported.Lines))
+	assert.Equal(t, 1, imported.Duplicates, fmt.Sprintf("expected %d duplicates got %d", 1, imported.Duplicates))
+
+	_, err = svc.Import(ctx, deposits.Statement{Namespace: namespace}, strings.NewReader("Narrative,Amount\nBANK CHARGES,\n"))
+	assert.Equal(t, errors.KindBadRequest, errors.Kind(err), fmt.Sprintf("expected kind %d got err: '%v'", errors.KindBadRequest, err))
+
+	report, err := svc.Match(ctx, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, deposits.Report{Checked: 3, Matched: 1, Review: 2}, report, fmt.Sprintf("unexpected report %+v", report))
+
+	review := cast.StringPointer(string(deposits.Review))
+	page, err := svc.Lines(ctx, &deposits.Filters{Status: review, Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, page.Lines, 2, fmt.Sprintf("expected %d lines for review got %d", 2, len(page.Lines)))
+
+	var mismatched, unknown deposits.Line
+	for _, line := range page.Lines {
+		if line.Reference == "FT02" {
+			mismatched = line
+		} else {
+			unknown = line
+		}
+	}
+
+	cases := []struct {
+		desc      string
+		id        string
+		namespace string
+		resolve   bool
+		status    deposits.LineStatus
+		kind      int
+	}{
+		{
+			desc:      "resolve line of another sector",
+			id:        mismatched.ID,
+			namespace: other,
+			resolve:   true,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "dismiss line of another sector",
+			id:        unknown.ID,
+			namespace: other,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "resolve line with a house",
+			id:        mismatched.ID,
+			namespace: namespace,
+			resolve:   true,
+			status:    deposits.Matched,
+		},
+		{
+			desc:      "resolve matched line",
+			id:        mismatched.ID,
+			namespace: namespace,
+			resolve:   true,
+			kind:      errors.KindAlreadyExists,
+		},
+		{
+			desc:   "dismiss line without house code as a developer",
+			id:     unknown.ID,
+			status: deposits.Ignored,
+		},
+		{
+			desc:      "dismiss unknown line",
+			id:        uuid.New().ID(),
+			namespace: namespace,
+			kind:      errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		var line deposits.Line
+		if tc.resolve {
+			line, err = svc.Resolve(ctx, tc.id, tc.namespace, property.ID, "manager")
+		} else {
+			line, err = svc.Dismiss(ctx, tc.id, tc.namespace, "manager", "not a house payment")
+		}
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.status, line.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.status, line.Status))
+	}
+}
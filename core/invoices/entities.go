@@ -11,34 +11,75 @@ type Status string
 
 // possible invoice states
 const (
-	Pending Status = "pending"
-	Payed   Status = "payed"
-	Expired Status = "expired"
+	Pending       Status = "pending"
+	PartiallyPaid Status = "partially_paid"
+	Payed         Status = "payed"
+	Expired       Status = "expired"
+	Waived        Status = "waived"
 )
 
-// Invoice ...
+// Invoice charges the due of a property for a billing period, the period
+// ends the day before PeriodEnd. Waived is the part of the amount a waiver
+// discounted, it is never owed. Late payment penalties are invoices of their
+// own, PenaltyOf is the overdue invoice they were charged on.
 type Invoice struct {
-	ID        uint64    `json:"id"`
-	Amount    float64   `json:"amount"`
-	Property  string    `json:"property"`
-	Status    Status    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint64    `json:"id"`
+	Amount      float64   `json:"amount"`
+	Paid        float64   `json:"paid"`
+	Waived      float64   `json:"waived"`
+	PenaltyOf   uint64    `json:"penalty_of,omitempty"`
+	Property    string    `json:"property"`
+	Status      Status    `json:"status"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// Verify checkes wether the invoice satisfies requirements to be paid
+// Verify checkes wether the invoice satisfies requirements to be paid.
+// Any positive amount is accepted, it is allocated to the oldest unpaid
+// invoices first and whatever is left is kept as credit for the property.
 func (vc *Invoice) Verify(amount float64) error {
 	const op errors.Op = "core/payment/Invoice.Satisfy"
 
 	if vc.Status == Payed {
 		return errors.E(op, "you already payed", errors.KindRateLimit)
 	}
-	if vc.Amount != amount {
-		return errors.E(op, "amount doesn't match invoice", errors.KindBadRequest)
+	if vc.Status == Waived {
+		return errors.E(op, "nothing to pay, the invoice was waived", errors.KindRateLimit)
+	}
+	if amount <= 0 {
+		return errors.E(op, "amount must be greater than zero", errors.KindBadRequest)
 	}
 	return nil
 }
 
+// Balance is the amount still owed on the invoice
+func (vc *Invoice) Balance() float64 {
+	if vc.Paid+vc.Waived >= vc.Amount {
+		return 0
+	}
+	return vc.Amount - vc.Waived - vc.Paid
+}
+
+// IsPenalty tells whether the invoice is a late payment penalty
+func (vc *Invoice) IsPenalty() bool {
+	return vc.PenaltyOf != 0
+}
+
+// Settled tells whether nothing is left to pay on the invoice
+func (vc *Invoice) Settled() bool {
+	return vc.Status == Payed || vc.Status == Waived
+}
+
+// Credit is the amount a property has paid in excess of its invoices,
+// it is consumed by the invoices generated in the following months.
+type Credit struct {
+	Property  string    `json:"property"`
+	Amount    float64   `json:"amount"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
 // PageMetadata ...
 type PageMetadata struct {
 	Total       uint
@@ -0,0 +1,107 @@
+package invoices_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	const op errors.Op = "core/payment/Invoice.Satisfy"
+
+	cases := []struct {
+		desc    string
+		invoice invoices.Invoice
+		amount  float64
+		err     error
+	}{
+		{
+			desc:    "verify exact amount",
+			invoice: invoices.Invoice{Amount: 1000, Status: invoices.Pending},
+			amount:  1000,
+			err:     nil,
+		},
+		{
+			desc:    "verify partial amount",
+			invoice: invoices.Invoice{Amount: 1000, Status: invoices.Pending},
+			amount:  400,
+			err:     nil,
+		},
+		{
+			desc:    "verify amount exceeding the invoice",
+			invoice: invoices.Invoice{Amount: 1000, Paid: 400, Status: invoices.PartiallyPaid},
+			amount:  5000,
+			err:     nil,
+		},
+		{
+			desc:    "verify zero amount",
+			invoice: invoices.Invoice{Amount: 1000, Status: invoices.Pending},
+			amount:  0,
+			err:     errors.E(op, "amount must be greater than zero", errors.KindBadRequest),
+		},
+		{
+			desc:    "verify payed invoice",
+			invoice: invoices.Invoice{Amount: 1000, Paid: 1000, Status: invoices.Payed},
+			amount:  1000,
+			err:     errors.E(op, "you already payed", errors.KindRateLimit),
+		},
+		{
+			desc:    "verify waived invoice",
+			invoice: invoices.Invoice{Amount: 1000, Waived: 1000, Status: invoices.Waived},
+			amount:  1000,
+			err:     errors.E(op, "nothing to pay, the invoice was waived", errors.KindRateLimit),
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.invoice.Verify(tc.amount)
+		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+	}
+}
+
+func TestBalance(t *testing.T) {
+	cases := []struct {
+		desc    string
+		invoice invoices.Invoice
+		balance float64
+	}{
+		{
+			desc:    "balance of unpaid invoice",
+			invoice: invoices.Invoice{Amount: 1000},
+			balance: 1000,
+		},
+		{
+			desc:    "balance of partially paid invoice",
+			invoice: invoices.Invoice{Amount: 1000, Paid: 400},
+			balance: 600,
+		},
+		{
+			desc:    "balance of payed invoice",
+			invoice: invoices.Invoice{Amount: 1000, Paid: 1000},
+			balance: 0,
+		},
+		{
+			desc:    "balance of partially waived invoice",
+			invoice: invoices.Invoice{Amount: 1000, Paid: 200, Waived: 500},
+			balance: 300,
+		},
+		{
+			desc:    "balance of waived invoice",
+			invoice: invoices.Invoice{Amount: 1000, Waived: 1000},
+			balance: 0,
+		},
+		{
+			desc:    "balance of partially paid penalty",
+			invoice: invoices.Invoice{Amount: 300, Paid: 100, PenaltyOf: 1},
+			balance: 200,
+		},
+	}
+
+	for _, tc := range cases {
+		balance := tc.invoice.Balance()
+		assert.Equal(t, tc.balance, balance, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.balance, balance))
+	}
+}
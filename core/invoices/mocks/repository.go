@@ -167,3 +167,15 @@ func (repo *repository) Generate(ctx context.Context, id string, amount, months
 
 	return nil, errors.E(op, "Not implemented", errors.KindNotImplemented)
 }
+
+func (repo *repository) Credit(ctx context.Context, property string) (invoices.Credit, error) {
+	const op errors.Op = "app/invoices/mocks/repository.Credit"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.invoices[property]; !ok {
+		return invoices.Credit{}, errors.E(op, "property doesn't exists", errors.KindNotFound)
+	}
+	return invoices.Credit{Property: property}, nil
+}
@@ -18,8 +18,11 @@ type Repository interface {
 	Payed(ctx context.Context, property string, months uint) (InvoicePage, error)
 	// Expired retrieves invoices that are due to be archived(have passed payment date)
 	Archivable(context.Context) (InvoicePage, error)
-	// Unpaid invoices from last months
+	// Unpaid invoices from past billing periods
 	Unpaid(ctx context.Context, property string) (InvoicePage, error)
-	//Generate generates invoices for a house depending on the number of months
+	// Generate generates invoices for a house depending on the number of
+	// billing periods, starting with the current one
 	Generate(context.Context, string, uint, uint) ([]*Invoice, error)
+	// Credit retrieves the credit balance of a house
+	Credit(ctx context.Context, property string) (Credit, error)
 }
@@ -11,6 +11,7 @@ type Service interface {
 	RetrieveAll(ctx context.Context, property string, months uint) (InvoicePage, error)
 	RetrievePending(ctx context.Context, property string, months uint) (InvoicePage, error)
 	RetrievePayed(ctx context.Context, property string, months uint) (InvoicePage, error)
+	RetrieveCredit(ctx context.Context, property string) (Credit, error)
 }
 
 // Options ...
@@ -58,3 +59,13 @@ func (svc *service) RetrievePayed(ctx context.Context, property string, months u
 	}
 	return page, nil
 }
+
+func (svc *service) RetrieveCredit(ctx context.Context, property string) (Credit, error) {
+	const op errors.Op = "app/invoices/service.RetrieveCredit"
+
+	credit, err := svc.repo.Credit(ctx, property)
+	if err != nil {
+		return Credit{}, errors.E(op, err)
+	}
+	return credit, nil
+}
@@ -66,3 +66,36 @@ func TestRetrieveAll(t *testing.T) {
 func TestRetrievePending(t *testing.T) {}
 
 func TestRetrievePayed(t *testing.T) {}
+
+func TestRetrieveCredit(t *testing.T) {
+	svc := newService()
+
+	const op errors.Op = "app/invoices/service.RetrieveCredit"
+
+	cases := []struct {
+		desc     string
+		property string
+		amount   float64
+		err      error
+	}{
+		{
+			desc:     "retrieve credit for existing property",
+			property: property,
+			amount:   0,
+			err:      nil,
+		},
+		{
+			desc:     "retrieve credit for non existing property",
+			property: "invalid property",
+			amount:   0,
+			err:      errors.E(op, "property doesn't exists"),
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		credit, err := svc.RetrieveCredit(ctx, tc.property)
+		assert.True(t, errors.ErrEqual(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+		assert.Equal(t, tc.amount, credit.Amount, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.amount, credit.Amount))
+	}
+}
@@ -0,0 +1,258 @@
+package ledger
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// AccountKind tells on which side an account normally carries its balance
+type AccountKind string
+
+// Account kinds, assets and expenses grow with debits while liabilities
+// and revenue grow with credits.
+const (
+	Asset     AccountKind = "asset"
+	Liability AccountKind = "liability"
+	Revenue   AccountKind = "revenue"
+	Expense   AccountKind = "expense"
+)
+
+// Account prefixes, an account code is the prefix followed by the owner
+// of the account, e.g. "receivable:<property>" or "revenue:<namespace>".
+const (
+	ReceivablePrefix = "receivable"
+	RevenuePrefix    = "revenue"
+	ClearingPrefix   = "clearing"
+	FeesPrefix       = "fees"
+	PayoutsPrefix    = "payouts"
+	CashPrefix       = "cash"
+	BankPrefix       = "bank"
+	WaiversPrefix    = "waivers"
+)
+
+var kinds = map[string]AccountKind{
+	ReceivablePrefix: Asset,
+	RevenuePrefix:    Revenue,
+	ClearingPrefix:   Asset,
+	FeesPrefix:       Expense,
+	PayoutsPrefix:    Liability,
+	CashPrefix:       Asset,
+	BankPrefix:       Asset,
+	WaiversPrefix:    Expense,
+}
+
+// EntryKind is the money movement an entry records
+type EntryKind string
+
+// Entry kinds
+const (
+	InvoiceEntry    EntryKind = "invoice"
+	PaymentEntry    EntryKind = "payment"
+	RefundEntry     EntryKind = "refund"
+	PayoutEntry     EntryKind = "payout"
+	RemittanceEntry EntryKind = "remittance"
+	WaiverEntry     EntryKind = "waiver"
+	AdjustmentEntry EntryKind = "adjustment"
+)
+
+// Account is a ledger account
+type Account struct {
+	Code      string      `json:"code"`
+	Kind      AccountKind `json:"kind"`
+	Namespace string      `json:"namespace,omitempty"`
+}
+
+// AccountOf returns the account of a code
+func AccountOf(code, namespace string) (Account, error) {
+	const op errors.Op = "core/ledger/AccountOf"
+
+	i := strings.Index(code, ":")
+	if i <= 0 || i == len(code)-1 {
+		return Account{}, errors.E(op, fmt.Sprintf("invalid account code %s", code), errors.KindBadRequest)
+	}
+
+	kind, ok := kinds[code[:i]]
+	if !ok {
+		return Account{}, errors.E(op, fmt.Sprintf("unknown account %s", code), errors.KindBadRequest)
+	}
+	return Account{Code: code, Kind: kind, Namespace: namespace}, nil
+}
+
+// Receivable is what a household owes
+func Receivable(property string) string {
+	return ReceivablePrefix + ":" + property
+}
+
+// Revenues is what a sector earned from its invoices
+func Revenues(namespace string) string {
+	return RevenuePrefix + ":" + namespace
+}
+
+// Clearing is the money of a sector held by the payment gateway
+func Clearing(namespace string) string {
+	return ClearingPrefix + ":" + namespace
+}
+
+// Fees are the commissions a sector paid on its collections
+func Fees(namespace string) string {
+	return FeesPrefix + ":" + namespace
+}
+
+// Payouts is the money a sector owes to be sent out through the gateway
+func Payouts(namespace string) string {
+	return PayoutsPrefix + ":" + namespace
+}
+
+// Cash is the money of a sector collected by its agents and not yet
+// handed over
+func Cash(namespace string) string {
+	return CashPrefix + ":" + namespace
+}
+
+// Bank is the money of a sector deposited at its bank
+func Bank(namespace string) string {
+	return BankPrefix + ":" + namespace
+}
+
+// Waivers is the revenue a sector gave up on the invoices it waived
+func Waivers(namespace string) string {
+	return WaiversPrefix + ":" + namespace
+}
+
+// Line debits or credits a single account
+type Line struct {
+	Account string  `json:"account"`
+	Debit   float64 `json:"debit,omitempty"`
+	Credit  float64 `json:"credit,omitempty"`
+}
+
+// Entry is an immutable journal entry, its debits and credits balance
+type Entry struct {
+	ID          string    `json:"id,omitempty"`
+	Kind        EntryKind `json:"kind"`
+	Ref         string    `json:"ref"`
+	Namespace   string    `json:"namespace"`
+	Description string    `json:"description,omitempty"`
+	Lines       []Line    `json:"lines"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// NewEntry creates an entry without lines
+func NewEntry(kind EntryKind, ref, namespace, description string) *Entry {
+	return &Entry{Kind: kind, Ref: ref, Namespace: namespace, Description: description}
+}
+
+// Transfer moves an amount from an account to another. Zero amounts are
+// ignored so that optional legs such as fees can be added unconditionally.
+func (e *Entry) Transfer(debit, credit string, amount float64) *Entry {
+	if amount == 0 {
+		return e
+	}
+	e.Lines = append(e.Lines,
+		Line{Account: debit, Debit: amount},
+		Line{Account: credit, Credit: amount},
+	)
+	return e
+}
+
+// Validate ensures the entry is balanced and only uses known accounts
+func (e *Entry) Validate() error {
+	const op errors.Op = "core/ledger/Entry.Validate"
+
+	if e.Kind == "" || e.Ref == "" || e.Namespace == "" {
+		return errors.E(op, "invalid entry: missing kind, ref or namespace", errors.KindBadRequest)
+	}
+
+	if len(e.Lines) < 2 {
+		return errors.E(op, "invalid entry: at least two lines are required", errors.KindBadRequest)
+	}
+
+	var balance int64
+	for _, line := range e.Lines {
+		if _, err := AccountOf(line.Account, e.Namespace); err != nil {
+			return errors.E(op, err)
+		}
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit == 0) == (line.Credit == 0) {
+			return errors.E(op, "invalid entry: a line either debits or credits a positive amount", errors.KindBadRequest)
+		}
+		balance += cents(line.Debit) - cents(line.Credit)
+	}
+
+	if balance != 0 {
+		return errors.E(op, "invalid entry: debits and credits don't balance", errors.KindBadRequest)
+	}
+	return nil
+}
+
+// Balance sums the lines of an account
+type Balance struct {
+	Account
+	Debit  float64 `json:"debit"`
+	Credit float64 `json:"credit"`
+}
+
+// Amount is the balance on the normal side of the account
+func (b Balance) Amount() float64 {
+	if b.Kind == Asset || b.Kind == Expense {
+		return b.Debit - b.Credit
+	}
+	return b.Credit - b.Debit
+}
+
+// TrialBalance lists the balances of every account, it's balanced when
+// the debits equal the credits
+type TrialBalance struct {
+	Balances []Balance `json:"balances"`
+	Debit    float64   `json:"debit"`
+	Credit   float64   `json:"credit"`
+	Balanced bool      `json:"balanced"`
+}
+
+// NewTrialBalance totals account balances
+func NewTrialBalance(balances []Balance) TrialBalance {
+	tb := TrialBalance{Balances: balances}
+
+	var debit, credit int64
+	for _, b := range balances {
+		debit += cents(b.Debit)
+		credit += cents(b.Credit)
+	}
+	tb.Debit, tb.Credit = float64(debit)/100, float64(credit)/100
+	tb.Balanced = debit == credit
+	return tb
+}
+
+// StatementLine is a line of an account statement along with its entry
+type StatementLine struct {
+	Entry       string    `json:"entry"`
+	Kind        EntryKind `json:"kind"`
+	Ref         string    `json:"ref"`
+	Description string    `json:"description,omitempty"`
+	Debit       float64   `json:"debit,omitempty"`
+	Credit      float64   `json:"credit,omitempty"`
+	Balance     float64   `json:"balance"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PageMetadata contains page metadata that helps navigation.
+type PageMetadata struct {
+	Total  uint64 `json:"total"`
+	Offset uint64 `json:"offset"`
+	Limit  uint64 `json:"limit"`
+}
+
+// Statement lists the lines of an account starting with the most recent,
+// every line carries the running balance of the account after it.
+type Statement struct {
+	PageMetadata
+	Account Account         `json:"account"`
+	Lines   []StatementLine `json:"lines"`
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,86 @@
+package ledger_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEntry(t *testing.T) {
+	const ns = "kigali.gasabo.remera"
+
+	cases := []struct {
+		desc  string
+		entry *ledger.Entry
+		kind  int
+	}{
+		{
+			desc: "validate balanced entry",
+			entry: ledger.NewEntry(ledger.PaymentEntry, "ref", ns, "").
+				Transfer(ledger.Clearing(ns), ledger.Receivable("property"), 1000).
+				Transfer(ledger.Fees(ns), ledger.Clearing(ns), 50),
+		},
+		{
+			desc: "validate unbalanced entry",
+			entry: &ledger.Entry{Kind: ledger.PaymentEntry, Ref: "ref", Namespace: ns, Lines: []ledger.Line{
+				{Account: ledger.Clearing(ns), Debit: 1000},
+				{Account: ledger.Receivable("property"), Credit: 999.99},
+			}},
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc:  "validate entry without lines",
+			entry: ledger.NewEntry(ledger.PaymentEntry, "ref", ns, "").Transfer(ledger.Clearing(ns), ledger.Receivable("property"), 0),
+			kind:  errors.KindBadRequest,
+		},
+		{
+			desc: "validate entry with unknown account",
+			entry: ledger.NewEntry(ledger.PaymentEntry, "ref", ns, "").
+				Transfer("wallet:"+ns, ledger.Receivable("property"), 1000),
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "validate line with a debit and a credit",
+			entry: &ledger.Entry{Kind: ledger.PaymentEntry, Ref: "ref", Namespace: ns, Lines: []ledger.Line{
+				{Account: ledger.Clearing(ns), Debit: 1000, Credit: 1000},
+				{Account: ledger.Receivable("property"), Debit: 1000},
+				{Account: ledger.Revenues(ns), Credit: 1000},
+			}},
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "validate entry without ref",
+			entry: ledger.NewEntry(ledger.PaymentEntry, "", ns, "").
+				Transfer(ledger.Clearing(ns), ledger.Receivable("property"), 1000),
+			kind: errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.entry.Validate()
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
+
+func TestTrialBalance(t *testing.T) {
+	balances := []ledger.Balance{
+		{Account: ledger.Account{Code: ledger.Clearing("ns"), Kind: ledger.Asset}, Debit: 1000.1, Credit: 50},
+		{Account: ledger.Account{Code: ledger.Fees("ns"), Kind: ledger.Expense}, Debit: 50},
+		{Account: ledger.Account{Code: ledger.Revenues("ns"), Kind: ledger.Revenue}, Credit: 1000.1},
+	}
+
+	tb := ledger.NewTrialBalance(balances)
+	assert.True(t, tb.Balanced)
+	assert.Equal(t, 1050.1, tb.Debit)
+	assert.Equal(t, 1050.1, tb.Credit)
+
+	assert.Equal(t, 950.1, balances[0].Amount())
+	assert.Equal(t, 1000.1, balances[2].Amount())
+}
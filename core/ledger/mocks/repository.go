@@ -0,0 +1,134 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (ledger.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu       sync.Mutex
+	entries  []ledger.Entry
+	accounts map[string]ledger.Account
+}
+
+// NewRepository creates an in memory ledger.Repository
+func NewRepository() ledger.Repository {
+	return &repositoryMock{
+		accounts: make(map[string]ledger.Account),
+	}
+}
+
+func (repo *repositoryMock) Post(ctx context.Context, entry *ledger.Entry) error {
+	const op errors.Op = "core/ledger/mocks/repositoryMock.Post"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, e := range repo.entries {
+		if e.Kind == entry.Kind && e.Ref == entry.Ref {
+			return errors.E(op, fmt.Sprintf("%s entry %s already posted", entry.Kind, entry.Ref), errors.KindAlreadyExists)
+		}
+	}
+
+	for _, line := range entry.Lines {
+		if _, ok := repo.accounts[line.Account]; ok {
+			continue
+		}
+		acc, err := ledger.AccountOf(line.Account, entry.Namespace)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		repo.accounts[line.Account] = acc
+	}
+
+	entry.ID = uuid.New().ID()
+	entry.CreatedAt = time.Now()
+	repo.entries = append(repo.entries, *entry)
+	return nil
+}
+
+func (repo *repositoryMock) Balances(ctx context.Context, namespace string) ([]ledger.Balance, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	sums := make(map[string]*ledger.Balance)
+	for _, e := range repo.entries {
+		for _, line := range e.Lines {
+			acc := repo.accounts[line.Account]
+			if namespace != "" && acc.Namespace != namespace {
+				continue
+			}
+			if sums[acc.Code] == nil {
+				sums[acc.Code] = &ledger.Balance{Account: acc}
+			}
+			sums[acc.Code].Debit += line.Debit
+			sums[acc.Code].Credit += line.Credit
+		}
+	}
+
+	balances := []ledger.Balance{}
+	for _, b := range sums {
+		balances = append(balances, *b)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Code < balances[j].Code })
+	return balances, nil
+}
+
+func (repo *repositoryMock) Statement(ctx context.Context, code, namespace string, offset, limit uint64) (ledger.Statement, error) {
+	const op errors.Op = "core/ledger/mocks/repositoryMock.Statement"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	acc, ok := repo.accounts[code]
+	if !ok || (namespace != "" && acc.Namespace != namespace) {
+		return ledger.Statement{}, errors.E(op, "account not found", errors.KindNotFound)
+	}
+
+	var (
+		lines   []ledger.StatementLine
+		balance ledger.Balance
+	)
+	balance.Account = acc
+
+	for _, e := range repo.entries {
+		for _, line := range e.Lines {
+			if line.Account != code {
+				continue
+			}
+			balance.Debit += line.Debit
+			balance.Credit += line.Credit
+			lines = append(lines, ledger.StatementLine{
+				Entry:       e.ID,
+				Kind:        e.Kind,
+				Ref:         e.Ref,
+				Description: e.Description,
+				Debit:       line.Debit,
+				Credit:      line.Credit,
+				Balance:     balance.Amount(),
+				CreatedAt:   e.CreatedAt,
+			})
+		}
+	}
+
+	st := ledger.Statement{
+		PageMetadata: ledger.PageMetadata{Total: uint64(len(lines)), Offset: offset, Limit: limit},
+		Account:      acc,
+		Lines:        []ledger.StatementLine{},
+	}
+
+	// most recent first
+	for i := len(lines) - 1 - int(offset); i >= 0 && uint64(len(st.Lines)) < limit; i-- {
+		st.Lines = append(st.Lines, lines[i])
+	}
+	return st, nil
+}
@@ -0,0 +1,16 @@
+package ledger
+
+import "context"
+
+// Repository is the journal of every money movement
+type Repository interface {
+	// Post appends a balanced entry, an entry is posted once per kind and ref
+	Post(ctx context.Context, entry *Entry) error
+
+	// Balances sums the lines of the accounts of a namespace, every
+	// account is returned when the namespace is empty
+	Balances(ctx context.Context, namespace string) ([]Balance, error)
+
+	// Statement lists the lines of an account of a namespace
+	Statement(ctx context.Context, code, namespace string, offset, limit uint64) (Statement, error)
+}
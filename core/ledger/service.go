@@ -0,0 +1,71 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the ledger usecases
+type Service interface {
+	// Post records a balanced journal entry
+	Post(ctx context.Context, entry *Entry) error
+
+	// TrialBalance returns the balances of the accounts of a namespace,
+	// an empty namespace covers every account
+	TrialBalance(ctx context.Context, namespace string) (TrialBalance, error)
+
+	// Statement returns the lines of an account
+	Statement(ctx context.Context, code, namespace string, offset, limit uint64) (Statement, error)
+}
+
+// Options contains ledger.Service creation options
+type Options struct {
+	Repository Repository
+}
+
+type service struct {
+	repo Repository
+}
+
+// New instantiates the ledger.Service
+func New(opts *Options) Service {
+	return &service{repo: opts.Repository}
+}
+
+func (svc *service) Post(ctx context.Context, entry *Entry) error {
+	const op errors.Op = "core/ledger/service.Post"
+
+	if err := entry.Validate(); err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := svc.repo.Post(ctx, entry); err != nil {
+		return errors.E(op, err)
+	}
+	return nil
+}
+
+func (svc *service) TrialBalance(ctx context.Context, namespace string) (TrialBalance, error) {
+	const op errors.Op = "core/ledger/service.TrialBalance"
+
+	balances, err := svc.repo.Balances(ctx, namespace)
+	if err != nil {
+		return TrialBalance{}, errors.E(op, err)
+	}
+	return NewTrialBalance(balances), nil
+}
+
+func (svc *service) Statement(ctx context.Context, code, namespace string, offset, limit uint64) (Statement, error) {
+	const op errors.Op = "core/ledger/service.Statement"
+
+	if _, err := AccountOf(code, namespace); err != nil {
+		return Statement{}, errors.E(op, err)
+	}
+
+	st, err := svc.repo.Statement(ctx, code, namespace, offset, limit)
+	if err != nil {
+		return Statement{}, errors.E(op, err)
+	}
+	return st, nil
+}
@@ -0,0 +1,94 @@
+package ledger_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newService() ledger.Service {
+	return ledger.New(&ledger.Options{Repository: mocks.NewRepository()})
+}
+
+func TestPost(t *testing.T) {
+	svc := newService()
+
+	const ns = "kigali.gasabo.remera"
+
+	cases := []struct {
+		desc  string
+		entry *ledger.Entry
+		kind  int
+	}{
+		{
+			desc:  "post invoice",
+			entry: ledger.NewEntry(ledger.InvoiceEntry, "1", ns, "").Transfer(ledger.Receivable("property"), ledger.Revenues(ns), 1000),
+		},
+		{
+			desc:  "post invoice twice",
+			entry: ledger.NewEntry(ledger.InvoiceEntry, "1", ns, "").Transfer(ledger.Receivable("property"), ledger.Revenues(ns), 1000),
+			kind:  errors.KindAlreadyExists,
+		},
+		{
+			desc:  "post invalid entry",
+			entry: ledger.NewEntry(ledger.InvoiceEntry, "2", ns, ""),
+			kind:  errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.Post(context.Background(), tc.entry)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
+
+func TestStatement(t *testing.T) {
+	svc := newService()
+
+	ctx := context.Background()
+
+	const ns = "kigali.gasabo.remera"
+
+	entries := []*ledger.Entry{
+		ledger.NewEntry(ledger.InvoiceEntry, "1", ns, "").Transfer(ledger.Receivable("property"), ledger.Revenues(ns), 1000),
+		ledger.NewEntry(ledger.PaymentEntry, "ref", ns, "").
+			Transfer(ledger.Clearing(ns), ledger.Receivable("property"), 1000).
+			Transfer(ledger.Fees(ns), ledger.Clearing(ns), 50),
+		ledger.NewEntry(ledger.InvoiceEntry, "2", "kigali.gasabo.kimironko", "").
+			Transfer(ledger.Receivable("other"), ledger.Revenues("kigali.gasabo.kimironko"), 500),
+	}
+
+	for _, e := range entries {
+		err := svc.Post(ctx, e)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	tb, err := svc.TrialBalance(ctx, ns)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.True(t, tb.Balanced)
+	assert.Len(t, tb.Balances, 4)
+	assert.Equal(t, float64(2050), tb.Debit)
+
+	st, err := svc.Statement(ctx, ledger.Receivable("property"), ns, 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, st.Lines, 2)
+	assert.Equal(t, ledger.PaymentEntry, st.Lines[0].Kind, "expected the most recent line first")
+	assert.Equal(t, float64(0), st.Lines[0].Balance)
+	assert.Equal(t, float64(1000), st.Lines[1].Balance)
+
+	_, err = svc.Statement(ctx, ledger.Receivable("other"), ns, 0, 10)
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err), "expected accounts of other namespaces to be hidden")
+
+	_, err = svc.Statement(ctx, "unknown", ns, 0, 10)
+	assert.Equal(t, errors.KindBadRequest, errors.Kind(err))
+}
@@ -0,0 +1,130 @@
+package manual
+
+import (
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
+)
+
+// MaxSlipPhoto is the largest bank slip photo an agent can attach, in bytes
+const MaxSlipPhoto = 1 << 20
+
+// Status is the state of a manual payment
+type Status string
+
+// possible manual payment states
+const (
+	Pending  Status = "pending"
+	Approved Status = "approved"
+	Rejected Status = "rejected"
+	Failed   Status = "failed"
+)
+
+// Payment is money an agent collected in cash or saw deposited at a
+// bank. It settles invoices only once a manager approved it.
+type Payment struct {
+	ID         string         `json:"id,omitempty"`
+	Property   string         `json:"property,omitempty"`
+	Namespace  string         `json:"namespace,omitempty"`
+	Amount     float64        `json:"amount,omitempty"`
+	Months     uint           `json:"months,omitempty"`
+	Method     payment.Method `json:"method,omitempty"`
+	MSISDN     string         `json:"phone,omitempty"`
+	Slip       string         `json:"slip,omitempty"`
+	Photo      []byte         `json:"photo,omitempty"`
+	Status     Status         `json:"status,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	RecordedBy string         `json:"recorded_by,omitempty"`
+	ReviewedBy string         `json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time      `json:"created_at,omitempty"`
+	UpdatedAt  time.Time      `json:"updated_at,omitempty"`
+}
+
+// Validate checks whether a manual payment is complete
+func (m *Payment) Validate() error {
+	const op errors.Op = "core/manual/Payment.Validate"
+
+	if m.Property == "" {
+		return errors.E(op, "missing house code", errors.KindBadRequest)
+	}
+	if m.Amount <= 0 {
+		return errors.E(op, "amount must be greater than zero", errors.KindBadRequest)
+	}
+	if m.Method != payment.Cash && m.Method != payment.Bank {
+		return errors.E(op, "payment method must be cash or bank", errors.KindBadRequest)
+	}
+	if m.RecordedBy == "" {
+		return errors.E(op, "missing agent", errors.KindBadRequest)
+	}
+	if len(m.Photo) > MaxSlipPhoto {
+		return errors.E(op, "slip photo is too large", errors.KindBadRequest)
+	}
+	if m.MSISDN != "" {
+		number, err := msisdn.Parse(m.MSISDN)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		m.MSISDN = number.String()
+	}
+	return nil
+}
+
+// Filters narrows down listed manual payments, nil filters are ignored
+type Filters struct {
+	Namespace  *string
+	Status     *string
+	RecordedBy *string
+	Offset     uint64
+	Limit      uint64
+}
+
+// PageMetadata ...
+type PageMetadata struct {
+	Total  uint64
+	Amount float64 `json:"amount,omitempty"`
+	Offset uint64
+	Limit  uint64
+}
+
+// Page is a list of manual payments, slip photos are left out
+type Page struct {
+	PageMetadata
+	Payments []Payment `json:"payments"`
+}
+
+// Remittance is cash an agent handed over to the sector
+type Remittance struct {
+	ID         string    `json:"id,omitempty"`
+	Agent      string    `json:"agent,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Amount     float64   `json:"amount,omitempty"`
+	ReceivedBy string    `json:"received_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+}
+
+// Validate checks whether a remittance is complete
+func (r *Remittance) Validate() error {
+	const op errors.Op = "core/manual/Remittance.Validate"
+
+	if r.Agent == "" {
+		return errors.E(op, "missing agent", errors.KindBadRequest)
+	}
+	if r.Namespace == "" {
+		return errors.E(op, "missing namespace", errors.KindBadRequest)
+	}
+	if r.Amount <= 0 {
+		return errors.E(op, "amount must be greater than zero", errors.KindBadRequest)
+	}
+	return nil
+}
+
+// AgentCash is the cash an agent collected and still owes to the sector
+type AgentCash struct {
+	Agent     string  `json:"agent"`
+	Namespace string  `json:"namespace"`
+	Collected float64 `json:"collected"`
+	Remitted  float64 `json:"remitted"`
+	Owed      float64 `json:"owed"`
+}
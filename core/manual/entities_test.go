@@ -0,0 +1,57 @@
+package manual_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		desc   string
+		manual manual.Payment
+		kind   int
+	}{
+		{
+			desc:   "validate cash payment",
+			manual: manual.Payment{Property: "house", Amount: 1000, Method: payment.Cash, RecordedBy: "agent"},
+		},
+		{
+			desc:   "validate bank payment with the payer's phone",
+			manual: manual.Payment{Property: "house", Amount: 1000, Method: payment.Bank, MSISDN: "0787205106", RecordedBy: "agent"},
+		},
+		{
+			desc:   "validate payment without house",
+			manual: manual.Payment{Amount: 1000, Method: payment.Cash, RecordedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate mobile money payment",
+			manual: manual.Payment{Property: "house", Amount: 1000, Method: payment.MTN, RecordedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate payment without agent",
+			manual: manual.Payment{Property: "house", Amount: 1000, Method: payment.Cash},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate payment with a large slip photo",
+			manual: manual.Payment{Property: "house", Amount: 1000, Method: payment.Bank, Photo: make([]byte, manual.MaxSlipPhoto+1), RecordedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.manual.Validate()
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
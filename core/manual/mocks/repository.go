@@ -0,0 +1,201 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (manual.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu          sync.Mutex
+	payments    map[string]manual.Payment
+	remittances []manual.Remittance
+}
+
+// NewRepository creates an in memory mock of manual.Repository
+func NewRepository() manual.Repository {
+	return &repositoryMock{
+		payments: make(map[string]manual.Payment),
+	}
+}
+
+func (repo *repositoryMock) Save(ctx context.Context, m *manual.Payment) error {
+	const op errors.Op = "core/manual/mocks/repositoryMock.Save"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, prev := range repo.payments {
+		if m.Slip != "" && prev.Method == m.Method && prev.Slip == m.Slip && prev.Status != manual.Rejected {
+			return errors.E(op, "slip was already recorded", errors.KindAlreadyExists)
+		}
+	}
+
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = m.CreatedAt
+	repo.payments[m.ID] = *m
+	return nil
+}
+
+func (repo *repositoryMock) Find(ctx context.Context, id string) (manual.Payment, error) {
+	const op errors.Op = "core/manual/mocks/repositoryMock.Find"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	m, ok := repo.payments[id]
+	if !ok {
+		return manual.Payment{}, errors.E(op, "manual payment not found", errors.KindNotFound)
+	}
+	return m, nil
+}
+
+func (repo *repositoryMock) List(ctx context.Context, flts *manual.Filters) (manual.Page, error) {
+	const op errors.Op = "core/manual/mocks/repositoryMock.List"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	items := make([]manual.Payment, 0)
+	for _, m := range repo.payments {
+		if flts.Namespace != nil && m.Namespace != *flts.Namespace {
+			continue
+		}
+		if flts.Status != nil && string(m.Status) != *flts.Status {
+			continue
+		}
+		if flts.RecordedBy != nil && m.RecordedBy != *flts.RecordedBy {
+			continue
+		}
+		m.Photo = nil
+		items = append(items, m)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	page := manual.Page{
+		PageMetadata: manual.PageMetadata{
+			Total:  uint64(len(items)),
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+		},
+		Payments: []manual.Payment{},
+	}
+
+	for i, m := range items {
+		page.Amount += m.Amount
+		if uint64(i) >= flts.Offset && uint64(i) < flts.Offset+flts.Limit {
+			page.Payments = append(page.Payments, m)
+		}
+	}
+	return page, nil
+}
+
+func (repo *repositoryMock) Review(ctx context.Context, m manual.Payment) error {
+	const op errors.Op = "core/manual/mocks/repositoryMock.Review"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	prev, ok := repo.payments[m.ID]
+	if !ok || prev.Status != manual.Pending {
+		return errors.E(op, "manual payment was already reviewed", errors.KindAlreadyExists)
+	}
+
+	prev.Status = m.Status
+	prev.ReviewedBy = m.ReviewedBy
+	prev.Reason = m.Reason
+	prev.UpdatedAt = time.Now()
+	repo.payments[m.ID] = prev
+	return nil
+}
+
+func (repo *repositoryMock) Update(ctx context.Context, m manual.Payment) error {
+	const op errors.Op = "core/manual/mocks/repositoryMock.Update"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	prev, ok := repo.payments[m.ID]
+	if !ok {
+		return errors.E(op, "manual payment not found", errors.KindNotFound)
+	}
+
+	prev.Status = m.Status
+	prev.Reason = m.Reason
+	prev.UpdatedAt = time.Now()
+	repo.payments[m.ID] = prev
+	return nil
+}
+
+func (repo *repositoryMock) Remit(ctx context.Context, r *manual.Remittance) error {
+	const op errors.Op = "core/manual/mocks/repositoryMock.Remit"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	cash := repo.cash(r.Namespace)
+	for _, c := range cash {
+		if c.Agent == r.Agent && c.Owed >= r.Amount {
+			r.CreatedAt = time.Now()
+			repo.remittances = append(repo.remittances, *r)
+			return nil
+		}
+	}
+	return errors.E(op, "agent doesn't owe that much cash", errors.KindBadRequest)
+}
+
+func (repo *repositoryMock) AgentCash(ctx context.Context, namespace string) ([]manual.AgentCash, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.cash(namespace), nil
+}
+
+func (repo *repositoryMock) cash(namespace string) []manual.AgentCash {
+	agents := make(map[string]*manual.AgentCash)
+
+	get := func(agent, ns string) *manual.AgentCash {
+		key := ns + "/" + agent
+		if _, ok := agents[key]; !ok {
+			agents[key] = &manual.AgentCash{Agent: agent, Namespace: ns}
+		}
+		return agents[key]
+	}
+
+	for _, m := range repo.payments {
+		if m.Method != payment.Cash || m.Status != manual.Approved {
+			continue
+		}
+		if namespace == "" || m.Namespace == namespace {
+			get(m.RecordedBy, m.Namespace).Collected += m.Amount
+		}
+	}
+	for _, r := range repo.remittances {
+		if namespace == "" || r.Namespace == namespace {
+			get(r.Agent, r.Namespace).Remitted += r.Amount
+		}
+	}
+
+	out := make([]manual.AgentCash, 0, len(agents))
+	for _, c := range agents {
+		c.Owed = c.Collected - c.Remitted
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Agent < out[j].Agent
+	})
+	return out
+}
@@ -0,0 +1,30 @@
+package manual
+
+import "context"
+
+// Repository stores manual payments and the cash agents hand over
+type Repository interface {
+	// Save a new manual payment, a bank slip can only be recorded once
+	Save(ctx context.Context, m *Payment) error
+
+	// Find a manual payment by id
+	Find(ctx context.Context, id string) (Payment, error)
+
+	// List manual payments starting with the most recent
+	List(ctx context.Context, flts *Filters) (Page, error)
+
+	// Review moves a pending manual payment to the status of m. It fails if
+	// the payment was already reviewed so that it never settles twice.
+	Review(ctx context.Context, m Payment) error
+
+	// Update the status of a manual payment
+	Update(ctx context.Context, m Payment) error
+
+	// Remit records cash handed over by an agent, it fails if the agent
+	// doesn't owe as much.
+	Remit(ctx context.Context, r *Remittance) error
+
+	// AgentCash reports the cash owed by the agents of a namespace, every
+	// namespace is reported when it's empty.
+	AgentCash(ctx context.Context, namespace string) ([]AgentCash, error)
+}
@@ -0,0 +1,266 @@
+package manual
+
+import (
+	"context"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity"
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the payments agents collect outside of the gateway
+type Service interface {
+	// Record records cash or a bank deposit collected by an agent, it
+	// awaits approval before settling any invoice
+	Record(ctx context.Context, m Payment) (Payment, error)
+
+	// Approve settles the invoices of a pending manual payment of
+	// namespace the same way a gateway payment does
+	Approve(ctx context.Context, id, namespace, reviewer string) (Payment, error)
+
+	// Reject closes a pending manual payment of namespace without settling it
+	Reject(ctx context.Context, id, namespace, reviewer, reason string) (Payment, error)
+
+	// Retrieve a manual payment of namespace with its slip photo
+	Retrieve(ctx context.Context, id, namespace string) (Payment, error)
+
+	// List manual payments
+	List(ctx context.Context, flts *Filters) (Page, error)
+
+	// Remit records cash an agent handed over to the sector
+	Remit(ctx context.Context, r Remittance) (Remittance, error)
+
+	// AgentCash reports the cash agents collected and still owe
+	AgentCash(ctx context.Context, namespace string) ([]AgentCash, error)
+}
+
+// Options contains manual.Service creation options
+type Options struct {
+	Idp        identity.Provider
+	Repository Repository
+	Properties properties.Repository
+	Invoices   invoices.Repository
+	Payments   payment.Repository
+}
+
+type service struct {
+	idp        identity.Provider
+	repo       Repository
+	properties properties.Repository
+	invoices   invoices.Repository
+	payments   payment.Repository
+}
+
+// New instantiates the manual.Service
+func New(opts *Options) Service {
+	return &service{
+		idp:        opts.Idp,
+		repo:       opts.Repository,
+		properties: opts.Properties,
+		invoices:   opts.Invoices,
+		payments:   opts.Payments,
+	}
+}
+
+func (svc *service) Record(ctx context.Context, m Payment) (Payment, error) {
+	const op errors.Op = "core/manual/service.Record"
+
+	if err := m.Validate(); err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+
+	property, err := svc.properties.RetrieveByID(ctx, m.Property)
+	if err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+
+	// agents only collect for the houses of their own sector
+	if m.Namespace != "" && m.Namespace != property.Namespace {
+		return Payment{}, errors.E(op, "property not found", errors.KindNotFound)
+	}
+
+	if m.Months == 0 {
+		m.Months = 1
+	}
+	m.ID = svc.idp.ID()
+	m.Namespace = property.Namespace
+	m.Status = Pending
+	m.ReviewedBy = ""
+	m.Reason = ""
+
+	if err := svc.repo.Save(ctx, &m); err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+	return m, nil
+}
+
+func (svc *service) Approve(ctx context.Context, id, namespace, reviewer string) (Payment, error) {
+	const op errors.Op = "core/manual/service.Approve"
+
+	m, err := svc.review(ctx, id, namespace, reviewer, Approved, "")
+	if err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+
+	if err := svc.settle(ctx, m); err != nil {
+		m.Status = Failed
+		m.Reason = err.Error()
+		if uerr := svc.repo.Update(ctx, m); uerr != nil {
+			return m, errors.E(op, uerr)
+		}
+		return m, errors.E(op, err)
+	}
+	return m, nil
+}
+
+func (svc *service) Reject(ctx context.Context, id, namespace, reviewer, reason string) (Payment, error) {
+	const op errors.Op = "core/manual/service.Reject"
+
+	m, err := svc.review(ctx, id, namespace, reviewer, Rejected, reason)
+	if err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+	return m, nil
+}
+
+// review moves a pending manual payment of namespace to status, managers
+// only review the payments recorded in their own sector.
+func (svc *service) review(ctx context.Context, id, namespace, reviewer string, status Status, reason string) (Payment, error) {
+	const op errors.Op = "core/manual/service.review"
+
+	m, err := svc.Retrieve(ctx, id, namespace)
+	if err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+	if m.Status != Pending {
+		return Payment{}, errors.E(op, "manual payment was already reviewed", errors.KindAlreadyExists)
+	}
+
+	m.Status = status
+	m.ReviewedBy = reviewer
+	m.Reason = reason
+	if err := svc.repo.Review(ctx, m); err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+	return m, nil
+}
+
+// settle records the payments of an approved manual payment under its id
+// and confirms them right away. The invoices are picked like Pull does for
+// a single month and like BulkPull does for several.
+func (svc *service) settle(ctx context.Context, m Payment) error {
+	const op errors.Op = "core/manual/service.settle"
+
+	property, err := svc.properties.RetrieveByID(ctx, m.Property)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	msisdn := m.MSISDN
+	if msisdn == "" {
+		msisdn = property.Owner.Phone
+	}
+
+	payments := make([]*payment.TxRequest, 0)
+
+	newPayment := func(invoice uint64, amount float64) *payment.TxRequest {
+		return &payment.TxRequest{
+			ID:        svc.idp.ID(),
+			Code:      property.ID,
+			Ref:       m.ID,
+			Amount:    amount,
+			Invoice:   invoice,
+			Method:    m.Method,
+			MSISDN:    msisdn,
+			Namespace: property.Namespace,
+		}
+	}
+
+	if m.Months <= 1 {
+		invoice, err := svc.invoices.Earliest(ctx, property.ID)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		if err := invoice.Verify(m.Amount); err != nil {
+			return errors.E(op, err)
+		}
+		payments = append(payments, newPayment(invoice.ID, m.Amount))
+	} else {
+		invoices, err := svc.invoices.Generate(ctx, property.ID, uint(m.Amount), m.Months)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		// the declared amount is what was received, it pays the balance of
+		// the invoices in order and the last one takes whatever is left
+		left := m.Amount
+		for i, invoice := range invoices {
+			paid := invoice.Balance()
+			if paid > left || i == len(invoices)-1 {
+				paid = left
+			}
+			if paid <= 0 {
+				break
+			}
+			payments = append(payments, newPayment(invoice.ID, paid))
+			left -= paid
+		}
+	}
+
+	if len(payments) == 0 {
+		return errors.E(op, "no invoice to settle", errors.KindBadRequest)
+	}
+
+	if err := svc.payments.Settle(ctx, payments); err != nil {
+		return errors.E(op, err)
+	}
+	return nil
+}
+
+func (svc *service) Retrieve(ctx context.Context, id, namespace string) (Payment, error) {
+	const op errors.Op = "core/manual/service.Retrieve"
+
+	m, err := svc.repo.Find(ctx, id)
+	if err != nil {
+		return Payment{}, errors.E(op, err)
+	}
+	if namespace != "" && namespace != m.Namespace {
+		return Payment{}, errors.E(op, "manual payment not found", errors.KindNotFound)
+	}
+	return m, nil
+}
+
+func (svc *service) List(ctx context.Context, flts *Filters) (Page, error) {
+	const op errors.Op = "core/manual/service.List"
+
+	page, err := svc.repo.List(ctx, flts)
+	if err != nil {
+		return Page{}, errors.E(op, err)
+	}
+	return page, nil
+}
+
+func (svc *service) Remit(ctx context.Context, r Remittance) (Remittance, error) {
+	const op errors.Op = "core/manual/service.Remit"
+
+	if err := r.Validate(); err != nil {
+		return Remittance{}, errors.E(op, err)
+	}
+
+	r.ID = svc.idp.ID()
+	if err := svc.repo.Remit(ctx, &r); err != nil {
+		return Remittance{}, errors.E(op, err)
+	}
+	return r, nil
+}
+
+func (svc *service) AgentCash(ctx context.Context, namespace string) ([]AgentCash, error) {
+	const op errors.Op = "core/manual/service.AgentCash"
+
+	cash, err := svc.repo.AgentCash(ctx, namespace)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return cash, nil
+}
@@ -0,0 +1,261 @@
+package manual_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	pmocks "github.com/nshimiyimanaamani/paypack-backend/core/payment/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	namespace = "kigali.gasabo.remera"
+	other     = "kigali.gasabo.kimironko"
+)
+
+func newService(t *testing.T, amount float64) (manual.Service, payment.Repository, properties.Property) {
+	props := pmocks.NewPropertyRepository()
+
+	property, err := props.Save(context.Background(), properties.Property{
+		ID:        uuid.New().ID(),
+		Due:       1000,
+		Namespace: namespace,
+		Owner:     properties.Owner{ID: "owner", Phone: "0787205106"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	invs := pmocks.NewInvoiceRepository(map[string]invoices.Invoice{
+		property.ID: {ID: 1, Amount: amount, Property: property.ID, Status: invoices.Pending, CreatedAt: time.Now()},
+	})
+
+	payments := pmocks.NewPaymentRepository()
+
+	svc := manual.New(&manual.Options{
+		Idp:        mocks.NewIdentityProvider(),
+		Repository: mocks.NewRepository(),
+		Properties: props,
+		Invoices:   invs,
+		Payments:   payments,
+	})
+	return svc, payments, property
+}
+
+func TestRecord(t *testing.T) {
+	svc, _, property := newService(t, 1000)
+
+	ctx := context.Background()
+
+	recorded, err := svc.Record(ctx, manual.Payment{Property: property.ID, Amount: 1000, Method: payment.Bank, Slip: "BK-001", RecordedBy: "agent"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, manual.Pending, recorded.Status, fmt.Sprintf("expected %s got %s", manual.Pending, recorded.Status))
+	assert.Equal(t, property.Namespace, recorded.Namespace, fmt.Sprintf("expected %s got %s", property.Namespace, recorded.Namespace))
+
+	cases := []struct {
+		desc   string
+		manual manual.Payment
+		kind   int
+	}{
+		{
+			desc:   "record payment with an already recorded slip",
+			manual: manual.Payment{Property: property.ID, Amount: 1000, Method: payment.Bank, Slip: "BK-001", RecordedBy: "agent"},
+			kind:   errors.KindAlreadyExists,
+		},
+		{
+			desc:   "record payment with unknown method",
+			manual: manual.Payment{Property: property.ID, Amount: 1000, Method: payment.MTN, RecordedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "record payment for a house of another sector",
+			manual: manual.Payment{Property: property.ID, Namespace: other, Amount: 1000, Method: payment.Cash, RecordedBy: "agent"},
+			kind:   errors.KindNotFound,
+		},
+		{
+			desc:   "record payment for unknown house",
+			manual: manual.Payment{Property: uuid.New().ID(), Amount: 1000, Method: payment.Cash, RecordedBy: "agent"},
+			kind:   errors.KindNotFound,
+		},
+		{
+			desc:   "record cash payment of the agent's sector",
+			manual: manual.Payment{Property: property.ID, Namespace: namespace, Amount: 1000, Method: payment.Cash, RecordedBy: "agent"},
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.Record(ctx, tc.manual)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
+
+func TestReview(t *testing.T) {
+	svc, _, property := newService(t, 1000)
+
+	ctx := context.Background()
+
+	cash, err := svc.Record(ctx, manual.Payment{Property: property.ID, Amount: 1000, Method: payment.Cash, RecordedBy: "agent"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	deposit, err := svc.Record(ctx, manual.Payment{Property: property.ID, Amount: 1000, Method: payment.Bank, Slip: "BK-001", RecordedBy: "agent"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc      string
+		id        string
+		namespace string
+		approve   bool
+		status    manual.Status
+		kind      int
+	}{
+		{
+			desc:      "approve cash payment of another sector",
+			id:        cash.ID,
+			namespace: other,
+			approve:   true,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "reject bank payment of another sector",
+			id:        deposit.ID,
+			namespace: other,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "approve pending cash payment",
+			id:        cash.ID,
+			namespace: namespace,
+			approve:   true,
+			status:    manual.Approved,
+		},
+		{
+			desc:      "approve approved cash payment",
+			id:        cash.ID,
+			namespace: namespace,
+			approve:   true,
+			kind:      errors.KindAlreadyExists,
+		},
+		{
+			desc:    "reject pending bank payment as a developer",
+			id:      deposit.ID,
+			approve: false,
+			status:  manual.Rejected,
+		},
+		{
+			desc:      "approve unknown payment",
+			id:        uuid.New().ID(),
+			namespace: namespace,
+			approve:   true,
+			kind:      errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		var (
+			m   manual.Payment
+			err error
+		)
+		if tc.approve {
+			m, err = svc.Approve(ctx, tc.id, tc.namespace, "manager")
+		} else {
+			m, err = svc.Reject(ctx, tc.id, tc.namespace, "manager", "slip not found at the bank")
+		}
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.status, m.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.status, m.Status))
+	}
+
+	_, err = svc.Retrieve(ctx, cash.ID, other)
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err), fmt.Sprintf("expected kind %d got err: '%v'", errors.KindNotFound, err))
+
+	page, err := svc.List(ctx, &manual.Filters{Namespace: cast.StringPointer(namespace), Status: cast.StringPointer(string(manual.Approved)), Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(1), page.Total, fmt.Sprintf("expected %d payments got %d", 1, page.Total))
+}
+
+func TestApproveMonths(t *testing.T) {
+	// the current invoice was prorated, it charges less than the due
+	svc, payments, property := newService(t, 600)
+
+	ctx := context.Background()
+
+	recorded, err := svc.Record(ctx, manual.Payment{Property: property.ID, Amount: 2000, Months: 2, Method: payment.Cash, RecordedBy: "agent"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	_, err = svc.Approve(ctx, recorded.ID, namespace, "manager")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	settled, err := payments.Find(ctx, recorded.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, settled, 2, fmt.Sprintf("expected 2 payments got %d", len(settled)))
+
+	var total float64
+	for _, pmt := range settled {
+		assert.Equal(t, string(payment.Successful), pmt.Status, fmt.Sprintf("expected %s got %s", payment.Successful, pmt.Status))
+		total += pmt.Amount
+	}
+	assert.Equal(t, recorded.Amount, total, fmt.Sprintf("expected %v recorded got %v", recorded.Amount, total))
+}
+
+func TestRemit(t *testing.T) {
+	svc, _, property := newService(t, 1000)
+
+	ctx := context.Background()
+
+	recorded, err := svc.Record(ctx, manual.Payment{Property: property.ID, Amount: 1000, Method: payment.Cash, RecordedBy: "agent"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	_, err = svc.Approve(ctx, recorded.ID, namespace, "manager")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc       string
+		remittance manual.Remittance
+		kind       int
+	}{
+		{
+			desc:       "remit part of the collected cash",
+			remittance: manual.Remittance{Agent: "agent", Namespace: namespace, Amount: 600},
+		},
+		{
+			desc:       "remit more than the agent owes",
+			remittance: manual.Remittance{Agent: "agent", Namespace: namespace, Amount: 600},
+			kind:       errors.KindBadRequest,
+		},
+		{
+			desc:       "remit without amount",
+			remittance: manual.Remittance{Agent: "agent", Namespace: namespace},
+			kind:       errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.Remit(ctx, tc.remittance)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+
+	cash, err := svc.AgentCash(ctx, namespace)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, cash, 1, fmt.Sprintf("expected 1 agent got %d", len(cash)))
+	assert.Equal(t, float64(400), cash[0].Owed, fmt.Sprintf("expected %v owed got %v", 400, cash[0].Owed))
+}
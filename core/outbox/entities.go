@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"time"
+)
+
+// MaxAttempts is the number of times a message is tried before it is given up on
+const MaxAttempts = 5
+
+// DefaultBatchSize is the number of messages claimed at once when the
+// caller doesn't set one
+const DefaultBatchSize = 50
+
+// ClaimTimeout is how long a claimed message is held by a dispatcher, it
+// becomes due again if the dispatcher dies before updating it.
+const ClaimTimeout = 5 * time.Minute
+
+// Status of an outbox message
+type Status string
+
+// Possible outbox message states
+const (
+	Pending Status = "pending"
+	Sent    Status = "sent"
+	Failed  Status = "failed"
+)
+
+// Message is an sms waiting to be delivered
+type Message struct {
+	ID          string    `json:"id,omitempty"`
+	Sender      string    `json:"sender,omitempty"`
+	Recipients  []string  `json:"recipients,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Status      Status    `json:"status,omitempty"`
+	Attempts    int       `json:"attempts,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// Backoff returns how long to wait before retrying a message
+// that already failed the given number of times.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	return time.Duration(1<<uint(attempts-1)) * time.Minute
+}
+
+// Delivered marks the message as sent
+func (msg *Message) Delivered() {
+	msg.Status = Sent
+	msg.LastError = ""
+}
+
+// Retry records a failed delivery attempt and schedules the next one,
+// the message is marked as failed once it runs out of attempts.
+func (msg *Message) Retry(reason error, now time.Time) {
+	msg.Attempts++
+	msg.LastError = reason.Error()
+	if msg.Attempts >= MaxAttempts {
+		msg.Status = Failed
+		return
+	}
+	msg.Status = Pending
+	msg.NextAttempt = now.Add(Backoff(msg.Attempts))
+}
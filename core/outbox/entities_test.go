@@ -0,0 +1,54 @@
+package outbox_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	const op errors.Op = "core/outbox/Message.Retry"
+
+	now := time.Now()
+	reason := errors.E(op, "sms gateway unavailable")
+
+	cases := []struct {
+		desc     string
+		attempts int
+		status   outbox.Status
+		next     time.Time
+	}{
+		{
+			desc:     "retry after first failure",
+			attempts: 0,
+			status:   outbox.Pending,
+			next:     now.Add(time.Minute),
+		},
+		{
+			desc:     "retry after third failure",
+			attempts: 2,
+			status:   outbox.Pending,
+			next:     now.Add(4 * time.Minute),
+		},
+		{
+			desc:     "give up after last attempt",
+			attempts: outbox.MaxAttempts - 1,
+			status:   outbox.Failed,
+		},
+	}
+
+	for _, tc := range cases {
+		msg := outbox.Message{Status: outbox.Pending, Attempts: tc.attempts}
+		msg.Retry(reason, now)
+		assert.Equal(t, tc.attempts+1, msg.Attempts, fmt.Sprintf("%s: expected %d attempts got %d", tc.desc, tc.attempts+1, msg.Attempts))
+		assert.Equal(t, tc.status, msg.Status, fmt.Sprintf("%s: expected status %s got %s", tc.desc, tc.status, msg.Status))
+		assert.Equal(t, reason.Error(), msg.LastError, fmt.Sprintf("%s: expected error %s got %s", tc.desc, reason, msg.LastError))
+		if tc.status == outbox.Pending {
+			assert.Equal(t, tc.next, msg.NextAttempt, fmt.Sprintf("%s: expected next attempt at %v got %v", tc.desc, tc.next, msg.NextAttempt))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (outbox.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu       sync.Mutex
+	messages map[string]outbox.Message
+}
+
+// NewRepository creates an in memory mock of outbox.Repository
+func NewRepository(msgs ...outbox.Message) outbox.Repository {
+	messages := make(map[string]outbox.Message)
+	for _, msg := range msgs {
+		messages[msg.ID] = msg
+	}
+	return &repositoryMock{messages: messages}
+}
+
+func (repo *repositoryMock) Pending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+
+	out := []outbox.Message{}
+	for _, msg := range repo.messages {
+		if msg.Status == outbox.Pending && !msg.NextAttempt.After(now) {
+			out = append(out, msg)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+
+	if len(out) > limit {
+		out = out[:limit]
+	}
+
+	for _, msg := range out {
+		msg.NextAttempt = now.Add(outbox.ClaimTimeout)
+		repo.messages[msg.ID] = msg
+	}
+	return out, nil
+}
+
+func (repo *repositoryMock) Update(ctx context.Context, msg outbox.Message) error {
+	const op errors.Op = "core/outbox/mocks/repositoryMock.Update"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.messages[msg.ID]; !ok {
+		return errors.E(op, "message not found", errors.KindNotFound)
+	}
+	repo.messages[msg.ID] = msg
+	return nil
+}
@@ -0,0 +1,14 @@
+package outbox
+
+import "context"
+
+// Repository stores messages waiting to be delivered
+type Repository interface {
+	// Pending claims messages that are due for delivery, oldest first.
+	// Claimed messages aren't due again until ClaimTimeout elapsed, so
+	// concurrent dispatchers never get the same message.
+	Pending(ctx context.Context, limit int) ([]Message, error)
+
+	// Update persists the delivery state of a message
+	Update(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service delivers messages from the outbox
+type Service interface {
+	// Dispatch sends all due messages in batches of bsize and
+	// returns the number of messages that were delivered, a bsize
+	// of zero uses DefaultBatchSize
+	Dispatch(ctx context.Context, bsize int) (int, error)
+}
+
+// Options ...
+type Options struct {
+	Repository Repository
+	SMS        notifs.Service
+}
+
+type service struct {
+	repo Repository
+	sms  notifs.Service
+}
+
+// New ...
+func New(opts *Options) Service {
+	return &service{
+		repo: opts.Repository,
+		sms:  opts.SMS,
+	}
+}
+
+func (svc *service) Dispatch(ctx context.Context, bsize int) (int, error) {
+	const op errors.Op = "core/outbox/service.Dispatch"
+
+	if bsize <= 0 {
+		bsize = DefaultBatchSize
+	}
+
+	var sent int
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return sent, errors.E(op, err)
+		}
+
+		msgs, err := svc.repo.Pending(ctx, bsize)
+		if err != nil {
+			return sent, errors.E(op, err)
+		}
+
+		for _, msg := range msgs {
+			if svc.deliver(ctx, &msg) {
+				sent++
+			}
+			// failed messages are pushed back, so they won't show up in the next batch
+			if err := svc.repo.Update(ctx, msg); err != nil {
+				return sent, errors.E(op, err)
+			}
+		}
+
+		if len(msgs) < bsize {
+			return sent, nil
+		}
+	}
+}
+
+func (svc *service) deliver(ctx context.Context, msg *Message) bool {
+	n := notifs.Notification{
+		Sender:     msg.Sender,
+		Recipients: msg.Recipients,
+		Message:    msg.Message,
+	}
+	if _, err := svc.sms.Send(ctx, n); err != nil {
+		msg.Retry(err, time.Now())
+		return false
+	}
+	msg.Delivered()
+	return true
+}
@@ -0,0 +1,89 @@
+package outbox_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
+	nmocks "github.com/nshimiyimanaamani/paypack-backend/core/notifs/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatch(t *testing.T) {
+	now := time.Now()
+
+	valid := outbox.Message{
+		ID:         "1",
+		Sender:     "paypack",
+		Recipients: []string{"0788123501"},
+		Message:    "hello",
+		Status:     outbox.Pending,
+		CreatedAt:  now.Add(-2 * time.Minute),
+	}
+
+	// the sms backend mock refuses messages without recipients
+	invalid := outbox.Message{
+		ID:        "2",
+		Sender:    "paypack",
+		Message:   "hello",
+		Status:    outbox.Pending,
+		CreatedAt: now.Add(-time.Minute),
+	}
+
+	later := outbox.Message{
+		ID:          "3",
+		Sender:      "paypack",
+		Recipients:  []string{"0788123501"},
+		Message:     "hello",
+		Status:      outbox.Pending,
+		NextAttempt: now.Add(time.Hour),
+		CreatedAt:   now.Add(-3 * time.Minute),
+	}
+
+	repo := mocks.NewRepository(valid, invalid, later)
+	svc := newService(repo)
+
+	sent, err := svc.Dispatch(context.Background(), 1)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 1, sent, fmt.Sprintf("expected %d sent messages got %d", 1, sent))
+
+	// nothing should be due until the failed message is retried
+	pending, err := repo.Pending(context.Background(), 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Empty(t, pending, fmt.Sprintf("expected no pending messages got %d", len(pending)))
+}
+
+func TestDispatchDefaultBatch(t *testing.T) {
+	msg := outbox.Message{
+		ID:         "1",
+		Sender:     "paypack",
+		Recipients: []string{"0788123501"},
+		Message:    "hello",
+		Status:     outbox.Pending,
+		CreatedAt:  time.Now(),
+	}
+
+	svc := newService(mocks.NewRepository(msg))
+
+	sent, err := svc.Dispatch(context.Background(), 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 1, sent, fmt.Sprintf("expected %d sent messages got %d", 1, sent))
+}
+
+func newService(repo outbox.Repository) outbox.Service {
+	var nopts notifs.Options
+	nopts.Backend = nmocks.NewBackend()
+	nopts.IDP = nmocks.NewIdentityProvider()
+	nopts.Store = nmocks.NewRepository()
+
+	opts := &outbox.Options{
+		Repository: repo,
+		SMS:        notifs.New(&nopts),
+	}
+	return outbox.New(opts)
+}
@@ -2,7 +2,8 @@ package owners
 
 import (
 	"errors"
-	"regexp"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 )
 
 // Sentinel Errors
@@ -33,16 +34,18 @@ type PageMetadata struct {
 	Limit  uint64
 }
 
-// Validate validates owner instance fields
+// Validate validates owner instance fields, the phone number is kept in
+// E.164 format
 func (own *Owner) Validate() error {
 	if own.Fname == "" || own.Lname == "" || own.Phone == "" {
 		return ErrInvalidEntity
 	}
 
-	var phoneRegex = `^(\+?25)?(078|079|073|072)\d{7}$`
-	if !regexp.MustCompile(phoneRegex).MatchString(own.Phone) {
-		return errors.New("invalid phone number provided")
+	phone, err := msisdn.Parse(own.Phone)
+	if err != nil {
+		return ErrInvalidEntity
 	}
+	own.Phone = phone.String()
 
 	return nil
 }
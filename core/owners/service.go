@@ -4,6 +4,8 @@ import "context"
 
 import "github.com/nshimiyimanaamani/paypack-backend/core/identity"
 
+import "github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
+
 // Service defines the owners module usecases
 type Service interface {
 	// Record adds a new property adn returns his id if the operation is a success
@@ -75,9 +77,10 @@ func (svc *service) List(ctx context.Context, offset, limit uint64) (OwnerPage,
 }
 
 func (svc *service) Search(ctx context.Context, owner Owner) (Owner, error) {
+	owner.Phone = msisdn.Normalize(owner.Phone)
 	return svc.repo.Search(ctx, owner)
 }
 
 func (svc *service) RetrieveByPhone(ctx context.Context, phone string) (Owner, error) {
-	return svc.repo.RetrieveByPhone(ctx, phone)
+	return svc.repo.RetrieveByPhone(ctx, msisdn.Normalize(phone))
 }
@@ -1,9 +1,49 @@
 package payment
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Client defines the payment initialiion client
 type Client interface {
 	Pull(context.Context, *TxRequest) (*TxResponse, error)
 	Push(context.Context, *TxRequest) (*TxResponse, error)
+
+	// Status looks up the gateway record of a transaction by its ref
+	Status(ctx context.Context, ref string) (*Data, error)
+}
+
+// BreakerState tells whether calls to a backend go through
+type BreakerState string
+
+// possible circuit breaker states
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails calls right away until the backend cools down
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe through to test the backend
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BackendHealth summarises the recent calls made to a payment backend
+type BackendHealth struct {
+	Name        string       `json:"name"`
+	Healthy     bool         `json:"healthy"`
+	Breaker     BreakerState `json:"breaker,omitempty"`
+	Failures    int          `json:"consecutive_failures"`
+	LastError   string       `json:"last_error,omitempty"`
+	LastSuccess time.Time    `json:"last_success,omitempty"`
+	LastFailure time.Time    `json:"last_failure,omitempty"`
+}
+
+// HealthReporter is implemented by clients that track the health of their backends
+type HealthReporter interface {
+	Health() []BackendHealth
+}
+
+// BreakerReporter is implemented by clients guarded by a circuit breaker
+type BreakerReporter interface {
+	Breaker() BreakerState
 }
@@ -0,0 +1,116 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Callback signature headers set by the payment gateway
+const (
+	SignatureHeader = "X-Paypack-Signature"
+	TimestampHeader = "X-Paypack-Timestamp"
+)
+
+// DefaultTolerance is the default maximum age of a signed callback
+const DefaultTolerance = 5 * time.Minute
+
+// SignedCallback is a raw callback as it was received from the gateway
+type SignedCallback struct {
+	Route     string
+	Payload   []byte
+	Signature string
+	Timestamp string
+}
+
+// RejectedCallback is a callback that failed verification
+type RejectedCallback struct {
+	ID        string    `json:"id,omitempty"`
+	Route     string    `json:"route,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Payload   string    `json:"payload,omitempty"`
+	Signature string    `json:"signature,omitempty"`
+	Timestamp string    `json:"timestamp,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// RejectedCallbackPage is a list of rejected callbacks
+type RejectedCallbackPage struct {
+	PageMetadata
+	Callbacks []RejectedCallback `json:"callbacks"`
+}
+
+// CallbackRepository stores callbacks that failed verification
+type CallbackRepository interface {
+	// Save a rejected callback
+	Save(ctx context.Context, cb *RejectedCallback) error
+
+	// List rejected callbacks starting with the most recent
+	List(ctx context.Context, offset, limit uint64) (RejectedCallbackPage, error)
+}
+
+// ReplayCache remembers callback events that were already accepted
+type ReplayCache interface {
+	// Claim marks an event as seen and returns false if it was seen before
+	Claim(ctx context.Context, event string, ttl time.Duration) (bool, error)
+
+	// Release forgets an event so that it can be claimed again
+	Release(ctx context.Context, event string) error
+}
+
+// Sign computes the signature of a callback payload sent at the given timestamp
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and freshness of a signed callback
+func (sc *SignedCallback) Verify(secret string, tolerance time.Duration, now time.Time) error {
+	const op errors.Op = "core/payment/SignedCallback.Verify"
+
+	if sc.Signature == "" {
+		return errors.E(op, "missing callback signature", errors.KindAccessDenied)
+	}
+
+	if sc.Timestamp == "" {
+		return errors.E(op, "missing callback timestamp", errors.KindAccessDenied)
+	}
+
+	secs, err := strconv.ParseInt(sc.Timestamp, 10, 64)
+	if err != nil {
+		return errors.E(op, "invalid callback timestamp", errors.KindAccessDenied)
+	}
+
+	expected := Sign(secret, sc.Timestamp, sc.Payload)
+	if !hmac.Equal([]byte(expected), []byte(sc.Signature)) {
+		return errors.E(op, "invalid callback signature", errors.KindAccessDenied)
+	}
+
+	age := now.Sub(time.Unix(secs, 0))
+	if age < 0 {
+		age = -age
+	}
+	if age > tolerance {
+		return errors.E(op, "callback timestamp outside of tolerance", errors.KindAccessDenied)
+	}
+	return nil
+}
+
+// Reject builds a RejectedCallback out of a signed callback and the reason it was rejected
+func (sc *SignedCallback) Reject(reason error) *RejectedCallback {
+	return &RejectedCallback{
+		Route:     sc.Route,
+		Reason:    reason.Error(),
+		Payload:   string(sc.Payload),
+		Signature: sc.Signature,
+		Timestamp: sc.Timestamp,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 )
 
 // TxExpiration is the time it takes for a non confirmed treansaction to expire
@@ -18,6 +19,28 @@ const (
 	AIRTEL Method = "momo-airtel-rw"
 )
 
+// payment methods recorded by agents rather than a gateway
+const (
+	Cash Method = "cash"
+	Bank Method = "bank"
+)
+
+// SelectMethod picks the mobile money method of the operator phone belongs
+// to, it is empty when the phone isn't a mobile number
+func SelectMethod(phone string) Method {
+	number, err := msisdn.Parse(phone)
+	if err != nil {
+		return ""
+	}
+	switch number.Operator() {
+	case msisdn.MTN:
+		return MTN
+	case msisdn.Airtel:
+		return AIRTEL
+	}
+	return ""
+}
+
 // Webhook defines the webhook mode
 const WebhookMode = "production"
 
@@ -55,6 +78,8 @@ type TxResponse struct {
 type PageMetadata struct {
 	Total  uint64
 	Amount float64 `json:"amount,omitempty"`
+	Fees   float64 `json:"fees,omitempty"`
+	Net    float64 `json:"net,omitempty"`
 	Offset uint64
 	Limit  uint64
 }
@@ -66,6 +91,8 @@ type Payment struct {
 	Phone      string `json:"phone,omitempty"`
 	PropertyID string `json:"property_id,omitempty"`
 	Amount     string `json:"amount,omitempty"`
+	Fee        string `json:"fee,omitempty"`
+	Net        string `json:"net,omitempty"`
 	Village    string `json:"village,omitempty"`
 	Cell       string `json:"cell,omitempty"`
 	Sector     string `json:"sector,omitempty"`
@@ -77,8 +104,9 @@ type PaymentResponse struct {
 
 // Callback defines the response got from the callback
 type Callback struct {
-	Data Data   `json:"data"`
-	Kind string `json:"kind"`
+	EventID string `json:"event_id,omitempty"`
+	Data    Data   `json:"data"`
+	Kind    string `json:"kind"`
 }
 
 // Filters ...
@@ -180,6 +208,15 @@ type TxRequest struct {
 	CreatedAt time.Time `json:"recorded_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
 	PayedDate time.Time `json:"payed_invoice,omitempty"`
+
+	// IdempotencyKey is set from the Idempotency-Key header of the request
+	IdempotencyKey string `json:"-"`
+
+	// Namespace of the paid property, used to pick a payment backend
+	Namespace string `json:"-"`
+
+	// GatewayFee is the share of the fee reported by the gateway callback
+	GatewayFee float64 `json:"-"`
 }
 
 // Confirm payment
@@ -207,7 +244,8 @@ func (p *TxRequest) HasInvoice() error {
 	return nil
 }
 
-// Ready to send be sent to the payment gateway
+// Ready to send be sent to the payment gateway, the phone number is kept
+// in E.164 format
 func (p *TxRequest) Ready() error {
 	const op errors.Op = "core/payment/Payment.Ready"
 
@@ -215,6 +253,12 @@ func (p *TxRequest) Ready() error {
 		return errors.E(op, "missing phone number", errors.KindBadRequest)
 	}
 
+	number, err := msisdn.Parse(p.MSISDN)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	p.MSISDN = number.String()
+
 	if p.Amount == float64(0) {
 		return errors.E(op, "amount must be greater than zero", errors.KindBadRequest)
 	}
@@ -2,7 +2,9 @@ package payment_test
 
 import (
 	"fmt"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/identity/uuid"
 	"github.com/nshimiyimanaamani/paypack-backend/core/nanoid"
@@ -77,6 +79,28 @@ func TestPaymentReady(t *testing.T) {
 	}
 }
 
+func TestSelectMethod(t *testing.T) {
+	cases := []struct {
+		desc   string
+		phone  string
+		method payment.Method
+	}{
+		{desc: "select method of mtn number", phone: "0784607135", method: payment.MTN},
+		{desc: "select method of international airtel number", phone: "+250734607135", method: payment.AIRTEL},
+		{desc: "select method of invalid number", phone: "77878333", method: ""},
+	}
+
+	for _, tc := range cases {
+		method := payment.SelectMethod(tc.phone)
+		assert.Equal(t, tc.method, method, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.method, method))
+	}
+
+	tx := payment.TxRequest{Amount: 1000, MSISDN: "250784607135", Method: payment.MTN}
+	err := tx.Ready()
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, "+250784607135", tx.MSISDN, "expected phone in E.164 format")
+}
+
 func TestValidateCallback(t *testing.T) {
 	const op errors.Op = "core/payment/Callback.Validate"
 
@@ -150,3 +174,61 @@ func TestValidateCallback(t *testing.T) {
 	}
 
 }
+
+func TestVerifySignedCallback(t *testing.T) {
+	const op errors.Op = "core/payment/SignedCallback.Verify"
+
+	const secret = "callback-secret"
+
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	payload := []byte(`{"event_id":"1","kind":"transaction:processed"}`)
+
+	cases := []struct {
+		desc     string
+		callback payment.SignedCallback
+		err      error
+	}{
+		{
+			desc:     "verify valid callback",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: ts, Signature: payment.Sign(secret, ts, payload)},
+			err:      nil,
+		},
+		{
+			desc:     "verify callback without signature",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: ts},
+			err:      errors.E(op, "missing callback signature", errors.KindAccessDenied),
+		},
+		{
+			desc:     "verify callback without timestamp",
+			callback: payment.SignedCallback{Payload: payload, Signature: payment.Sign(secret, ts, payload)},
+			err:      errors.E(op, "missing callback timestamp", errors.KindAccessDenied),
+		},
+		{
+			desc:     "verify callback with malformed timestamp",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: "yesterday", Signature: payment.Sign(secret, "yesterday", payload)},
+			err:      errors.E(op, "invalid callback timestamp", errors.KindAccessDenied),
+		},
+		{
+			desc:     "verify callback signed with another secret",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: ts, Signature: payment.Sign("other", ts, payload)},
+			err:      errors.E(op, "invalid callback signature", errors.KindAccessDenied),
+		},
+		{
+			desc:     "verify tampered callback",
+			callback: payment.SignedCallback{Payload: []byte(`{"event_id":"2"}`), Timestamp: ts, Signature: payment.Sign(secret, ts, payload)},
+			err:      errors.E(op, "invalid callback signature", errors.KindAccessDenied),
+		},
+		{
+			desc:     "verify stale callback",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: stale, Signature: payment.Sign(secret, stale, payload)},
+			err:      errors.E(op, "callback timestamp outside of tolerance", errors.KindAccessDenied),
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.callback.Verify(secret, payment.DefaultTolerance, now)
+		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+	}
+}
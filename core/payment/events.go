@@ -0,0 +1,54 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// EventKind is a step in the lifecycle of a payment
+type EventKind string
+
+// Payment lifecycle events
+const (
+	Initiated        EventKind = "initiated"
+	GatewayAccepted  EventKind = "gateway_accepted"
+	CallbackReceived EventKind = "callback_received"
+	Confirmed        EventKind = "confirmed"
+	Declined         EventKind = "failed"
+	Expired          EventKind = "expired"
+)
+
+// Event records a step of a payment along with the payload that caused it
+type Event struct {
+	ID        string          `json:"id,omitempty"`
+	Ref       string          `json:"ref,omitempty"`
+	Kind      EventKind       `json:"kind,omitempty"`
+	Status    string          `json:"status,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"created_at,omitempty"`
+}
+
+// Timeline is the history of a payment, oldest event first
+type Timeline struct {
+	Ref    string  `json:"ref"`
+	Events []Event `json:"events"`
+}
+
+// EventRepository stores the history of payments
+type EventRepository interface {
+	// Record appends an event to the history of its payment
+	Record(ctx context.Context, ev *Event) error
+
+	// Timeline returns the events of a payment, oldest first
+	Timeline(ctx context.Context, ref string) ([]Event, error)
+}
+
+// NewEvent builds an event out of any json encodable payload
+func NewEvent(ref string, kind EventKind, status string, payload interface{}) *Event {
+	raw, err := json.Marshal(payload)
+	if err != nil || payload == nil {
+		raw = json.RawMessage("{}")
+	}
+	return &Event{Ref: ref, Kind: kind, Status: status, Payload: raw, CreatedAt: time.Now()}
+}
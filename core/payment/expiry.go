@@ -0,0 +1,10 @@
+package payment
+
+// ExpiredStatus marks pulled payments that got no callback within the expiry window
+const ExpiredStatus = "expired"
+
+// Reasons recorded when a payment expires or is settled after expiring
+const (
+	ExpiryReason       = "no gateway callback received before expiry"
+	LateCallbackReason = "gateway callback received after expiry"
+)
@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
+)
+
+// IdempotencyHeader carries the client chosen key of a payment request
+const IdempotencyHeader = "Idempotency-Key"
+
+// IdempotencyExpiration is how long a payment response is kept for replays
+const IdempotencyExpiration = 24 * time.Hour
+
+// IdempotentRecord is what is remembered about a request made with an idempotency key
+type IdempotentRecord struct {
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string `json:"fingerprint"`
+
+	// Response is empty while the first request is still in flight
+	Response *TxResponse `json:"response,omitempty"`
+
+	// Kind is the error kind of a request that failed after reaching
+	// the gateway, it is replayed along with the response
+	Kind int `json:"kind,omitempty"`
+}
+
+// IdempotencyStore keeps the responses of payment requests made with an idempotency key
+type IdempotencyStore interface {
+	// Reserve claims a key for a new request. If the key is already
+	// taken it returns false along with the record stored under it.
+	Reserve(ctx context.Context, key string, rec IdempotentRecord) (IdempotentRecord, bool, error)
+
+	// Save stores the final record of a reserved key
+	Save(ctx context.Context, key string, rec IdempotentRecord) error
+
+	// Release frees a reserved key so that the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint summarises the fields of a payment request that must not
+// change when it is retried with the same idempotency key.
+func (p *TxRequest) Fingerprint() string {
+	return fmt.Sprintf("%s|%.2f|%s|%s", p.Code, p.Amount, msisdn.Normalize(p.MSISDN), p.Method)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"sync"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
@@ -9,16 +10,29 @@ import (
 
 var _ (payment.Client) = (*backendMock)(nil)
 
-type backendMock struct{}
+// backendMock settles every pulled transaction successfully
+type backendMock struct {
+	mu      sync.Mutex
+	records map[string]payment.Data
+}
 
 // NewBackend instantiates a payment backend mock
 func NewBackend() payment.Client {
-	return &backendMock{}
+	return &backendMock{records: make(map[string]payment.Data)}
 }
 
 func (bc *backendMock) Pull(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
 	const op errors.Op = "core/payment/mocks/backendMock.Pull"
 
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.records[tx.ID] = payment.Data{
+		Ref:    tx.ID,
+		Amount: tx.Amount,
+		Status: string(payment.Successful),
+	}
+
 	return &payment.TxResponse{
 		TxID:    tx.ID,
 		Status:  "success",
@@ -35,3 +49,16 @@ func (bc *backendMock) Push(ctx context.Context, tx *payment.TxRequest) (*paymen
 		TxState: "processing",
 	}, nil
 }
+
+func (bc *backendMock) Status(ctx context.Context, ref string) (*payment.Data, error) {
+	const op errors.Op = "core/payment/mocks/backendMock.Status"
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	data, ok := bc.records[ref]
+	if !ok {
+		return nil, errors.E(op, "transaction not found", errors.KindNotFound)
+	}
+	return &data, nil
+}
@@ -0,0 +1,90 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.CallbackRepository) = (*callbacksMock)(nil)
+
+type callbacksMock struct {
+	mu        sync.Mutex
+	callbacks []payment.RejectedCallback
+}
+
+// NewCallbackRepository creates an in memory mock of payment.CallbackRepository
+func NewCallbackRepository() payment.CallbackRepository {
+	return &callbacksMock{}
+}
+
+func (repo *callbacksMock) Save(ctx context.Context, cb *payment.RejectedCallback) error {
+	const op errors.Op = "core/payment/mocks/callbacksMock.Save"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	cb.CreatedAt = time.Now()
+	repo.callbacks = append(repo.callbacks, *cb)
+	return nil
+}
+
+func (repo *callbacksMock) List(ctx context.Context, offset, limit uint64) (payment.RejectedCallbackPage, error) {
+	const op errors.Op = "core/payment/mocks/callbacksMock.List"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	page := payment.RejectedCallbackPage{
+		PageMetadata: payment.PageMetadata{
+			Total:  uint64(len(repo.callbacks)),
+			Offset: offset,
+			Limit:  limit,
+		},
+		Callbacks: []payment.RejectedCallback{},
+	}
+
+	for i := len(repo.callbacks) - 1; i >= 0; i-- {
+		pos := uint64(len(repo.callbacks) - 1 - i)
+		if pos >= offset && pos < offset+limit {
+			page.Callbacks = append(page.Callbacks, repo.callbacks[i])
+		}
+	}
+	return page, nil
+}
+
+var _ (payment.ReplayCache) = (*replaysMock)(nil)
+
+type replaysMock struct {
+	mu     sync.Mutex
+	events map[string]bool
+}
+
+// NewReplayCache creates an in memory mock of payment.ReplayCache
+func NewReplayCache() payment.ReplayCache {
+	return &replaysMock{
+		events: make(map[string]bool),
+	}
+}
+
+func (cache *replaysMock) Claim(ctx context.Context, event string, ttl time.Duration) (bool, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.events[event] {
+		return false, nil
+	}
+	cache.events[event] = true
+	return true, nil
+}
+
+func (cache *replaysMock) Release(ctx context.Context, event string) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	delete(cache.events, event)
+	return nil
+}
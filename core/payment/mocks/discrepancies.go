@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+)
+
+var _ (payment.DiscrepancyRepository) = (*discrepanciesMock)(nil)
+
+type discrepanciesMock struct {
+	mu            sync.Mutex
+	discrepancies []payment.Discrepancy
+}
+
+// NewDiscrepancyRepository creates an in memory mock of payment.DiscrepancyRepository
+func NewDiscrepancyRepository() payment.DiscrepancyRepository {
+	return &discrepanciesMock{}
+}
+
+func (repo *discrepanciesMock) Save(ctx context.Context, d *payment.Discrepancy) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, saved := range repo.discrepancies {
+		if saved.Ref == d.Ref && saved.Reason == d.Reason {
+			return nil
+		}
+	}
+
+	d.CreatedAt = time.Now()
+	repo.discrepancies = append(repo.discrepancies, *d)
+	return nil
+}
+
+func (repo *discrepanciesMock) List(ctx context.Context, offset, limit uint64) (payment.DiscrepancyPage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	page := payment.DiscrepancyPage{
+		PageMetadata: payment.PageMetadata{
+			Total:  uint64(len(repo.discrepancies)),
+			Offset: offset,
+			Limit:  limit,
+		},
+		Discrepancies: []payment.Discrepancy{},
+	}
+
+	for i := len(repo.discrepancies) - 1; i >= 0; i-- {
+		pos := uint64(len(repo.discrepancies) - 1 - i)
+		if pos >= offset && pos < offset+limit {
+			page.Discrepancies = append(page.Discrepancies, repo.discrepancies[i])
+		}
+	}
+	return page, nil
+}
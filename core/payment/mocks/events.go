@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+)
+
+var _ (payment.EventRepository) = (*eventsMock)(nil)
+
+type eventsMock struct {
+	mu     sync.Mutex
+	events []payment.Event
+}
+
+// NewEventRepository creates an in memory mock of payment.EventRepository
+func NewEventRepository() payment.EventRepository {
+	return &eventsMock{}
+}
+
+func (repo *eventsMock) Record(ctx context.Context, ev *payment.Event) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	ev.ID = fmt.Sprintf("%d", len(repo.events)+1)
+	repo.events = append(repo.events, *ev)
+	return nil
+}
+
+func (repo *eventsMock) Timeline(ctx context.Context, ref string) ([]payment.Event, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	events := []payment.Event{}
+	for _, ev := range repo.events {
+		if ev.Ref == ref {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
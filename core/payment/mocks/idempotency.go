@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+)
+
+var _ (payment.IdempotencyStore) = (*idempotencyMock)(nil)
+
+type idempotencyMock struct {
+	mu      sync.Mutex
+	records map[string]payment.IdempotentRecord
+}
+
+// NewIdempotencyStore creates an in memory mock of payment.IdempotencyStore
+func NewIdempotencyStore() payment.IdempotencyStore {
+	return &idempotencyMock{
+		records: make(map[string]payment.IdempotentRecord),
+	}
+}
+
+func (store *idempotencyMock) Reserve(ctx context.Context, key string, rec payment.IdempotentRecord) (payment.IdempotentRecord, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if prev, ok := store.records[key]; ok {
+		return prev, false, nil
+	}
+	store.records[key] = rec
+	return rec, true, nil
+}
+
+func (store *idempotencyMock) Save(ctx context.Context, key string, rec payment.IdempotentRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.records[key] = rec
+	return nil
+}
+
+func (store *idempotencyMock) Release(ctx context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.records, key)
+	return nil
+}
@@ -181,5 +181,32 @@ func (repo *invoicesMock) Generate(ctx context.Context, id string, amount, month
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	return nil, errors.E(op, "Not implemented", errors.KindNotImplemented)
+	current, ok := repo.invoices[id]
+	if !ok {
+		return nil, errors.E(op, "property not found", errors.KindNotFound)
+	}
+	if months == 0 {
+		return nil, errors.E(op, "amount must be the due of the house for every billing period", errors.KindBadRequest)
+	}
+
+	// the current invoice comes first, the following ones charge the due
+	out := []*invoices.Invoice{&current}
+	for i := uint(1); i < months; i++ {
+		out = append(out, &invoices.Invoice{
+			ID:       current.ID + uint64(i),
+			Amount:   float64(amount / months),
+			Property: id,
+			Status:   invoices.Pending,
+		})
+	}
+	return out, nil
+}
+
+func (repo *invoicesMock) Credit(ctx context.Context, property string) (invoices.Credit, error) {
+	const op errors.Op = "app/invoices/mocks/repository.Credit"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return invoices.Credit{Property: property}, nil
 }
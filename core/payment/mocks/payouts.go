@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.PayoutRepository) = (*payoutsMock)(nil)
+
+type payoutsMock struct {
+	mu      sync.Mutex
+	payouts []payment.Payout
+}
+
+// NewPayoutRepository creates an in memory mock of payment.PayoutRepository
+func NewPayoutRepository() payment.PayoutRepository {
+	return &payoutsMock{}
+}
+
+func (repo *payoutsMock) Save(ctx context.Context, p *payment.Payout) error {
+	const op errors.Op = "core/payment/mocks/payoutsMock.Save"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, saved := range repo.payouts {
+		if saved.Ref == p.Ref {
+			return errors.E(op, "payout already exists", errors.KindAlreadyExists)
+		}
+	}
+
+	p.ID = uuid.New().ID()
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	repo.payouts = append(repo.payouts, *p)
+	return nil
+}
+
+func (repo *payoutsMock) Find(ctx context.Context, ref string) (payment.Payout, error) {
+	const op errors.Op = "core/payment/mocks/payoutsMock.Find"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, saved := range repo.payouts {
+		if saved.Ref == ref {
+			return saved, nil
+		}
+	}
+	return payment.Payout{}, errors.E(op, "payout not found", errors.KindNotFound)
+}
+
+func (repo *payoutsMock) Settle(ctx context.Context, ref string, status payment.PayoutStatus) (payment.Payout, error) {
+	const op errors.Op = "core/payment/mocks/payoutsMock.Settle"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i, saved := range repo.payouts {
+		if saved.Ref != ref {
+			continue
+		}
+		if saved.Status != payment.PayoutPending {
+			return payment.Payout{}, errors.E(op, "payout already settled", errors.KindAlreadyExists)
+		}
+		saved.Status = status
+		saved.UpdatedAt = time.Now()
+		repo.payouts[i] = saved
+		return saved, nil
+	}
+	return payment.Payout{}, errors.E(op, "payout not found", errors.KindNotFound)
+}
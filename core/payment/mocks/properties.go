@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
@@ -40,7 +41,9 @@ func (str *propertyRepository) Save(ctx context.Context, property properties.Pro
 	}
 
 	str.counter++
-	property.ID = strconv.FormatUint(str.counter, 10)
+	if property.ID == "" {
+		property.ID = strconv.FormatUint(str.counter, 10)
+	}
 	str.properties[property.ID] = property
 	return property, nil
 }
@@ -60,6 +63,28 @@ func (str *propertyRepository) Update(ctx context.Context, property properties.P
 	return nil
 }
 
+func (str *propertyRepository) ChangeDue(ctx context.Context, tc *properties.TariffChange) error {
+	const op errors.Op = "app/properties/mocks/repository.ChangeDue"
+
+	str.mu.Lock()
+	defer str.mu.Unlock()
+
+	property, ok := str.properties[tc.Property]
+	if !ok {
+		return errors.E(op, "property not found", errors.KindNotFound)
+	}
+
+	str.counter++
+	tc.ID = strconv.FormatUint(str.counter, 10)
+	tc.Previous = property.Due
+	tc.CreatedAt = time.Now()
+
+	property.Due = tc.Due
+	str.properties[property.ID] = property
+
+	return nil
+}
+
 func (str *propertyRepository) Delete(ctx context.Context, uid string) error {
 	const op errors.Op = "app/properties/mocks/repository.UpdateProperty"
 
@@ -2,7 +2,9 @@ package mocks
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
@@ -33,6 +35,13 @@ func (repo *repositoryMock) Save(ctx context.Context, payment *payment.TxRequest
 		}
 	}
 
+	if payment.Status == "" {
+		payment.Status = "pending"
+	}
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = time.Now()
+	}
+
 	repo.counter++
 	repo.payments[payment.ID] = *payment
 
@@ -45,25 +54,86 @@ func (repo *repositoryMock) Find(ctx context.Context, id string) ([]*payment.TxR
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	return nil, errors.E(op, "not implemented", errors.KindUnexpected)
+	out := make([]*payment.TxRequest, 0)
+	for _, py := range repo.payments {
+		if py.Ref == id {
+			py := py
+			out = append(out, &py)
+		}
+	}
+	return out, nil
 }
 
-func (repo *repositoryMock) Update(ctx context.Context, status string, payment []*payment.TxRequest) error {
+func (repo *repositoryMock) Update(ctx context.Context, status string, payments []*payment.TxRequest) error {
 	const op errors.Op = "core/payment/mocks/repositoryMock.Update"
 
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	return errors.E(op, "not implemented", errors.KindUnexpected)
+	for _, py := range payments {
+		saved, ok := repo.payments[py.ID]
+		if !ok {
+			return errors.E(op, "payment not found", errors.KindNotFound)
+		}
+		saved.Status = status
+		saved.Confirmed = true
+		repo.payments[py.ID] = saved
+	}
+	return nil
 }
 
-func (repo *repositoryMock) BulkSave(ctx context.Context, payment []*payment.TxRequest) error {
-	const op errors.Op = "core/payment/mocks/repositoryMock.Update"
+func (repo *repositoryMock) BulkSave(ctx context.Context, payments []*payment.TxRequest) error {
+	const op errors.Op = "core/payment/mocks/repositoryMock.BulkSave"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, py := range payments {
+		if _, ok := repo.payments[py.ID]; ok {
+			return errors.E(op, "payment already exists", errors.KindAlreadyExists)
+		}
+	}
+
+	for _, py := range payments {
+		if py.Status == "" {
+			py.Status = "pending"
+		}
+		if py.CreatedAt.IsZero() {
+			py.CreatedAt = time.Now()
+		}
+		repo.counter++
+		repo.payments[py.ID] = *py
+	}
+	return nil
+}
+
+func (repo *repositoryMock) Settle(ctx context.Context, payments []*payment.TxRequest) error {
+	const op errors.Op = "core/payment/mocks/repositoryMock.Settle"
 
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	return errors.E(op, "not implemented", errors.KindUnexpected)
+	if len(payments) == 0 {
+		return errors.E(op, "no payment to settle", errors.KindBadRequest)
+	}
+
+	for _, py := range payments {
+		if _, ok := repo.payments[py.ID]; ok {
+			return errors.E(op, "payment already exists", errors.KindAlreadyExists)
+		}
+	}
+
+	for _, py := range payments {
+		if py.CreatedAt.IsZero() {
+			py.CreatedAt = time.Now()
+		}
+		saved := *py
+		saved.Status = string(payment.Successful)
+		saved.Confirmed = true
+		repo.counter++
+		repo.payments[py.ID] = saved
+	}
+	return nil
 }
 
 func (repo *repositoryMock) List(ctx context.Context, flts *payment.Filters) (payment.PaymentResponse, error) {
@@ -110,3 +180,51 @@ func (repo *repositoryMock) UnpaidHouses(ctx context.Context, flts *payment.Metr
 
 	return payment.PaymentResponse{}, errors.E(op, "not implemented", errors.KindUnexpected)
 }
+
+func (repo *repositoryMock) Stale(ctx context.Context, before time.Time, offset, limit uint64) ([]string, error) {
+	const op errors.Op = "core/payment/mocks/repositoryMock.Stale"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	seen := make(map[string]bool)
+	refs := []string{}
+	for _, py := range repo.payments {
+		if py.Status == "pending" && py.CreatedAt.Before(before) && !seen[py.Ref] {
+			seen[py.Ref] = true
+			refs = append(refs, py.Ref)
+		}
+	}
+	sort.Strings(refs)
+
+	if offset >= uint64(len(refs)) {
+		return []string{}, nil
+	}
+	refs = refs[offset:]
+	if uint64(len(refs)) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+func (repo *repositoryMock) Expire(ctx context.Context, before time.Time, reason string) ([]string, error) {
+	const op errors.Op = "core/payment/mocks/repositoryMock.Expire"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	seen := make(map[string]bool)
+	refs := []string{}
+	for id, py := range repo.payments {
+		if py.Status == "pending" && py.CreatedAt.Before(before) {
+			py.Status = payment.ExpiredStatus
+			repo.payments[id] = py
+			if !seen[py.Ref] {
+				seen[py.Ref] = true
+				refs = append(refs, py.Ref)
+			}
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+)
+
+var _ (payment.Reversals) = (*reversalsMock)(nil)
+
+type reversalsMock struct{}
+
+// NewReversals creates a mock of payment.Reversals for payments without
+// reversals, every push is left alone
+func NewReversals() payment.Reversals {
+	return &reversalsMock{}
+}
+
+func (rv *reversalsMock) Settle(ctx context.Context, ref string, status payment.PayoutStatus) error {
+	return nil
+}
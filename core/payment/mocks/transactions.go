@@ -192,3 +192,25 @@ func (str *transactionRepoMock) RetrieveByMethod(ctx context.Context, m string,
 
 	return page, nil
 }
+
+func (str *transactionRepoMock) RetrieveReceipt(ctx context.Context, id string) (transactions.Receipt, error) {
+	const op errors.Op = "core/payment/mocks/repository.RetrieveReceipt"
+
+	str.mu.Lock()
+	defer str.mu.Unlock()
+
+	tx, ok := str.transactions[id]
+	if !ok {
+		return transactions.Receipt{}, errors.E(op, "transaction not found", errors.KindNotFound)
+	}
+
+	receipt := transactions.Receipt{
+		ID:       tx.ID,
+		Property: tx.MadeFor,
+		Method:   tx.Method,
+		Amount:   tx.Amount,
+		PaidAt:   tx.DateRecorded,
+		Items:    []transactions.ReceiptItem{{Invoice: tx.Invoice, Month: tx.DateRecorded, Amount: tx.Amount}},
+	}
+	return receipt, nil
+}
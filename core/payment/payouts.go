@@ -0,0 +1,64 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// PayoutStatus is the state of money sent out through the gateway
+type PayoutStatus string
+
+// Possible payout statuses, they follow the gateway callbacks
+const (
+	PayoutPending    PayoutStatus = "pending"
+	PayoutSuccessful PayoutStatus = "successful"
+	PayoutFailed     PayoutStatus = "failed"
+)
+
+// Payout is a disbursement made through Push
+type Payout struct {
+	ID        string       `json:"id,omitempty"`
+	Ref       string       `json:"ref,omitempty"`
+	Namespace string       `json:"namespace,omitempty"`
+	MSISDN    string       `json:"phone,omitempty"`
+	Method    Method       `json:"method,omitempty"`
+	Amount    float64      `json:"amount,omitempty"`
+	Status    PayoutStatus `json:"status,omitempty"`
+	CreatedAt time.Time    `json:"created_at,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at,omitempty"`
+}
+
+// PayoutRepository is the ledger of disbursements
+type PayoutRepository interface {
+	// Save a new payout
+	Save(ctx context.Context, p *Payout) error
+
+	// Find a payout by the ref of its push
+	Find(ctx context.Context, ref string) (Payout, error)
+
+	// Settle moves a pending payout to its final status
+	Settle(ctx context.Context, ref string, status PayoutStatus) (Payout, error)
+}
+
+// Reversals settles the pushes that pay money back to a payer
+type Reversals interface {
+	// Settle completes or fails the reversal paid out by the push ref,
+	// pushes that aren't reversals are left alone.
+	Settle(ctx context.Context, ref string, status PayoutStatus) error
+}
+
+// ToPayoutStatus maps the status of a gateway callback to a final payout status
+func ToPayoutStatus(status string) (PayoutStatus, error) {
+	const op errors.Op = "core/payment/ToPayoutStatus"
+
+	switch State(status) {
+	case Successful:
+		return PayoutSuccessful, nil
+	case Failed:
+		return PayoutFailed, nil
+	default:
+		return "", errors.E(op, "unexpected payout status "+status, errors.KindBadRequest)
+	}
+}
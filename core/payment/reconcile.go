@@ -0,0 +1,60 @@
+package payment
+
+import (
+	"context"
+	"time"
+)
+
+// ProcessedEvent is the callback kind of a transaction the gateway is done with
+const ProcessedEvent = "transaction:processed"
+
+// Reasons recorded when a payment couldn't be reconciled, the payment is
+// tried again on the next run
+const (
+	StatusCheckReason = "gateway status check failed"
+	ApplyStatusReason = "gateway record could not be applied"
+)
+
+// Discrepancy is a pending payment that couldn't be settled against the gateway record
+type Discrepancy struct {
+	ID           string    `json:"id,omitempty"`
+	Ref          string    `json:"ref,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	LocalStatus  string    `json:"local_status,omitempty"`
+	RemoteStatus string    `json:"remote_status,omitempty"`
+	LocalAmount  float64   `json:"local_amount,omitempty"`
+	RemoteAmount float64   `json:"remote_amount,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+}
+
+// DiscrepancyPage is a list of discrepancies
+type DiscrepancyPage struct {
+	PageMetadata
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// Report summarises a reconciliation run
+type Report struct {
+	Checked       int           `json:"checked"`
+	Successful    int           `json:"successful"`
+	Failed        int           `json:"failed"`
+	Pending       int           `json:"pending"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Failures      []Failure     `json:"failures"`
+}
+
+// Failure is a payment a reconciliation run couldn't check, it is tried
+// again on the next run
+type Failure struct {
+	Ref    string `json:"ref"`
+	Reason string `json:"reason"`
+}
+
+// DiscrepancyRepository stores discrepancies found during reconciliation
+type DiscrepancyRepository interface {
+	// Save a discrepancy, saving the same ref and reason twice is a no-op
+	Save(ctx context.Context, d *Discrepancy) error
+
+	// List discrepancies starting with the most recent
+	List(ctx context.Context, offset, limit uint64) (DiscrepancyPage, error)
+}
@@ -2,6 +2,7 @@ package payment
 
 import (
 	"context"
+	"time"
 )
 
 // Repository saves validated Transactions to the underlying datastore
@@ -17,6 +18,10 @@ type Repository interface {
 	//BulkSave saves multiple payments to the database
 	BulkSave(context.Context, []*TxRequest) error
 
+	// Settle saves payments received outside of the gateway and confirms
+	// them as successful in a single transaction
+	Settle(context.Context, []*TxRequest) error
+
 	// PaymentRequest generates all payments
 	List(context.Context, *Filters) (PaymentResponse, error)
 
@@ -30,4 +35,11 @@ type Repository interface {
 	TodaySummary(context.Context, *MetricFilters) (Summaries, error)
 	// returns unpaid houses
 	UnpaidHouses(context.Context, *MetricFilters) (PaymentResponse, error)
+
+	// Stale returns the refs of payments still pending since before the given time
+	Stale(ctx context.Context, before time.Time, offset, limit uint64) ([]string, error)
+
+	// Expire marks payments still pending since before the given time as
+	// expired and returns their refs
+	Expire(ctx context.Context, before time.Time, reason string) ([]string, error)
 }
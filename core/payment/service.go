@@ -3,9 +3,12 @@ package payment
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
 	"github.com/nshimiyimanaamani/paypack-backend/core/identity"
 	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
 	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
@@ -36,6 +39,35 @@ type Service interface {
 
 	//CreditPull initiate payment for credited invoices
 	CreditPull(context.Context, *TxRequest, []invoices.Invoice) (*TxResponse, error)
+
+	// VerifyCallback authenticates a signed gateway callback and decodes it.
+	// Callbacks failing verification are stored for later inspection.
+	VerifyCallback(ctx context.Context, sc *SignedCallback) (Callback, error)
+
+	// ReleaseCallback forgets a verified callback whose processing failed
+	// so that the gateway can deliver it again
+	ReleaseCallback(ctx context.Context, cb Callback) error
+
+	// RejectedCallbacks lists callbacks that failed verification
+	RejectedCallbacks(ctx context.Context, offset, limit uint64) (RejectedCallbackPage, error)
+
+	// Reconcile settles payments pending for longer than age using
+	// the gateway records, processing them in batches of bsize
+	Reconcile(ctx context.Context, age time.Duration, bsize uint64) (Report, error)
+
+	// Expire gives up on payments pending for longer than age, a callback
+	// arriving later still settles them but is flagged as a discrepancy
+	Expire(ctx context.Context, age time.Duration) ([]string, error)
+
+	// Timeline returns the history of a payment made in namespace, an
+	// empty namespace stands for every namespace
+	Timeline(ctx context.Context, ref, namespace string) (Timeline, error)
+
+	// Discrepancies lists payments reconciliation couldn't settle
+	Discrepancies(ctx context.Context, offset, limit uint64) (DiscrepancyPage, error)
+
+	// Backends reports the health of the payment backends
+	Backends(ctx context.Context) ([]BackendHealth, error)
 }
 
 // Options simplifies New func signature
@@ -49,6 +81,16 @@ type Options struct {
 	Invoices     invoices.Repository
 	Transactions transactions.Repository
 	Repository   Repository
+	Callbacks    CallbackRepository
+	Replays      ReplayCache
+	Discrepancy  DiscrepancyRepository
+	Idempotency  IdempotencyStore
+	Reversals    Reversals
+	Events       EventRepository
+	Payouts      PayoutRepository
+	Fees         accounts.FeeRepository
+	Secret       string
+	Tolerance    time.Duration
 }
 type service struct {
 	backend      Client
@@ -60,10 +102,24 @@ type service struct {
 	transactions transactions.Repository
 	invoices     invoices.Repository
 	repository   Repository
+	callbacks    CallbackRepository
+	replays      ReplayCache
+	discrepancy  DiscrepancyRepository
+	idempotency  IdempotencyStore
+	reversals    Reversals
+	events       EventRepository
+	payouts      PayoutRepository
+	fees         accounts.FeeRepository
+	secret       string
+	tolerance    time.Duration
 }
 
 // New initializes the payment service
 func New(opts *Options) Service {
+	tolerance := opts.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
 	return &service{
 		queue:        opts.Queue,
 		idp:          opts.Idp,
@@ -74,11 +130,27 @@ func New(opts *Options) Service {
 		invoices:     opts.Invoices,
 		transactions: opts.Transactions,
 		repository:   opts.Repository,
+		callbacks:    opts.Callbacks,
+		replays:      opts.Replays,
+		discrepancy:  opts.Discrepancy,
+		idempotency:  opts.Idempotency,
+		reversals:    opts.Reversals,
+		events:       opts.Events,
+		payouts:      opts.Payouts,
+		fees:         opts.Fees,
+		secret:       opts.Secret,
+		tolerance:    tolerance,
 	}
 }
 
 // Pull is for initiating payment for last invoice
 func (svc service) Pull(ctx context.Context, payment *TxRequest) (*TxResponse, error) {
+	return svc.idempotent(ctx, "pull", payment, func(sent *bool) (*TxResponse, error) {
+		return svc.pull(ctx, payment, sent)
+	})
+}
+
+func (svc service) pull(ctx context.Context, payment *TxRequest, sent *bool) (*TxResponse, error) {
 	const op errors.Op = "core/payment/service.Pull"
 
 	failed := &TxResponse{TxState: "failed"}
@@ -107,6 +179,7 @@ func (svc service) Pull(ctx context.Context, payment *TxRequest) (*TxResponse, e
 		return failed, errors.E(op, err)
 	}
 	payment.ID = svc.idp.ID()
+	payment.Namespace = property.Namespace
 
 	payment.Invoice = invoice.ID
 	if err := payment.HasInvoice(); err != nil {
@@ -120,12 +193,28 @@ func (svc service) Pull(ctx context.Context, payment *TxRequest) (*TxResponse, e
 		return failed, errors.E(op, err)
 	}
 
-	res, err := svc.backend.Pull(ctx, payment)
+	fee, err := svc.fee(ctx, property.Namespace, payment.Amount)
+	if err != nil {
+		failed.Message = err.Error()
+		return failed, errors.E(op, err)
+	}
+	pulled := *payment
+	pulled.Amount += fee
+
+	started := time.Now()
+
+	*sent = true
+	res, err := svc.backend.Pull(ctx, &pulled)
 	if err != nil {
 		failed.Message = err.Error()
 		return failed, errors.E(op, err)
 	}
 
+	if err := svc.initiated(ctx, started, payment, res); err != nil {
+		failed.Message = err.Error()
+		return failed, errors.E(op, err)
+	}
+
 	payment.ID = uuid.NewV4().String()
 	payment.Ref = res.TxID
 	payment.Confirmed = false
@@ -140,6 +229,12 @@ func (svc service) Pull(ctx context.Context, payment *TxRequest) (*TxResponse, e
 
 // BulkPull initiate payment for many invoices
 func (svc service) BulkPull(ctx context.Context, payment *TxRequest, month int) (*TxResponse, error) {
+	return svc.idempotent(ctx, "bulk", payment, func(sent *bool) (*TxResponse, error) {
+		return svc.bulkPull(ctx, payment, month, sent)
+	})
+}
+
+func (svc service) bulkPull(ctx context.Context, payment *TxRequest, month int, sent *bool) (*TxResponse, error) {
 	const op errors.Op = "core/payment/service.BulkPull"
 
 	failed := &TxResponse{TxState: "failed"}
@@ -169,12 +264,33 @@ func (svc service) BulkPull(ctx context.Context, payment *TxRequest, month int)
 		return failed, errors.E(op, failed.Message, errors.KindUnexpected)
 	}
 
-	res, err := svc.backend.Pull(ctx, payment)
+	var amounts []float64
+	for _, invoice := range invoices {
+		amounts = append(amounts, invoice.Amount)
+	}
+
+	fee, err := svc.surcharge(ctx, payment.Code, amounts...)
+	if err != nil {
+		failed.Message = err.Error()
+		return failed, errors.E(op, err)
+	}
+	pulled := *payment
+	pulled.Amount += fee
+
+	started := time.Now()
+
+	*sent = true
+	res, err := svc.backend.Pull(ctx, &pulled)
 	if err != nil {
 		failed.Message = err.Error()
 		return failed, errors.E(op, err)
 	}
 
+	if err := svc.initiated(ctx, started, payment, res); err != nil {
+		failed.Message = err.Error()
+		return failed, errors.E(op, err)
+	}
+
 	for _, invoice := range invoices {
 		payment := &TxRequest{
 			ID:        svc.idp.ID(),
@@ -199,6 +315,12 @@ func (svc service) BulkPull(ctx context.Context, payment *TxRequest, month int)
 
 // CreditPull initiate payment for credited invoices
 func (svc service) CreditPull(ctx context.Context, payment *TxRequest, invoices []invoices.Invoice) (*TxResponse, error) {
+	return svc.idempotent(ctx, "credit", payment, func(sent *bool) (*TxResponse, error) {
+		return svc.creditPull(ctx, payment, invoices, sent)
+	})
+}
+
+func (svc service) creditPull(ctx context.Context, payment *TxRequest, invoices []invoices.Invoice, sent *bool) (*TxResponse, error) {
 	const op errors.Op = "core/payment/service.CreditPull"
 
 	failed := &TxResponse{TxState: "failed"}
@@ -213,23 +335,41 @@ func (svc service) CreditPull(ctx context.Context, payment *TxRequest, invoices
 		return failed, errors.E(op, err)
 	}
 
-	var amount float64
+	var (
+		amount  float64
+		amounts []float64
+	)
 	for _, invoice := range invoices {
-		amount += invoice.Amount
+		amount += invoice.Balance()
+		amounts = append(amounts, invoice.Balance())
 	}
 	payment.Amount = amount
 
-	res, err := svc.backend.Pull(ctx, payment)
+	fee, err := svc.surcharge(ctx, payment.Code, amounts...)
+	if err != nil {
+		return failed, errors.E(op, err)
+	}
+	pulled := *payment
+	pulled.Amount += fee
+
+	started := time.Now()
+
+	*sent = true
+	res, err := svc.backend.Pull(ctx, &pulled)
 	if err != nil {
 		return failed, errors.E(op, err)
 	}
 
+	if err := svc.initiated(ctx, started, payment, res); err != nil {
+		return failed, errors.E(op, err)
+	}
+
 	payments := make([]*TxRequest, 0)
 
 	for _, invoice := range invoices {
 		payment := &TxRequest{
 			ID:        svc.idp.ID(),
-			Amount:    invoice.Amount,
+			Amount:    invoice.Balance(),
 			Invoice:   invoice.ID,
 			Method:    payment.Method,
 			MSISDN:    payment.MSISDN,
@@ -247,7 +387,55 @@ func (svc service) CreditPull(ctx context.Context, payment *TxRequest, invoices
 	return res, nil
 }
 
+// surcharge is the fee pulled on top of the amounts paid for a property,
+// amounts are still recorded and settled as they are.
+func (svc service) surcharge(ctx context.Context, code string, amounts ...float64) (float64, error) {
+	const op errors.Op = "core/payment/service.surcharge"
+
+	property, err := svc.properties.RetrieveByID(ctx, code)
+	if err != nil {
+		return 0, errors.E(op, err)
+	}
+
+	fee, err := svc.fee(ctx, property.Namespace, amounts...)
+	if err != nil {
+		return 0, errors.E(op, err)
+	}
+	return fee, nil
+}
+
+// fee sums the fee of every amount when the payers of the account bear
+// it, each amount is recorded as its own transaction with its own fee.
+// Accounts without a fee rule aren't charged.
+func (svc service) fee(ctx context.Context, account string, amounts ...float64) (float64, error) {
+	const op errors.Op = "core/payment/service.fee"
+
+	rule, err := svc.fees.RetrieveFeeRule(ctx, account)
+	if err != nil {
+		if errors.Kind(err) == errors.KindNotFound {
+			return 0, nil
+		}
+		return 0, errors.E(op, err)
+	}
+
+	if rule.Bearer != accounts.Payer {
+		return 0, nil
+	}
+
+	var fee float64
+	for _, amount := range amounts {
+		fee += rule.Compute(amount)
+	}
+	return math.Round(fee*100) / 100, nil
+}
+
 func (svc *service) Push(ctx context.Context, payment *TxRequest) (*TxResponse, error) {
+	return svc.idempotent(ctx, "push", payment, func(sent *bool) (*TxResponse, error) {
+		return svc.push(ctx, payment, sent)
+	})
+}
+
+func (svc *service) push(ctx context.Context, payment *TxRequest, sent *bool) (*TxResponse, error) {
 	const op errors.Op = "core/payment/service.Push"
 
 	failed := &TxResponse{TxState: "failed"}
@@ -258,19 +446,96 @@ func (svc *service) Push(ctx context.Context, payment *TxRequest) (*TxResponse,
 
 	payment.ID = svc.idp.ID()
 
+	started := time.Now()
+
+	*sent = true
 	res, err := svc.backend.Push(ctx, payment)
 	if err != nil {
 		return failed, errors.E(op, err)
 	}
 
+	if err := svc.initiated(ctx, started, payment, res); err != nil {
+		return failed, errors.E(op, err)
+	}
+
+	payout := &Payout{
+		Ref:       res.TxID,
+		Namespace: payment.Namespace,
+		MSISDN:    payment.MSISDN,
+		Method:    payment.Method,
+		Amount:    payment.Amount,
+		Status:    PayoutPending,
+	}
+	if err := svc.payouts.Save(ctx, payout); err != nil {
+		return failed, errors.E(op, err)
+	}
+
 	payment.ID = res.TxID
-	//save instead to payments
 	if err := svc.queue.Set(ctx, payment); err != nil {
 		return failed, errors.E(op, err)
 	}
 	return res, nil
 }
 
+// idempotent runs fn once per idempotency key and replays its outcome
+// afterwards. fn sets sent before it calls the gateway, a request that
+// fails before that frees the key so that it can be retried. Requests
+// without a key are always executed.
+func (svc *service) idempotent(ctx context.Context, scope string, tx *TxRequest, fn func(sent *bool) (*TxResponse, error)) (*TxResponse, error) {
+	const op errors.Op = "core/payment/service.idempotent"
+
+	var sent bool
+
+	if tx.IdempotencyKey == "" {
+		return fn(&sent)
+	}
+
+	key := fmt.Sprintf("%s:%s", scope, tx.IdempotencyKey)
+	rec := IdempotentRecord{Fingerprint: tx.Fingerprint()}
+
+	prev, reserved, err := svc.idempotency.Reserve(ctx, key, rec)
+	if err != nil {
+		return &TxResponse{TxState: "failed"}, errors.E(op, err)
+	}
+
+	if !reserved {
+		if prev.Fingerprint != rec.Fingerprint {
+			return &TxResponse{TxState: "failed"}, errors.E(op, "idempotency key was already used for a different request", errors.KindBadRequest)
+		}
+		if prev.Response == nil {
+			return &TxResponse{TxState: "failed"}, errors.E(op, "a request with this idempotency key is still being processed", errors.KindAlreadyExists)
+		}
+		if prev.Kind != 0 {
+			return prev.Response, errors.E(op, prev.Response.Message, prev.Kind)
+		}
+		return prev.Response, nil
+	}
+
+	res, err := fn(&sent)
+	if err != nil && !sent {
+		if rerr := svc.idempotency.Release(ctx, key); rerr != nil {
+			return res, errors.E(op, rerr)
+		}
+		return res, err
+	}
+
+	// the gateway was already hit, failures are kept as well since the
+	// payer may have been charged. If the outcome can't be saved the key
+	// stays reserved until it expires rather than risking a second charge.
+	rec.Response = res
+	if err != nil {
+		rec.Kind = errors.Kind(err)
+		rec.Response = &TxResponse{TxState: "failed", Message: err.Error()}
+		if res != nil && res.Message != "" {
+			rec.Response.Message = res.Message
+		}
+	}
+	if serr := svc.idempotency.Save(ctx, key, rec); serr != nil {
+		return res, errors.E(op, serr)
+	}
+	return res, err
+}
+
 func (svc *service) ProcessHook(ctx context.Context, cb Callback) error {
 	const op errors.Op = "core/payment/service.ProcessHook"
 
@@ -287,6 +552,15 @@ func (svc *service) ProcessHook(ctx context.Context, cb Callback) error {
 		return errors.E(op, fmt.Sprintf("no payments found for this ref %s", cb.Data.Ref), errors.KindUnexpected)
 	}
 
+	shareGatewayFee(payments, cb.Data.Fee)
+
+	if payments[0].Status == ExpiredStatus {
+		if err := svc.settleExpired(ctx, cb, payments); err != nil {
+			return errors.E(op, err)
+		}
+		return nil
+	}
+
 	if payments[0].Status != "pending" {
 		return errors.E(op, fmt.Sprintf("payment with ref %s is already processed", cb.Data.Ref), errors.KindUnexpected)
 	}
@@ -298,6 +572,54 @@ func (svc *service) ProcessHook(ctx context.Context, cb Callback) error {
 	return nil
 }
 
+// shareGatewayFee splits the fee the gateway reported for a callback across
+// the payments it settles in proportion to their amounts.
+func shareGatewayFee(payments []*TxRequest, fee float64) {
+	var total float64
+	for _, py := range payments {
+		total += py.Amount
+	}
+	if total == 0 {
+		return
+	}
+	for _, py := range payments {
+		py.GatewayFee = math.Round(fee*py.Amount/total*100) / 100
+	}
+}
+
+// settleExpired applies a callback that arrived after its payment expired.
+// The gateway has the final word so the payment is reopened with its status,
+// money that was collected anyway is flagged for review.
+func (svc *service) settleExpired(ctx context.Context, cb Callback, payments []*TxRequest) error {
+	const op errors.Op = "core/payment/service.settleExpired"
+
+	if err := svc.repository.Update(ctx, cb.Data.Status, payments); err != nil {
+		return errors.E(op, err, errors.Kind(err))
+	}
+
+	if State(cb.Data.Status) != Successful {
+		return nil
+	}
+
+	var amount float64
+	for _, py := range payments {
+		amount += py.Amount
+	}
+
+	d := &Discrepancy{
+		Ref:          cb.Data.Ref,
+		Reason:       LateCallbackReason,
+		LocalStatus:  ExpiredStatus,
+		LocalAmount:  amount,
+		RemoteStatus: cb.Data.Status,
+		RemoteAmount: cb.Data.Amount,
+	}
+	if err := svc.discrepancy.Save(ctx, d); err != nil {
+		return errors.E(op, err)
+	}
+	return nil
+}
+
 func (svc *service) ConfirmPush(ctx context.Context, cb Callback) error {
 	const op errors.Op = "core/payment/service.ConfirmPush"
 
@@ -305,22 +627,309 @@ func (svc *service) ConfirmPush(ctx context.Context, cb Callback) error {
 		return errors.E(op, err)
 	}
 
-	if State(cb.Data.Status) != Successful {
-		return errors.E(op, cb.Data.Status, errors.KindUnexpected)
+	status, err := ToPayoutStatus(cb.Data.Status)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// reversals are settled first, a retried callback finds the payout
+	// settled already
+	if err := svc.reversals.Settle(ctx, cb.Data.Ref, status); err != nil {
+		return errors.E(op, err)
+	}
+
+	if _, err := svc.payouts.Settle(ctx, cb.Data.Ref, status); err != nil {
+		return errors.E(op, err)
+	}
+
+	// the cached transaction expires on its own, it is only removed early
+	if err := svc.queue.Remove(ctx, cb.Data.Ref); err != nil && errors.Kind(err) != errors.KindNotFound {
+		return errors.E(op, err)
+	}
+	return nil
+}
+
+func (svc *service) VerifyCallback(ctx context.Context, sc *SignedCallback) (Callback, error) {
+	const op errors.Op = "core/payment/service.VerifyCallback"
+
+	var cb Callback
+
+	reject := func(err error) (Callback, error) {
+		if serr := svc.callbacks.Save(ctx, sc.Reject(err)); serr != nil {
+			return Callback{}, errors.E(op, serr)
+		}
+		return Callback{}, errors.E(op, err)
+	}
+
+	if err := sc.Verify(svc.secret, svc.tolerance, time.Now()); err != nil {
+		return reject(err)
+	}
+
+	if err := json.Unmarshal(sc.Payload, &cb); err != nil {
+		return reject(errors.E(op, "invalid callback payload", errors.KindBadRequest))
+	}
+
+	if cb.EventID == "" {
+		return reject(errors.E(op, "missing callback event id", errors.KindBadRequest))
 	}
 
-	//retrieve from payments instead
-	tx, err := svc.queue.Get(ctx, cb.Data.Ref)
+	// signatures older than the tolerance are already refused, so
+	// remembering events for twice that long is enough to stop replays.
+	claimed, err := svc.replays.Claim(ctx, cb.EventID, 2*svc.tolerance)
 	if err != nil {
+		return Callback{}, errors.E(op, err)
+	}
+	if !claimed {
+		return reject(errors.E(op, fmt.Sprintf("callback event %s was already received", cb.EventID), errors.KindAlreadyExists))
+	}
+
+	ev := NewEvent(cb.Data.Ref, CallbackReceived, cb.Data.Status, nil)
+	ev.Payload = sc.Payload
+	if err := svc.events.Record(ctx, ev); err != nil {
+		if rerr := svc.replays.Release(ctx, cb.EventID); rerr != nil {
+			return Callback{}, errors.E(op, rerr)
+		}
+		return Callback{}, errors.E(op, err)
+	}
+	return cb, nil
+}
+
+// initiated records that a payment was sent to the gateway and what the gateway answered
+func (svc service) initiated(ctx context.Context, started time.Time, tx *TxRequest, res *TxResponse) error {
+	const op errors.Op = "core/payment/service.initiated"
+
+	ev := NewEvent(res.TxID, Initiated, "pending", tx)
+	ev.CreatedAt = started
+	if err := svc.events.Record(ctx, ev); err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := svc.events.Record(ctx, NewEvent(res.TxID, GatewayAccepted, res.Status, res)); err != nil {
 		return errors.E(op, err)
 	}
-	//remove tx from the cache
-	if err := svc.queue.Remove(ctx, tx.ID); err != nil {
+	return nil
+}
+
+func (svc service) Timeline(ctx context.Context, ref, namespace string) (Timeline, error) {
+	const op errors.Op = "core/payment/service.Timeline"
+
+	events, err := svc.events.Timeline(ctx, ref)
+	if err != nil {
+		return Timeline{}, errors.E(op, err)
+	}
+
+	if len(events) == 0 {
+		return Timeline{}, errors.E(op, fmt.Sprintf("no history found for payment %s", ref), errors.KindNotFound)
+	}
+
+	// the history holds the payer's number and raw gateway payloads, it is
+	// hidden from the other sectors
+	if namespace != "" {
+		owner, err := svc.namespaceOf(ctx, ref)
+		if err != nil {
+			return Timeline{}, errors.E(op, err)
+		}
+		if owner != namespace {
+			return Timeline{}, errors.E(op, fmt.Sprintf("no history found for payment %s", ref), errors.KindNotFound)
+		}
+	}
+	return Timeline{Ref: ref, Events: events}, nil
+}
+
+// namespaceOf finds the namespace a pull was made for or a push was made from
+func (svc service) namespaceOf(ctx context.Context, ref string) (string, error) {
+	const op errors.Op = "core/payment/service.namespaceOf"
+
+	payments, err := svc.repository.Find(ctx, ref)
+	if err != nil {
+		return "", errors.E(op, err)
+	}
+
+	if len(payments) > 0 {
+		property, err := svc.properties.RetrieveByID(ctx, payments[0].Code)
+		if err != nil {
+			return "", errors.E(op, err)
+		}
+		return property.Namespace, nil
+	}
+
+	payout, err := svc.payouts.Find(ctx, ref)
+	if err != nil {
+		return "", errors.E(op, err)
+	}
+	return payout.Namespace, nil
+}
+
+func (svc *service) ReleaseCallback(ctx context.Context, cb Callback) error {
+	const op errors.Op = "core/payment/service.ReleaseCallback"
+
+	if err := svc.replays.Release(ctx, cb.EventID); err != nil {
 		return errors.E(op, err)
 	}
 	return nil
 }
 
+func (svc *service) RejectedCallbacks(ctx context.Context, offset, limit uint64) (RejectedCallbackPage, error) {
+	const op errors.Op = "core/payment/service.RejectedCallbacks"
+
+	page, err := svc.callbacks.List(ctx, offset, limit)
+	if err != nil {
+		return RejectedCallbackPage{}, errors.E(op, err)
+	}
+	return page, nil
+}
+
+func (svc *service) Reconcile(ctx context.Context, age time.Duration, bsize uint64) (Report, error) {
+	const op errors.Op = "core/payment/service.Reconcile"
+
+	report := Report{Discrepancies: []Discrepancy{}, Failures: []Failure{}}
+
+	before := time.Now().Add(-age)
+
+	// payments that stay pending are skipped over on the next batch
+	var offset uint64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, errors.E(op, err)
+		}
+
+		refs, err := svc.repository.Stale(ctx, before, offset, bsize)
+		if err != nil {
+			return report, errors.E(op, err)
+		}
+
+		for _, ref := range refs {
+			// a payment that can't be checked doesn't hold back the batch
+			settled, err := svc.reconcile(ctx, ref, &report)
+			if err != nil {
+				report.Failures = append(report.Failures, Failure{Ref: ref, Reason: err.Error()})
+			}
+			if !settled {
+				offset++
+			}
+		}
+
+		if uint64(len(refs)) < bsize {
+			return report, nil
+		}
+	}
+}
+
+// reconcile applies the gateway record of a single payment and
+// reports whether the payment left the pending state. Gateway failures
+// are recorded as discrepancies so they don't hold back the batch.
+func (svc *service) reconcile(ctx context.Context, ref string, report *Report) (bool, error) {
+	const op errors.Op = "core/payment/service.reconcile"
+
+	report.Checked++
+
+	payments, err := svc.repository.Find(ctx, ref)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	if len(payments) == 0 {
+		return false, nil
+	}
+
+	var amount float64
+	for _, py := range payments {
+		amount += py.Amount
+	}
+
+	d := Discrepancy{Ref: ref, LocalStatus: payments[0].Status, LocalAmount: amount}
+
+	remote, err := svc.backend.Status(ctx, ref)
+	if err != nil {
+		d.Reason = StatusCheckReason
+		if errors.Kind(err) == errors.KindNotFound {
+			d.Reason = "payment not found on the gateway"
+		}
+		return false, svc.discrepancyFound(ctx, report, d)
+	}
+
+	d.RemoteStatus = remote.Status
+	d.RemoteAmount = remote.Amount
+
+	var settled *int
+
+	switch State(remote.Status) {
+	case Successful:
+		var amounts []float64
+		for _, py := range payments {
+			amounts = append(amounts, py.Amount)
+		}
+		fee, err := svc.surcharge(ctx, payments[0].Code, amounts...)
+		if err != nil {
+			return false, errors.E(op, err)
+		}
+		if remote.Amount != amount+fee {
+			d.Reason = "amount differs from the gateway"
+			return false, svc.discrepancyFound(ctx, report, d)
+		}
+		settled = &report.Successful
+	case Failed:
+		settled = &report.Failed
+	case "pending", Pending:
+		report.Pending++
+		return false, nil
+	default:
+		d.Reason = fmt.Sprintf("unknown gateway status %q", remote.Status)
+		return false, svc.discrepancyFound(ctx, report, d)
+	}
+
+	cb := Callback{Kind: ProcessedEvent, Data: *remote}
+	cb.Data.Ref = ref
+
+	if err := svc.ProcessHook(ctx, cb); err != nil {
+		d.Reason = ApplyStatusReason
+		return false, svc.discrepancyFound(ctx, report, d)
+	}
+	*settled++
+	return true, nil
+}
+
+func (svc *service) Expire(ctx context.Context, age time.Duration) ([]string, error) {
+	const op errors.Op = "core/payment/service.Expire"
+
+	refs, err := svc.repository.Expire(ctx, time.Now().Add(-age), ExpiryReason)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return refs, nil
+}
+
+func (svc *service) discrepancyFound(ctx context.Context, report *Report, d Discrepancy) error {
+	const op errors.Op = "core/payment/service.discrepancyFound"
+
+	if err := svc.discrepancy.Save(ctx, &d); err != nil {
+		return errors.E(op, err)
+	}
+	report.Discrepancies = append(report.Discrepancies, d)
+	return nil
+}
+
+func (svc *service) Discrepancies(ctx context.Context, offset, limit uint64) (DiscrepancyPage, error) {
+	const op errors.Op = "core/payment/service.Discrepancies"
+
+	page, err := svc.discrepancy.List(ctx, offset, limit)
+	if err != nil {
+		return DiscrepancyPage{}, errors.E(op, err)
+	}
+	return page, nil
+}
+
+func (svc *service) Backends(ctx context.Context) ([]BackendHealth, error) {
+	const op errors.Op = "core/payment/service.Backends"
+
+	reporter, ok := svc.backend.(HealthReporter)
+	if !ok {
+		return nil, errors.E(op, "payment backend does not report its health", errors.KindNotImplemented)
+	}
+	return reporter.Health(), nil
+}
+
 func (svc *service) Notify(ctx context.Context, py TxRequest, tx transactions.Transaction) error {
 	const op errors.Op = "core/app/payment/service.Notify"
 
@@ -3,9 +3,12 @@ package payment_test
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	amocks "github.com/nshimiyimanaamani/paypack-backend/core/accounts/mocks"
 	"github.com/nshimiyimanaamani/paypack-backend/core/identity/uuid"
 	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
 	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
@@ -19,7 +22,10 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-const namespace = "remera"
+const (
+	namespace = "remera"
+	secret    = "callback-secret"
+)
 
 func TestPull(t *testing.T) {
 	const op errors.Op = "core/payment/service.Pull"
@@ -54,10 +60,16 @@ func TestPull(t *testing.T) {
 			err:     errors.E(op, "property not found"),
 		},
 		{
-			desc:    "initialize payment with invalid amount(different from invoice)",
-			payment: &payment.TxRequest{Code: property.ID, Amount: 100, MSISDN: "0784607135", Method: "mtn-momo-rw"},
+			desc:    "initialize partial payment of the invoice",
+			payment: &payment.TxRequest{Code: property.ID, Amount: invoice.Amount / 2, MSISDN: "0784607135", Method: "mtn-momo-rw"},
+			state:   "processing",
+			err:     nil,
+		},
+		{
+			desc:    "initialize payment with zero amount",
+			payment: &payment.TxRequest{Code: property.ID, MSISDN: "0784607135", Method: "mtn-momo-rw"},
 			state:   "failed",
-			err:     errors.E(op, "amount doesn't match invoice"),
+			err:     errors.E(op, "amount must be greater than zero"),
 		},
 	}
 
@@ -161,6 +173,111 @@ func TestPush(t *testing.T) {
 
 }
 
+func TestPullIdempotency(t *testing.T) {
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, invoice := newInvoiceStore(property)
+	svc := newService(owners, properties, invoices)
+
+	ctx := context.Background()
+	key := uuid.New().ID()
+
+	first, err := svc.Pull(ctx, &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN, IdempotencyKey: key})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc    string
+		payment *payment.TxRequest
+		txID    string
+		err     error
+	}{
+		{
+			desc:    "retry payment with the same idempotency key",
+			payment: &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN, IdempotencyKey: key},
+			txID:    first.TxID,
+			err:     nil,
+		},
+		{
+			desc:    "reuse idempotency key for a different payment",
+			payment: &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0788000000", Method: payment.MTN, IdempotencyKey: key},
+			txID:    "",
+			err:     errors.E("idempotency key was already used for a different request", errors.KindBadRequest),
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := svc.Pull(ctx, tc.payment)
+		assert.True(t, errors.ErrEqual(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+		assert.Equal(t, tc.txID, res.TxID, fmt.Sprintf("%s: expected %s got '%s'\n", tc.desc, tc.txID, res.TxID))
+	}
+
+	other, err := svc.Pull(ctx, &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.NotEqual(t, first.TxID, other.TxID, "payments without an idempotency key must not be deduplicated")
+}
+
+func TestPullIdempotencyFailure(t *testing.T) {
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, invoice := newInvoiceStore(property)
+
+	backend := &timeout{Client: mocks.NewBackend()}
+
+	var opts payment.Options
+	opts.Owners = owners
+	opts.Properties = properties
+	opts.Invoices = invoices
+	opts.Repository = mocks.NewPaymentRepository()
+	opts.Idp = mocks.NewIdentityProvider()
+	opts.Backend = backend
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Events = mocks.NewEventRepository()
+	opts.Fees = amocks.NewFeeRepository()
+	svc := payment.New(&opts)
+
+	ctx := context.Background()
+
+	cases := []struct {
+		desc    string
+		payment *payment.TxRequest
+		calls   int
+	}{
+		{
+			desc:    "pull payment with a request that never reaches the gateway",
+			payment: &payment.TxRequest{Code: "invalid", Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN, IdempotencyKey: "key"},
+			calls:   0,
+		},
+		{
+			desc:    "pull payment with the same idempotency key once fixed",
+			payment: &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN, IdempotencyKey: "key"},
+			calls:   1,
+		},
+		{
+			desc:    "retry payment that timed out on the gateway",
+			payment: &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN, IdempotencyKey: "key"},
+			calls:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := svc.Pull(ctx, tc.payment)
+		assert.NotNil(t, err, fmt.Sprintf("%s: expected an error", tc.desc))
+		assert.Equal(t, payment.Failed, res.TxState, fmt.Sprintf("%s: expected %s got '%s'\n", tc.desc, payment.Failed, res.TxState))
+		assert.Equal(t, tc.calls, backend.calls, fmt.Sprintf("%s: expected %d gateway calls got %d", tc.desc, tc.calls, backend.calls))
+	}
+}
+
+// timeout is a gateway that times out after the payer may have been charged
+type timeout struct {
+	payment.Client
+	calls int
+}
+
+func (bc *timeout) Pull(ctx context.Context, tx *payment.TxRequest) (*payment.TxResponse, error) {
+	bc.calls++
+	return nil, errors.E(errors.Op("timeout.Pull"), "gateway timed out", errors.KindUnexpected)
+}
+
 func TestConfirmPush(t *testing.T) {
 	const op errors.Op = "core/payment/service.ConfirmPush"
 
@@ -215,6 +332,499 @@ func TestConfirmPush(t *testing.T) {
 	}
 }
 
+func TestPayouts(t *testing.T) {
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, _ := newInvoiceStore(property)
+	svc := newService(owners, properties, invoices)
+
+	var refs []string
+	for i := 0; i < 3; i++ {
+		tx := &payment.TxRequest{
+			ID:        uuid.New().ID(),
+			Code:      property.ID,
+			Amount:    1000,
+			MSISDN:    "0784607135",
+			Method:    payment.MTN,
+			Namespace: property.Namespace,
+		}
+		res, err := svc.Push(context.Background(), tx)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+		refs = append(refs, res.TxID)
+	}
+
+	cases := []struct {
+		desc   string
+		ref    string
+		status string
+		kind   int
+	}{
+		{
+			desc:   "settle successful payout",
+			ref:    refs[0],
+			status: string(payment.Successful),
+		},
+		{
+			desc:   "settle failed payout",
+			ref:    refs[1],
+			status: string(payment.Failed),
+		},
+		{
+			desc:   "settle payout twice",
+			ref:    refs[0],
+			status: string(payment.Failed),
+			kind:   errors.KindAlreadyExists,
+		},
+		{
+			desc:   "settle payout with a pending status",
+			ref:    refs[2],
+			status: string(payment.Pending),
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		cb := payment.Callback{
+			Kind: payment.ProcessedEvent,
+			Data: payment.Data{Ref: tc.ref, Status: tc.status},
+		}
+		err := svc.ConfirmPush(context.Background(), cb)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
+
+func TestVerifyCallback(t *testing.T) {
+	const op errors.Op = "core/payment/service.VerifyCallback"
+
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, _ := newInvoiceStore(property)
+	svc := newService(owners, properties, invoices)
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	payload := []byte(`{"event_id":"evt-1","kind":"transaction:processed"}`)
+	anonymous := []byte(`{"kind":"transaction:processed"}`)
+
+	cases := []struct {
+		desc     string
+		callback payment.SignedCallback
+		err      error
+	}{
+		{
+			desc:     "verify valid callback",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: ts, Signature: payment.Sign(secret, ts, payload)},
+			err:      nil,
+		},
+		{
+			desc:     "verify replayed callback",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: ts, Signature: payment.Sign(secret, ts, payload)},
+			err:      errors.E(op, "callback event evt-1 was already received"),
+		},
+		{
+			desc:     "verify callback with invalid signature",
+			callback: payment.SignedCallback{Payload: payload, Timestamp: ts, Signature: payment.Sign("other", ts, payload)},
+			err:      errors.E(op, "invalid callback signature"),
+		},
+		{
+			desc:     "verify callback without event id",
+			callback: payment.SignedCallback{Payload: anonymous, Timestamp: ts, Signature: payment.Sign(secret, ts, anonymous)},
+			err:      errors.E(op, "missing callback event id"),
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		_, err := svc.VerifyCallback(ctx, &tc.callback)
+		assert.True(t, errors.ErrEqual(tc.err, err), fmt.Sprintf("%s: expected %s got '%s'\n", tc.desc, tc.err, err))
+	}
+
+	page, err := svc.RejectedCallbacks(context.Background(), 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(3), page.Total, fmt.Sprintf("expected %d rejected callbacks got %d", 3, page.Total))
+}
+
+func TestTimeline(t *testing.T) {
+	owners, owner := newOwnersStore()
+
+	props := mocks.NewPropertyRepository()
+	property, err := props.Save(context.Background(), properties.Property{ID: uuid.New().ID(), Due: 1000, Namespace: namespace, Owner: properties.Owner{ID: owner.ID}})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	invoices, invoice := newInvoiceStore(property)
+	svc := newService(owners, props, invoices)
+
+	ctx := context.Background()
+
+	tx := &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN}
+	res, err := svc.Pull(ctx, tx)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	payload := []byte(fmt.Sprintf(`{"event_id":"evt-1","kind":"transaction:processed","data":{"ref":"%s","status":"successful"}}`, res.TxID))
+	_, err = svc.VerifyCallback(ctx, &payment.SignedCallback{Payload: payload, Timestamp: ts, Signature: payment.Sign(secret, ts, payload)})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	payout, err := svc.Push(ctx, &payment.TxRequest{Amount: 500, MSISDN: "0784607135", Method: payment.MTN, Namespace: namespace})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	const other = "kigali.gasabo.kimironko"
+
+	cases := []struct {
+		desc      string
+		ref       string
+		namespace string
+		kinds     []payment.EventKind
+		kind      int
+	}{
+		{
+			desc:  "retrieve timeline of a payment",
+			ref:   res.TxID,
+			kinds: []payment.EventKind{payment.Initiated, payment.GatewayAccepted, payment.CallbackReceived},
+		},
+		{
+			desc:      "retrieve timeline of a payment of the caller's namespace",
+			ref:       res.TxID,
+			namespace: namespace,
+			kinds:     []payment.EventKind{payment.Initiated, payment.GatewayAccepted, payment.CallbackReceived},
+		},
+		{
+			desc:      "retrieve timeline of a payment of another namespace",
+			ref:       res.TxID,
+			namespace: other,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "retrieve timeline of a payout of the caller's namespace",
+			ref:       payout.TxID,
+			namespace: namespace,
+			kinds:     []payment.EventKind{payment.Initiated, payment.GatewayAccepted},
+		},
+		{
+			desc:      "retrieve timeline of a payout of another namespace",
+			ref:       payout.TxID,
+			namespace: other,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc: "retrieve timeline of an unknown payment",
+			ref:  uuid.New().ID(),
+			kind: errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		timeline, err := svc.Timeline(ctx, tc.ref, tc.namespace)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		kinds := []payment.EventKind{}
+		for _, ev := range timeline.Events {
+			kinds = append(kinds, ev.Kind)
+		}
+		assert.Equal(t, tc.kinds, kinds, fmt.Sprintf("%s: expected events %v got %v", tc.desc, tc.kinds, kinds))
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, invoice := newInvoiceStore(property)
+
+	repo := mocks.NewPaymentRepository()
+
+	var opts payment.Options
+	opts.Owners = owners
+	opts.Properties = properties
+	opts.Invoices = invoices
+	opts.Repository = repo
+	opts.Idp = mocks.NewIdentityProvider()
+	opts.Backend = mocks.NewBackend()
+	opts.Discrepancy = mocks.NewDiscrepancyRepository()
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Reversals = mocks.NewReversals()
+	opts.Events = mocks.NewEventRepository()
+	opts.Payouts = mocks.NewPayoutRepository()
+	opts.Fees = amocks.NewFeeRepository()
+	svc := payment.New(&opts)
+
+	ctx := context.Background()
+
+	// settled by the gateway but the callback never arrived
+	tx := &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN}
+	_, err := svc.Pull(ctx, tx)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	// never reached the gateway
+	lost := &payment.TxRequest{ID: uuid.New().ID(), Ref: uuid.New().ID(), Code: property.ID, Amount: invoice.Amount}
+	err = repo.Save(ctx, lost)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc          string
+		checked       int
+		successful    int
+		discrepancies int
+	}{
+		{
+			desc:          "reconcile stale payments",
+			checked:       2,
+			successful:    1,
+			discrepancies: 1,
+		},
+		{
+			desc:          "reconcile payments unknown to the gateway again",
+			checked:       1,
+			successful:    0,
+			discrepancies: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		report, err := svc.Reconcile(ctx, 0, 1)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.checked, report.Checked, fmt.Sprintf("%s: expected %d checked got %d", tc.desc, tc.checked, report.Checked))
+		assert.Equal(t, tc.successful, report.Successful, fmt.Sprintf("%s: expected %d successful got %d", tc.desc, tc.successful, report.Successful))
+		assert.Equal(t, tc.discrepancies, len(report.Discrepancies), fmt.Sprintf("%s: expected %d discrepancies got %d", tc.desc, tc.discrepancies, len(report.Discrepancies)))
+	}
+
+	page, err := svc.Discrepancies(ctx, 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(1), page.Total, fmt.Sprintf("expected %d discrepancies got %d", 1, page.Total))
+}
+
+func TestPayerFee(t *testing.T) {
+	owners, owner := newOwnersStore()
+
+	props := mocks.NewPropertyRepository()
+	property, err := props.Save(context.Background(), properties.Property{ID: uuid.New().ID(), Due: 1000, Namespace: namespace, Owner: properties.Owner{ID: owner.ID}})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	invoices, invoice := newInvoiceStore(property)
+
+	fees := amocks.NewFeeRepository()
+	_, err = fees.SaveFeeRule(context.Background(), accounts.FeeRule{Account: namespace, Type: accounts.PercentageFee, Value: 0.05, Bearer: accounts.Payer})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	repo := mocks.NewPaymentRepository()
+	backend := mocks.NewBackend()
+
+	var opts payment.Options
+	opts.Owners = owners
+	opts.Properties = props
+	opts.Invoices = invoices
+	opts.Repository = repo
+	opts.Idp = mocks.NewIdentityProvider()
+	opts.Backend = backend
+	opts.Discrepancy = mocks.NewDiscrepancyRepository()
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Reversals = mocks.NewReversals()
+	opts.Events = mocks.NewEventRepository()
+	opts.Payouts = mocks.NewPayoutRepository()
+	opts.Fees = fees
+	svc := payment.New(&opts)
+
+	ctx := context.Background()
+
+	tx := &payment.TxRequest{Code: property.ID, Amount: invoice.Amount, MSISDN: "0784607135", Method: payment.MTN}
+	res, err := svc.Pull(ctx, tx)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	pulled, err := backend.Status(ctx, res.TxID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, invoice.Amount*1.05, pulled.Amount, "expected the fee to be pulled on top of the amount")
+
+	saved, err := repo.Find(ctx, res.TxID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, saved, 1)
+	assert.Equal(t, invoice.Amount, saved[0].Amount, "expected the payment to keep the invoice amount")
+
+	report, err := svc.Reconcile(ctx, 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 1, report.Successful, fmt.Sprintf("expected %d successful got %d", 1, report.Successful))
+	assert.Empty(t, report.Discrepancies, "expected the surcharged amount to match the gateway")
+}
+
+func TestReconcileUnreachable(t *testing.T) {
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, invoice := newInvoiceStore(property)
+
+	repo := mocks.NewPaymentRepository()
+
+	var opts payment.Options
+	opts.Owners = owners
+	opts.Properties = properties
+	opts.Invoices = invoices
+	opts.Repository = repo
+	opts.Idp = mocks.NewIdentityProvider()
+	opts.Backend = unreachable{mocks.NewBackend()}
+	opts.Discrepancy = mocks.NewDiscrepancyRepository()
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Reversals = mocks.NewReversals()
+	opts.Events = mocks.NewEventRepository()
+	opts.Payouts = mocks.NewPayoutRepository()
+	opts.Fees = amocks.NewFeeRepository()
+	svc := payment.New(&opts)
+
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		tx := &payment.TxRequest{ID: uuid.New().ID(), Ref: uuid.New().ID(), Code: property.ID, Amount: invoice.Amount}
+		err := repo.Save(ctx, tx)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	report, err := svc.Reconcile(ctx, 0, 1)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 2, report.Checked, fmt.Sprintf("expected %d checked got %d", 2, report.Checked))
+	require.Len(t, report.Discrepancies, 2, fmt.Sprintf("expected %d discrepancies got %d", 2, len(report.Discrepancies)))
+	for _, d := range report.Discrepancies {
+		assert.Equal(t, payment.StatusCheckReason, d.Reason, fmt.Sprintf("expected reason %s got %s", payment.StatusCheckReason, d.Reason))
+	}
+}
+
+// unreachable is a gateway whose status checks always fail
+type unreachable struct {
+	payment.Client
+}
+
+func (unreachable) Status(ctx context.Context, ref string) (*payment.Data, error) {
+	return nil, errors.E(errors.Op("unreachable.Status"), "connection refused", errors.KindUnexpected)
+}
+
+func TestReconcileFailures(t *testing.T) {
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, invoice := newInvoiceStore(property)
+
+	ctx := context.Background()
+
+	repo := mocks.NewPaymentRepository()
+
+	refs := []string{uuid.New().ID(), uuid.New().ID()}
+	for _, ref := range refs {
+		tx := &payment.TxRequest{ID: uuid.New().ID(), Ref: ref, Code: property.ID, Amount: invoice.Amount}
+		err := repo.Save(ctx, tx)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	var opts payment.Options
+	opts.Owners = owners
+	opts.Properties = properties
+	opts.Invoices = invoices
+	opts.Repository = unreadable{Repository: repo, ref: refs[0]}
+	opts.Idp = mocks.NewIdentityProvider()
+	opts.Backend = mocks.NewBackend()
+	opts.Discrepancy = mocks.NewDiscrepancyRepository()
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Reversals = mocks.NewReversals()
+	opts.Events = mocks.NewEventRepository()
+	opts.Payouts = mocks.NewPayoutRepository()
+	opts.Fees = amocks.NewFeeRepository()
+	svc := payment.New(&opts)
+
+	report, err := svc.Reconcile(ctx, 0, 1)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 2, report.Checked, fmt.Sprintf("expected %d checked got %d", 2, report.Checked))
+	require.Len(t, report.Failures, 1, fmt.Sprintf("expected %d failures got %d", 1, len(report.Failures)))
+	assert.Equal(t, refs[0], report.Failures[0].Ref, fmt.Sprintf("expected ref %s got %s", refs[0], report.Failures[0].Ref))
+}
+
+// unreadable is a payment store that fails to read the payments of ref
+type unreadable struct {
+	payment.Repository
+	ref string
+}
+
+func (repo unreadable) Find(ctx context.Context, ref string) ([]*payment.TxRequest, error) {
+	if ref == repo.ref {
+		return nil, errors.E(errors.Op("unreadable.Find"), "connection reset", errors.KindUnexpected)
+	}
+	return repo.Repository.Find(ctx, ref)
+}
+
+func TestExpire(t *testing.T) {
+	owners, owner := newOwnersStore()
+	properties, property := newPropertiesStore(owner)
+	invoices, invoice := newInvoiceStore(property)
+
+	repo := mocks.NewPaymentRepository()
+
+	var opts payment.Options
+	opts.Owners = owners
+	opts.Properties = properties
+	opts.Invoices = invoices
+	opts.Repository = repo
+	opts.Idp = mocks.NewIdentityProvider()
+	opts.Backend = mocks.NewBackend()
+	opts.Discrepancy = mocks.NewDiscrepancyRepository()
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Reversals = mocks.NewReversals()
+	opts.Events = mocks.NewEventRepository()
+	opts.Payouts = mocks.NewPayoutRepository()
+	opts.Fees = amocks.NewFeeRepository()
+	svc := payment.New(&opts)
+
+	ctx := context.Background()
+
+	paid := &payment.TxRequest{ID: uuid.New().ID(), Ref: uuid.New().ID(), Code: property.ID, Amount: invoice.Amount}
+	err := repo.Save(ctx, paid)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	declined := &payment.TxRequest{ID: uuid.New().ID(), Ref: uuid.New().ID(), Code: property.ID, Amount: invoice.Amount}
+	err = repo.Save(ctx, declined)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	refs, err := svc.Expire(ctx, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Empty(t, refs, "expected recent payments to stay pending")
+
+	refs, err = svc.Expire(ctx, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.ElementsMatch(t, []string{paid.Ref, declined.Ref}, refs, "expected stale payments to expire")
+
+	cases := []struct {
+		desc          string
+		ref           string
+		status        string
+		discrepancies uint64
+	}{
+		{
+			desc:          "settle expired payment with a late successful callback",
+			ref:           paid.Ref,
+			status:        "successful",
+			discrepancies: 1,
+		},
+		{
+			desc:          "settle expired payment with a late failed callback",
+			ref:           declined.Ref,
+			status:        "failed",
+			discrepancies: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		cb := payment.Callback{Kind: payment.ProcessedEvent, Data: payment.Data{Ref: tc.ref, Status: tc.status, Amount: invoice.Amount}}
+		err := svc.ProcessHook(ctx, cb)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		payments, err := repo.Find(ctx, tc.ref)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.status, payments[0].Status, fmt.Sprintf("%s: expected status %s got %s", tc.desc, tc.status, payments[0].Status))
+
+		page, err := svc.Discrepancies(ctx, 0, 10)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.discrepancies, page.Total, fmt.Sprintf("%s: expected %d discrepancies got %d", tc.desc, tc.discrepancies, page.Total))
+	}
+}
+
 func TestFormatMessage(t *testing.T) {
 
 	p := properties.Property{
@@ -273,6 +883,15 @@ func newService(ws owners.Repository, ps properties.Repository, vc invoices.Repo
 	opts.Backend = mocks.NewBackend()
 	opts.Queue = mocks.NewQueue()
 	opts.Transactions = mocks.NewTransactionsRepository()
+	opts.Callbacks = mocks.NewCallbackRepository()
+	opts.Replays = mocks.NewReplayCache()
+	opts.Discrepancy = mocks.NewDiscrepancyRepository()
+	opts.Idempotency = mocks.NewIdempotencyStore()
+	opts.Reversals = mocks.NewReversals()
+	opts.Events = mocks.NewEventRepository()
+	opts.Payouts = mocks.NewPayoutRepository()
+	opts.Fees = amocks.NewFeeRepository()
+	opts.Secret = secret
 	return payment.New(&opts)
 }
 
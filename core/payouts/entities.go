@@ -0,0 +1,36 @@
+package payouts
+
+import "github.com/nshimiyimanaamani/paypack-backend/core/payment"
+
+// Filters narrows down listed payouts, nil filters are ignored
+type Filters struct {
+	Namespace *string
+	Status    *string
+	MSISDN    *string
+	From      *string
+	To        *string
+	Offset    uint64
+	Limit     uint64
+}
+
+// PageMetadata ...
+type PageMetadata struct {
+	Total  uint64
+	Amount float64 `json:"amount,omitempty"`
+	Offset uint64
+	Limit  uint64
+}
+
+// Page is a list of payouts, the page amount sums every matching payout
+type Page struct {
+	PageMetadata
+	Payouts []payment.Payout `json:"payouts"`
+}
+
+// Total sums the payouts of a namespace by status
+type Total struct {
+	Namespace string               `json:"namespace"`
+	Status    payment.PayoutStatus `json:"status"`
+	Count     uint64               `json:"count"`
+	Amount    float64              `json:"amount"`
+}
@@ -0,0 +1,89 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payouts"
+)
+
+var _ (payouts.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu      sync.Mutex
+	payouts []payment.Payout
+}
+
+// NewRepository creates an in memory mock of payouts.Repository holding
+// the given payouts, oldest first
+func NewRepository(saved ...payment.Payout) payouts.Repository {
+	return &repositoryMock{payouts: saved}
+}
+
+func (repo *repositoryMock) List(ctx context.Context, flts *payouts.Filters) (payouts.Page, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	page := payouts.Page{
+		PageMetadata: payouts.PageMetadata{Offset: flts.Offset, Limit: flts.Limit},
+		Payouts:      []payment.Payout{},
+	}
+
+	for i := len(repo.payouts) - 1; i >= 0; i-- {
+		p := repo.payouts[i]
+		if !match(p, flts) {
+			continue
+		}
+		if page.Total >= flts.Offset && page.Total < flts.Offset+flts.Limit {
+			page.Payouts = append(page.Payouts, p)
+		}
+		page.Total++
+		page.Amount += p.Amount
+	}
+	return page, nil
+}
+
+func (repo *repositoryMock) Totals(ctx context.Context, flts *payouts.Filters) ([]payouts.Total, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	sums := make(map[[2]string]*payouts.Total)
+	for _, p := range repo.payouts {
+		if !match(p, flts) {
+			continue
+		}
+		key := [2]string{p.Namespace, string(p.Status)}
+		if sums[key] == nil {
+			sums[key] = &payouts.Total{Namespace: p.Namespace, Status: p.Status}
+		}
+		sums[key].Count++
+		sums[key].Amount += p.Amount
+	}
+
+	totals := []payouts.Total{}
+	for _, t := range sums {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Namespace != totals[j].Namespace {
+			return totals[i].Namespace < totals[j].Namespace
+		}
+		return totals[i].Status < totals[j].Status
+	})
+	return totals, nil
+}
+
+func match(p payment.Payout, flts *payouts.Filters) bool {
+	if flts.Namespace != nil && p.Namespace != *flts.Namespace {
+		return false
+	}
+	if flts.Status != nil && string(p.Status) != *flts.Status {
+		return false
+	}
+	if flts.MSISDN != nil && p.MSISDN != *flts.MSISDN {
+		return false
+	}
+	return true
+}
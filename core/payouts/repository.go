@@ -0,0 +1,12 @@
+package payouts
+
+import "context"
+
+// Repository reports on the disbursements made through the payment service
+type Repository interface {
+	// List payouts starting with the most recent
+	List(ctx context.Context, flts *Filters) (Page, error)
+
+	// Totals sums payouts per namespace and status
+	Totals(ctx context.Context, flts *Filters) ([]Total, error)
+}
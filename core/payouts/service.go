@@ -0,0 +1,50 @@
+package payouts
+
+import (
+	"context"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the payout reports
+type Service interface {
+	// List disbursements made through Push
+	List(ctx context.Context, flts *Filters) (Page, error)
+
+	// Totals sums disbursements per namespace and status
+	Totals(ctx context.Context, flts *Filters) ([]Total, error)
+}
+
+// Options contains payouts.Service creation options
+type Options struct {
+	Repository Repository
+}
+
+type service struct {
+	repo Repository
+}
+
+// New instantiates the payouts.Service
+func New(opts *Options) Service {
+	return &service{repo: opts.Repository}
+}
+
+func (svc *service) List(ctx context.Context, flts *Filters) (Page, error) {
+	const op errors.Op = "core/payouts/service.List"
+
+	page, err := svc.repo.List(ctx, flts)
+	if err != nil {
+		return Page{}, errors.E(op, err)
+	}
+	return page, nil
+}
+
+func (svc *service) Totals(ctx context.Context, flts *Filters) ([]Total, error) {
+	const op errors.Op = "core/payouts/service.Totals"
+
+	totals, err := svc.repo.Totals(ctx, flts)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,83 @@
+package payouts_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payouts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payouts/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	namespace = "kigali.gasabo.remera"
+	other     = "kigali.gasabo.kimironko"
+)
+
+func newService() payouts.Service {
+	repo := mocks.NewRepository(
+		payment.Payout{Ref: "1", Namespace: namespace, MSISDN: "250784607135", Amount: 1000, Status: payment.PayoutSuccessful},
+		payment.Payout{Ref: "2", Namespace: namespace, MSISDN: "250784607135", Amount: 1000, Status: payment.PayoutFailed},
+		payment.Payout{Ref: "3", Namespace: namespace, MSISDN: "250784607135", Amount: 1000, Status: payment.PayoutPending},
+		payment.Payout{Ref: "4", Namespace: other, MSISDN: "250787205106", Amount: 500, Status: payment.PayoutSuccessful},
+	)
+	return payouts.New(&payouts.Options{Repository: repo})
+}
+
+func TestList(t *testing.T) {
+	svc := newService()
+
+	cases := []struct {
+		desc   string
+		flts   *payouts.Filters
+		refs   []string
+		amount float64
+	}{
+		{
+			desc:   "list payouts of every namespace",
+			flts:   &payouts.Filters{Limit: 10},
+			refs:   []string{"4", "3", "2", "1"},
+			amount: 3500,
+		},
+		{
+			desc:   "list payouts of a namespace",
+			flts:   &payouts.Filters{Namespace: cast.StringPointer(other), Limit: 10},
+			refs:   []string{"4"},
+			amount: 500,
+		},
+		{
+			desc:   "list successful payouts of a namespace",
+			flts:   &payouts.Filters{Namespace: cast.StringPointer(namespace), Status: cast.StringPointer(string(payment.PayoutSuccessful)), Limit: 10},
+			refs:   []string{"1"},
+			amount: 1000,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := svc.List(context.Background(), tc.flts)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		refs := []string{}
+		for _, p := range page.Payouts {
+			refs = append(refs, p.Ref)
+		}
+		assert.Equal(t, tc.refs, refs, fmt.Sprintf("%s: expected payouts %v got %v", tc.desc, tc.refs, refs))
+		assert.Equal(t, tc.amount, page.Amount, fmt.Sprintf("%s: expected amount %v got %v", tc.desc, tc.amount, page.Amount))
+	}
+}
+
+func TestTotals(t *testing.T) {
+	svc := newService()
+
+	totals, err := svc.Totals(context.Background(), &payouts.Filters{Namespace: cast.StringPointer(namespace)})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, []payouts.Total{
+		{Namespace: namespace, Status: payment.PayoutFailed, Count: 1, Amount: 1000},
+		{Namespace: namespace, Status: payment.PayoutPending, Count: 1, Amount: 1000},
+		{Namespace: namespace, Status: payment.PayoutSuccessful, Count: 1, Amount: 1000},
+	}, totals)
+}
@@ -0,0 +1,95 @@
+package properties
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// BillingCycle is how often a property is invoiced
+type BillingCycle string
+
+// supported billing cycles
+const (
+	Monthly   BillingCycle = "monthly"
+	Quarterly BillingCycle = "quarterly"
+	Yearly    BillingCycle = "yearly"
+)
+
+// MaxAnchorDay is the last day a billing period may start on, every month
+// has it
+const MaxAnchorDay = 28
+
+// Billing tells when a property is invoiced. Periods start on the anchor
+// day of the month, quarterly periods start in January, April, July and
+// October and yearly ones in January. The due of the property is charged
+// once per period.
+type Billing struct {
+	Cycle  BillingCycle `json:"cycle,omitempty"`
+	Anchor int          `json:"anchor_day,omitempty"`
+}
+
+// DefaultBilling invoices on the first day of every month
+var DefaultBilling = Billing{Cycle: Monthly, Anchor: 1}
+
+// Validate the billing and fill in the defaults of the empty fields
+func (b *Billing) Validate() error {
+	const op errors.Op = "core/properties/Billing.Validate"
+
+	if b.Cycle == "" {
+		b.Cycle = DefaultBilling.Cycle
+	}
+	if b.Anchor == 0 {
+		b.Anchor = DefaultBilling.Anchor
+	}
+
+	switch b.Cycle {
+	case Monthly, Quarterly, Yearly:
+	default:
+		return errors.E(op, fmt.Sprintf("invalid property: unknown billing cycle '%s'", b.Cycle), errors.KindBadRequest)
+	}
+
+	if b.Anchor < 1 || b.Anchor > MaxAnchorDay {
+		return errors.E(op, fmt.Sprintf("invalid property: billing anchor day must be between 1 and %d", MaxAnchorDay), errors.KindBadRequest)
+	}
+	return nil
+}
+
+// Months is the length of a billing period in months
+func (b Billing) Months() int {
+	switch b.Cycle {
+	case Quarterly:
+		return 3
+	case Yearly:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// PeriodStart is the start of the billing period t falls in
+func (b Billing) PeriodStart(t time.Time) time.Time {
+	anchor := b.Anchor
+	if anchor < 1 {
+		anchor = 1
+	}
+
+	shifted := t.AddDate(0, 0, 1-anchor)
+
+	month := shifted.Month()
+	switch b.Cycle {
+	case Quarterly:
+		month -= (month - 1) % 3
+	case Yearly:
+		month = time.January
+	}
+
+	return time.Date(shifted.Year(), month, anchor, 0, 0, 0, 0, t.Location())
+}
+
+// PeriodEnd is the end of the billing period t falls in, the period
+// doesn't include it
+func (b Billing) PeriodEnd(t time.Time) time.Time {
+	return b.PeriodStart(t).AddDate(0, b.Months(), 0)
+}
@@ -0,0 +1,107 @@
+package properties_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBillingValidate(t *testing.T) {
+	cases := []struct {
+		desc    string
+		billing properties.Billing
+		want    properties.Billing
+		kind    int
+	}{
+		{
+			desc:    "validate empty billing",
+			billing: properties.Billing{},
+			want:    properties.DefaultBilling,
+		},
+		{
+			desc:    "validate quarterly billing with anchor day",
+			billing: properties.Billing{Cycle: properties.Quarterly, Anchor: 15},
+			want:    properties.Billing{Cycle: properties.Quarterly, Anchor: 15},
+		},
+		{
+			desc:    "validate unknown billing cycle",
+			billing: properties.Billing{Cycle: "weekly"},
+			kind:    errors.KindBadRequest,
+		},
+		{
+			desc:    "validate anchor day missing from some months",
+			billing: properties.Billing{Cycle: properties.Monthly, Anchor: 31},
+			kind:    errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.billing.Validate()
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.want, tc.billing, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.want, tc.billing))
+	}
+}
+
+func TestBillingPeriod(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		desc    string
+		billing properties.Billing
+		at      time.Time
+		start   time.Time
+		end     time.Time
+	}{
+		{
+			desc:    "monthly period",
+			billing: properties.Billing{Cycle: properties.Monthly, Anchor: 1},
+			at:      day(2026, time.October, 18),
+			start:   day(2026, time.October, 1),
+			end:     day(2026, time.November, 1),
+		},
+		{
+			desc:    "monthly period before the anchor day",
+			billing: properties.Billing{Cycle: properties.Monthly, Anchor: 15},
+			at:      day(2026, time.January, 10),
+			start:   day(2025, time.December, 15),
+			end:     day(2026, time.January, 15),
+		},
+		{
+			desc:    "quarterly period",
+			billing: properties.Billing{Cycle: properties.Quarterly, Anchor: 1},
+			at:      day(2026, time.August, 31),
+			start:   day(2026, time.July, 1),
+			end:     day(2026, time.October, 1),
+		},
+		{
+			desc:    "quarterly period on the anchor day",
+			billing: properties.Billing{Cycle: properties.Quarterly, Anchor: 10},
+			at:      day(2026, time.October, 10),
+			start:   day(2026, time.October, 10),
+			end:     day(2027, time.January, 10),
+		},
+		{
+			desc:    "yearly period before the anchor day",
+			billing: properties.Billing{Cycle: properties.Yearly, Anchor: 5},
+			at:      day(2026, time.January, 4),
+			start:   day(2025, time.January, 5),
+			end:     day(2026, time.January, 5),
+		},
+	}
+
+	for _, tc := range cases {
+		start, end := tc.billing.PeriodStart(tc.at), tc.billing.PeriodEnd(tc.at)
+		assert.Equal(t, tc.start, start, fmt.Sprintf("%s: expected start %s got %s", tc.desc, tc.start, start))
+		assert.Equal(t, tc.end, end, fmt.Sprintf("%s: expected end %s got %s", tc.desc, tc.end, end))
+	}
+}
@@ -12,6 +12,7 @@ type Property struct {
 	Due        float64   `json:"due,string,omitempty"`
 	Owner      Owner     `json:"owner,omitempty"`
 	Address    Address   `json:"address,omitempty"`
+	Billing    Billing   `json:"billing,omitempty"`
 	Occupied   bool      `json:"occupied,omitempty"`
 	ForRent    bool      `json:"for_rent,omitempty"`
 	Namespace  string    `json:"namespace"`
@@ -77,6 +78,9 @@ func (prt *Property) Validate() error {
 	if prt.Due == float64(0) {
 		return errors.E(op, "invalid property: missing due", errors.KindBadRequest)
 	}
+	if err := prt.Billing.Validate(); err != nil {
+		return errors.E(op, err, errors.Kind(err))
+	}
 	if prt.RecordedBy == "" {
 		return errors.E(op, "invalid property: missing recording agent", errors.KindBadRequest)
 	}
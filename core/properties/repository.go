@@ -8,9 +8,14 @@ type Repository interface {
 	// if the operation is successful or otherwise an error.
 	Save(ctx context.Context, p Property) (Property, error)
 
-	// Update the given property entity's mutable fields.
+	// Update the given property entity's mutable fields. A new due takes
+	// effect on the day of the update.
 	Update(ctx context.Context, p Property) error
 
+	// ChangeDue applies a tariff change, it fills in the previous due, the
+	// id and the creation time of the change.
+	ChangeDue(ctx context.Context, tc *TariffChange) error
+
 	//Delete removes a single entity fron the underlying store.
 	Delete(ctx context.Context, uid string) error
 
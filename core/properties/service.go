@@ -23,6 +23,10 @@ type Service interface {
 	// it returns the updated property an  nil error if the operation is a success.
 	Update(ctx context.Context, prop Property) error
 
+	// ChangeDue replaces the due of a property from a given day and adjusts
+	// the open invoices accordingly.
+	ChangeDue(ctx context.Context, tc TariffChange) (TariffChange, error)
+
 	// Retrieve returns a property entity and a nil error if the operation
 	// is successful given its unique id.
 	Retrieve(ctx context.Context, uid string) (Property, error)
@@ -96,6 +100,19 @@ func (svc *service) Update(ctx context.Context, prop Property) error {
 	return nil
 }
 
+func (svc *service) ChangeDue(ctx context.Context, tc TariffChange) (TariffChange, error) {
+	const op errors.Op = "app/properties/service.ChangeDue"
+
+	if err := tc.Validate(); err != nil {
+		return TariffChange{}, errors.E(op, err)
+	}
+
+	if err := svc.repo.ChangeDue(ctx, &tc); err != nil {
+		return TariffChange{}, errors.E(op, err)
+	}
+	return tc, nil
+}
+
 func (svc *service) Retrieve(ctx context.Context, uid string) (Property, error) {
 	const op errors.Op = "app/properties/service.Retrieve"
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	//"github.com/nshimiyimanaamani/paypack-backend/core"
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
@@ -171,6 +172,60 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestChangeDue(t *testing.T) {
+	owner := properties.Owner{ID: uuid.New().ID()}
+	svc := newService(owner)
+
+	property := properties.Property{
+		Owner:      properties.Owner{ID: owner.ID},
+		Address:    properties.Address{Sector: "Remera", Cell: "Gishushu", Village: "Ingabo"},
+		Due:        float64(1000),
+		Namespace:  "kigali.gasabo.remera",
+		RecordedBy: uuid.New().ID(),
+	}
+
+	ctx := context.Background()
+	saved, err := svc.Register(ctx, property)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	const op errors.Op = "app/properties/service.ChangeDue"
+
+	cases := []struct {
+		desc   string
+		change properties.TariffChange
+		err    error
+	}{
+		{
+			desc:   "change the due of an existing property",
+			change: properties.TariffChange{Property: saved.ID, Due: 1500, EffectiveFrom: time.Now(), ChangedBy: "manager"},
+			err:    nil,
+		},
+		{
+			desc:   "change the due of a non-existing property",
+			change: properties.TariffChange{Property: wrongValue, Due: 1500, EffectiveFrom: time.Now(), ChangedBy: "manager"},
+			err:    errors.E(op, "property not found"),
+		},
+		{
+			desc:   "change the due to zero",
+			change: properties.TariffChange{Property: saved.ID, EffectiveFrom: time.Now(), ChangedBy: "manager"},
+			err:    errors.E(op, "invalid tariff change: due must be greater than zero"),
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		res, err := svc.ChangeDue(ctx, tc.change)
+		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, saved.Due, res.Previous, fmt.Sprintf("%s: expected previous due %v got %v", tc.desc, saved.Due, res.Previous))
+		}
+	}
+
+	updated, err := svc.Retrieve(ctx, saved.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, float64(1500), updated.Due, fmt.Sprintf("expected due %v got %v", 1500, updated.Due))
+}
+
 func TestRetrieve(t *testing.T) {
 	owner := properties.Owner{ID: uuid.New().ID()}
 	svc := newService(owner)
@@ -0,0 +1,51 @@
+package properties
+
+import (
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// DayLayout is the layout of the day a tariff change takes effect
+const DayLayout = "2006-01-02"
+
+// TariffChange replaces the due of a property from a given day. The open
+// invoices whose period isn't over by then are adjusted for the days left
+// in their period, every adjustment is recorded next to the invoice and
+// in the ledger.
+type TariffChange struct {
+	ID            string    `json:"id,omitempty"`
+	Property      string    `json:"property,omitempty"`
+	Previous      float64   `json:"previous"`
+	Due           float64   `json:"due"`
+	EffectiveFrom time.Time `json:"effective_from,omitempty"`
+	ChangedBy     string    `json:"changed_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+}
+
+// Validate checks whether a tariff change can be applied, the effective
+// day is truncated to a whole day
+func (tc *TariffChange) Validate() error {
+	const op errors.Op = "app/properties/tariffChange.Validate"
+
+	if tc.Property == "" {
+		return errors.E(op, "invalid tariff change: missing house code", errors.KindBadRequest)
+	}
+	if tc.Due <= 0 {
+		return errors.E(op, "invalid tariff change: due must be greater than zero", errors.KindBadRequest)
+	}
+	if tc.EffectiveFrom.IsZero() {
+		return errors.E(op, "invalid tariff change: missing effective day", errors.KindBadRequest)
+	}
+
+	tc.EffectiveFrom = time.Date(tc.EffectiveFrom.Year(), tc.EffectiveFrom.Month(), tc.EffectiveFrom.Day(), 0, 0, 0, 0, time.UTC)
+
+	// the dues of the periods to come are billed as they start
+	if tc.EffectiveFrom.After(time.Now().UTC()) {
+		return errors.E(op, "invalid tariff change: it can't take effect in the future", errors.KindBadRequest)
+	}
+	if tc.ChangedBy == "" {
+		return errors.E(op, "invalid tariff change: missing author", errors.KindBadRequest)
+	}
+	return nil
+}
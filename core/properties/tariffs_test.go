@@ -0,0 +1,69 @@
+package properties_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTariffChangeValidate(t *testing.T) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc   string
+		change properties.TariffChange
+		want   time.Time
+		kind   int
+	}{
+		{
+			desc:   "validate change effective today",
+			change: properties.TariffChange{Property: "1000-4433-3343", Due: 2000, EffectiveFrom: now, ChangedBy: "manager"},
+			want:   today,
+		},
+		{
+			desc:   "validate backdated change",
+			change: properties.TariffChange{Property: "1000-4433-3343", Due: 2000, EffectiveFrom: today.AddDate(0, -1, 0), ChangedBy: "manager"},
+			want:   today.AddDate(0, -1, 0),
+		},
+		{
+			desc:   "validate change without property",
+			change: properties.TariffChange{Due: 2000, EffectiveFrom: now, ChangedBy: "manager"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate change without due",
+			change: properties.TariffChange{Property: "1000-4433-3343", EffectiveFrom: now, ChangedBy: "manager"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate change without effective day",
+			change: properties.TariffChange{Property: "1000-4433-3343", Due: 2000, ChangedBy: "manager"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate change effective in the future",
+			change: properties.TariffChange{Property: "1000-4433-3343", Due: 2000, EffectiveFrom: today.AddDate(0, 0, 1), ChangedBy: "manager"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate change without author",
+			change: properties.TariffChange{Property: "1000-4433-3343", Due: 2000, EffectiveFrom: now},
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.change.Validate()
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.want, tc.change.EffectiveFrom, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.want, tc.change.EffectiveFrom))
+	}
+}
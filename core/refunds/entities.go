@@ -0,0 +1,86 @@
+package refunds
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Status is the state of a refund
+type Status string
+
+// possible refund states
+const (
+	Pending   Status = "pending"
+	Approved  Status = "approved"
+	Completed Status = "completed"
+	Rejected  Status = "rejected"
+	Failed    Status = "failed"
+)
+
+// Refund returns a successful payment to the account it was paid from
+type Refund struct {
+	ID          string         `json:"id,omitempty"`
+	Ref         string         `json:"ref,omitempty"`
+	Reversal    string         `json:"reversal,omitempty"`
+	Property    string         `json:"property,omitempty"`
+	Namespace   string         `json:"namespace,omitempty"`
+	MSISDN      string         `json:"msisdn,omitempty"`
+	Method      payment.Method `json:"method,omitempty"`
+	Amount      float64        `json:"amount,omitempty"`
+	Reason      string         `json:"reason,omitempty"`
+	Status      Status         `json:"status,omitempty"`
+	RequestedBy string         `json:"requested_by,omitempty"`
+	ReviewedBy  string         `json:"reviewed_by,omitempty"`
+	CreatedAt   time.Time      `json:"created_at,omitempty"`
+	UpdatedAt   time.Time      `json:"updated_at,omitempty"`
+}
+
+// Validate checks whether a refund request is complete
+func (r *Refund) Validate() error {
+	const op errors.Op = "core/refunds/Refund.Validate"
+
+	if r.Ref == "" {
+		return errors.E(op, "missing payment reference", errors.KindBadRequest)
+	}
+	if r.Reason == "" {
+		return errors.E(op, "missing refund reason", errors.KindBadRequest)
+	}
+	return nil
+}
+
+// Filters narrows down listed refunds, nil filters are ignored
+type Filters struct {
+	Namespace *string
+	Status    *string
+	Offset    uint64
+	Limit     uint64
+}
+
+// PageMetadata ...
+type PageMetadata struct {
+	Total  uint64
+	Offset uint64
+	Limit  uint64
+}
+
+// Page is a list of refunds
+type Page struct {
+	PageMetadata
+	Refunds []Refund `json:"refunds"`
+}
+
+// FormatMessage creates the sms sent when a payment is refunded
+func FormatMessage(r Refund, timestamp string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("Amafaranga mwishyuye yasubijwe.\n\n")
+	buf.WriteString(fmt.Sprintf("Nimero yasubijweho: %s\n", r.MSISDN))
+	buf.WriteString(fmt.Sprintf("Itariki: %s\n", timestamp))
+	buf.WriteString(fmt.Sprintf("Umubare w' amafaranga: %dRWF\n", int(r.Amount)))
+	buf.WriteString(fmt.Sprintf("Code y' inzu ni: %s", r.Property))
+	return buf.String()
+}
@@ -0,0 +1,42 @@
+package refunds_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		desc   string
+		refund refunds.Refund
+		kind   int
+	}{
+		{
+			desc:   "validate complete refund",
+			refund: refunds.Refund{Ref: "ref", Reason: "paid twice"},
+		},
+		{
+			desc:   "validate refund without payment reference",
+			refund: refunds.Refund{Reason: "paid twice"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate refund without reason",
+			refund: refunds.Refund{Ref: "ref"},
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.refund.Validate()
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+}
@@ -0,0 +1,163 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (refunds.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu      sync.Mutex
+	refunds map[string]refunds.Refund
+}
+
+// NewRepository creates an in memory mock of refunds.Repository
+func NewRepository() refunds.Repository {
+	return &repositoryMock{
+		refunds: make(map[string]refunds.Refund),
+	}
+}
+
+func (repo *repositoryMock) Save(ctx context.Context, r *refunds.Refund) error {
+	const op errors.Op = "core/refunds/mocks/repositoryMock.Save"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, prev := range repo.refunds {
+		open := prev.Status != refunds.Rejected && prev.Status != refunds.Failed
+		if prev.Ref == r.Ref && open {
+			return errors.E(op, "payment already has a refund", errors.KindAlreadyExists)
+		}
+	}
+
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	repo.refunds[r.ID] = *r
+	return nil
+}
+
+func (repo *repositoryMock) Find(ctx context.Context, id string) (refunds.Refund, error) {
+	const op errors.Op = "core/refunds/mocks/repositoryMock.Find"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	r, ok := repo.refunds[id]
+	if !ok {
+		return refunds.Refund{}, errors.E(op, "refund not found", errors.KindNotFound)
+	}
+	return r, nil
+}
+
+func (repo *repositoryMock) FindByReversal(ctx context.Context, reversal string) (refunds.Refund, error) {
+	const op errors.Op = "core/refunds/mocks/repositoryMock.FindByReversal"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, r := range repo.refunds {
+		if reversal != "" && r.Reversal == reversal {
+			return r, nil
+		}
+	}
+	return refunds.Refund{}, errors.E(op, "refund not found", errors.KindNotFound)
+}
+
+func (repo *repositoryMock) List(ctx context.Context, flts *refunds.Filters) (refunds.Page, error) {
+	const op errors.Op = "core/refunds/mocks/repositoryMock.List"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	items := make([]refunds.Refund, 0)
+	for _, r := range repo.refunds {
+		if flts.Namespace != nil && r.Namespace != *flts.Namespace {
+			continue
+		}
+		if flts.Status != nil && string(r.Status) != *flts.Status {
+			continue
+		}
+		items = append(items, r)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	page := refunds.Page{
+		PageMetadata: refunds.PageMetadata{
+			Total:  uint64(len(items)),
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+		},
+		Refunds: []refunds.Refund{},
+	}
+
+	for i, r := range items {
+		if uint64(i) >= flts.Offset && uint64(i) < flts.Offset+flts.Limit {
+			page.Refunds = append(page.Refunds, r)
+		}
+	}
+	return page, nil
+}
+
+func (repo *repositoryMock) Review(ctx context.Context, r refunds.Refund) error {
+	const op errors.Op = "core/refunds/mocks/repositoryMock.Review"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	prev, ok := repo.refunds[r.ID]
+	if !ok || prev.Status != refunds.Pending {
+		return errors.E(op, "refund was already reviewed", errors.KindAlreadyExists)
+	}
+
+	prev.Status = r.Status
+	prev.ReviewedBy = r.ReviewedBy
+	prev.UpdatedAt = time.Now()
+	repo.refunds[r.ID] = prev
+	return nil
+}
+
+func (repo *repositoryMock) Update(ctx context.Context, r refunds.Refund) error {
+	const op errors.Op = "core/refunds/mocks/repositoryMock.Update"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	prev, ok := repo.refunds[r.ID]
+	if !ok || prev.Status != refunds.Approved {
+		return errors.E(op, "refund not found or already completed", errors.KindNotFound)
+	}
+
+	prev.Status = r.Status
+	prev.Reversal = r.Reversal
+	prev.UpdatedAt = time.Now()
+	repo.refunds[r.ID] = prev
+	return nil
+}
+
+func (repo *repositoryMock) Reverse(ctx context.Context, r refunds.Refund) error {
+	const op errors.Op = "core/refunds/mocks/repositoryMock.Reverse"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	prev, ok := repo.refunds[r.ID]
+	if !ok || prev.Status != refunds.Approved {
+		return errors.E(op, "refund not found or already completed", errors.KindNotFound)
+	}
+
+	prev.Status = r.Status
+	prev.Reversal = r.Reversal
+	prev.UpdatedAt = time.Now()
+	repo.refunds[r.ID] = prev
+	return nil
+}
@@ -0,0 +1,30 @@
+package refunds
+
+import "context"
+
+// Repository stores refunds
+type Repository interface {
+	// Save a new refund request, only one open refund is allowed per payment
+	Save(ctx context.Context, r *Refund) error
+
+	// Find a refund by id
+	Find(ctx context.Context, id string) (Refund, error)
+
+	// List refunds starting with the most recent
+	List(ctx context.Context, flts *Filters) (Page, error)
+
+	// Review moves a pending refund to the status of r. It fails if the
+	// refund was already reviewed so that it is never paid out twice.
+	Review(ctx context.Context, r Refund) error
+
+	// FindByReversal finds the refund paid out by the given push
+	FindByReversal(ctx context.Context, reversal string) (Refund, error)
+
+	// Update the status and reversal of a refund
+	Update(ctx context.Context, r Refund) error
+
+	// Reverse completes an approved refund once its payout is confirmed.
+	// It records the reversal transactions, takes back what the original
+	// payment paid off, invoices and credit alike, and queues the refund sms.
+	Reverse(ctx context.Context, r Refund) error
+}
@@ -0,0 +1,52 @@
+package refunds
+
+import (
+	"context"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.Reversals) = (*reversals)(nil)
+
+type reversals struct {
+	repo Repository
+}
+
+// NewReversals lets the payment service settle the refunds paid out
+// through its pushes
+func NewReversals(repo Repository) payment.Reversals {
+	return &reversals{repo: repo}
+}
+
+// Settle completes or fails the refund paid out by the push ref,
+// pushes that aren't refunds are left alone.
+func (rv *reversals) Settle(ctx context.Context, ref string, status payment.PayoutStatus) error {
+	const op errors.Op = "core/refunds/reversals.Settle"
+
+	r, err := rv.repo.FindByReversal(ctx, ref)
+	if err != nil {
+		if errors.Kind(err) == errors.KindNotFound {
+			return nil
+		}
+		return errors.E(op, err)
+	}
+
+	if r.Status != Approved {
+		return nil
+	}
+
+	if status == payment.PayoutFailed {
+		r.Status = Failed
+		if err := rv.repo.Update(ctx, r); err != nil {
+			return errors.E(op, err)
+		}
+		return nil
+	}
+
+	r.Status = Completed
+	if err := rv.repo.Reverse(ctx, r); err != nil {
+		return errors.E(op, err)
+	}
+	return nil
+}
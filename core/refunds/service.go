@@ -0,0 +1,183 @@
+package refunds
+
+import (
+	"context"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the refund usecases. The namespace given to reviews is
+// the one of the caller, it is empty for developers who see every namespace.
+type Service interface {
+	// Request records a refund of a successful payment awaiting approval
+	Request(ctx context.Context, r Refund) (Refund, error)
+
+	// Approve pays a pending refund back to the payer, the refund is
+	// completed once the gateway confirms the payout
+	Approve(ctx context.Context, id, namespace, reviewer string) (Refund, error)
+
+	// Reject closes a pending refund without paying it
+	Reject(ctx context.Context, id, namespace, reviewer string) (Refund, error)
+
+	// List refunds
+	List(ctx context.Context, flts *Filters) (Page, error)
+}
+
+// Options contains refunds.Service creation options
+type Options struct {
+	Idp        identity.Provider
+	Repository Repository
+	Properties properties.Repository
+	Payments   payment.Repository
+
+	// Gateway pays approved refunds out
+	Gateway payment.Service
+}
+
+type service struct {
+	idp        identity.Provider
+	repo       Repository
+	properties properties.Repository
+	payments   payment.Repository
+	gateway    payment.Service
+}
+
+// New instantiates the refunds.Service
+func New(opts *Options) Service {
+	return &service{
+		idp:        opts.Idp,
+		repo:       opts.Repository,
+		properties: opts.Properties,
+		payments:   opts.Payments,
+		gateway:    opts.Gateway,
+	}
+}
+
+func (svc *service) Request(ctx context.Context, r Refund) (Refund, error) {
+	const op errors.Op = "core/refunds/service.Request"
+
+	if err := r.Validate(); err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+
+	payments, err := svc.payments.Find(ctx, r.Ref)
+	if err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+	if len(payments) == 0 {
+		return Refund{}, errors.E(op, "payment not found", errors.KindNotFound)
+	}
+
+	property, err := svc.properties.RetrieveByID(ctx, payments[0].Code)
+	if err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+
+	// staff only refund the payments of their own sector
+	if r.Namespace != "" && r.Namespace != property.Namespace {
+		return Refund{}, errors.E(op, "payment not found", errors.KindNotFound)
+	}
+
+	if payment.State(payments[0].Status) != payment.Successful {
+		return Refund{}, errors.E(op, "only successful payments can be refunded", errors.KindBadRequest)
+	}
+
+	r.Amount = 0
+	for _, py := range payments {
+		r.Amount += py.Amount
+	}
+	r.ID = svc.idp.ID()
+	r.Property = property.ID
+	r.Namespace = property.Namespace
+	r.MSISDN = payments[0].MSISDN
+	r.Method = payments[0].Method
+	r.Status = Pending
+	r.Reversal = ""
+	r.ReviewedBy = ""
+
+	if err := svc.repo.Save(ctx, &r); err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+	return r, nil
+}
+
+func (svc *service) Approve(ctx context.Context, id, namespace, reviewer string) (Refund, error) {
+	const op errors.Op = "core/refunds/service.Approve"
+
+	r, err := svc.review(ctx, id, namespace, reviewer, Approved)
+	if err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+
+	tx := &payment.TxRequest{
+		Code:      r.Property,
+		Amount:    r.Amount,
+		MSISDN:    r.MSISDN,
+		Method:    r.Method,
+		Namespace: r.Namespace,
+	}
+
+	res, err := svc.gateway.Push(ctx, tx)
+	if err != nil {
+		r.Status = Failed
+		if uerr := svc.repo.Update(ctx, r); uerr != nil {
+			return r, errors.E(op, uerr)
+		}
+		return r, errors.E(op, err)
+	}
+
+	// the refund is completed by the reversals once the gateway sent the money
+	r.Reversal = res.TxID
+	if err := svc.repo.Update(ctx, r); err != nil {
+		return r, errors.E(op, err)
+	}
+	return r, nil
+}
+
+func (svc *service) Reject(ctx context.Context, id, namespace, reviewer string) (Refund, error) {
+	const op errors.Op = "core/refunds/service.Reject"
+
+	r, err := svc.review(ctx, id, namespace, reviewer, Rejected)
+	if err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+	return r, nil
+}
+
+func (svc *service) review(ctx context.Context, id, namespace, reviewer string, status Status) (Refund, error) {
+	const op errors.Op = "core/refunds/service.review"
+
+	r, err := svc.repo.Find(ctx, id)
+	if err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+
+	// the refunds of other sectors are hidden from reviewers
+	if namespace != "" && namespace != r.Namespace {
+		return Refund{}, errors.E(op, "refund not found", errors.KindNotFound)
+	}
+
+	if r.Status != Pending {
+		return Refund{}, errors.E(op, "refund was already reviewed", errors.KindAlreadyExists)
+	}
+
+	r.Status = status
+	r.ReviewedBy = reviewer
+	if err := svc.repo.Review(ctx, r); err != nil {
+		return Refund{}, errors.E(op, err)
+	}
+	return r, nil
+}
+
+func (svc *service) List(ctx context.Context, flts *Filters) (Page, error) {
+	const op errors.Op = "core/refunds/service.List"
+
+	page, err := svc.repo.List(ctx, flts)
+	if err != nil {
+		return Page{}, errors.E(op, err)
+	}
+	return page, nil
+}
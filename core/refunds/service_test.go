@@ -0,0 +1,223 @@
+package refunds_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	pmocks "github.com/nshimiyimanaamani/paypack-backend/core/payment/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds"
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	namespace = "kigali.gasabo.remera"
+	other     = "kigali.gasabo.kimironko"
+)
+
+type fixture struct {
+	svc      refunds.Service
+	gateway  payment.Service
+	payments payment.Repository
+	property properties.Property
+}
+
+func newFixture(t *testing.T) fixture {
+	props := pmocks.NewPropertyRepository()
+
+	property, err := props.Save(context.Background(), properties.Property{
+		ID:        uuid.New().ID(),
+		Due:       1000,
+		Namespace: namespace,
+		Owner:     properties.Owner{ID: "owner"},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	repo := mocks.NewRepository()
+	payments := pmocks.NewPaymentRepository()
+
+	gateway := payment.New(&payment.Options{
+		Idp:        pmocks.NewIdentityProvider(),
+		Backend:    pmocks.NewBackend(),
+		Queue:      pmocks.NewQueue(),
+		Properties: props,
+		Repository: payments,
+		Events:     pmocks.NewEventRepository(),
+		Payouts:    pmocks.NewPayoutRepository(),
+		Reversals:  refunds.NewReversals(repo),
+	})
+
+	svc := refunds.New(&refunds.Options{
+		Idp:        mocks.NewIdentityProvider(),
+		Repository: repo,
+		Properties: props,
+		Payments:   payments,
+		Gateway:    gateway,
+	})
+	return fixture{svc: svc, gateway: gateway, payments: payments, property: property}
+}
+
+func (f fixture) pay(t *testing.T, status string) string {
+	py := &payment.TxRequest{
+		ID:     uuid.New().ID(),
+		Ref:    uuid.New().ID(),
+		Code:   f.property.ID,
+		Amount: 1000,
+		MSISDN: "0784607135",
+		Method: payment.MTN,
+		Status: status,
+	}
+	err := f.payments.Save(context.Background(), py)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	return py.Ref
+}
+
+func TestRequest(t *testing.T) {
+	f := newFixture(t)
+
+	paid := f.pay(t, "successful")
+	pending := f.pay(t, "")
+
+	cases := []struct {
+		desc   string
+		refund refunds.Refund
+		kind   int
+	}{
+		{
+			desc:   "request refund of payment of another namespace",
+			refund: refunds.Refund{Ref: paid, Reason: "paid twice", Namespace: other},
+			kind:   errors.KindNotFound,
+		},
+		{
+			desc:   "request refund",
+			refund: refunds.Refund{Ref: paid, Reason: "paid twice", Namespace: namespace},
+		},
+		{
+			desc:   "request refund of already refunded payment",
+			refund: refunds.Refund{Ref: paid, Reason: "paid twice"},
+			kind:   errors.KindAlreadyExists,
+		},
+		{
+			desc:   "request refund of pending payment",
+			refund: refunds.Refund{Ref: pending, Reason: "paid twice"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "request refund of unknown payment",
+			refund: refunds.Refund{Ref: uuid.New().ID(), Reason: "paid twice"},
+			kind:   errors.KindNotFound,
+		},
+		{
+			desc:   "request refund without reason",
+			refund: refunds.Refund{Ref: paid},
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := f.svc.Request(context.Background(), tc.refund)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, refunds.Pending, res.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, refunds.Pending, res.Status))
+		assert.Equal(t, namespace, res.Namespace, fmt.Sprintf("%s: expected namespace %s got %s", tc.desc, namespace, res.Namespace))
+		assert.Equal(t, float64(1000), res.Amount, fmt.Sprintf("%s: expected %v got %v", tc.desc, 1000, res.Amount))
+	}
+}
+
+func TestReview(t *testing.T) {
+	f := newFixture(t)
+
+	ctx := context.Background()
+
+	requested, err := f.svc.Request(ctx, refunds.Refund{Ref: f.pay(t, "successful"), Reason: "paid twice", RequestedBy: "agent"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc      string
+		id        string
+		namespace string
+		approve   bool
+		status    refunds.Status
+		kind      int
+	}{
+		{
+			desc:      "approve refund of another namespace",
+			id:        requested.ID,
+			namespace: other,
+			approve:   true,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "reject refund of another namespace",
+			id:        requested.ID,
+			namespace: other,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "approve pending refund",
+			id:        requested.ID,
+			namespace: namespace,
+			approve:   true,
+			status:    refunds.Approved,
+		},
+		{
+			desc:    "approve approved refund",
+			id:      requested.ID,
+			approve: true,
+			kind:    errors.KindAlreadyExists,
+		},
+		{
+			desc: "reject approved refund",
+			id:   requested.ID,
+			kind: errors.KindAlreadyExists,
+		},
+		{
+			desc:    "approve unknown refund",
+			id:      uuid.New().ID(),
+			approve: true,
+			kind:    errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		review := f.svc.Reject
+		if tc.approve {
+			review = f.svc.Approve
+		}
+		res, err := review(ctx, tc.id, tc.namespace, "admin")
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.status, res.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.status, res.Status))
+	}
+
+	page, err := f.svc.List(ctx, &refunds.Filters{Namespace: &requested.Namespace, Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Equal(t, uint64(1), page.Total, fmt.Sprintf("expected %d refunds got %d", 1, page.Total))
+	require.NotEmpty(t, page.Refunds[0].Reversal, "approved refund must reference its reversal")
+
+	// the refund is only completed once the gateway confirms the payout
+	cb := payment.Callback{Kind: payment.ProcessedEvent, Data: payment.Data{Ref: page.Refunds[0].Reversal, Status: string(payment.Successful)}}
+	err = f.gateway.ConfirmPush(ctx, cb)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	page, err = f.svc.List(ctx, &refunds.Filters{Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, refunds.Completed, page.Refunds[0].Status, fmt.Sprintf("expected %s got %s", refunds.Completed, page.Refunds[0].Status))
+
+	page, err = f.svc.List(ctx, &refunds.Filters{Namespace: cast.StringPointer(other), Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(0), page.Total, fmt.Sprintf("expected %d refunds got %d", 0, page.Total))
+}
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
@@ -27,21 +28,31 @@ type Options struct {
 	Queue    Queue
 	Counter  Counter
 	Invoices invoices.Repository
+
+	// ReconcileAfter is the age of pending payments picked by the reconcile task
+	ReconcileAfter time.Duration
+
+	// ExpireAfter is the age of pending payments given up by the expire task
+	ExpireAfter time.Duration
 }
 
 type service struct {
-	queue    Queue
-	auditble Counter
-	invoices invoices.Repository
-	tasks    map[string]Task
+	queue          Queue
+	auditble       Counter
+	invoices       invoices.Repository
+	reconcileAfter time.Duration
+	expireAfter    time.Duration
+	tasks          map[string]Task
 }
 
 // New ...
 func New(opts *Options) Service {
 	svc := &service{
-		queue:    opts.Queue,
-		auditble: opts.Counter,
-		invoices: opts.Invoices,
+		queue:          opts.Queue,
+		auditble:       opts.Counter,
+		invoices:       opts.Invoices,
+		reconcileAfter: opts.ReconcileAfter,
+		expireAfter:    opts.ExpireAfter,
 	}
 	return svc
 }
@@ -3,15 +3,21 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	audit    = "audit"
-	reminder = "reminder"
-	archive  = "archive"
+	audit      = "audit"
+	reminder   = "reminder"
+	archive    = "archive"
+	receipts   = "receipts"
+	reconcile  = "reconcile"
+	expire     = "expire"
+	statements = "statements"
+	settle     = "settlements"
 )
 
 // Task is schedulable unit of work
@@ -28,6 +34,16 @@ func (svc *service) Run(ctx context.Context, name string) error {
 		return svc.ReminderTask(ctx, name)
 	case archive:
 		return svc.ArchiveTask(ctx, name)
+	case receipts:
+		return svc.ReceiptsTask(ctx, name)
+	case reconcile:
+		return svc.ReconcileTask(ctx, name)
+	case expire:
+		return svc.ExpireTask(ctx, name)
+	case statements:
+		return svc.StatementsTask(ctx, name)
+	case settle:
+		return svc.SettlementsTask(ctx, name)
 	default:
 		return svc.UnknownTask(ctx, name)
 	}
@@ -93,3 +109,90 @@ func (svc *service) ArchiveTask(ctx context.Context, name string) error {
 
 	return nil
 }
+
+// ReceiptsTask schedules delivery of the payment receipts waiting in the outbox
+func (svc *service) ReceiptsTask(ctx context.Context, name string) error {
+	const op errors.Op = "core/scheduler/service.ReceiptsTask"
+
+	const batch = 50
+
+	var args = make(map[string]interface{})
+
+	args["batch"] = batch
+
+	err := svc.queue.Enqueue(ctx, name, args)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// ReconcileTask schedules reconciliation of stale pending payments with the gateway
+func (svc *service) ReconcileTask(ctx context.Context, name string) error {
+	const op errors.Op = "core/scheduler/service.ReconcileTask"
+
+	const batch = 50
+
+	var args = make(map[string]interface{})
+
+	args["age"] = int(svc.reconcileAfter.Minutes())
+	args["batch"] = batch
+
+	err := svc.queue.Enqueue(ctx, name, args)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// ExpireTask schedules expiry of payments that stayed pending for too long
+func (svc *service) ExpireTask(ctx context.Context, name string) error {
+	const op errors.Op = "core/scheduler/service.ExpireTask"
+
+	var args = make(map[string]interface{})
+
+	args["age"] = int(svc.expireAfter.Minutes())
+
+	err := svc.queue.Enqueue(ctx, name, args)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// StatementsTask schedules matching of imported bank statement lines
+func (svc *service) StatementsTask(ctx context.Context, name string) error {
+	const op errors.Op = "core/scheduler/service.StatementsTask"
+
+	const batch = 200
+
+	var args = make(map[string]interface{})
+
+	args["batch"] = batch
+
+	err := svc.queue.Enqueue(ctx, name, args)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// SettlementsTask schedules the settlement of the previous day
+func (svc *service) SettlementsTask(ctx context.Context, name string) error {
+	const op errors.Op = "core/scheduler/service.SettlementsTask"
+
+	var args = make(map[string]interface{})
+
+	args["day"] = time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+
+	err := svc.queue.Enqueue(ctx, name, args)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
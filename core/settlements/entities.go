@@ -0,0 +1,124 @@
+package settlements
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// DayLayout is the format of settlement days
+const DayLayout = "2006-01-02"
+
+// Status tells whether the payments of a day add up
+type Status string
+
+// possible settlement states
+const (
+	// Balanced settlements recorded a transaction for every payment
+	Balanced Status = "balanced"
+	// Unbalanced settlements need to be looked into by hand
+	Unbalanced Status = "unbalanced"
+)
+
+// Settlement sums what the gateway collected and paid out for a namespace
+// in a day. Cash and bank payments don't go through the gateway and are
+// left out.
+type Settlement struct {
+	ID           string    `json:"id,omitempty"`
+	Namespace    string    `json:"namespace"`
+	Day          time.Time `json:"day"`
+	Payments     uint64    `json:"payments"`
+	Collected    float64   `json:"collected"`
+	Transactions uint64    `json:"transactions"`
+	Recorded     float64   `json:"recorded"`
+	Fees         float64   `json:"fees"`
+	GatewayFees  float64   `json:"gateway_fees"`
+	Payouts      uint64    `json:"payouts"`
+	PaidOut      float64   `json:"paid_out"`
+	Net          float64   `json:"net"`
+	Status       Status    `json:"status"`
+	Signature    string    `json:"signature,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+}
+
+// Balance computes the net amount the gateway owes and whether every
+// payment was recorded as a transaction
+func (s *Settlement) Balance() {
+	s.Net = round(s.Collected - s.GatewayFees - s.PaidOut)
+
+	s.Status = Balanced
+	if s.Payments != s.Transactions || math.Abs(s.Collected-s.Recorded) >= 0.01 {
+		s.Status = Unbalanced
+	}
+}
+
+// Sign the settlement totals with secret
+func (s *Settlement) Sign(secret string) {
+	s.Signature = s.signature(secret)
+}
+
+// Verify checks that the settlement totals weren't changed since they were signed
+func (s *Settlement) Verify(secret string) error {
+	const op errors.Op = "core/settlements/Settlement.Verify"
+
+	if s.Signature == "" {
+		return errors.E(op, "settlement isn't signed", errors.KindUnexpected)
+	}
+	if !hmac.Equal([]byte(s.signature(secret)), []byte(s.Signature)) {
+		return errors.E(op, "settlement signature doesn't match its totals", errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (s *Settlement) signature(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s|%s|%d|%.2f|%d|%.2f|%.2f|%.2f|%d|%.2f|%.2f|%s",
+		s.Namespace,
+		s.Day.Format(DayLayout),
+		s.Payments,
+		s.Collected,
+		s.Transactions,
+		s.Recorded,
+		s.Fees,
+		s.GatewayFees,
+		s.Payouts,
+		s.PaidOut,
+		s.Net,
+		s.Status,
+	)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Filters narrows down listed settlements, nil filters are ignored
+type Filters struct {
+	Namespace *string
+	Status    *string
+	From      *string
+	To        *string
+	Offset    uint64
+	Limit     uint64
+}
+
+// PageMetadata ...
+type PageMetadata struct {
+	Total  uint64
+	Amount float64 `json:"amount,omitempty"`
+	Offset uint64
+	Limit  uint64
+}
+
+// Page is a list of settlements starting with the most recent day
+type Page struct {
+	PageMetadata
+	Settlements []Settlement `json:"settlements"`
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
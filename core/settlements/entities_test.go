@@ -0,0 +1,88 @@
+package settlements_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementBalance(t *testing.T) {
+	cases := []struct {
+		desc       string
+		settlement settlements.Settlement
+		net        float64
+		status     settlements.Status
+	}{
+		{
+			desc:       "balance recorded payments",
+			settlement: settlements.Settlement{Payments: 2, Collected: 2000, Transactions: 2, Recorded: 2000, GatewayFees: 46, Payouts: 1, PaidOut: 1000},
+			net:        954,
+			status:     settlements.Balanced,
+		},
+		{
+			desc:       "balance payments without transaction",
+			settlement: settlements.Settlement{Payments: 2, Collected: 2000, Transactions: 1, Recorded: 1000, GatewayFees: 23},
+			net:        1977,
+			status:     settlements.Unbalanced,
+		},
+		{
+			desc:       "balance transactions of another amount",
+			settlement: settlements.Settlement{Payments: 1, Collected: 1000, Transactions: 1, Recorded: 1000.5},
+			net:        1000,
+			status:     settlements.Unbalanced,
+		},
+		{
+			desc:       "balance day without payments",
+			settlement: settlements.Settlement{Payouts: 1, PaidOut: 500},
+			net:        -500,
+			status:     settlements.Balanced,
+		},
+	}
+
+	for _, tc := range cases {
+		tc.settlement.Balance()
+		assert.Equal(t, tc.net, tc.settlement.Net, fmt.Sprintf("%s: expected net %v got %v", tc.desc, tc.net, tc.settlement.Net))
+		assert.Equal(t, tc.status, tc.settlement.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.status, tc.settlement.Status))
+	}
+}
+
+func TestSettlementSignature(t *testing.T) {
+	signed := settlements.Settlement{
+		Namespace:    "kigali",
+		Day:          time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+		Payments:     1,
+		Collected:    1000,
+		Transactions: 1,
+		Recorded:     1000,
+	}
+	signed.Balance()
+	signed.Sign(secret)
+
+	tampered := signed
+	tampered.Collected = 2000
+
+	cases := []struct {
+		desc       string
+		settlement settlements.Settlement
+		secret     string
+		kind       int
+	}{
+		{desc: "verify signed settlement", settlement: signed, secret: secret},
+		{desc: "verify settlement with another secret", settlement: signed, secret: "another", kind: errors.KindUnexpected},
+		{desc: "verify changed settlement", settlement: tampered, secret: secret, kind: errors.KindUnexpected},
+		{desc: "verify unsigned settlement", settlement: settlements.Settlement{Namespace: "kigali"}, secret: secret, kind: errors.KindUnexpected},
+	}
+
+	for _, tc := range cases {
+		err := tc.settlement.Verify(tc.secret)
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+}
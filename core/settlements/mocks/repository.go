@@ -0,0 +1,110 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (settlements.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu          sync.Mutex
+	totals      []settlements.Settlement
+	settlements map[string]settlements.Settlement
+}
+
+// NewRepository creates an in memory mock of settlements.Repository,
+// totals are returned for any day.
+func NewRepository(totals ...settlements.Settlement) settlements.Repository {
+	return &repositoryMock{
+		totals:      totals,
+		settlements: make(map[string]settlements.Settlement),
+	}
+}
+
+func (repo *repositoryMock) Totals(ctx context.Context, day time.Time) ([]settlements.Settlement, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	totals := make([]settlements.Settlement, len(repo.totals))
+	copy(totals, repo.totals)
+	return totals, nil
+}
+
+func (repo *repositoryMock) Save(ctx context.Context, s *settlements.Settlement) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	s.CreatedAt, s.UpdatedAt = now, now
+
+	for id, prev := range repo.settlements {
+		if prev.Namespace == s.Namespace && prev.Day.Equal(s.Day) {
+			s.ID = id
+			s.CreatedAt = prev.CreatedAt
+		}
+	}
+	repo.settlements[s.ID] = *s
+	return nil
+}
+
+func (repo *repositoryMock) Find(ctx context.Context, id string) (settlements.Settlement, error) {
+	const op errors.Op = "core/settlements/mocks/repositoryMock.Find"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	s, ok := repo.settlements[id]
+	if !ok {
+		return settlements.Settlement{}, errors.E(op, "settlement not found", errors.KindNotFound)
+	}
+	return s, nil
+}
+
+func (repo *repositoryMock) List(ctx context.Context, flts *settlements.Filters) (settlements.Page, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	items := make([]settlements.Settlement, 0)
+	for _, s := range repo.settlements {
+		if flts.Namespace != nil && s.Namespace != *flts.Namespace {
+			continue
+		}
+		if flts.Status != nil && string(s.Status) != *flts.Status {
+			continue
+		}
+		if flts.From != nil && s.Day.Format(settlements.DayLayout) < *flts.From {
+			continue
+		}
+		if flts.To != nil && s.Day.Format(settlements.DayLayout) > *flts.To {
+			continue
+		}
+		items = append(items, s)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Day.After(items[j].Day)
+	})
+
+	page := settlements.Page{
+		PageMetadata: settlements.PageMetadata{
+			Total:  uint64(len(items)),
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+		},
+		Settlements: []settlements.Settlement{},
+	}
+
+	for i, s := range items {
+		page.Amount += s.Net
+		if uint64(i) >= flts.Offset && uint64(i) < flts.Offset+flts.Limit {
+			page.Settlements = append(page.Settlements, s)
+		}
+	}
+	return page, nil
+}
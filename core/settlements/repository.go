@@ -0,0 +1,23 @@
+package settlements
+
+import (
+	"context"
+	"time"
+)
+
+// Repository computes and stores daily settlements
+type Repository interface {
+	// Totals sums the gateway payments, transactions and payouts of every
+	// namespace active on day
+	Totals(ctx context.Context, day time.Time) ([]Settlement, error)
+
+	// Save a settlement, it replaces an earlier one of the same namespace
+	// and day
+	Save(ctx context.Context, s *Settlement) error
+
+	// Find a settlement by id
+	Find(ctx context.Context, id string) (Settlement, error)
+
+	// List settlements starting with the most recent day
+	List(ctx context.Context, flts *Filters) (Page, error)
+}
@@ -0,0 +1,92 @@
+package settlements
+
+import (
+	"context"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the settlement usecases
+type Service interface {
+	// Settle computes and signs the settlement of every namespace active on day
+	Settle(ctx context.Context, day time.Time) ([]Settlement, error)
+
+	// List daily settlements
+	List(ctx context.Context, flts *Filters) (Page, error)
+
+	// Retrieve a settlement once its signature is checked
+	Retrieve(ctx context.Context, id string) (Settlement, error)
+}
+
+// Options contains settlements.Service creation options
+type Options struct {
+	Idp        identity.Provider
+	Repository Repository
+
+	// Secret signs settlements
+	Secret string
+}
+
+type service struct {
+	idp    identity.Provider
+	repo   Repository
+	secret string
+}
+
+// New instantiates the settlements.Service
+func New(opts *Options) Service {
+	return &service{
+		idp:    opts.Idp,
+		repo:   opts.Repository,
+		secret: opts.Secret,
+	}
+}
+
+func (svc *service) Settle(ctx context.Context, day time.Time) ([]Settlement, error) {
+	const op errors.Op = "core/settlements/service.Settle"
+
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	settlements, err := svc.repo.Totals(ctx, day)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	for i := range settlements {
+		s := &settlements[i]
+		s.ID = svc.idp.ID()
+		s.Day = day
+		s.Balance()
+		s.Sign(svc.secret)
+
+		if err := svc.repo.Save(ctx, s); err != nil {
+			return nil, errors.E(op, err)
+		}
+	}
+	return settlements, nil
+}
+
+func (svc *service) List(ctx context.Context, flts *Filters) (Page, error) {
+	const op errors.Op = "core/settlements/service.List"
+
+	page, err := svc.repo.List(ctx, flts)
+	if err != nil {
+		return Page{}, errors.E(op, err)
+	}
+	return page, nil
+}
+
+func (svc *service) Retrieve(ctx context.Context, id string) (Settlement, error) {
+	const op errors.Op = "core/settlements/service.Retrieve"
+
+	s, err := svc.repo.Find(ctx, id)
+	if err != nil {
+		return Settlement{}, errors.E(op, err)
+	}
+	if err := s.Verify(svc.secret); err != nil {
+		return Settlement{}, errors.E(op, err)
+	}
+	return s, nil
+}
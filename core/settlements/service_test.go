@@ -0,0 +1,58 @@
+package settlements_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "settlement-secret"
+
+func TestSettle(t *testing.T) {
+	svc := settlements.New(&settlements.Options{
+		Idp: mocks.NewIdentityProvider(),
+		Repository: mocks.NewRepository(
+			settlements.Settlement{Namespace: "gasabo", Payments: 2, Collected: 2000, Transactions: 2, Recorded: 2000, GatewayFees: 46},
+			settlements.Settlement{Namespace: "remera", Payments: 2, Collected: 2000, Transactions: 1, Recorded: 1000, GatewayFees: 23},
+		),
+		Secret: secret,
+	})
+
+	ctx := context.Background()
+
+	day := time.Date(2020, 10, 1, 23, 30, 0, 0, time.UTC)
+
+	settled, err := svc.Settle(ctx, day)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, settled, 2, fmt.Sprintf("expected %d settlements got %d", 2, len(settled)))
+
+	midnight := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, midnight, settled[0].Day, fmt.Sprintf("expected %v got %v", midnight, settled[0].Day))
+	assert.Equal(t, settlements.Balanced, settled[0].Status, fmt.Sprintf("expected %s got %s", settlements.Balanced, settled[0].Status))
+	assert.Equal(t, settlements.Unbalanced, settled[1].Status, fmt.Sprintf("expected %s got %s", settlements.Unbalanced, settled[1].Status))
+
+	found, err := svc.Retrieve(ctx, settled[0].ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 1954.0, found.Net, fmt.Sprintf("expected %v got %v", 1954.0, found.Net))
+
+	// settling the same day again replaces the settlements
+	again, err := svc.Settle(ctx, day)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, settled[0].ID, again[0].ID, fmt.Sprintf("expected %s got %s", settled[0].ID, again[0].ID))
+
+	unbalanced := cast.StringPointer(string(settlements.Unbalanced))
+	page, err := svc.List(ctx, &settlements.Filters{Status: unbalanced, Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(1), page.Total, fmt.Sprintf("expected %d settlements got %d", 1, page.Total))
+
+	_, err = svc.Retrieve(ctx, "unknown")
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err), fmt.Sprintf("expected kind %d got err: '%v'", errors.KindNotFound, err))
+}
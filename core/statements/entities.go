@@ -0,0 +1,99 @@
+package statements
+
+import (
+	"math"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// DayLayout is the layout of the first and last days of a statement
+const DayLayout = "2006-01-02"
+
+// Kind is the money movement a statement item records
+type Kind string
+
+// Item kinds, invoices and penalties are charged while payments and
+// waivers settle them
+const (
+	Invoice    Kind = "invoice"
+	Penalty    Kind = "penalty"
+	Payment    Kind = "payment"
+	Waiver     Kind = "waiver"
+	Refund     Kind = "refund"
+	Adjustment Kind = "adjustment"
+)
+
+// Item is a single movement on the account of a property, Balance is what
+// the property owes right after it. A negative balance is credit.
+type Item struct {
+	Kind        Kind      `json:"kind"`
+	Ref         string    `json:"ref"`
+	Description string    `json:"description,omitempty"`
+	Debit       float64   `json:"debit,omitempty"`
+	Credit      float64   `json:"credit,omitempty"`
+	Balance     float64   `json:"balance"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Statement lists the movements on the account of a property between two
+// days, both included, in the order they happened.
+type Statement struct {
+	Property  string    `json:"property"`
+	Owner     string    `json:"owner"`
+	Namespace string    `json:"namespace"`
+	Sector    string    `json:"sector"`
+	Cell      string    `json:"cell"`
+	Village   string    `json:"village"`
+	Due       float64   `json:"due"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Opening   float64   `json:"opening_balance"`
+	Items     []Item    `json:"items"`
+	Charged   float64   `json:"charged"`
+	Settled   float64   `json:"settled"`
+	Closing   float64   `json:"closing_balance"`
+}
+
+// Balance computes the running balance of every item along with the
+// totals and the closing balance of the statement
+func (st *Statement) Balance() {
+	balance := cents(st.Opening)
+
+	var charged, settled int64
+	for i := range st.Items {
+		charged += cents(st.Items[i].Debit)
+		settled += cents(st.Items[i].Credit)
+		balance += cents(st.Items[i].Debit) - cents(st.Items[i].Credit)
+		st.Items[i].Balance = float64(balance) / 100
+	}
+
+	st.Charged = float64(charged) / 100
+	st.Settled = float64(settled) / 100
+	st.Closing = float64(balance) / 100
+}
+
+// Period checks the days a statement covers, they are truncated to whole
+// days
+func Period(from, to time.Time) (time.Time, time.Time, error) {
+	const op errors.Op = "core/statements/Period"
+
+	if from.IsZero() || to.IsZero() {
+		return from, to, errors.E(op, "missing statement period", errors.KindBadRequest)
+	}
+
+	from, to = day(from), day(to)
+
+	if to.Before(from) {
+		return from, to, errors.E(op, "statement period ends before it starts", errors.KindBadRequest)
+	}
+	return from, to, nil
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
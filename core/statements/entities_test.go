@@ -0,0 +1,77 @@
+package statements_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalance(t *testing.T) {
+	st := statements.Statement{
+		Opening: 500,
+		Items: []statements.Item{
+			{Kind: statements.Invoice, Debit: 1000},
+			{Kind: statements.Waiver, Credit: 250.5},
+			{Kind: statements.Payment, Credit: 2000},
+			{Kind: statements.Penalty, Debit: 100.1},
+		},
+	}
+	st.Balance()
+
+	balances := []float64{1500, 1249.5, -750.5, -650.4}
+	for i, want := range balances {
+		assert.Equal(t, want, st.Items[i].Balance, fmt.Sprintf("item %d: expected balance %v got %v", i, want, st.Items[i].Balance))
+	}
+
+	assert.Equal(t, 1100.1, st.Charged, fmt.Sprintf("expected charged %v got %v", 1100.1, st.Charged))
+	assert.Equal(t, 2250.5, st.Settled, fmt.Sprintf("expected settled %v got %v", 2250.5, st.Settled))
+	assert.Equal(t, -650.4, st.Closing, fmt.Sprintf("expected closing balance %v got %v", -650.4, st.Closing))
+}
+
+func TestPeriod(t *testing.T) {
+	from := time.Date(2021, time.January, 1, 10, 30, 0, 0, time.UTC)
+	to := time.Date(2021, time.March, 31, 23, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc string
+		from time.Time
+		to   time.Time
+		kind int
+	}{
+		{
+			desc: "validate quarter",
+			from: from,
+			to:   to,
+		},
+		{
+			desc: "validate single day",
+			from: from,
+			to:   from,
+		},
+		{
+			desc: "validate missing first day",
+			to:   to,
+			kind: errors.KindBadRequest,
+		},
+		{
+			desc: "validate period ending before it starts",
+			from: to,
+			to:   from,
+			kind: errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		first, last, err := statements.Period(tc.from, tc.to)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.True(t, first.Hour() == 0 && last.Hour() == 0, fmt.Sprintf("%s: expected whole days got %v and %v", tc.desc, first, last))
+	}
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (statements.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu       sync.Mutex
+	accounts map[string]statements.Statement
+}
+
+// NewRepository creates an in memory mock of statements.Repository, every
+// account lists all the movements of its property in chronological order
+func NewRepository(accounts ...statements.Statement) statements.Repository {
+	repo := &repositoryMock{accounts: make(map[string]statements.Statement)}
+	for _, account := range accounts {
+		repo.accounts[account.Property] = account
+	}
+	return repo
+}
+
+func (repo *repositoryMock) Statement(ctx context.Context, property, namespace string, from, to time.Time) (statements.Statement, error) {
+	const op errors.Op = "core/statements/mocks/repositoryMock.Statement"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	account, ok := repo.accounts[property]
+	if !ok || namespace != "" && namespace != account.Namespace {
+		return statements.Statement{}, errors.E(op, "property not found", errors.KindNotFound)
+	}
+
+	st := account
+	st.Items = []statements.Item{}
+	for _, item := range account.Items {
+		switch {
+		case item.CreatedAt.Before(from):
+			st.Opening += item.Debit - item.Credit
+		case item.CreatedAt.Before(to.AddDate(0, 0, 1)):
+			st.Items = append(st.Items, item)
+		}
+	}
+	return st, nil
+}
@@ -0,0 +1,15 @@
+package statements
+
+import (
+	"context"
+	"time"
+)
+
+// Repository reads the account of a property
+type Repository interface {
+	// Statement returns the property, the balance it carried before from
+	// and the movements on its account from the first to the last day.
+	// An empty namespace covers every property. The running balances are
+	// left to the caller.
+	Statement(ctx context.Context, property, namespace string, from, to time.Time) (Statement, error)
+}
@@ -0,0 +1,52 @@
+package statements
+
+import (
+	"context"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the property statement usecases
+type Service interface {
+	// Statement returns what a property was charged and what it paid
+	// between two days, both included, along with its running balance.
+	// An empty namespace covers every property.
+	Statement(ctx context.Context, property, namespace string, from, to time.Time) (Statement, error)
+}
+
+// Options contains statements.Service creation options
+type Options struct {
+	Repository Repository
+}
+
+type service struct {
+	repo Repository
+}
+
+// New instantiates the statements.Service
+func New(opts *Options) Service {
+	return &service{repo: opts.Repository}
+}
+
+func (svc *service) Statement(ctx context.Context, property, namespace string, from, to time.Time) (Statement, error) {
+	const op errors.Op = "core/statements/service.Statement"
+
+	if property == "" {
+		return Statement{}, errors.E(op, "missing house code", errors.KindBadRequest)
+	}
+
+	from, to, err := Period(from, to)
+	if err != nil {
+		return Statement{}, errors.E(op, err)
+	}
+
+	st, err := svc.repo.Statement(ctx, property, namespace, from, to)
+	if err != nil {
+		return Statement{}, errors.E(op, err)
+	}
+
+	st.From, st.To = from, to
+	st.Balance()
+	return st, nil
+}
@@ -0,0 +1,107 @@
+package statements_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const namespace = "kigali.gasabo.remera"
+
+func month(m time.Month) time.Time {
+	return time.Date(2021, m, 1, 8, 0, 0, 0, time.UTC)
+}
+
+func newService() statements.Service {
+	account := statements.Statement{
+		Property:  "1000-4433-3343",
+		Namespace: namespace,
+		Items: []statements.Item{
+			{Kind: statements.Invoice, Ref: "1", Debit: 1000, CreatedAt: month(time.December).AddDate(-1, 0, 0)},
+			{Kind: statements.Invoice, Ref: "2", Debit: 1000, CreatedAt: month(time.January)},
+			{Kind: statements.Payment, Ref: "p1", Credit: 1500, CreatedAt: month(time.January).AddDate(0, 0, 3)},
+			{Kind: statements.Invoice, Ref: "3", Debit: 1000, CreatedAt: month(time.February)},
+			{Kind: statements.Penalty, Ref: "4", Debit: 100, CreatedAt: month(time.February).AddDate(0, 0, 10)},
+			{Kind: statements.Invoice, Ref: "5", Debit: 1000, CreatedAt: month(time.March)},
+		},
+	}
+	return statements.New(&statements.Options{Repository: mocks.NewRepository(account)})
+}
+
+func TestStatement(t *testing.T) {
+	svc := newService()
+
+	cases := []struct {
+		desc      string
+		property  string
+		namespace string
+		from      time.Time
+		to        time.Time
+		opening   float64
+		items     int
+		closing   float64
+		kind      int
+	}{
+		{
+			desc:      "retrieve statement since january",
+			property:  "1000-4433-3343",
+			namespace: namespace,
+			from:      month(time.January),
+			to:        month(time.February).AddDate(0, 0, 27),
+			opening:   1000,
+			items:     4,
+			closing:   1600,
+		},
+		{
+			desc:     "retrieve statement across namespaces",
+			property: "1000-4433-3343",
+			from:     month(time.March),
+			to:       month(time.March),
+			opening:  1600,
+			items:    1,
+			closing:  2600,
+		},
+		{
+			desc:      "retrieve statement of another namespace",
+			property:  "1000-4433-3343",
+			namespace: "kigali.gasabo.kimironko",
+			from:      month(time.January),
+			to:        month(time.March),
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:      "retrieve statement without property",
+			namespace: namespace,
+			from:      month(time.January),
+			to:        month(time.March),
+			kind:      errors.KindBadRequest,
+		},
+		{
+			desc:      "retrieve statement of an inverted period",
+			property:  "1000-4433-3343",
+			namespace: namespace,
+			from:      month(time.March),
+			to:        month(time.January),
+			kind:      errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		st, err := svc.Statement(ctx, tc.property, tc.namespace, tc.from, tc.to)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.opening, st.Opening, fmt.Sprintf("%s: expected opening balance %v got %v", tc.desc, tc.opening, st.Opening))
+		assert.Len(t, st.Items, tc.items, fmt.Sprintf("%s: expected %d items got %d", tc.desc, tc.items, len(st.Items)))
+		assert.Equal(t, tc.closing, st.Closing, fmt.Sprintf("%s: expected closing balance %v got %v", tc.desc, tc.closing, st.Closing))
+	}
+}
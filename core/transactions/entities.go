@@ -22,6 +22,9 @@ type Transaction struct {
 	Namespace    string    `json:"namespace,omitempty"`
 	DateRecorded time.Time `json:"date_recorded,omitempty"`
 	Fees         float64   `json:"fee,omitempty"`
+	GatewayFee   float64   `json:"gateway_fee,omitempty"`
+	FeeBearer    string    `json:"fee_bearer,omitempty"`
+	Net          float64   `json:"net,omitempty"`
 }
 
 // PageMetadata contains page metadata that helps navigation.
@@ -31,8 +34,6 @@ type PageMetadata struct {
 	Limit  uint64
 }
 
-const RateFee = 0.05
-
 // TransactionPage represents a list of transaction.
 type TransactionPage struct {
 	PageMetadata
@@ -191,3 +191,32 @@ func (str *repository) RetrieveByMethod(ctx context.Context, m string, offset, l
 
 	return page, nil
 }
+
+func (str *repository) RetrieveReceipt(ctx context.Context, id string) (transactions.Receipt, error) {
+	const op errors.Op = "app/transactions/mocks/repository.RetrieveReceipt"
+
+	str.mu.Lock()
+	defer str.mu.Unlock()
+
+	tx, ok := str.transactions[id]
+	if !ok {
+		return transactions.Receipt{}, errors.E(op, "transaction not found", errors.KindNotFound)
+	}
+
+	receipt := transactions.Receipt{
+		ID:        tx.ID,
+		Property:  tx.MadeFor,
+		Owner:     tx.OwneFname + " " + tx.OwnerLname,
+		Sector:    tx.Sector,
+		Cell:      tx.Cell,
+		Village:   tx.Village,
+		Namespace: tx.Namespace,
+		Method:    tx.Method,
+		Amount:    tx.Amount,
+		PaidAt:    tx.DateRecorded,
+		Items: []transactions.ReceiptItem{
+			{Invoice: tx.Invoice, Month: tx.DateRecorded, Amount: tx.Amount},
+		},
+	}
+	return receipt, nil
+}
@@ -0,0 +1,77 @@
+package transactions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Receipt is the proof of payment handed to residents. A single payment
+// can settle several invoices, the receipt lists them all.
+type Receipt struct {
+	ID        string        `json:"id,omitempty"`
+	Ref       string        `json:"ref,omitempty"`
+	Property  string        `json:"property,omitempty"`
+	Owner     string        `json:"owner,omitempty"`
+	Sector    string        `json:"sector,omitempty"`
+	Cell      string        `json:"cell,omitempty"`
+	Village   string        `json:"village,omitempty"`
+	Namespace string        `json:"namespace,omitempty"`
+	MSISDN    string        `json:"msisdn,omitempty"`
+	Method    string        `json:"method,omitempty"`
+	Amount    float64       `json:"amount,omitempty"`
+	Items     []ReceiptItem `json:"items,omitempty"`
+	PaidAt    time.Time     `json:"paid_at,omitempty"`
+	URL       string        `json:"url,omitempty"`
+}
+
+// ReceiptItem is an invoice settled by the payment
+type ReceiptItem struct {
+	Invoice uint64    `json:"invoice,omitempty"`
+	Month   time.Time `json:"month,omitempty"`
+	Amount  float64   `json:"amount,omitempty"`
+}
+
+// Mask hides the personal data of the owner, receipts are opened by
+// whoever holds the link without logging in.
+func (r Receipt) Mask() Receipt {
+	words := strings.Fields(r.Owner)
+	for i, word := range words {
+		first, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(first) + "***"
+	}
+	r.Owner = strings.Join(words, " ")
+
+	if n := len(r.MSISDN); n > 3 {
+		r.MSISDN = strings.Repeat("*", n-3) + r.MSISDN[n-3:]
+	}
+	return r
+}
+
+// receiptTokenSize is the number of bytes of the mac kept in receipt
+// links, enough to resist guessing while keeping the sms short.
+const receiptTokenSize = 16
+
+// ReceiptToken signs the id of a transaction with secret, the receipt is
+// only served to whoever got the signed link in the payment sms.
+func ReceiptToken(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:receiptTokenSize])
+}
+
+// ValidReceiptToken tells whether token was issued for the transaction id
+func ValidReceiptToken(secret, id, token string) bool {
+	return hmac.Equal([]byte(ReceiptToken(secret, id)), []byte(token))
+}
+
+// ReceiptURL is where the receipt of a transaction is downloaded from,
+// base is the public url of the api and token the one the id was signed
+// with.
+func ReceiptURL(base, id, token string) string {
+	return fmt.Sprintf("%s/transactions/%s/receipt?token=%s", strings.TrimSuffix(base, "/"), id, token)
+}
@@ -24,4 +24,8 @@ type Repository interface {
 
 	// RetrieveByMethodretrieves the subset of transactions that where made during the given month.
 	RetrieveByMethod(ctx context.Context, m string, offset, limit uint64) (TransactionPage, error)
+
+	// RetrieveReceipt builds the receipt of the payment the transaction
+	// identified by the given id belongs to.
+	RetrieveReceipt(ctx context.Context, id string) (Receipt, error)
 }
@@ -49,26 +49,41 @@ type Service interface {
 	// ListTransactionByDate retrieves data about a subset of transactions that were made using
 	// a given method.
 	ListByMethod(ctx context.Context, m string, offset, limit uint64) (TransactionPage, error)
+
+	// Receipt retrieves the receipt of the payment the transaction identified
+	// with the provided ID belongs to, token is the one the receipt link was
+	// signed with. The personal data of the owner is masked.
+	Receipt(ctx context.Context, id, token string) (Receipt, error)
 }
 
 var _ Service = (*service)(nil)
 
 type service struct {
-	idp  identity.Provider
-	repo Repository
+	idp      identity.Provider
+	repo     Repository
+	receipts string
+	secret   string
 }
 
 // Options ...
 type Options struct {
 	Idp  identity.Provider
 	Repo Repository
+
+	// ReceiptsURL is the public api url receipts are downloaded from, it's
+	// what the QR code of a receipt points to
+	ReceiptsURL string
+	// ReceiptsSecret signs the receipt links sent in payment sms
+	ReceiptsSecret string
 }
 
 // New instantiates a new transaxtions service
 func New(opts *Options) Service {
 	return &service{
-		idp:  opts.Idp,
-		repo: opts.Repo,
+		idp:      opts.Idp,
+		repo:     opts.Repo,
+		receipts: opts.ReceiptsURL,
+		secret:   opts.ReceiptsSecret,
 	}
 }
 
@@ -141,3 +156,22 @@ func (svc *service) ListByMethod(ctx context.Context, m string, offset, limit ui
 	}
 	return page, nil
 }
+
+func (svc *service) Receipt(ctx context.Context, id, token string) (Receipt, error) {
+	const op errors.Op = "app/transactions/service.Receipt"
+
+	// a wrong token can't tell whether the transaction exists
+	if svc.secret == "" || !ValidReceiptToken(svc.secret, id, token) {
+		return Receipt{}, errors.E(op, "transaction not found", errors.KindNotFound)
+	}
+
+	receipt, err := svc.repo.RetrieveReceipt(ctx, id)
+	if err != nil {
+		return Receipt{}, errors.E(op, err)
+	}
+
+	if svc.receipts != "" {
+		receipt.URL = ReceiptURL(svc.receipts, receipt.ID, token)
+	}
+	return receipt.Mask(), nil
+}
@@ -12,6 +12,11 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+const (
+	receiptsURL    = "https://paypack.rw/api"
+	receiptsSecret = "secret"
+)
+
 var transaction = transactions.Transaction{
 	ID:      "1000-4433-3343",
 	Amount:  1000.00,
@@ -24,8 +29,10 @@ func newService() transactions.Service {
 	repo := mocks.NewRepository()
 	idp := mocks.NewIdentityProvider()
 	opts := &transactions.Options{
-		Repo: repo,
-		Idp:  idp,
+		Repo:           repo,
+		Idp:            idp,
+		ReceiptsURL:    receiptsURL,
+		ReceiptsSecret: receiptsSecret,
 	}
 	return transactions.New(opts)
 }
@@ -344,3 +351,66 @@ func TestListTransactionsByMethod(t *testing.T) {
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected '%v' got '%v'\n", tc.desc, tc.err, err))
 	}
 }
+
+func TestReceipt(t *testing.T) {
+	svc := newService()
+
+	ctx := context.Background()
+	transaction, err := svc.Record(ctx, transaction)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	token := transactions.ReceiptToken(receiptsSecret, transaction.ID)
+	assert.Len(t, token, 22, "expected a 16 bytes token encoded in base64url")
+
+	const op errors.Op = "app/transactions/service.Receipt"
+
+	cases := []struct {
+		desc     string
+		identity string
+		token    string
+		err      error
+	}{
+		{
+			desc:     "retrieve receipt of existing transaction",
+			identity: transaction.ID,
+			token:    token,
+			err:      nil,
+		},
+		{
+			desc:     "retrieve receipt with the token of another transaction",
+			identity: transaction.ID,
+			token:    transactions.ReceiptToken(receiptsSecret, wrong),
+			err:      errors.E(op, "transaction not found", errors.KindNotFound),
+		},
+		{
+			desc:     "retrieve receipt without token",
+			identity: transaction.ID,
+			err:      errors.E(op, "transaction not found", errors.KindNotFound),
+		},
+		{
+			desc:     "retrieve receipt of non-existing transaction",
+			identity: wrong,
+			token:    transactions.ReceiptToken(receiptsSecret, wrong),
+			err:      errors.E(op, "transaction not found"),
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		receipt, err := svc.Receipt(ctx, tc.identity, tc.token)
+		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, transaction.Amount, receipt.Amount, tc.desc)
+			assert.Len(t, receipt.Items, 1, tc.desc)
+			assert.Equal(t, transactions.ReceiptURL(receiptsURL, transaction.ID, token), receipt.URL, tc.desc)
+		}
+	}
+}
+
+func TestMaskReceipt(t *testing.T) {
+	receipt := transactions.Receipt{Owner: "rugwiro james", MSISDN: "+250784677882"}
+
+	masked := receipt.Mask()
+	assert.Equal(t, "r*** j***", masked.Owner)
+	assert.Equal(t, "**********882", masked.MSISDN)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 )
 
 // Role represents user access level
@@ -73,6 +74,11 @@ func (ent *Agent) Validate() error {
 	if ent.Telephone == "" {
 		return errors.E(op, "invalid agent: missing phone", errors.KindBadRequest)
 	}
+	// the telephone doubles as the agent username, it is checked but kept
+	// the way the agent logs in
+	if !msisdn.Valid(ent.Telephone) {
+		return errors.E(op, "invalid agent: invalid phone number", errors.KindBadRequest)
+	}
 	if ent.FirstName == "" || ent.LastName == "" {
 		return errors.E(op, "invalid agent: missing names", errors.KindBadRequest)
 	}
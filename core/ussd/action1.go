@@ -4,10 +4,10 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/platypus"
 )
 
@@ -19,8 +19,9 @@ func (svc *service) Action1(ctx context.Context, cmd *platypus.Command) (platypu
 
 	params := platypus.ParamsFromContext(ctx)
 
-	cmd.Phone = strings.TrimPrefix(cmd.Phone, "25")
-	cmd.Phone = strings.TrimPrefix(cmd.Phone, "+25")
+	// owners are stored with normalized numbers, agents aren't
+	dialed := cmd.Phone
+	cmd.Phone = msisdn.Normalize(cmd.Phone)
 
 	leaf, err := params.GetBool("isleaf")
 	if err != nil {
@@ -31,7 +32,7 @@ func (svc *service) Action1(ctx context.Context, cmd *platypus.Command) (platypu
 	if err != nil {
 		switch errors.Kind(err) {
 		case errors.KindNotFound:
-			_, err = svc.agents.RetrieveAgent(ctx, cmd.Phone)
+			_, err = svc.retrieveAgent(ctx, dialed)
 			if err != nil {
 				return platypus.Result{Out: "Ntabwo wemerewe gukora iki gikorwa", Leaf: true}, errors.E(op, fmt.Errorf("error: %v:%v", err, cmd.Phone))
 			}
@@ -123,6 +124,7 @@ func (svc *service) ActionPreview(ctx context.Context, cmd *platypus.Command) (p
 		} else {
 			in += fmt.Sprintf("\n3. Kwemeza Kwishyura ikirarane cy' amezi (%d)"+" angana:%sRWF", invoices.Total, strconv.Itoa(int(invoices.TotalAmount)))
 		}
+		in += penaltyNote(invoices.Invoices)
 	}
 	out := fmt.Sprintf(
 		in,
@@ -345,14 +347,15 @@ func (svc *service) Action1_1_1_1_3(ctx context.Context, cmd *platypus.Command)
 
 	if len(invoices.Invoices) > 0 {
 		if invoices.Total == 1 {
-			out = fmt.Sprintf("%s cyukwezi kumwe kwa %d bingana na:%d (RWF)\n 1. kwemeza kwishyura", out, invoices.Invoices[0].CreatedAt.Month(), int(invoices.Invoices[0].Amount))
+			out = fmt.Sprintf("%s cyukwezi kumwe kwa %d bingana na:%d (RWF)\n 1. kwemeza kwishyura", out, invoices.Invoices[0].CreatedAt.Month(), int(invoices.Invoices[0].Balance()))
 		} else {
 			var amount int64
 			for _, invoice := range invoices.Invoices {
-				amount += int64(invoice.Amount)
+				amount += int64(invoice.Balance())
 			}
 			out = fmt.Sprintf("%s cyamezi %d bigana na:%d (RWF)\n 1. kwemeza kwishyura", out, invoices.Total, amount)
 		}
+		out += penaltyNote(invoices.Invoices)
 	}
 
 	return platypus.Result{Out: out, Leaf: leaf}, nil
@@ -532,10 +535,7 @@ func (svc *service) matchProperty(ctx context.Context, id, phone string) (string
 
 	codeIndex, err := strconv.Atoi(id)
 	if err == nil && codeIndex <= 10 {
-		phone = strings.TrimPrefix(phone, "25")
-		phone = strings.TrimPrefix(phone, "+25")
-
-		owner, err := svc.owners.RetrieveByPhone(ctx, phone)
+		owner, err := svc.owners.RetrieveByPhone(ctx, msisdn.Normalize(phone))
 		if err != nil {
 			return "", fmt.Errorf("failed to retrieve owner by phone: %w", err)
 		}
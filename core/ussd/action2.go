@@ -9,6 +9,7 @@ import (
 	"github.com/nshimiyimanaamani/paypack-backend/core/owners"
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/platypus"
 )
 
@@ -171,6 +172,7 @@ func (svc *service) action2_payment_preview(ctx context.Context, cmd *platypus.C
 		} else {
 			in += fmt.Sprintf("\n3. Kwemeza Kwishyura ikirarane cy' amezi (%d)"+" angana:%sRWF", invoices.Total, strconv.Itoa(int(invoices.TotalAmount)))
 		}
+		in += penaltyNote(invoices.Invoices)
 	}
 
 	out := fmt.Sprintf(
@@ -207,7 +209,7 @@ func (svc *service) action2_1(ctx context.Context, cmd *platypus.Command) (platy
 		return platypus.Result{}, errors.E(op, err, errors.KindNotFound)
 	}
 
-	owner, err = svc.owners.RetrieveByPhone(ctx, owner.Phone)
+	owner, err = svc.owners.RetrieveByPhone(ctx, msisdn.Normalize(owner.Phone))
 	if err != nil {
 		switch errors.Kind(err) {
 		case errors.KindNotFound:
@@ -272,14 +274,15 @@ func (svc *service) Action2_phone_house_prev(ctx context.Context, cmd *platypus.
 
 	if len(invoices.Invoices) > 0 {
 		if invoices.Total == 1 {
-			out = fmt.Sprintf("%s cyukwezi kumwe kwa %d bingana na:%d (RWF)\n 1. kwemeza kwishyura", out, invoices.Invoices[0].CreatedAt.Month(), int64(invoices.Invoices[0].Amount))
+			out = fmt.Sprintf("%s cyukwezi kumwe kwa %d bingana na:%d (RWF)\n 1. kwemeza kwishyura", out, invoices.Invoices[0].CreatedAt.Month(), int64(invoices.Invoices[0].Balance()))
 		} else {
 			var amount int64
 			for _, invoice := range invoices.Invoices {
-				amount += int64(invoice.Amount)
+				amount += int64(invoice.Balance())
 			}
 			out = fmt.Sprintf("%s cyamezi %d bigana na:%d (RWF)\n 1. kwemeza kwishyura", out, invoices.Total, amount)
 		}
+		out += penaltyNote(invoices.Invoices)
 	}
 
 	return platypus.Result{Out: out, Leaf: leaf}, nil
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
 	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
@@ -57,3 +58,43 @@ func (svc *paymentMock) CreditPull(ctx context.Context, tx *payment.TxRequest, i
 	const op errors.Op = "core/ussd/mocks/paymentMock.CreditPull"
 	return nil, errors.E(op, errors.KindNotImplemented)
 }
+
+func (svc *paymentMock) VerifyCallback(ctx context.Context, sc *payment.SignedCallback) (payment.Callback, error) {
+	const op errors.Op = "core/ussd/mocks/paymentMock.VerifyCallback"
+	return payment.Callback{}, errors.E(op, errors.KindNotImplemented)
+}
+
+func (svc *paymentMock) ReleaseCallback(ctx context.Context, cb payment.Callback) error {
+	const op errors.Op = "core/ussd/mocks/paymentMock.ReleaseCallback"
+	return errors.E(op, errors.KindNotImplemented)
+}
+
+func (svc *paymentMock) RejectedCallbacks(ctx context.Context, offset, limit uint64) (payment.RejectedCallbackPage, error) {
+	const op errors.Op = "core/ussd/mocks/paymentMock.RejectedCallbacks"
+	return payment.RejectedCallbackPage{}, errors.E(op, errors.KindNotImplemented)
+}
+
+func (svc *paymentMock) Reconcile(ctx context.Context, age time.Duration, bsize uint64) (payment.Report, error) {
+	const op errors.Op = "core/ussd/mocks/paymentMock.Reconcile"
+	return payment.Report{}, errors.E(op, errors.KindNotImplemented)
+}
+
+func (svc *paymentMock) Discrepancies(ctx context.Context, offset, limit uint64) (payment.DiscrepancyPage, error) {
+	const op errors.Op = "core/ussd/mocks/paymentMock.Discrepancies"
+	return payment.DiscrepancyPage{}, errors.E(op, errors.KindNotImplemented)
+}
+
+func (svc *paymentMock) Backends(ctx context.Context) ([]payment.BackendHealth, error) {
+	const op errors.Op = "core/ussd/mocks/paymentMock.Backends"
+	return nil, errors.E(op, errors.KindNotImplemented)
+}
+
+func (svc *paymentMock) Expire(ctx context.Context, age time.Duration) ([]string, error) {
+	const op errors.Op = "core/ussd/mocks/paymentMock.Expire"
+	return nil, errors.E(op, errors.KindNotImplemented)
+}
+
+func (svc *paymentMock) Timeline(ctx context.Context, ref, namespace string) (payment.Timeline, error) {
+	const op errors.Op = "core/ussd/mocks/paymentMock.Timeline"
+	return payment.Timeline{}, errors.E(op, errors.KindNotImplemented)
+}
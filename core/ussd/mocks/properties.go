@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
@@ -65,6 +66,28 @@ func (str *repository) Update(ctx context.Context, property properties.Property)
 	return nil
 }
 
+func (str *repository) ChangeDue(ctx context.Context, tc *properties.TariffChange) error {
+	const op errors.Op = "app/properties/mocks/repository.ChangeDue"
+
+	str.mu.Lock()
+	defer str.mu.Unlock()
+
+	property, ok := str.properties[tc.Property]
+	if !ok {
+		return errors.E(op, "property not found", errors.KindNotFound)
+	}
+
+	str.counter++
+	tc.ID = strconv.FormatUint(str.counter, 10)
+	tc.Previous = property.Due
+	tc.CreatedAt = time.Now()
+
+	property.Due = tc.Due
+	str.properties[property.ID] = property
+
+	return nil
+}
+
 func (str *repository) Delete(ctx context.Context, uid string) error {
 	const op errors.Op = "app/properties/mocks/repository.UpdateProperty"
 
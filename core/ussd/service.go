@@ -13,6 +13,7 @@ import (
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
 	"github.com/nshimiyimanaamani/paypack-backend/core/users"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/platypus"
 )
 
@@ -125,6 +126,9 @@ func register(prefix string, svc *service, mux *platypus.Mux) *platypus.Mux {
 	return mux
 }
 
+// sessionKey carries the ussd session id through the request context
+type sessionKey struct{}
+
 func (svc *service) Process(ctx context.Context, req *Request) (Response, error) {
 	const op errors.Op = "core/ussd/service.Process"
 
@@ -133,6 +137,8 @@ func (svc *service) Process(ctx context.Context, req *Request) (Response, error)
 	// }
 	cmd := platypus.NewCommand(req.Msisdn, req.UserInput)
 
+	ctx = context.WithValue(ctx, sessionKey{}, req.SessionID)
+
 	result, err := svc.mux.Process(ctx, cmd)
 	if err != nil {
 		return respond(svc.idp.ID(), result, req), errors.E(op, err)
@@ -143,16 +149,15 @@ func (svc *service) Process(ctx context.Context, req *Request) (Response, error)
 
 func (svc *service) Pay(ctx context.Context, p properties.Property, phone string) (string, error) {
 
-	//remove country code prefix so that payment can be able to pass through on the payment gateway(paypack-payments)
-	phone = strings.TrimPrefix(phone, "25")
-	phone = strings.TrimPrefix(phone, "+25")
-
 	tx := &payment.TxRequest{
 		Code:   p.ID,
 		MSISDN: phone,
 		Amount: p.Due,
 		Method: SelectMethod(phone),
 	}
+
+	tx.IdempotencyKey = idempotencyKey(ctx, p)
+
 	status, err := svc.payment.Pull(ctx, tx)
 	if err != nil {
 		return status.Message, err
@@ -162,9 +167,6 @@ func (svc *service) Pay(ctx context.Context, p properties.Property, phone string
 
 func (svc *service) BulkPay(ctx context.Context, p properties.Property, phone string, month int) (string, error) {
 
-	phone = strings.TrimPrefix(phone, "25")
-	phone = strings.TrimPrefix(phone, "+25")
-
 	tx := &payment.TxRequest{
 		Code:   p.ID,
 		MSISDN: phone,
@@ -172,6 +174,8 @@ func (svc *service) BulkPay(ctx context.Context, p properties.Property, phone st
 		Method: SelectMethod(phone),
 	}
 
+	tx.IdempotencyKey = idempotencyKey(ctx, p)
+
 	status, err := svc.payment.BulkPull(ctx, tx, month)
 	if err != nil {
 		return status.Message, err
@@ -184,9 +188,6 @@ func (svc *service) CreditPay(ctx context.Context, p properties.Property, phone
 
 	const op errors.Op = "core/ussd/service.CreditPay"
 
-	phone = strings.TrimPrefix(phone, "25")
-	phone = strings.TrimPrefix(phone, "+25")
-
 	tx := &payment.TxRequest{
 		Code:   p.ID,
 		MSISDN: phone,
@@ -198,6 +199,8 @@ func (svc *service) CreditPay(ctx context.Context, p properties.Property, phone
 		return "Ntabirarane mufite bibanditseho", errors.E(op, "no unpaid invoices found", errors.KindNotFound)
 	}
 
+	tx.IdempotencyKey = idempotencyKey(ctx, p)
+
 	status, err := svc.payment.CreditPull(ctx, tx, invoices)
 	if err != nil {
 		return status.Message, err
@@ -205,6 +208,62 @@ func (svc *service) CreditPay(ctx context.Context, p properties.Property, phone
 	return status.Message, nil
 }
 
+// retrieveAgent finds the agent dialing from phone. Agents are looked up
+// by their username which keeps the number the way they log in with, so
+// the number is tried as dialed, in its local form and in E.164.
+func (svc *service) retrieveAgent(ctx context.Context, phone string) (users.Agent, error) {
+	const op errors.Op = "core/ussd/service.retrieveAgent"
+
+	candidates := []string{phone}
+	if number, err := msisdn.Parse(phone); err == nil {
+		candidates = append(candidates, number.Local(), number.String(), strings.TrimPrefix(number.String(), "+"))
+	}
+
+	var err error
+
+	tried := make(map[string]bool)
+	for _, candidate := range candidates {
+		if tried[candidate] {
+			continue
+		}
+		tried[candidate] = true
+
+		var agent users.Agent
+		agent, err = svc.agents.RetrieveAgent(ctx, candidate)
+		if err == nil {
+			return agent, nil
+		}
+		if errors.Kind(err) != errors.KindNotFound {
+			return users.Agent{}, errors.E(op, err)
+		}
+	}
+	return users.Agent{}, errors.E(op, err)
+}
+
+// idempotencyKey ties a payment to the session it was confirmed in, a
+// session is confirmed once and resubmissions must not charge again
+func idempotencyKey(ctx context.Context, p properties.Property) string {
+	session, ok := ctx.Value(sessionKey{}).(string)
+	if !ok || session == "" {
+		return ""
+	}
+	return fmt.Sprintf("ussd:%s:%s", session, p.ID)
+}
+
+// penaltyNote tells how much of the arrears are late payment penalties
+func penaltyNote(items []invoices.Invoice) string {
+	var amount int64
+	for _, invoice := range items {
+		if invoice.IsPenalty() {
+			amount += int64(invoice.Balance())
+		}
+	}
+	if amount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n(harimo ibihano by'ubukererwe:%dRWF)", amount)
+}
+
 func sequence(res platypus.Result) int {
 	if res.Tail() {
 		return 0
@@ -225,27 +284,7 @@ func respond(ref string, result platypus.Result, req *Request) Response {
 	}
 }
 
-// SelectMethod selects payment method based on
+// SelectMethod selects the payment method of the operator phone belongs to
 func SelectMethod(phone string) payment.Method {
-	phone = NormalizePhoneNumber(phone)
-
-	if strings.HasPrefix(phone, "25073") || strings.HasPrefix(phone, "25072") {
-		return payment.AIRTEL
-	}
-	if strings.HasPrefix(phone, "25078") || strings.HasPrefix(phone, "25079") {
-		return payment.MTN
-	}
-	return ""
-}
-
-// NormalizePhoneNumber ...
-func NormalizePhoneNumber(phone string) string {
-	const op errors.Op = "core/ussd/NormalizePhone"
-	if len(phone) < 10 || len(phone) > 12 {
-		return ""
-	}
-	if len(phone) == 10 {
-		return fmt.Sprintf("%s%s", "25", phone)
-	}
-	return phone
+	return payment.SelectMethod(phone)
 }
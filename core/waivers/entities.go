@@ -0,0 +1,116 @@
+package waivers
+
+import (
+	"math"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// DayLayout is the layout of the first and last days of a waiver
+const DayLayout = "2006-01-02"
+
+// Status of a waiver
+type Status string
+
+// possible waiver states
+const (
+	Pending  Status = "pending"
+	Approved Status = "approved"
+	Rejected Status = "rejected"
+)
+
+// Waiver discounts the invoices of a property whose billing period starts
+// between From and To, both days included. A waiver of 100 percent exempts
+// the property altogether. It only applies once a manager approved it.
+type Waiver struct {
+	ID          string    `json:"id,omitempty"`
+	Property    string    `json:"property,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Percent     float64   `json:"percent,omitempty"`
+	From        time.Time `json:"from,omitempty"`
+	To          time.Time `json:"to,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Status      Status    `json:"status,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	ReviewedBy  string    `json:"reviewed_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks whether a waiver is complete, its range is truncated to
+// whole days
+func (w *Waiver) Validate() error {
+	const op errors.Op = "core/waivers/Waiver.Validate"
+
+	if w.Property == "" {
+		return errors.E(op, "missing house code", errors.KindBadRequest)
+	}
+	if w.Percent <= 0 || w.Percent > 100 {
+		return errors.E(op, "percent must be greater than zero and at most 100", errors.KindBadRequest)
+	}
+	if w.From.IsZero() || w.To.IsZero() {
+		return errors.E(op, "missing waiver period", errors.KindBadRequest)
+	}
+
+	w.From = day(w.From)
+	w.To = day(w.To)
+
+	if w.To.Before(w.From) {
+		return errors.E(op, "waiver period ends before it starts", errors.KindBadRequest)
+	}
+	if w.Reason == "" {
+		return errors.E(op, "missing reason", errors.KindBadRequest)
+	}
+	if w.RequestedBy == "" {
+		return errors.E(op, "missing requester", errors.KindBadRequest)
+	}
+	return nil
+}
+
+// Exempts tells whether the waiver discounts invoices entirely
+func (w Waiver) Exempts() bool {
+	return w.Percent >= 100
+}
+
+// Covers tells whether an invoice whose period starts on start is discounted
+func (w Waiver) Covers(start time.Time) bool {
+	start = day(start)
+	return !start.Before(w.From) && !start.After(w.To)
+}
+
+// Discount is the part of amount the waiver takes off, rounded to cents
+func (w Waiver) Discount(amount float64) float64 {
+	discount := math.Round(amount*w.Percent) / 100
+	if discount > amount {
+		return amount
+	}
+	return discount
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Filters narrows down listed waivers, nil filters are ignored
+type Filters struct {
+	Namespace *string
+	Property  *string
+	Status    *string
+	Offset    uint64
+	Limit     uint64
+}
+
+// PageMetadata ...
+type PageMetadata struct {
+	Total  uint64
+	Offset uint64
+	Limit  uint64
+}
+
+// Page is a list of waivers
+type Page struct {
+	PageMetadata
+	Waivers []Waiver `json:"waivers"`
+}
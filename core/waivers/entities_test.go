@@ -0,0 +1,105 @@
+package waivers_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	from := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc   string
+		waiver waivers.Waiver
+		kind   int
+	}{
+		{
+			desc:   "validate exemption",
+			waiver: waivers.Waiver{Property: "1", Percent: 100, From: from, To: to, Reason: "ubudehe", RequestedBy: "agent"},
+		},
+		{
+			desc:   "validate partial waiver",
+			waiver: waivers.Waiver{Property: "1", Percent: 50, From: from, To: from, Reason: "ubudehe", RequestedBy: "agent"},
+		},
+		{
+			desc:   "validate waiver without property",
+			waiver: waivers.Waiver{Percent: 100, From: from, To: to, Reason: "ubudehe", RequestedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate waiver without percent",
+			waiver: waivers.Waiver{Property: "1", From: from, To: to, Reason: "ubudehe", RequestedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate waiver above 100 percent",
+			waiver: waivers.Waiver{Property: "1", Percent: 101, From: from, To: to, Reason: "ubudehe", RequestedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate waiver ending before it starts",
+			waiver: waivers.Waiver{Property: "1", Percent: 100, From: to, To: from, Reason: "ubudehe", RequestedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+		{
+			desc:   "validate waiver without reason",
+			waiver: waivers.Waiver{Property: "1", Percent: 100, From: from, To: to, RequestedBy: "agent"},
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.waiver.Validate()
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	from := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc     string
+		percent  float64
+		start    time.Time
+		covered  bool
+		discount float64
+	}{
+		{
+			desc:     "exempt invoice in range",
+			percent:  100,
+			start:    from,
+			covered:  true,
+			discount: 1500,
+		},
+		{
+			desc:     "discount invoice on the last day",
+			percent:  33.33,
+			start:    to.Add(12 * time.Hour),
+			covered:  true,
+			discount: 499.95,
+		},
+		{
+			desc:     "leave invoice out of range",
+			percent:  50,
+			start:    to.AddDate(0, 0, 1),
+			discount: 750,
+		},
+	}
+
+	for _, tc := range cases {
+		w := waivers.Waiver{Percent: tc.percent, From: from, To: to}
+		assert.Equal(t, tc.covered, w.Covers(tc.start), fmt.Sprintf("%s: expected covered to be %v", tc.desc, tc.covered))
+		assert.Equal(t, tc.discount, w.Discount(1500), fmt.Sprintf("%s: expected %f got %f", tc.desc, tc.discount, w.Discount(1500)))
+	}
+}
@@ -0,0 +1,114 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (waivers.Repository) = (*repositoryMock)(nil)
+
+type repositoryMock struct {
+	mu      sync.Mutex
+	waivers map[string]waivers.Waiver
+}
+
+// NewRepository creates an in memory mock of waivers.Repository
+func NewRepository() waivers.Repository {
+	return &repositoryMock{
+		waivers: make(map[string]waivers.Waiver),
+	}
+}
+
+func (repo *repositoryMock) Save(ctx context.Context, w *waivers.Waiver) error {
+	const op errors.Op = "core/waivers/mocks/repositoryMock.Save"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.waivers[w.ID]; ok {
+		return errors.E(op, "waiver already exists", errors.KindAlreadyExists)
+	}
+
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+	repo.waivers[w.ID] = *w
+	return nil
+}
+
+func (repo *repositoryMock) Find(ctx context.Context, id string) (waivers.Waiver, error) {
+	const op errors.Op = "core/waivers/mocks/repositoryMock.Find"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	w, ok := repo.waivers[id]
+	if !ok {
+		return waivers.Waiver{}, errors.E(op, "waiver not found", errors.KindNotFound)
+	}
+	return w, nil
+}
+
+func (repo *repositoryMock) List(ctx context.Context, flts *waivers.Filters) (waivers.Page, error) {
+	const op errors.Op = "core/waivers/mocks/repositoryMock.List"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	items := make([]waivers.Waiver, 0)
+	for _, w := range repo.waivers {
+		if flts.Namespace != nil && w.Namespace != *flts.Namespace {
+			continue
+		}
+		if flts.Property != nil && w.Property != *flts.Property {
+			continue
+		}
+		if flts.Status != nil && string(w.Status) != *flts.Status {
+			continue
+		}
+		items = append(items, w)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	page := waivers.Page{
+		PageMetadata: waivers.PageMetadata{
+			Total:  uint64(len(items)),
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+		},
+		Waivers: []waivers.Waiver{},
+	}
+
+	for i, w := range items {
+		if uint64(i) >= flts.Offset && uint64(i) < flts.Offset+flts.Limit {
+			page.Waivers = append(page.Waivers, w)
+		}
+	}
+	return page, nil
+}
+
+func (repo *repositoryMock) Review(ctx context.Context, w waivers.Waiver) error {
+	const op errors.Op = "core/waivers/mocks/repositoryMock.Review"
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	prev, ok := repo.waivers[w.ID]
+	if !ok || prev.Status != waivers.Pending {
+		return errors.E(op, "waiver was already reviewed", errors.KindAlreadyExists)
+	}
+
+	prev.Status = w.Status
+	prev.ReviewedBy = w.ReviewedBy
+	prev.Note = w.Note
+	prev.UpdatedAt = time.Now()
+	repo.waivers[w.ID] = prev
+	return nil
+}
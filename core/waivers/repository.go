@@ -0,0 +1,21 @@
+package waivers
+
+import "context"
+
+// Repository stores waivers
+type Repository interface {
+	// Save a new waiver
+	Save(ctx context.Context, w *Waiver) error
+
+	// Find a waiver by id
+	Find(ctx context.Context, id string) (Waiver, error)
+
+	// List waivers starting with the most recent
+	List(ctx context.Context, flts *Filters) (Page, error)
+
+	// Review moves a pending waiver to the status of w. It fails if the
+	// waiver was already reviewed. An approved waiver discounts right away
+	// the open invoices it covers, later invoices are discounted as they
+	// are generated.
+	Review(ctx context.Context, w Waiver) error
+}
@@ -0,0 +1,147 @@
+package waivers
+
+import (
+	"context"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// Service exposes the waiver usecases
+type Service interface {
+	// Request a waiver, it applies once approved
+	Request(ctx context.Context, w Waiver) (Waiver, error)
+
+	// Approve a pending waiver, the requester can't approve it
+	Approve(ctx context.Context, id, reviewer string) (Waiver, error)
+
+	// Reject a pending waiver with an optional note
+	Reject(ctx context.Context, id, reviewer, note string) (Waiver, error)
+
+	// Retrieve a waiver
+	Retrieve(ctx context.Context, id string) (Waiver, error)
+
+	// List waivers
+	List(ctx context.Context, flts *Filters) (Page, error)
+}
+
+// Options contains waivers.Service creation options
+type Options struct {
+	Idp        identity.Provider
+	Repository Repository
+	Properties properties.Repository
+}
+
+type service struct {
+	idp        identity.Provider
+	repo       Repository
+	properties properties.Repository
+}
+
+// New instantiates the waivers.Service
+func New(opts *Options) Service {
+	return &service{
+		idp:        opts.Idp,
+		repo:       opts.Repository,
+		properties: opts.Properties,
+	}
+}
+
+func (svc *service) Request(ctx context.Context, w Waiver) (Waiver, error) {
+	const op errors.Op = "core/waivers/service.Request"
+
+	if err := w.Validate(); err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+
+	property, err := svc.properties.RetrieveByID(ctx, w.Property)
+	if err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+
+	// staff only waive the houses of their own sector
+	if w.Namespace != "" && w.Namespace != property.Namespace {
+		return Waiver{}, errors.E(op, "property not found", errors.KindNotFound)
+	}
+
+	w.ID = svc.idp.ID()
+	w.Namespace = property.Namespace
+	w.Status = Pending
+	w.ReviewedBy = ""
+	w.Note = ""
+
+	if err := svc.repo.Save(ctx, &w); err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+	return w, nil
+}
+
+func (svc *service) Approve(ctx context.Context, id, reviewer string) (Waiver, error) {
+	const op errors.Op = "core/waivers/service.Approve"
+
+	w, err := svc.review(ctx, id, reviewer, Approved, "")
+	if err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+	return w, nil
+}
+
+func (svc *service) Reject(ctx context.Context, id, reviewer, note string) (Waiver, error) {
+	const op errors.Op = "core/waivers/service.Reject"
+
+	w, err := svc.review(ctx, id, reviewer, Rejected, note)
+	if err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+	return w, nil
+}
+
+func (svc *service) review(ctx context.Context, id, reviewer string, status Status, note string) (Waiver, error) {
+	const op errors.Op = "core/waivers/service.review"
+
+	if reviewer == "" {
+		return Waiver{}, errors.E(op, "missing reviewer", errors.KindBadRequest)
+	}
+
+	w, err := svc.repo.Find(ctx, id)
+	if err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+	if w.Status != Pending {
+		return Waiver{}, errors.E(op, "waiver was already reviewed", errors.KindAlreadyExists)
+	}
+
+	// money is given up only when two people agreed on it
+	if status == Approved && reviewer == w.RequestedBy {
+		return Waiver{}, errors.E(op, "a waiver can't be approved by its requester", errors.KindAccessDenied)
+	}
+
+	w.Status = status
+	w.ReviewedBy = reviewer
+	w.Note = note
+	if err := svc.repo.Review(ctx, w); err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+	return w, nil
+}
+
+func (svc *service) Retrieve(ctx context.Context, id string) (Waiver, error) {
+	const op errors.Op = "core/waivers/service.Retrieve"
+
+	w, err := svc.repo.Find(ctx, id)
+	if err != nil {
+		return Waiver{}, errors.E(op, err)
+	}
+	return w, nil
+}
+
+func (svc *service) List(ctx context.Context, flts *Filters) (Page, error) {
+	const op errors.Op = "core/waivers/service.List"
+
+	page, err := svc.repo.List(ctx, flts)
+	if err != nil {
+		return Page{}, errors.E(op, err)
+	}
+	return page, nil
+}
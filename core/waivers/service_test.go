@@ -0,0 +1,178 @@
+package waivers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	pmocks "github.com/nshimiyimanaamani/paypack-backend/core/properties/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers"
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers/mocks"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	owner     = "owner"
+	namespace = "kigali.gasabo.remera"
+)
+
+func newService(t *testing.T) (waivers.Service, properties.Property) {
+	props := pmocks.NewRepository(owner)
+
+	property, err := props.Save(context.Background(), properties.Property{
+		Due:       1000,
+		Namespace: namespace,
+		Owner:     properties.Owner{ID: owner},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	svc := waivers.New(&waivers.Options{
+		Idp:        mocks.NewIdentityProvider(),
+		Repository: mocks.NewRepository(),
+		Properties: props,
+	})
+	return svc, property
+}
+
+func newWaiver(property string) waivers.Waiver {
+	return waivers.Waiver{
+		Property:    property,
+		Percent:     100,
+		From:        time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2021, time.December, 31, 0, 0, 0, 0, time.UTC),
+		Reason:      "ubudehe category 1",
+		RequestedBy: "agent",
+	}
+}
+
+func TestRequest(t *testing.T) {
+	svc, property := newService(t)
+
+	other := newWaiver(property.ID)
+	other.Namespace = "kigali.gasabo.kimironko"
+
+	invalid := newWaiver(property.ID)
+	invalid.Percent = 120
+
+	cases := []struct {
+		desc   string
+		waiver waivers.Waiver
+		kind   int
+	}{
+		{
+			desc:   "request waiver",
+			waiver: newWaiver(property.ID),
+		},
+		{
+			desc:   "request waiver for unknown property",
+			waiver: newWaiver("unknown"),
+			kind:   errors.KindNotFound,
+		},
+		{
+			desc:   "request waiver for property of another namespace",
+			waiver: other,
+			kind:   errors.KindNotFound,
+		},
+		{
+			desc:   "request invalid waiver",
+			waiver: invalid,
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := svc.Request(context.Background(), tc.waiver)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, waivers.Pending, res.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, waivers.Pending, res.Status))
+		assert.Equal(t, namespace, res.Namespace, fmt.Sprintf("%s: expected %s got %s", tc.desc, namespace, res.Namespace))
+	}
+}
+
+func TestReview(t *testing.T) {
+	svc, property := newService(t)
+
+	ctx := context.Background()
+
+	approved, err := svc.Request(ctx, newWaiver(property.ID))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	rejected, err := svc.Request(ctx, newWaiver(property.ID))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc     string
+		id       string
+		reviewer string
+		approve  bool
+		status   waivers.Status
+		kind     int
+	}{
+		{
+			desc:     "approve own waiver",
+			id:       approved.ID,
+			reviewer: "agent",
+			approve:  true,
+			kind:     errors.KindAccessDenied,
+		},
+		{
+			desc:     "approve waiver",
+			id:       approved.ID,
+			reviewer: "manager",
+			approve:  true,
+			status:   waivers.Approved,
+		},
+		{
+			desc:     "approve waiver twice",
+			id:       approved.ID,
+			reviewer: "manager",
+			approve:  true,
+			kind:     errors.KindAlreadyExists,
+		},
+		{
+			desc:     "reject waiver",
+			id:       rejected.ID,
+			reviewer: "manager",
+			status:   waivers.Rejected,
+		},
+		{
+			desc:     "reject approved waiver",
+			id:       approved.ID,
+			reviewer: "manager",
+			kind:     errors.KindAlreadyExists,
+		},
+		{
+			desc:     "review unknown waiver",
+			id:       "unknown",
+			reviewer: "manager",
+			kind:     errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		var res waivers.Waiver
+		if tc.approve {
+			res, err = svc.Approve(ctx, tc.id, tc.reviewer)
+		} else {
+			res, err = svc.Reject(ctx, tc.id, tc.reviewer, "not eligible")
+		}
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.status, res.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.status, res.Status))
+		assert.Equal(t, tc.reviewer, res.ReviewedBy, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.reviewer, res.ReviewedBy))
+	}
+
+	page, err := svc.List(ctx, &waivers.Filters{Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(2), page.Total, fmt.Sprintf("expected %d waivers got %d", 2, page.Total))
+}
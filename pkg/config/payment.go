@@ -1,12 +1,57 @@
 package config
 
-import validate "github.com/go-playground/validator/v10"
+import (
+	"time"
+
+	validate "github.com/go-playground/validator/v10"
+)
 
 // PaymentConfig ...
 type PaymentConfig struct {
-	PaymentURL string `validate:"required" envconfig:"PAYMENT_BASE_URL"`
-	Secret     string `validate:"required" envconfig:"PAYMENT_CLIENT_SECRET"`
-	AppID      string `validate:"required" envconfig:"PAYMENT_CLIENT_ID"`
+	// Backends lists the payment backends to start, either fdi or fake.
+	// The fake backend only starts in development.
+	Backends string `envconfig:"PAYMENT_BACKENDS" default:"fdi"`
+
+	// fdi credentials, required when the fdi backend is started
+	PaymentURL string `envconfig:"PAYMENT_BASE_URL"`
+	Secret     string `envconfig:"PAYMENT_CLIENT_SECRET"`
+	AppID      string `envconfig:"PAYMENT_CLIENT_ID"`
+
+	// FakeURL is the api base url the fake backend delivers callbacks to
+	FakeURL string `envconfig:"PAYMENT_FAKE_URL" default:"http://localhost:8080"`
+	// FakeOutcome of every fake transaction: successful, failed or silent
+	FakeOutcome string `envconfig:"PAYMENT_FAKE_OUTCOME" default:"successful"`
+	// FakeDelay is how long the fake backend waits before calling back
+	FakeDelay time.Duration `envconfig:"PAYMENT_FAKE_DELAY" default:"2s"`
+
+	// CallbackSecret is the shared secret used to sign gateway callbacks
+	CallbackSecret string `validate:"required" envconfig:"PAYMENT_CALLBACK_SECRET"`
+	// SettlementSecret signs daily settlements
+	SettlementSecret string `validate:"required" envconfig:"PAYMENT_SETTLEMENT_SECRET"`
+	// CallbackTolerance is how old a signed callback can be before it is rejected
+	CallbackTolerance time.Duration `envconfig:"PAYMENT_CALLBACK_TOLERANCE" default:"5m"`
+	// ReconcileAfter is how long a payment stays pending before it is reconciled with the gateway
+	ReconcileAfter time.Duration `envconfig:"PAYMENT_RECONCILE_AFTER" default:"30m"`
+	// ExpireAfter is how long a payment stays pending before it expires, it should leave reconciliation a chance to run first
+	ExpireAfter time.Duration `envconfig:"PAYMENT_EXPIRE_AFTER" default:"24h"`
+	// Routes picks the backends of every payment method, e.g. "momo-mtn-rw=fdi,momo-airtel-rw@kigali=fdi"
+	// every method is served by the first backend when it is empty
+	Routes string `envconfig:"PAYMENT_ROUTES"`
+	// ReceiptsURL is the public api url receipt links in payment sms point to, e.g. "https://paypack.rw/api",
+	// receipts aren't linked when it is empty
+	ReceiptsURL string `envconfig:"PAYMENT_RECEIPTS_URL"`
+	// ReceiptsSecret signs receipt links
+	ReceiptsSecret string `validate:"required" envconfig:"PAYMENT_RECEIPTS_SECRET"`
+	// RouteTimeout bounds a single backend call before failing over to the next backend
+	RouteTimeout time.Duration `envconfig:"PAYMENT_ROUTE_TIMEOUT" default:"30s"`
+	// CallTimeout bounds every attempt made to a backend, retries included in RouteTimeout
+	CallTimeout time.Duration `envconfig:"PAYMENT_CALL_TIMEOUT" default:"10s"`
+	// CallRetries is the number of retries of a call that failed on a transient error, a negative value turns them off
+	CallRetries int `envconfig:"PAYMENT_CALL_RETRIES" default:"2"`
+	// BreakerThreshold is the number of consecutive failures that stop calls to a backend
+	BreakerThreshold int `envconfig:"PAYMENT_BREAKER_THRESHOLD" default:"5"`
+	// BreakerCooldown is how long calls to a failing backend stop before one is let through again
+	BreakerCooldown time.Duration `envconfig:"PAYMENT_BREAKER_COOLDOWN" default:"30s"`
 }
 
 // Validate PaymentConfig
@@ -0,0 +1,2 @@
+// Package msisdn parses and validates rwandan mobile numbers
+package msisdn
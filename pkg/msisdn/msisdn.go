@@ -0,0 +1,108 @@
+package msisdn
+
+import (
+	"strings"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+// CountryCode of rwandan numbers
+const CountryCode = "250"
+
+// Operator is the mobile network a number belongs to
+type Operator string
+
+// known operators
+const (
+	MTN    Operator = "mtn"
+	Airtel Operator = "airtel"
+)
+
+// prefixes maps the first two digits of a national number to its operator
+var prefixes = map[string]Operator{
+	"78": MTN,
+	"79": MTN,
+	"72": Airtel,
+	"73": Airtel,
+}
+
+// nationalLength is the number of digits after the country code
+const nationalLength = 9
+
+// MSISDN is a mobile number in E.164 format, e.g. +250788123456
+type MSISDN string
+
+// Parse any local or international format of a rwandan mobile number,
+// spaces, dashes, dots and brackets are ignored.
+func Parse(s string) (MSISDN, error) {
+	const op errors.Op = "pkg/msisdn/Parse"
+
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	}
+
+	switch {
+	case len(digits) == len(CountryCode)+nationalLength && strings.HasPrefix(digits, CountryCode):
+		digits = digits[len(CountryCode):]
+	case len(digits) == nationalLength+1 && strings.HasPrefix(digits, "0"):
+		digits = digits[1:]
+	}
+
+	if len(digits) != nationalLength || strings.Trim(digits, "0123456789") != "" {
+		return "", errors.E(op, "invalid phone number", errors.KindBadRequest)
+	}
+	if _, ok := prefixes[digits[:2]]; !ok {
+		return "", errors.E(op, "unknown mobile operator", errors.KindBadRequest)
+	}
+	return MSISDN("+" + CountryCode + digits), nil
+}
+
+// Valid tells whether s is a rwandan mobile number
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Normalize returns the E.164 format of s or s itself when it isn't
+// a rwandan mobile number
+func Normalize(s string) string {
+	number, err := Parse(s)
+	if err != nil {
+		return s
+	}
+	return number.String()
+}
+
+// String returns the E.164 format of the number
+func (m MSISDN) String() string {
+	return string(m)
+}
+
+// Local returns the number the way it is dialed inside the country, e.g. 0788123456
+func (m MSISDN) Local() string {
+	return "0" + m.national()
+}
+
+// Operator the number belongs to
+func (m MSISDN) Operator() Operator {
+	national := m.national()
+	if len(national) < 2 {
+		return ""
+	}
+	return prefixes[national[:2]]
+}
+
+func (m MSISDN) national() string {
+	return strings.TrimPrefix(string(m), "+"+CountryCode)
+}
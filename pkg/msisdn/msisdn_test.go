@@ -0,0 +1,54 @@
+package msisdn_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/msisdn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected msisdn.MSISDN
+		operator msisdn.Operator
+		kind     int
+	}{
+		{desc: "parse local number", input: "0788123456", expected: "+250788123456", operator: msisdn.MTN},
+		{desc: "parse number without leading zero", input: "788123456", expected: "+250788123456", operator: msisdn.MTN},
+		{desc: "parse international number", input: "250788123456", expected: "+250788123456", operator: msisdn.MTN},
+		{desc: "parse E.164 number", input: "+250728123456", expected: "+250728123456", operator: msisdn.Airtel},
+		{desc: "parse number with exit code", input: "00250738123456", expected: "+250738123456", operator: msisdn.Airtel},
+		{desc: "parse formatted number", input: " +250 (79) 812-34.56 ", expected: "+250798123456", operator: msisdn.MTN},
+		{desc: "parse short number", input: "8888", kind: errors.KindBadRequest},
+		{desc: "parse number with letters", input: "07881234ab", kind: errors.KindBadRequest},
+		{desc: "parse foreign number", input: "+254712345678", kind: errors.KindBadRequest},
+		{desc: "parse landline number", input: "0252123456", kind: errors.KindBadRequest},
+		{desc: "parse empty number", input: "", kind: errors.KindBadRequest},
+	}
+
+	for _, tc := range cases {
+		number, err := msisdn.Parse(tc.input)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.expected, number, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.expected, number))
+		assert.Equal(t, tc.operator, number.Operator(), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.operator, number.Operator()))
+	}
+}
+
+func TestFormats(t *testing.T) {
+	number, err := msisdn.Parse("0788123456")
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	assert.Equal(t, "+250788123456", number.String(), "expected E.164 format")
+	assert.Equal(t, "0788123456", number.Local(), "expected local format")
+
+	assert.Equal(t, "+250788123456", msisdn.Normalize("250788123456"), "expected normalized number")
+	assert.Equal(t, "wrong_number", msisdn.Normalize("wrong_number"), "expected invalid number as is")
+}
@@ -0,0 +1,174 @@
+// Package pdf renders the printable documents handed to residents, e.g.
+// payment receipts, on top of gofpdf.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// ContentType of the rendered documents
+const ContentType = "application/pdf"
+
+const (
+	font       = "Helvetica"
+	lineHeight = 7.0
+	labelWidth = 50.0
+)
+
+// Column describes a table column, Align is one of "L", "C" or "R"
+type Column struct {
+	Header string
+	Width  float64
+	Align  string
+}
+
+// Document is an A4 document laid out top to bottom
+type Document struct {
+	pdf *gofpdf.Fpdf
+	tr  func(string) string
+	qrs int
+}
+
+// New starts a document with a title and an optional subtitle
+func New(title, subtitle string) *Document {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+
+	d := &Document{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	pdf.SetFont(font, "B", 18)
+	pdf.CellFormat(0, 10, d.tr(title), "", 1, "L", false, 0, "")
+	if subtitle != "" {
+		pdf.SetFont(font, "", 10)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(0, 6, d.tr(subtitle), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+	pdf.Ln(4)
+	return d
+}
+
+// Section starts a titled block of fields or a table
+func (d *Document) Section(title string) {
+	d.pdf.Ln(3)
+	d.pdf.SetFont(font, "B", 12)
+	d.pdf.CellFormat(0, 8, d.tr(title), "B", 1, "L", false, 0, "")
+	d.pdf.Ln(1)
+}
+
+// Field writes a labelled value on its own line
+func (d *Document) Field(label, value string) {
+	d.pdf.SetFont(font, "", 10)
+	d.pdf.SetTextColor(110, 110, 110)
+	d.pdf.CellFormat(labelWidth, lineHeight, d.tr(label), "", 0, "L", false, 0, "")
+	d.pdf.SetTextColor(0, 0, 0)
+	d.pdf.SetFont(font, "B", 10)
+	d.pdf.MultiCell(0, lineHeight, d.tr(value), "", "L", false)
+}
+
+// Text writes a paragraph
+func (d *Document) Text(text string) {
+	d.pdf.SetFont(font, "", 9)
+	d.pdf.MultiCell(0, 5, d.tr(text), "", "L", false)
+}
+
+// Table writes a table with a header row, the header is repeated when
+// the rows spill over to a new page.
+func (d *Document) Table(columns []Column, rows [][]string) {
+	header := func() {
+		d.pdf.SetFont(font, "B", 9)
+		d.pdf.SetFillColor(235, 235, 235)
+		for _, col := range columns {
+			d.pdf.CellFormat(col.Width, lineHeight, d.tr(col.Header), "1", 0, col.Align, true, 0, "")
+		}
+		d.pdf.Ln(-1)
+		d.pdf.SetFont(font, "", 9)
+	}
+
+	header()
+
+	_, pageHeight := d.pdf.GetPageSize()
+	_, _, _, bottom := d.pdf.GetMargins()
+
+	for _, row := range rows {
+		if d.pdf.GetY()+lineHeight > pageHeight-bottom-lineHeight {
+			d.pdf.AddPage()
+			header()
+		}
+		for i, col := range columns {
+			var cell string
+			if i < len(row) {
+				cell = row[i]
+			}
+			d.pdf.CellFormat(col.Width, lineHeight, d.tr(cell), "1", 0, col.Align, false, 0, "")
+		}
+		d.pdf.Ln(-1)
+	}
+}
+
+// QR draws a square QR code of the content with a caption underneath
+func (d *Document) QR(content, caption string, size float64) error {
+	const op errors.Op = "pkg/pdf/Document.QR"
+
+	png, err := qrcode.Encode(content, qrcode.Medium, 256)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	d.qrs++
+	name := fmt.Sprintf("qr-%d", d.qrs)
+
+	opts := gofpdf.ImageOptions{ImageType: "PNG"}
+	d.pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(png))
+
+	d.pdf.Ln(4)
+	left, _, _, _ := d.pdf.GetMargins()
+	d.pdf.ImageOptions(name, left, d.pdf.GetY(), size, size, true, opts, 0, "")
+
+	if caption != "" {
+		d.pdf.SetFont(font, "", 8)
+		d.pdf.SetTextColor(110, 110, 110)
+		d.pdf.MultiCell(0, 4, d.tr(caption), "", "L", false)
+		d.pdf.SetTextColor(0, 0, 0)
+	}
+	return nil
+}
+
+// Money formats an amount in whole francs with thousands separators
+func Money(amount float64) string {
+	var sign string
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := strconv.FormatInt(int64(amount+0.5), 10)
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String() + " RWF"
+}
+
+// Write renders the document
+func (d *Document) Write(w io.Writer) error {
+	const op errors.Op = "pkg/pdf/Document.Write"
+
+	if err := d.pdf.Output(w); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
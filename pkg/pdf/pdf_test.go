@@ -0,0 +1,51 @@
+package pdf_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	doc := pdf.New("Inyemezabwishyu", "kigali.gasabo.remera")
+
+	doc.Section("Payment")
+	doc.Field("Amount", "1,000 RWF")
+	doc.Field("Owner", "Umutoni Aimée")
+
+	rows := make([][]string, 100)
+	for i := range rows {
+		rows[i] = []string{"1", "2021-01", "1,000"}
+	}
+	doc.Table([]pdf.Column{
+		{Header: "Invoice", Width: 40},
+		{Header: "Month", Width: 40},
+		{Header: "Amount", Width: 40, Align: "R"},
+	}, rows)
+
+	err := doc.QR("https://paypack.rw/api/transactions/1/receipt", "scan to verify", 30)
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	err = doc.Write(&buf)
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "%PDF-"), "expected a pdf document")
+}
+
+func TestMoney(t *testing.T) {
+	cases := map[float64]string{
+		0:          "0 RWF",
+		999.5:      "1,000 RWF",
+		1234567:    "1,234,567 RWF",
+		-250000.25: "-250,000 RWF",
+	}
+
+	for amount, want := range cases {
+		assert.Equal(t, want, pdf.Money(amount), fmt.Sprintf("expected %s for %v", want, amount))
+	}
+}
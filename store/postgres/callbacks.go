@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.CallbackRepository) = (*callbackStore)(nil)
+
+type callbackStore struct {
+	*sql.DB
+}
+
+// NewCallbackRepository creates a postgres backed payment.CallbackRepository
+func NewCallbackRepository(db *sql.DB) payment.CallbackRepository {
+	return &callbackStore{db}
+}
+
+func (repo *callbackStore) Save(ctx context.Context, cb *payment.RejectedCallback) error {
+	const op errors.Op = "store/postgres/callbackStore.Save"
+
+	q := `
+		INSERT INTO rejected_callbacks (
+			route,
+			reason,
+			payload,
+			signature,
+			timestamp
+		) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		cb.Route,
+		cb.Reason,
+		cb.Payload,
+		cb.Signature,
+		cb.Timestamp,
+	).Scan(&cb.ID, &cb.CreatedAt)
+
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *callbackStore) List(ctx context.Context, offset, limit uint64) (payment.RejectedCallbackPage, error) {
+	const op errors.Op = "store/postgres/callbackStore.List"
+
+	q := `
+		SELECT
+			id,
+			route,
+			reason,
+			payload,
+			signature,
+			timestamp,
+			created_at
+		FROM
+			rejected_callbacks
+		ORDER BY created_at DESC OFFSET $1 LIMIT $2
+	`
+
+	var empty payment.RejectedCallbackPage
+
+	rows, err := repo.QueryContext(ctx, q, offset, limit)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var items = []payment.RejectedCallback{}
+
+	for rows.Next() {
+		var cb payment.RejectedCallback
+		if err := rows.Scan(
+			&cb.ID,
+			&cb.Route,
+			&cb.Reason,
+			&cb.Payload,
+			&cb.Signature,
+			&cb.Timestamp,
+			&cb.CreatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		items = append(items, cb)
+	}
+
+	q = `SELECT count(*) FROM rejected_callbacks`
+
+	var total uint64
+	if err := repo.QueryRowContext(ctx, q).Scan(&total); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := payment.RejectedCallbackPage{
+		Callbacks: items,
+		PageMetadata: payment.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}
+	return page, nil
+}
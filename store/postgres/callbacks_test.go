@@ -0,0 +1,101 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveRejectedCallback(t *testing.T) {
+	repo := postgres.NewCallbackRepository(db)
+
+	defer CleanDB(t, db)
+
+	cases := []struct {
+		desc string
+		cb   *payment.RejectedCallback
+		err  error
+	}{
+		{
+			desc: "save rejected callback",
+			cb: &payment.RejectedCallback{
+				Route:     "/payment/confirm",
+				Reason:    "invalid callback signature",
+				Payload:   `{"kind":"transaction:processed"}`,
+				Signature: "signature",
+				Timestamp: "1600000000",
+			},
+			err: nil,
+		},
+		{
+			desc: "save rejected callback without signature",
+			cb: &payment.RejectedCallback{
+				Route:  "/payment/credit/confirm",
+				Reason: "missing callback signature",
+			},
+			err: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Save(context.Background(), tc.cb)
+		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+		assert.NotEmpty(t, tc.cb.ID, fmt.Sprintf("%s: expected id to be set", tc.desc))
+	}
+}
+
+func TestListRejectedCallbacks(t *testing.T) {
+	repo := postgres.NewCallbackRepository(db)
+
+	defer CleanDB(t, db)
+
+	n := uint64(10)
+	for i := uint64(0); i < n; i++ {
+		cb := &payment.RejectedCallback{
+			Route:  "/payment/confirm",
+			Reason: "invalid callback signature",
+		}
+		err := repo.Save(context.Background(), cb)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	cases := []struct {
+		desc   string
+		offset uint64
+		limit  uint64
+		size   uint64
+	}{
+		{
+			desc:   "list all rejected callbacks",
+			offset: 0,
+			limit:  n,
+			size:   n,
+		},
+		{
+			desc:   "list half of rejected callbacks",
+			offset: n / 2,
+			limit:  n,
+			size:   n / 2,
+		},
+		{
+			desc:   "list rejected callbacks past the end",
+			offset: n,
+			limit:  n,
+			size:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.List(context.Background(), tc.offset, tc.limit)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		size := uint64(len(page.Callbacks))
+		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d", tc.desc, tc.size, size))
+		assert.Equal(t, n, page.Total, fmt.Sprintf("%s: expected total %d got %d", tc.desc, n, page.Total))
+	}
+}
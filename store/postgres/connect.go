@@ -12,6 +12,7 @@ const (
 	errFK         = "foreign_key_violation"
 	errInvalid    = "invalid_text_representation"
 	errTruncation = "string_data_right_truncation"
+	errCheck      = "check_violation"
 )
 
 // Connect creates and returns a connection to a PostgreSQl instance.
@@ -28,7 +28,7 @@ func (c *auditableCounter) Count(ctx context.Context) (int, error) {
 
 	var count int
 
-	q := `SELECT count(*) FROM one_month_old_properties_view`
+	q := `SELECT count(*) FROM billable_properties_view`
 
 	if err := c.QueryRowContext(ctx, q).Scan(&count); err != nil {
 		return 0, errors.E(op, err)
@@ -39,7 +39,7 @@ func (c *auditableCounter) Count(ctx context.Context) (int, error) {
 func (c *archivableCounter) Count(ctx context.Context) (int, error) {
 	const op errors.Op = "store/postgres/archivable.Count"
 
-	q := `SELECT count(*) FROM one_month_old_properties_view`
+	q := `SELECT count(*) FROM billable_properties_view`
 
 	var count int
 
@@ -0,0 +1,318 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (deposits.Repository) = (*depositStore)(nil)
+
+type depositStore struct {
+	*sql.DB
+}
+
+// NewDepositRepository creates a postgres backed deposits.Repository
+func NewDepositRepository(db *sql.DB) deposits.Repository {
+	return &depositStore{db}
+}
+
+func (repo *depositStore) Save(ctx context.Context, s *deposits.Statement, lines []deposits.Line) error {
+	const op errors.Op = "store/postgres/depositStore.Save"
+
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	q := `
+		INSERT INTO bank_statements (id, namespace, filename, uploaded_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	if err := tx.QueryRowContext(ctx, q, s.ID, s.Namespace, s.Filename, s.UploadedBy).Scan(&s.CreatedAt); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errDuplicate:
+				return errors.E(op, "statement already exists", errors.KindAlreadyExists)
+			case errInvalid, errTruncation:
+				return errors.E(op, "invalid statement entity", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	// deposits imported by an earlier statement are skipped
+	q = `
+		INSERT INTO statement_lines (
+			id,
+			statement,
+			namespace,
+			posted_at,
+			reference,
+			narrative,
+			amount,
+			status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING
+	`
+
+	s.Lines, s.Duplicates = 0, 0
+
+	for _, line := range lines {
+		res, err := tx.ExecContext(ctx, q,
+			line.ID,
+			s.ID,
+			s.Namespace,
+			postedAt(line.Date),
+			line.Reference,
+			line.Narrative,
+			line.Amount,
+			line.Status,
+		)
+		if err != nil {
+			pqErr, ok := err.(*pq.Error)
+			if ok {
+				switch pqErr.Code.Name() {
+				case errInvalid, errTruncation, errCheck:
+					return errors.E(op, "invalid statement line", errors.KindBadRequest)
+				}
+			}
+			return errors.E(op, err, errors.KindUnexpected)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return errors.E(op, err, errors.KindUnexpected)
+		}
+		if n == 0 {
+			s.Duplicates++
+			continue
+		}
+		s.Lines++
+	}
+
+	q = `UPDATE bank_statements SET lines=$1, duplicates=$2 WHERE id=$3`
+	if _, err := tx.ExecContext(ctx, q, s.Lines, s.Duplicates, s.ID); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *depositStore) Pending(ctx context.Context, batch uint64) ([]deposits.Line, error) {
+	const op errors.Op = "store/postgres/depositStore.Pending"
+
+	q := fmt.Sprintf(`%s WHERE status=$1 ORDER BY created_at LIMIT $2`, selectLines)
+
+	rows, err := repo.QueryContext(ctx, q, deposits.Pending, batch)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	lines, err := scanLines(rows)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	return lines, nil
+}
+
+func (repo *depositStore) Line(ctx context.Context, id string) (deposits.Line, error) {
+	const op errors.Op = "store/postgres/depositStore.Line"
+
+	q := fmt.Sprintf(`%s WHERE id=$1`, selectLines)
+
+	rows, err := repo.QueryContext(ctx, q, id)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && errInvalid == pqErr.Code.Name() {
+			return deposits.Line{}, errors.E(op, "statement line not found", errors.KindNotFound)
+		}
+		return deposits.Line{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	lines, err := scanLines(rows)
+	if err != nil {
+		return deposits.Line{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	if len(lines) == 0 {
+		return deposits.Line{}, errors.E(op, "statement line not found", errors.KindNotFound)
+	}
+	return lines[0], nil
+}
+
+func (repo *depositStore) Lines(ctx context.Context, flts *deposits.Filters) (deposits.Page, error) {
+	const op errors.Op = "store/postgres/depositStore.Lines"
+
+	where, args := statementConditions(flts)
+
+	q := fmt.Sprintf(`%s %s ORDER BY created_at DESC OFFSET $%d LIMIT $%d`, selectLines, where, len(args)+1, len(args)+2)
+
+	var empty deposits.Page
+
+	rows, err := repo.QueryContext(ctx, q, append(args, flts.Offset, flts.Limit)...)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	lines, err := scanLines(rows)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(amount), 0.0) FROM statement_lines %s`, where)
+
+	var (
+		total  uint64
+		amount float64
+	)
+
+	if err := repo.QueryRowContext(ctx, q, args...).Scan(&total, &amount); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := deposits.Page{
+		Lines: lines,
+		PageMetadata: deposits.PageMetadata{
+			Total:  total,
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+			Amount: amount,
+		},
+	}
+	return page, nil
+}
+
+func (repo *depositStore) Transition(ctx context.Context, line deposits.Line, from deposits.LineStatus) error {
+	const op errors.Op = "store/postgres/depositStore.Transition"
+
+	q := `
+		UPDATE statement_lines SET
+			status=$1,
+			property=$2,
+			reason=$3,
+			reviewed_by=$4
+		WHERE id=$5 AND status=$6
+	`
+
+	res, err := repo.ExecContext(ctx, q, line.Status, line.Property, line.Reason, line.ReviewedBy, line.ID, from)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errInvalid, errTruncation, errCheck:
+				return errors.E(op, "invalid statement line", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if n == 0 {
+		return errors.E(op, "statement line was already resolved", errors.KindAlreadyExists)
+	}
+	return nil
+}
+
+const selectLines = `
+	SELECT
+		id,
+		statement,
+		namespace,
+		posted_at,
+		reference,
+		narrative,
+		amount,
+		status,
+		property,
+		reason,
+		reviewed_by,
+		created_at,
+		updated_at
+	FROM
+		statement_lines
+`
+
+func scanLines(rows *sql.Rows) ([]deposits.Line, error) {
+	var lines = []deposits.Line{}
+
+	for rows.Next() {
+		var (
+			line   deposits.Line
+			posted pq.NullTime
+		)
+		if err := rows.Scan(
+			&line.ID,
+			&line.Statement,
+			&line.Namespace,
+			&posted,
+			&line.Reference,
+			&line.Narrative,
+			&line.Amount,
+			&line.Status,
+			&line.Property,
+			&line.Reason,
+			&line.ReviewedBy,
+			&line.CreatedAt,
+			&line.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		line.Date = posted.Time
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// postedAt stores statement lines without a date as null
+func postedAt(date time.Time) interface{} {
+	if date.IsZero() {
+		return nil
+	}
+	return date
+}
+
+// statementConditions builds the where clause of the statement line filters
+func statementConditions(flts *deposits.Filters) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if flts.Namespace != nil {
+		add("namespace = $%d", *flts.Namespace)
+	}
+	if flts.Status != nil {
+		add("status = $%d", *flts.Status)
+	}
+	if flts.Statement != nil {
+		add("statement = $%d", *flts.Statement)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,117 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/deposits"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveStatement(t *testing.T) {
+	repo := postgres.NewDepositRepository(db)
+
+	defer CleanDB(t, db)
+
+	date := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	first := &deposits.Statement{ID: uuid.New().ID(), Namespace: "kigali", UploadedBy: "manager"}
+	err := repo.Save(context.Background(), first, []deposits.Line{
+		newStatementLine("FT01", date),
+		newStatementLine("FT02", date),
+		newStatementLine("", time.Time{}),
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 3, first.Lines, fmt.Sprintf("expected %d lines got %d", 3, first.Lines))
+
+	// the second statement overlaps the first one
+	second := &deposits.Statement{ID: uuid.New().ID(), Namespace: "kigali", UploadedBy: "manager"}
+	err = repo.Save(context.Background(), second, []deposits.Line{
+		newStatementLine("FT02", date),
+		newStatementLine("FT03", date),
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 1, second.Lines, fmt.Sprintf("expected %d lines got %d", 1, second.Lines))
+	assert.Equal(t, 1, second.Duplicates, fmt.Sprintf("expected %d duplicates got %d", 1, second.Duplicates))
+
+	pending, err := repo.Pending(context.Background(), 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Len(t, pending, 4, fmt.Sprintf("expected %d pending lines got %d", 4, len(pending)))
+}
+
+func TestTransitionStatementLine(t *testing.T) {
+	repo := postgres.NewDepositRepository(db)
+
+	defer CleanDB(t, db)
+
+	line := newStatementLine("FT01", time.Now())
+
+	s := &deposits.Statement{ID: uuid.New().ID(), Namespace: "kigali"}
+	err := repo.Save(context.Background(), s, []deposits.Line{line})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc   string
+		id     string
+		status deposits.LineStatus
+		from   deposits.LineStatus
+		kind   int
+	}{
+		{
+			desc:   "send pending line for review",
+			id:     line.ID,
+			status: deposits.Review,
+			from:   deposits.Pending,
+		},
+		{
+			desc:   "match line that moved on",
+			id:     line.ID,
+			status: deposits.Matched,
+			from:   deposits.Pending,
+			kind:   errors.KindAlreadyExists,
+		},
+		{
+			desc:   "dismiss reviewed line",
+			id:     line.ID,
+			status: deposits.Ignored,
+			from:   deposits.Review,
+		},
+		{
+			desc:   "move unknown line",
+			id:     uuid.New().ID(),
+			status: deposits.Review,
+			from:   deposits.Pending,
+			kind:   errors.KindAlreadyExists,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Transition(context.Background(), deposits.Line{ID: tc.id, Status: tc.status, ReviewedBy: "manager"}, tc.from)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+
+	saved, err := repo.Line(context.Background(), line.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, deposits.Ignored, saved.Status, fmt.Sprintf("expected %s got %s", deposits.Ignored, saved.Status))
+}
+
+func newStatementLine(reference string, date time.Time) deposits.Line {
+	return deposits.Line{
+		ID:        uuid.New().ID(),
+		Date:      date,
+		Reference: reference,
+		Narrative: "DEPOSIT 22C95179",
+		Amount:    1000,
+		Status:    deposits.Pending,
+	}
+}
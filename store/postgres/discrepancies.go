@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.DiscrepancyRepository) = (*discrepancyStore)(nil)
+
+type discrepancyStore struct {
+	*sql.DB
+}
+
+// NewDiscrepancyRepository creates a postgres backed payment.DiscrepancyRepository
+func NewDiscrepancyRepository(db *sql.DB) payment.DiscrepancyRepository {
+	return &discrepancyStore{db}
+}
+
+func (repo *discrepancyStore) Save(ctx context.Context, d *payment.Discrepancy) error {
+	const op errors.Op = "store/postgres/discrepancyStore.Save"
+
+	q := `
+		INSERT INTO payment_discrepancies (
+			ref,
+			reason,
+			local_status,
+			remote_status,
+			local_amount,
+			remote_amount
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (ref, reason) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		d.Ref,
+		d.Reason,
+		d.LocalStatus,
+		d.RemoteStatus,
+		d.LocalAmount,
+		d.RemoteAmount,
+	).Scan(&d.ID, &d.CreatedAt)
+
+	// the discrepancy was already reported by a previous run
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *discrepancyStore) List(ctx context.Context, offset, limit uint64) (payment.DiscrepancyPage, error) {
+	const op errors.Op = "store/postgres/discrepancyStore.List"
+
+	q := `
+		SELECT
+			id,
+			ref,
+			reason,
+			local_status,
+			remote_status,
+			local_amount,
+			remote_amount,
+			created_at
+		FROM
+			payment_discrepancies
+		ORDER BY created_at DESC OFFSET $1 LIMIT $2
+	`
+
+	var empty payment.DiscrepancyPage
+
+	rows, err := repo.QueryContext(ctx, q, offset, limit)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var items = []payment.Discrepancy{}
+
+	for rows.Next() {
+		var d payment.Discrepancy
+		if err := rows.Scan(
+			&d.ID,
+			&d.Ref,
+			&d.Reason,
+			&d.LocalStatus,
+			&d.RemoteStatus,
+			&d.LocalAmount,
+			&d.RemoteAmount,
+			&d.CreatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		items = append(items, d)
+	}
+
+	q = `SELECT count(*) FROM payment_discrepancies`
+
+	var total uint64
+	if err := repo.QueryRowContext(ctx, q).Scan(&total); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := payment.DiscrepancyPage{
+		Discrepancies: items,
+		PageMetadata: payment.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}
+	return page, nil
+}
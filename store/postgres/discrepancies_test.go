@@ -0,0 +1,100 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveDiscrepancy(t *testing.T) {
+	repo := postgres.NewDiscrepancyRepository(db)
+
+	defer CleanDB(t, db)
+
+	ref := uuid.New().ID()
+
+	cases := []struct {
+		desc string
+		d    *payment.Discrepancy
+		err  error
+	}{
+		{
+			desc: "save discrepancy",
+			d: &payment.Discrepancy{
+				Ref:          ref,
+				Reason:       "amount differs from the gateway",
+				LocalStatus:  "pending",
+				RemoteStatus: "successful",
+				LocalAmount:  1000,
+				RemoteAmount: 500,
+			},
+			err: nil,
+		},
+		{
+			desc: "save already reported discrepancy",
+			d: &payment.Discrepancy{
+				Ref:    ref,
+				Reason: "amount differs from the gateway",
+			},
+			err: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Save(context.Background(), tc.d)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+
+	page, err := repo.List(context.Background(), 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(1), page.Total, fmt.Sprintf("expected total %d got %d", 1, page.Total))
+}
+
+func TestListDiscrepancies(t *testing.T) {
+	repo := postgres.NewDiscrepancyRepository(db)
+
+	defer CleanDB(t, db)
+
+	n := uint64(10)
+	for i := uint64(0); i < n; i++ {
+		d := &payment.Discrepancy{
+			Ref:    uuid.New().ID(),
+			Reason: "payment not found on the gateway",
+		}
+		err := repo.Save(context.Background(), d)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	cases := []struct {
+		desc   string
+		offset uint64
+		limit  uint64
+		size   uint64
+	}{
+		{
+			desc:   "list all discrepancies",
+			offset: 0,
+			limit:  n,
+			size:   n,
+		},
+		{
+			desc:   "list half of discrepancies",
+			offset: n / 2,
+			limit:  n,
+			size:   n / 2,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.List(context.Background(), tc.offset, tc.limit)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		size := uint64(len(page.Discrepancies))
+		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d", tc.desc, tc.size, size))
+	}
+}
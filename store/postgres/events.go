@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.EventRepository) = (*eventStore)(nil)
+
+type eventStore struct {
+	*sql.DB
+}
+
+// NewEventRepository creates a postgres backed payment.EventRepository
+func NewEventRepository(db *sql.DB) payment.EventRepository {
+	return &eventStore{db}
+}
+
+func (repo *eventStore) Record(ctx context.Context, ev *payment.Event) error {
+	const op errors.Op = "store/postgres/eventStore.Record"
+
+	if err := recordEvent(ctx, repo.DB, ev); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *eventStore) Timeline(ctx context.Context, ref string) ([]payment.Event, error) {
+	const op errors.Op = "store/postgres/eventStore.Timeline"
+
+	q := `
+		SELECT
+			id,
+			ref,
+			kind,
+			status,
+			payload,
+			created_at
+		FROM
+			payment_events
+		WHERE ref=$1
+		ORDER BY created_at, id
+	`
+
+	rows, err := repo.QueryContext(ctx, q, ref)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	events := []payment.Event{}
+	for rows.Next() {
+		var (
+			ev      payment.Event
+			payload []byte
+		)
+		if err := rows.Scan(&ev.ID, &ev.Ref, &ev.Kind, &ev.Status, &payload, &ev.CreatedAt); err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		ev.Payload = json.RawMessage(payload)
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	return events, nil
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// recordEvent appends a payment event, it runs inside the transaction that
+// changes the payment when there is one.
+func recordEvent(ctx context.Context, db rowQuerier, ev *payment.Event) error {
+	q := `
+		INSERT INTO payment_events (
+			ref,
+			kind,
+			status,
+			payload,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+
+	payload := []byte(ev.Payload)
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	return db.QueryRowContext(ctx, q, ev.Ref, ev.Kind, ev.Status, payload, ev.CreatedAt).Scan(&ev.ID, &ev.CreatedAt)
+}
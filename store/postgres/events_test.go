@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentTimeline(t *testing.T) {
+	repo := postgres.NewEventRepository(db)
+
+	defer CleanDB(t, db)
+
+	ref := uuid.New().ID()
+	started := time.Now().Add(-time.Minute)
+
+	initiated := payment.NewEvent(ref, payment.Initiated, "pending", payment.TxRequest{Amount: 1000, MSISDN: "0784607135"})
+	initiated.CreatedAt = started
+
+	events := []*payment.Event{
+		payment.NewEvent(ref, payment.GatewayAccepted, "pending", payment.TxResponse{TxID: ref, Status: "pending"}),
+		initiated,
+		payment.NewEvent(uuid.New().ID(), payment.Initiated, "pending", nil),
+	}
+
+	for _, ev := range events {
+		err := repo.Record(context.Background(), ev)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+		assert.NotEmpty(t, ev.ID, "expected an event id")
+	}
+
+	timeline, err := repo.Timeline(context.Background(), ref)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, timeline, 2)
+	assert.Equal(t, payment.Initiated, timeline[0].Kind, "expected the oldest event first")
+	assert.Equal(t, payment.GatewayAccepted, timeline[1].Kind)
+	assert.JSONEq(t, string(events[0].Payload), string(timeline[1].Payload))
+}
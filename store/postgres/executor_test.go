@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
 	"github.com/nshimiyimanaamani/paypack-backend/core/nanoid"
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
 	"github.com/nshimiyimanaamani/paypack-backend/core/users"
@@ -171,3 +172,200 @@ func TestArchiveFunc(t *testing.T) {
 
 	assert.Equal(t, exp, got, fmt.Sprintf("expected count: %d got %d", exp, got))
 }
+
+func TestAuditFuncBillingCycles(t *testing.T) {
+	exec := postgres.NewExecutor(db)
+
+	defer CleanDB(t, db)
+
+	account := accounts.Account{
+		ID:            "paypack.developers",
+		Name:          "remera",
+		NumberOfSeats: 10,
+		Type:          accounts.Devs,
+	}
+
+	account = saveAccount(t, db, account)
+
+	agent := users.Agent{
+		Telephone: random(15),
+		FirstName: "first",
+		LastName:  "last",
+		Password:  "password",
+		Cell:      "cell",
+		Sector:    "Sector",
+		Village:   "village",
+		Role:      users.Dev,
+		Account:   account.ID,
+	}
+	agent = saveAgent(t, db, agent)
+
+	owner := properties.Owner{
+		ID:    uuid.New().ID(),
+		Fname: "rugwiro",
+		Lname: "james",
+		Phone: "0784677882",
+	}
+
+	saved := saveOwner(t, db, owner)
+
+	created := tools.AddMonth(tools.BeginningOfMonth(), -13)
+
+	cases := []struct {
+		desc    string
+		billing properties.Billing
+	}{
+		{
+			desc:    "audit monthly property",
+			billing: properties.Billing{Cycle: properties.Monthly, Anchor: 1},
+		},
+		{
+			desc:    "audit quarterly property",
+			billing: properties.Billing{Cycle: properties.Quarterly, Anchor: 1},
+		},
+		{
+			desc:    "audit yearly property",
+			billing: properties.Billing{Cycle: properties.Yearly, Anchor: 1},
+		},
+	}
+
+	ids := make([]string, len(cases))
+
+	for i, tc := range cases {
+		p := properties.Property{
+			ID:    nanoid.New(nil).ID(),
+			Owner: properties.Owner{ID: saved.ID},
+			Address: properties.Address{
+				Sector:  "Kigomna",
+				Cell:    "Kigeme",
+				Village: "Tetero",
+			},
+			Billing:    tc.billing,
+			Namespace:  account.ID,
+			CreatedAt:  created,
+			UpdatedAt:  created,
+			Due:        float64(1000),
+			RecordedBy: agent.Telephone,
+			Occupied:   true,
+		}
+		ids[i] = savePropertyOn(t, db, p).ID
+	}
+
+	// auditing again within a period doesn't invoice twice
+	for i := 0; i < 2; i++ {
+		_, err := exec.AuditFunc(context.Background(), 0, 10)
+		require.Nil(t, err, fmt.Sprintf("error %v is not nil", err))
+	}
+
+	now := time.Now()
+
+	for i, tc := range cases {
+		q := `SELECT period_start, period_end FROM invoices WHERE property=$1 AND period_start <= CURRENT_DATE AND CURRENT_DATE < period_end`
+
+		var start, end time.Time
+
+		err := db.QueryRow(q, ids[i]).Scan(&start, &end)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		layout := "2006-01-02"
+		assert.Equal(t, tc.billing.PeriodStart(now).Format(layout), start.Format(layout), fmt.Sprintf("%s: unexpected period start", tc.desc))
+		assert.Equal(t, tc.billing.PeriodEnd(now).Format(layout), end.Format(layout), fmt.Sprintf("%s: unexpected period end", tc.desc))
+
+		var count int
+
+		err = db.QueryRow(`SELECT COUNT(*) FROM invoices WHERE property=$1`, ids[i]).Scan(&count)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, 2, count, fmt.Sprintf("%s: expected %d invoices got %d", tc.desc, 2, count))
+	}
+}
+
+func TestPenaltyFunc(t *testing.T) {
+	exec := postgres.NewExecutor(db)
+
+	defer CleanDB(t, db)
+
+	account := accounts.Account{
+		ID:            "paypack.developers",
+		Name:          "remera",
+		NumberOfSeats: 10,
+		Type:          accounts.Devs,
+	}
+
+	account = saveAccount(t, db, account)
+
+	agent := users.Agent{
+		Telephone: random(15),
+		FirstName: "first",
+		LastName:  "last",
+		Password:  "password",
+		Cell:      "cell",
+		Sector:    "Sector",
+		Village:   "village",
+		Role:      users.Dev,
+		Account:   account.ID,
+	}
+	agent = saveAgent(t, db, agent)
+
+	owner := properties.Owner{
+		ID:    uuid.New().ID(),
+		Fname: "rugwiro",
+		Lname: "james",
+		Phone: "0784677882",
+	}
+
+	saved := saveOwner(t, db, owner)
+
+	p := properties.Property{
+		ID:    nanoid.New(nil).ID(),
+		Owner: properties.Owner{ID: saved.ID},
+		Address: properties.Address{
+			Sector:  "Kigomna",
+			Cell:    "Kigeme",
+			Village: "Tetero",
+		},
+		Namespace:  account.ID,
+		Due:        float64(1000),
+		RecordedBy: agent.Telephone,
+		Occupied:   true,
+	}
+	p = saveProperty(t, db, p)
+
+	// an invoice whose period ended today
+	q := `
+		INSERT INTO invoices (amount, property, status, period_start, period_end)
+		VALUES ($1, $2, 'expired', CURRENT_DATE - 40, CURRENT_DATE) RETURNING id
+	`
+	var overdue uint64
+
+	err := db.QueryRow(q, p.Due, p.ID).Scan(&overdue)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	got, err := exec.PenaltyFunc(context.Background())
+	require.Nil(t, err, fmt.Sprintf("error %v is not nil", err))
+	assert.Equal(t, 0, got, "expected no penalty without a policy")
+
+	policy := accounts.PenaltyPolicy{Account: account.ID, Type: accounts.PercentagePenalty, Value: 0.5, Cap: 300, Enabled: true}
+
+	_, err = postgres.NewPenaltyRepository(db).SavePenaltyPolicy(context.Background(), policy)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	// penalties are charged once
+	for i, exp := range []int{1, 0} {
+		got, err := exec.PenaltyFunc(context.Background())
+		require.Nil(t, err, fmt.Sprintf("error %v is not nil", err))
+		assert.Equal(t, exp, got, fmt.Sprintf("run %d: expected count: %d got %d", i, exp, got))
+	}
+
+	// penalties don't expire with their period
+	_, err = exec.ArchiveFunc(context.Background(), 0, 10)
+	require.Nil(t, err, fmt.Sprintf("error %v is not nil", err))
+
+	page, err := postgres.NewInvoiceRepository(db).Unpaid(context.Background(), p.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, page.Invoices, 1)
+
+	penalty := page.Invoices[0]
+	assert.Equal(t, overdue, penalty.PenaltyOf)
+	assert.Equal(t, float64(300), penalty.Amount, "expected the capped penalty")
+	assert.Equal(t, invoices.Pending, penalty.Status)
+}
@@ -48,3 +48,18 @@ func (exec *Executor) ArchiveFunc(ctx context.Context, offset, limit int) (int,
 	}
 	return count, nil
 }
+
+// PenaltyFunc executes the penalty query function, it charges late payment
+// penalties in batches and returns how many it charged
+func (exec *Executor) PenaltyFunc(ctx context.Context) (int, error) {
+	const op errors.Op = "store/postgres/Executor.PenaltyFunc"
+
+	var count int
+
+	q := `SELECT penalty_func();`
+
+	if err := exec.QueryRowContext(ctx, q).Scan(&count); err != nil {
+		return count, errors.E(op, err, errors.KindUnexpected)
+	}
+	return count, nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (accounts.FeeRepository) = (*feeStore)(nil)
+
+type feeStore struct {
+	*sql.DB
+}
+
+// NewFeeRepository creates a postgres backed accounts.FeeRepository
+func NewFeeRepository(db *sql.DB) accounts.FeeRepository {
+	return &feeStore{db}
+}
+
+func (repo *feeStore) SaveFeeRule(ctx context.Context, rule accounts.FeeRule) (accounts.FeeRule, error) {
+	const op errors.Op = "store/postgres/feeStore.SaveFeeRule"
+
+	tiers, err := json.Marshal(rule.Tiers)
+	if err != nil {
+		return accounts.FeeRule{}, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	q := `
+		INSERT INTO fee_rules (
+			account,
+			type,
+			value,
+			tiers,
+			bearer
+		) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (account) DO UPDATE SET
+			type=EXCLUDED.type,
+			value=EXCLUDED.value,
+			tiers=EXCLUDED.tiers,
+			bearer=EXCLUDED.bearer
+		RETURNING created_at, updated_at
+	`
+
+	err = repo.QueryRowContext(ctx, q,
+		rule.Account,
+		rule.Type,
+		rule.Value,
+		tiers,
+		rule.Bearer,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errFK:
+				return accounts.FeeRule{}, errors.E(op, "account not found", errors.KindNotFound)
+			case errInvalid, errTruncation:
+				return accounts.FeeRule{}, errors.E(op, "invalid fee rule", errors.KindBadRequest)
+			}
+		}
+		return accounts.FeeRule{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return rule, nil
+}
+
+func (repo *feeStore) RetrieveFeeRule(ctx context.Context, account string) (accounts.FeeRule, error) {
+	const op errors.Op = "store/postgres/feeStore.RetrieveFeeRule"
+
+	rule, err := retrieveFeeRule(ctx, repo, account)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return accounts.FeeRule{}, errors.E(op, "fee rule not found", errors.KindNotFound)
+		}
+		return accounts.FeeRule{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return rule, nil
+}
+
+// accountFeeRule returns the fee rule applied to the transactions of an
+// account, accounts without a rule of their own aren't charged.
+func accountFeeRule(ctx context.Context, db rowQuerier, account string) (accounts.FeeRule, error) {
+	rule, err := retrieveFeeRule(ctx, db, account)
+	if err == sql.ErrNoRows {
+		return accounts.NoFeeRule(account), nil
+	}
+	return rule, err
+}
+
+func retrieveFeeRule(ctx context.Context, db rowQuerier, account string) (accounts.FeeRule, error) {
+	q := `
+		SELECT
+			account,
+			type,
+			value,
+			tiers,
+			bearer,
+			created_at,
+			updated_at
+		FROM
+			fee_rules
+		WHERE account=$1
+	`
+
+	var (
+		rule  accounts.FeeRule
+		tiers []byte
+	)
+
+	err := db.QueryRowContext(ctx, q, account).Scan(
+		&rule.Account,
+		&rule.Type,
+		&rule.Value,
+		&tiers,
+		&rule.Bearer,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return accounts.FeeRule{}, err
+	}
+
+	if err := json.Unmarshal(tiers, &rule.Tiers); err != nil {
+		return accounts.FeeRule{}, err
+	}
+	return rule, nil
+}
@@ -0,0 +1,62 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveFeeRule(t *testing.T) {
+	repo := postgres.NewFeeRepository(db)
+
+	defer CleanDB(t, db)
+
+	account := saveAccount(t, db, accounts.Account{ID: "paypack.developers", Name: "remera", NumberOfSeats: 10, Type: accounts.Devs})
+
+	_, err := repo.RetrieveFeeRule(context.Background(), account.ID)
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err), "expected no fee rule")
+
+	cases := []struct {
+		desc string
+		rule accounts.FeeRule
+		kind int
+	}{
+		{
+			desc: "save fee rule",
+			rule: accounts.FeeRule{Account: account.ID, Type: accounts.FlatFee, Value: 100, Bearer: accounts.Payee},
+		},
+		{
+			desc: "replace fee rule",
+			rule: accounts.FeeRule{Account: account.ID, Type: accounts.TieredFee, Bearer: accounts.Payer, Tiers: []accounts.FeeTier{
+				{UpTo: 1000, Type: accounts.FlatFee, Value: 50},
+				{Type: accounts.PercentageFee, Value: 0.03},
+			}},
+		},
+		{
+			desc: "save fee rule of unknown account",
+			rule: accounts.FeeRule{Account: "invalid", Type: accounts.FlatFee, Value: 100, Bearer: accounts.Payee},
+			kind: errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := repo.SaveFeeRule(context.Background(), tc.rule)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+
+	rule, err := repo.RetrieveFeeRule(context.Background(), account.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, accounts.TieredFee, rule.Type)
+	assert.Equal(t, accounts.Payer, rule.Bearer)
+	assert.Len(t, rule.Tiers, 2)
+}
@@ -174,10 +174,15 @@ func savePropertyOn(t *testing.T, db *sql.DB, pro properties.Property) propertie
 			occupied,
 			namespace,
 			created_at,
-			updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+			updated_at,
+			billing_cycle,
+			billing_anchor
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
 
-	_, err := db.Exec(q,
+	err := pro.Billing.Validate()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	_, err = db.Exec(q,
 		pro.ID,
 		pro.Owner.ID,
 		pro.Due,
@@ -189,6 +194,8 @@ func savePropertyOn(t *testing.T, db *sql.DB, pro properties.Property) propertie
 		pro.Namespace,
 		pro.CreatedAt,
 		pro.UpdatedAt,
+		pro.Billing.Cycle,
+		pro.Billing.Anchor,
 	)
 
 	if err != nil {
@@ -223,6 +230,27 @@ func retrieveInvoice(t *testing.T, db *sql.DB, id string) invoices.Invoice {
 func CleanDB(t *testing.T, db *sql.DB) {
 	q := `
 		TRUNCATE TABLE
+			rejected_callbacks,
+			sms_outbox,
+			payment_discrepancies,
+			property_balances,
+			refunds,
+			payment_events,
+			payouts,
+			fee_rules,
+			penalty_policies,
+			journal_lines,
+			journal_entries,
+			ledger_accounts,
+			manual_payments,
+			cash_remittances,
+			statement_lines,
+			bank_statements,
+			settlements,
+			waivers,
+			invoice_adjustments,
+			tariff_changes,
+			payment_allocations,
 			sms_notifications,
 			messages, 
 			transactions, 
@@ -9,6 +9,9 @@ import (
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
 )
 
+// periodLayout formats the bounds of invoice periods, they are dates
+const periodLayout = "2006-01-02"
+
 type invoiceRepository struct {
 	*sql.DB
 }
@@ -25,8 +28,13 @@ func (repo *invoiceRepository) Find(ctx context.Context, id uint64) (invoices.In
 		SELECT 
 			id, 
 			amount, 
+			paid, 
+			waived, 
+			COALESCE(penalty_of, 0), 
 			property, 
 			status, 
+			period_start, 
+			period_end, 
 			created_at, 
 			updated_at 
 		FROM invoices WHERE id=$1 
@@ -44,8 +52,13 @@ func (repo *invoiceRepository) Find(ctx context.Context, id uint64) (invoices.In
 	err = tx.QueryRow(q, id).Scan(
 		&invoice.ID,
 		&invoice.Amount,
+		&invoice.Paid,
+		&invoice.Waived,
+		&invoice.PenaltyOf,
 		&invoice.Property,
 		&invoice.Status,
+		&invoice.PeriodStart,
+		&invoice.PeriodEnd,
 		&invoice.CreatedAt,
 		&invoice.UpdatedAt,
 	)
@@ -76,8 +89,13 @@ func (repo *invoiceRepository) All(ctx context.Context, property string, months
 		SELECT 
 			id, 
 			amount, 
+			paid, 
+			waived, 
+			COALESCE(penalty_of, 0), 
 			property, 
 			status, 
+			period_start, 
+			period_end, 
 			created_at, 
 			updated_at 
 		FROM 
@@ -101,7 +119,7 @@ func (repo *invoiceRepository) All(ctx context.Context, property string, months
 	for rows.Next() {
 		c := invoices.Invoice{}
 
-		if err := rows.Scan(&c.ID, &c.Amount, &c.Property, &c.Status, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Amount, &c.Paid, &c.Waived, &c.PenaltyOf, &c.Property, &c.Status, &c.PeriodStart, &c.PeriodEnd, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return invoices.InvoicePage{}, errors.E(op, err, errors.KindUnexpected)
 		}
 		items = append(items, c)
@@ -132,8 +150,13 @@ func (repo *invoiceRepository) Pending(ctx context.Context, property string, mon
 		SELECT 
 			id, 
 			amount, 
+			paid, 
+			waived, 
+			COALESCE(penalty_of, 0), 
 			property, 
 			status, 
+			period_start, 
+			period_end, 
 			created_at, 
 			updated_at 
 		FROM 
@@ -141,7 +164,7 @@ func (repo *invoiceRepository) Pending(ctx context.Context, property string, mon
 		WHERE 
 			property=$1 
 		AND
-			status='pending' 
+			status IN ('pending', 'partially_paid') 
 		AND 
 			created_at >= DATE_TRUNC('month', CURRENT_TIMESTAMP) - INTERVAL '1 month' * $2
 		ORDER BY created_at DESC
@@ -159,13 +182,13 @@ func (repo *invoiceRepository) Pending(ctx context.Context, property string, mon
 	for rows.Next() {
 		c := invoices.Invoice{}
 
-		if err := rows.Scan(&c.ID, &c.Amount, &c.Property, &c.Status, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Amount, &c.Paid, &c.Waived, &c.PenaltyOf, &c.Property, &c.Status, &c.PeriodStart, &c.PeriodEnd, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return invoices.InvoicePage{}, errors.E(op, err, errors.KindUnexpected)
 		}
 		items = append(items, c)
 	}
 
-	q = `SELECT COUNT(*) FROM invoices WHERE property=$1 AND status='pending';`
+	q = `SELECT COUNT(*) FROM invoices WHERE property=$1 AND status IN ('pending', 'partially_paid');`
 
 	var total uint
 
@@ -190,8 +213,13 @@ func (repo *invoiceRepository) Payed(ctx context.Context, property string, month
 		SELECT 
 			id, 
 			amount, 
+			paid, 
+			waived, 
+			COALESCE(penalty_of, 0), 
 			property, 
 			status, 
+			period_start, 
+			period_end, 
 			created_at, 
 			updated_at 
 		FROM 
@@ -217,7 +245,7 @@ func (repo *invoiceRepository) Payed(ctx context.Context, property string, month
 	for rows.Next() {
 		c := invoices.Invoice{}
 
-		if err := rows.Scan(&c.ID, &c.Amount, &c.Property, &c.Status, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Amount, &c.Paid, &c.Waived, &c.PenaltyOf, &c.Property, &c.Status, &c.PeriodStart, &c.PeriodEnd, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return invoices.InvoicePage{}, errors.E(op, err, errors.KindUnexpected)
 		}
 		items = append(items, c)
@@ -248,24 +276,32 @@ func (repo *invoiceRepository) Earliest(ctx context.Context, property string) (i
 		SELECT 
 			id, 
 			amount, 
+			paid, 
+			waived, 
 			property, 
 			status, 
+			period_start, 
+			period_end, 
 			created_at, 
 			updated_at 
 		FROM 
 			invoices 
 		WHERE
-			property=$1 AND status='pending'
+			property=$1 AND status IN ('pending', 'partially_paid') AND penalty_of IS NULL
 		AND  
-			DATE_TRUNC('month', created_at) = DATE_TRUNC('month', CURRENT_DATE) ;
+			period_start <= CURRENT_DATE AND CURRENT_DATE < period_end;
 	`
 	var invoice invoices.Invoice
 
 	err := repo.QueryRow(q, property).Scan(
 		&invoice.ID,
 		&invoice.Amount,
+		&invoice.Paid,
+		&invoice.Waived,
 		&invoice.Property,
 		&invoice.Status,
+		&invoice.PeriodStart,
+		&invoice.PeriodEnd,
 		&invoice.CreatedAt,
 		&invoice.UpdatedAt,
 	)
@@ -286,16 +322,22 @@ func (repo *invoiceRepository) Archivable(ctx context.Context) (invoices.Invoice
 		select 
 			id,
 			amount,
+			paid,
+			waived,
 			property,
-			status,
+			status, 
+			period_start, 
+			period_end, 
 			created_at,
 			updated_at
 		FROM 
 			invoices
 		WHERE 
-			status='pending' 
+			status IN ('pending', 'partially_paid') 
+		AND 
+			penalty_of IS NULL
 		AND 
-			created_at < date_trunc('month', now())::date
+			period_end <= CURRENT_DATE
 		ORDER BY id
 	`
 
@@ -311,13 +353,13 @@ func (repo *invoiceRepository) Archivable(ctx context.Context) (invoices.Invoice
 	for rows.Next() {
 		c := invoices.Invoice{}
 
-		if err := rows.Scan(&c.ID, &c.Amount, &c.Property, &c.Status, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Amount, &c.Paid, &c.Waived, &c.Property, &c.Status, &c.PeriodStart, &c.PeriodEnd, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return invoices.InvoicePage{}, errors.E(op, err, errors.KindUnexpected)
 		}
 		items = append(items, c)
 	}
 
-	q = `SELECT COUNT(*) FROM invoices WHERE status='pending' AND created_at < date_trunc('month', now())::date;`
+	q = `SELECT COUNT(*) FROM invoices WHERE status IN ('pending', 'partially_paid') AND penalty_of IS NULL AND period_end <= CURRENT_DATE;`
 
 	var total uint
 
@@ -342,8 +384,13 @@ func (repo *invoiceRepository) Unpaid(ctx context.Context, property string) (inv
 		SELECT 
 			id, 
 			amount, 
+			paid, 
+			waived, 
+			COALESCE(penalty_of, 0), 
 			property, 
 			status, 
+			period_start, 
+			period_end, 
 			created_at, 
 			updated_at 
 		FROM 
@@ -351,9 +398,9 @@ func (repo *invoiceRepository) Unpaid(ctx context.Context, property string) (inv
 		WHERE 
 			property=$1 
 		AND
-			status='pending' 
+			status IN ('pending', 'partially_paid') 
 		AND 
-			created_at < DATE_TRUNC('month', CURRENT_DATE)
+			period_end <= CURRENT_DATE
 		ORDER BY created_at DESC
 	`
 
@@ -369,7 +416,7 @@ func (repo *invoiceRepository) Unpaid(ctx context.Context, property string) (inv
 	for rows.Next() {
 		c := invoices.Invoice{}
 
-		if err := rows.Scan(&c.ID, &c.Amount, &c.Property, &c.Status, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Amount, &c.Paid, &c.Waived, &c.PenaltyOf, &c.Property, &c.Status, &c.PeriodStart, &c.PeriodEnd, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return invoices.InvoicePage{}, errors.E(op, err, errors.KindUnexpected)
 		}
 		items = append(items, c)
@@ -377,11 +424,11 @@ func (repo *invoiceRepository) Unpaid(ctx context.Context, property string) (inv
 
 	q = `SELECT 
 			COUNT(*),
-			COALESCE(sum(amount), 0.0) as total_unpaid
+			COALESCE(sum(amount - waived - paid), 0.0) as total_unpaid
 		FROM 
 			invoices 
 		WHERE 
-			property=$1 AND status='pending' AND created_at < DATE_TRUNC('month', CURRENT_DATE);`
+			property=$1 AND status IN ('pending', 'partially_paid') AND period_end <= CURRENT_DATE;`
 
 	var (
 		total       uint
@@ -402,7 +449,7 @@ func (repo *invoiceRepository) Unpaid(ctx context.Context, property string) (inv
 	return page, nil
 }
 
-// Generate generates a new invoice for a given months count from the current month on wards with the given amount and property id
+// Generate generates invoices for a given count of billing periods from the current period on wards with the given amount and property id
 func (repo *invoiceRepository) Generate(ctx context.Context, property string, amount, months uint) ([]*invoices.Invoice, error) {
 	const op errors.Op = "store/postgres/invoices.Generate"
 
@@ -412,17 +459,30 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 	}
 	defer tx.Rollback()
 
+	// every invoice generated charges the due of the property, which is
+	// charged once per billing period whatever the billing cycle
+	var due float64
+	if err := tx.QueryRowContext(ctx, `SELECT due FROM properties WHERE id=$1`, property).Scan(&due); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.E(op, "property not found", errors.KindNotFound)
+		}
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	if months == 0 || float64(amount) != due*float64(months) {
+		return nil, errors.E(op, "amount must be the due of the house for every billing period", errors.KindBadRequest)
+	}
+
 	out := make([]*invoices.Invoice, 0)
 
 	selectQuery := `
 		SELECT
-			id, amount, property, status, created_at, updated_at
+			id, amount, paid, waived, property, status, period_start, period_end, created_at, updated_at
 		FROM
 			invoices
 		WHERE
-			property=$1
+			property=$1 AND penalty_of IS NULL
 		AND
-			DATE_TRUNC('month', created_at) = DATE_TRUNC('month', CURRENT_DATE)
+			period_start <= CURRENT_DATE AND CURRENT_DATE < period_end
 	`
 
 	current := new(invoices.Invoice)
@@ -433,8 +493,12 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 	).Scan(
 		&current.ID,
 		&current.Amount,
+		&current.Paid,
+		&current.Waived,
 		&current.Property,
 		&current.Status,
+		&current.PeriodStart,
+		&current.PeriodEnd,
 		&current.CreatedAt,
 		&current.UpdatedAt,
 	); err != nil {
@@ -442,7 +506,7 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 	}
 
 	m := months
-	if current.Status == invoices.Pending {
+	if !current.Settled() {
 		out = append(out, current)
 		m--
 	}
@@ -453,17 +517,22 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 
 	datas := make([]*invoices.Invoice, 0)
 
+	// the following invoices start where the current one ends and follow
+	// the billing cycle of the property
 	generateQuery := `
 		SELECT
 			$1::numeric, 
-			$2::text,
+			properties.id,
 			'pending', 
-			DATE_TRUNC('month', CURRENT_DATE) + interval '1 month' * s.a, 
-			DATE_TRUNC('month', CURRENT_DATE) + interval '1 month' * s.a
+			$4::date + billing_interval(properties.billing_cycle) * (s.a - 1), 
+			$4::date + billing_interval(properties.billing_cycle) * (s.a - 1)
 		FROM
-			generate_series(1, $3::int) s(a)
+			properties, generate_series(1, $3::int) s(a)
+		WHERE
+			properties.id=$2
+		ORDER BY s.a
 	`
-	rows, err := tx.QueryContext(ctx, generateQuery, amount/months, property, m)
+	rows, err := tx.QueryContext(ctx, generateQuery, due, property, m, current.PeriodEnd.Format(periodLayout))
 	if err != nil {
 		pqErr, ok := err.(*pq.Error)
 		if ok && errInvalid == pqErr.Code.Name() {
@@ -491,13 +560,13 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 	for _, item := range datas {
 		selectQuery := `
 			SELECT
-				id, amount, property, status, created_at, updated_at
+				id, amount, paid, waived, property, status, period_start, period_end, created_at, updated_at
 			FROM
 				invoices
 			WHERE
-				property=$1
+				property=$1 AND penalty_of IS NULL
 			AND
-			  DATE_TRUNC('month', created_at) = DATE_TRUNC('month', $2::timestamp)
+				period_start = $2::date
 		`
 
 		invoice := new(invoices.Invoice)
@@ -505,12 +574,16 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 			ctx,
 			selectQuery,
 			item.Property,
-			item.CreatedAt,
+			item.CreatedAt.Format(periodLayout),
 		).Scan(
 			&invoice.ID,
 			&invoice.Amount,
+			&invoice.Paid,
+			&invoice.Waived,
 			&invoice.Property,
 			&invoice.Status,
+			&invoice.PeriodStart,
+			&invoice.PeriodEnd,
 			&invoice.CreatedAt,
 			&invoice.UpdatedAt,
 		); err != nil {
@@ -521,7 +594,7 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 					VALUES
 						($1, $2, $3, $4, $5)
 					RETURNING
-						id, amount, property, status, created_at, updated_at
+						id, amount, paid, waived, property, status, period_start, period_end, created_at, updated_at
 				`
 
 				if err := tx.QueryRowContext(
@@ -535,8 +608,12 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 				).Scan(
 					&invoice.ID,
 					&invoice.Amount,
+					&invoice.Paid,
+					&invoice.Waived,
 					&invoice.Property,
 					&invoice.Status,
+					&invoice.PeriodStart,
+					&invoice.PeriodEnd,
 					&invoice.CreatedAt,
 					&invoice.UpdatedAt,
 				); err != nil {
@@ -547,10 +624,27 @@ func (repo *invoiceRepository) Generate(ctx context.Context, property string, am
 			}
 		}
 
-		if invoice.Status == invoices.Pending {
+		if !invoice.Settled() {
 			out = append(out, invoice)
 		}
 	}
 
 	return out, tx.Commit()
 }
+
+func (repo *invoiceRepository) Credit(ctx context.Context, property string) (invoices.Credit, error) {
+	const op errors.Op = "store/postgres/invoices.Credit"
+
+	q := `SELECT credit, updated_at FROM property_balances WHERE property=$1`
+
+	credit := invoices.Credit{Property: property}
+
+	err := repo.QueryRowContext(ctx, q, property).Scan(&credit.Amount, &credit.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return credit, nil
+		}
+		return invoices.Credit{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return credit, nil
+}
@@ -423,3 +423,152 @@ func TestEarliest(t *testing.T) {
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error: '%v' got '%v'\n", tc.desc, tc.err, err))
 	}
 }
+
+func TestInvoiceCredit(t *testing.T) {
+	repo := postgres.NewInvoiceRepository(db)
+
+	defer CleanDB(t, db)
+
+	account := accounts.Account{
+		ID:            "paypack.developers",
+		Name:          "remera",
+		NumberOfSeats: 10,
+		Type:          accounts.Devs,
+	}
+	account = saveAccount(t, db, account)
+
+	agent := users.Agent{
+		Telephone: random(15),
+		FirstName: "first",
+		LastName:  "last",
+		Password:  "password",
+		Cell:      "cell",
+		Sector:    "Sector",
+		Village:   "village",
+		Role:      users.Dev,
+		Account:   account.ID,
+	}
+	agent = saveAgent(t, db, agent)
+
+	owner := properties.Owner{
+		ID:    uuid.New().ID(),
+		Fname: "rugwiro",
+		Lname: "james",
+		Phone: "0784677882",
+	}
+	owner = saveOwner(t, db, owner)
+
+	property := properties.Property{
+		ID:    nanoid.New(nil).ID(),
+		Owner: properties.Owner{ID: owner.ID},
+		Address: properties.Address{
+			Sector:  "Remera",
+			Cell:    "Gishushu",
+			Village: "Ingabo",
+		},
+		Namespace:  account.ID,
+		Due:        float64(1000),
+		RecordedBy: agent.Telephone,
+		Occupied:   true,
+	}
+	property = saveProperty(t, db, property)
+
+	credited := properties.Property{
+		ID:         nanoid.New(nil).ID(),
+		Owner:      properties.Owner{ID: owner.ID},
+		Address:    property.Address,
+		Namespace:  account.ID,
+		Due:        float64(1000),
+		RecordedBy: agent.Telephone,
+		Occupied:   true,
+	}
+	credited = saveProperty(t, db, credited)
+
+	q := `INSERT INTO property_balances (property, credit) VALUES ($1, $2)`
+	if _, err := db.Exec(q, credited.ID, 500); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		desc     string
+		property string
+		amount   float64
+	}{
+		{
+			desc:     "retrieve credit of property without credit",
+			property: property.ID,
+			amount:   0,
+		},
+		{
+			desc:     "retrieve credit of credited property",
+			property: credited.ID,
+			amount:   500,
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		credit, err := repo.Credit(ctx, tc.property)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.amount, credit.Amount, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.amount, credit.Amount))
+	}
+}
+
+func TestGenerateInvoices(t *testing.T) {
+	repo := postgres.NewInvoiceRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	cases := []struct {
+		desc     string
+		property string
+		amount   uint
+		months   uint
+		count    int
+		kind     int
+	}{
+		{
+			desc:     "generate invoices without billing periods",
+			property: property.ID,
+			amount:   1000,
+			kind:     errors.KindBadRequest,
+		},
+		{
+			desc:     "generate invoices with an amount that isn't the due of every period",
+			property: property.ID,
+			amount:   2001,
+			months:   2,
+			kind:     errors.KindBadRequest,
+		},
+		{
+			desc:     "generate invoices of non-existent property",
+			property: wrongValue,
+			amount:   2000,
+			months:   2,
+			kind:     errors.KindNotFound,
+		},
+		{
+			desc:     "generate invoices for the due of every period",
+			property: property.ID,
+			amount:   3000,
+			months:   3,
+			count:    3,
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		items, err := repo.Generate(ctx, tc.property, tc.amount, tc.months)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Len(t, items, tc.count, fmt.Sprintf("%s: expected %d invoices got %d", tc.desc, tc.count, len(items)))
+		for _, item := range items {
+			assert.Equal(t, property.Due, item.Amount, fmt.Sprintf("%s: expected amount %v got %v", tc.desc, property.Due, item.Amount))
+		}
+	}
+}
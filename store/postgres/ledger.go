@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (ledger.Repository) = (*ledgerStore)(nil)
+
+type ledgerStore struct {
+	*sql.DB
+}
+
+// NewLedgerRepository creates a postgres backed ledger.Repository
+func NewLedgerRepository(db *sql.DB) ledger.Repository {
+	return &ledgerStore{db}
+}
+
+func (repo *ledgerStore) Post(ctx context.Context, entry *ledger.Entry) error {
+	const op errors.Op = "store/postgres/ledgerStore.Post"
+
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	posted, err := postEntry(ctx, tx, entry)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !posted {
+		return errors.E(op, fmt.Sprintf("%s entry %s already posted", entry.Kind, entry.Ref), errors.KindAlreadyExists)
+	}
+
+	// balances are checked by a deferred trigger when committing
+	if err := tx.Commit(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *ledgerStore) Balances(ctx context.Context, namespace string) ([]ledger.Balance, error) {
+	const op errors.Op = "store/postgres/ledgerStore.Balances"
+
+	q := `
+		SELECT
+			a.code,
+			a.kind,
+			a.namespace,
+			COALESCE(SUM(l.debit), 0),
+			COALESCE(SUM(l.credit), 0)
+		FROM
+			ledger_accounts a
+		JOIN journal_lines l ON l.account = a.code
+		WHERE $1 = '' OR a.namespace = $1
+		GROUP BY a.code, a.kind, a.namespace
+		ORDER BY a.code
+	`
+
+	rows, err := repo.QueryContext(ctx, q, namespace)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var balances = []ledger.Balance{}
+
+	for rows.Next() {
+		var b ledger.Balance
+		if err := rows.Scan(&b.Code, &b.Kind, &b.Namespace, &b.Debit, &b.Credit); err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		balances = append(balances, b)
+	}
+	return balances, nil
+}
+
+func (repo *ledgerStore) Statement(ctx context.Context, code, namespace string, offset, limit uint64) (ledger.Statement, error) {
+	const op errors.Op = "store/postgres/ledgerStore.Statement"
+
+	var empty ledger.Statement
+
+	st := ledger.Statement{
+		PageMetadata: ledger.PageMetadata{Offset: offset, Limit: limit},
+		Lines:        []ledger.StatementLine{},
+	}
+
+	q := `
+		SELECT
+			a.code,
+			a.kind,
+			a.namespace,
+			(SELECT COUNT(*) FROM journal_lines WHERE account = a.code)
+		FROM
+			ledger_accounts a
+		WHERE a.code = $1 AND ($2 = '' OR a.namespace = $2)
+	`
+
+	err := repo.QueryRowContext(ctx, q, code, namespace).Scan(
+		&st.Account.Code,
+		&st.Account.Kind,
+		&st.Account.Namespace,
+		&st.Total,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return empty, errors.E(op, "account not found", errors.KindNotFound)
+		}
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	// the running balance is computed over every line of the account
+	// before the page is cut
+	q = `
+		SELECT
+			e.id,
+			e.kind,
+			e.ref,
+			e.description,
+			l.debit,
+			l.credit,
+			SUM(l.debit - l.credit) OVER (ORDER BY e.created_at, l.id),
+			e.created_at
+		FROM
+			journal_lines l
+		JOIN journal_entries e ON l.entry = e.id
+		WHERE l.account = $1
+		ORDER BY e.created_at DESC, l.id DESC
+		OFFSET $2 LIMIT $3
+	`
+
+	rows, err := repo.QueryContext(ctx, q, code, offset, limit)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line ledger.StatementLine
+		if err := rows.Scan(
+			&line.Entry,
+			&line.Kind,
+			&line.Ref,
+			&line.Description,
+			&line.Debit,
+			&line.Credit,
+			&line.Balance,
+			&line.CreatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		if st.Account.Kind == ledger.Liability || st.Account.Kind == ledger.Revenue {
+			line.Balance = -line.Balance
+		}
+		st.Lines = append(st.Lines, line)
+	}
+	return st, nil
+}
+
+// postEntry writes a journal entry inside the transaction that moved the
+// money. It reports false without writing anything when an entry of the
+// same kind and ref was already posted.
+func postEntry(ctx context.Context, tx *sql.Tx, entry *ledger.Entry) (bool, error) {
+	const op errors.Op = "store/postgres/postEntry"
+
+	if err := entry.Validate(); err != nil {
+		return false, errors.E(op, err)
+	}
+
+	q := `
+		INSERT INTO journal_entries (kind, ref, namespace, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (kind, ref) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(ctx, q, entry.Kind, entry.Ref, entry.Namespace, entry.Description).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	for _, line := range entry.Lines {
+		acc, _ := ledger.AccountOf(line.Account, entry.Namespace)
+
+		q = `INSERT INTO ledger_accounts (code, kind, namespace) VALUES ($1, $2, $3) ON CONFLICT (code) DO NOTHING`
+		if _, err := tx.ExecContext(ctx, q, acc.Code, acc.Kind, acc.Namespace); err != nil {
+			return false, errors.E(op, err, errors.KindUnexpected)
+		}
+
+		q = `INSERT INTO journal_lines (entry, account, debit, credit) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.ExecContext(ctx, q, entry.ID, line.Account, line.Debit, line.Credit); err != nil {
+			pqErr, ok := err.(*pq.Error)
+			if ok && pqErr.Code.Name() == errCheck {
+				return false, errors.E(op, "invalid journal line", errors.KindBadRequest)
+			}
+			return false, errors.E(op, err, errors.KindUnexpected)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,134 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/nanoid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/core/users"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostEntry(t *testing.T) {
+	repo := postgres.NewLedgerRepository(db)
+
+	defer CleanDB(t, db)
+
+	const ns = "kigali.gasabo.remera"
+
+	cases := []struct {
+		desc  string
+		entry *ledger.Entry
+		kind  int
+	}{
+		{
+			desc: "post balanced entry",
+			entry: ledger.NewEntry(ledger.PaymentEntry, "ref", ns, "payment").
+				Transfer(ledger.Clearing(ns), ledger.Receivable("property"), 1000).
+				Transfer(ledger.Fees(ns), ledger.Clearing(ns), 50),
+		},
+		{
+			desc: "post entry twice",
+			entry: ledger.NewEntry(ledger.PaymentEntry, "ref", ns, "payment").
+				Transfer(ledger.Clearing(ns), ledger.Receivable("property"), 1000),
+			kind: errors.KindAlreadyExists,
+		},
+		{
+			desc: "post unbalanced entry",
+			entry: &ledger.Entry{Kind: ledger.PaymentEntry, Ref: "other", Namespace: ns, Lines: []ledger.Line{
+				{Account: ledger.Clearing(ns), Debit: 1000},
+				{Account: ledger.Receivable("property"), Credit: 100},
+			}},
+			kind: errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Post(context.Background(), tc.entry)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.NotEmpty(t, tc.entry.ID, tc.desc)
+	}
+
+	_, err := db.Exec(`UPDATE journal_lines SET debit = debit + 1`)
+	assert.NotNil(t, err, "expected journal lines to be immutable")
+
+	_, err = db.Exec(`DELETE FROM journal_entries`)
+	assert.NotNil(t, err, "expected journal entries to be immutable")
+}
+
+func TestTrialBalance(t *testing.T) {
+	repo := postgres.NewLedgerRepository(db)
+
+	defer CleanDB(t, db)
+
+	account := accounts.Account{ID: "kigali.gasabo.remera", Name: "remera", NumberOfSeats: 10, Type: accounts.Devs}
+	account = saveAccount(t, db, account)
+
+	agent := users.Agent{
+		Telephone: random(15),
+		FirstName: "first",
+		LastName:  "last",
+		Password:  "password",
+		Cell:      "cell",
+		Sector:    "Sector",
+		Village:   "village",
+		Role:      users.Dev,
+		Account:   account.ID,
+	}
+	agent = saveAgent(t, db, agent)
+
+	owner := saveOwner(t, db, properties.Owner{ID: uuid.New().ID(), Fname: "rugwiro", Lname: "james", Phone: "0784677882"})
+
+	property := properties.Property{
+		ID:         nanoid.New(nil).ID(),
+		Owner:      properties.Owner{ID: owner.ID},
+		Address:    properties.Address{Sector: "Remera", Cell: "Gishushu", Village: "Ingabo"},
+		Namespace:  account.ID,
+		Due:        float64(1000),
+		RecordedBy: agent.Telephone,
+		Occupied:   true,
+	}
+	property = saveProperty(t, db, property)
+
+	var invoiced float64
+	err := db.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM invoices WHERE property = $1`, property.ID).Scan(&invoiced)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.NotZero(t, invoiced, "expected the property to be invoiced")
+
+	entry := ledger.NewEntry(ledger.PaymentEntry, uuid.New().ID(), account.ID, "").
+		Transfer(ledger.Clearing(account.ID), ledger.Receivable(property.ID), property.Due)
+	err = repo.Post(context.Background(), entry)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	balances, err := repo.Balances(context.Background(), account.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	tb := ledger.NewTrialBalance(balances)
+	assert.True(t, tb.Balanced, "expected a balanced trial balance")
+	assert.Equal(t, invoiced+property.Due, tb.Debit)
+
+	other, err := repo.Balances(context.Background(), "kigali.gasabo.kimironko")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Empty(t, other, "expected no balances for another namespace")
+
+	st, err := repo.Statement(context.Background(), ledger.Receivable(property.ID), account.ID, 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.NotEmpty(t, st.Lines)
+	assert.Equal(t, ledger.PaymentEntry, st.Lines[0].Kind, "expected the most recent line first")
+	assert.Equal(t, invoiced-property.Due, st.Lines[0].Balance)
+
+	_, err = repo.Statement(context.Background(), ledger.Receivable(property.ID), "kigali.gasabo.kimironko", 0, 10)
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err), "expected accounts of other namespaces to be hidden")
+}
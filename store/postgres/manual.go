@@ -0,0 +1,399 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (manual.Repository) = (*manualStore)(nil)
+
+type manualStore struct {
+	*sql.DB
+}
+
+// NewManualRepository creates a postgres backed manual.Repository
+func NewManualRepository(db *sql.DB) manual.Repository {
+	return &manualStore{db}
+}
+
+func (repo *manualStore) Save(ctx context.Context, m *manual.Payment) error {
+	const op errors.Op = "store/postgres/manualStore.Save"
+
+	q := `
+		INSERT INTO manual_payments (
+			id,
+			property,
+			namespace,
+			amount,
+			months,
+			method,
+			msisdn,
+			slip,
+			photo,
+			status,
+			recorded_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at, updated_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		m.ID,
+		m.Property,
+		m.Namespace,
+		m.Amount,
+		m.Months,
+		m.Method,
+		m.MSISDN,
+		m.Slip,
+		m.Photo,
+		m.Status,
+		m.RecordedBy,
+	).Scan(&m.CreatedAt, &m.UpdatedAt)
+
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errDuplicate:
+				return errors.E(op, "slip was already recorded", errors.KindAlreadyExists)
+			case errFK:
+				return errors.E(op, "property not found", errors.KindNotFound)
+			case errInvalid, errTruncation, errCheck:
+				return errors.E(op, "invalid manual payment entity", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *manualStore) Find(ctx context.Context, id string) (manual.Payment, error) {
+	const op errors.Op = "store/postgres/manualStore.Find"
+
+	q := `
+		SELECT
+			id,
+			property,
+			namespace,
+			amount,
+			months,
+			method,
+			msisdn,
+			slip,
+			photo,
+			status,
+			reason,
+			recorded_by,
+			reviewed_by,
+			created_at,
+			updated_at
+		FROM
+			manual_payments
+		WHERE id=$1
+	`
+
+	var m manual.Payment
+
+	err := repo.QueryRowContext(ctx, q, id).Scan(
+		&m.ID,
+		&m.Property,
+		&m.Namespace,
+		&m.Amount,
+		&m.Months,
+		&m.Method,
+		&m.MSISDN,
+		&m.Slip,
+		&m.Photo,
+		&m.Status,
+		&m.Reason,
+		&m.RecordedBy,
+		&m.ReviewedBy,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && errInvalid == pqErr.Code.Name() {
+			return manual.Payment{}, errors.E(op, "manual payment not found", errors.KindNotFound)
+		}
+		return manual.Payment{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return m, nil
+}
+
+func (repo *manualStore) List(ctx context.Context, flts *manual.Filters) (manual.Page, error) {
+	const op errors.Op = "store/postgres/manualStore.List"
+
+	where, args := manualConditions(flts)
+
+	// slip photos are only returned by Find
+	q := fmt.Sprintf(`
+		SELECT
+			id,
+			property,
+			namespace,
+			amount,
+			months,
+			method,
+			msisdn,
+			slip,
+			status,
+			reason,
+			recorded_by,
+			reviewed_by,
+			created_at,
+			updated_at
+		FROM
+			manual_payments
+		%s
+		ORDER BY created_at DESC OFFSET $%d LIMIT $%d
+	`, where, len(args)+1, len(args)+2)
+
+	var empty manual.Page
+
+	rows, err := repo.QueryContext(ctx, q, append(args, flts.Offset, flts.Limit)...)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var items = []manual.Payment{}
+
+	for rows.Next() {
+		var m manual.Payment
+		if err := rows.Scan(
+			&m.ID,
+			&m.Property,
+			&m.Namespace,
+			&m.Amount,
+			&m.Months,
+			&m.Method,
+			&m.MSISDN,
+			&m.Slip,
+			&m.Status,
+			&m.Reason,
+			&m.RecordedBy,
+			&m.ReviewedBy,
+			&m.CreatedAt,
+			&m.UpdatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		items = append(items, m)
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(amount), 0.0) FROM manual_payments %s`, where)
+
+	var (
+		total  uint64
+		amount float64
+	)
+
+	if err := repo.QueryRowContext(ctx, q, args...).Scan(&total, &amount); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := manual.Page{
+		Payments: items,
+		PageMetadata: manual.PageMetadata{
+			Total:  total,
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+			Amount: amount,
+		},
+	}
+	return page, nil
+}
+
+func (repo *manualStore) Review(ctx context.Context, m manual.Payment) error {
+	const op errors.Op = "store/postgres/manualStore.Review"
+
+	q := `UPDATE manual_payments SET status=$1, reviewed_by=$2, reason=$3 WHERE id=$4 AND status=$5`
+
+	res, err := repo.ExecContext(ctx, q, m.Status, m.ReviewedBy, m.Reason, m.ID, manual.Pending)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if n == 0 {
+		return errors.E(op, "manual payment was already reviewed", errors.KindAlreadyExists)
+	}
+	return nil
+}
+
+func (repo *manualStore) Update(ctx context.Context, m manual.Payment) error {
+	const op errors.Op = "store/postgres/manualStore.Update"
+
+	q := `UPDATE manual_payments SET status=$1, reason=$2 WHERE id=$3`
+
+	res, err := repo.ExecContext(ctx, q, m.Status, m.Reason, m.ID)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if n == 0 {
+		return errors.E(op, "manual payment not found", errors.KindNotFound)
+	}
+	return nil
+}
+
+func (repo *manualStore) Remit(ctx context.Context, r *manual.Remittance) error {
+	const op errors.Op = "store/postgres/manualStore.Remit"
+
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	// concurrent remittances of an agent could both pass the balance check
+	q := `SELECT pg_advisory_xact_lock(hashtext($1))`
+	if _, err := tx.ExecContext(ctx, q, r.Namespace+"/"+r.Agent); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	cash, err := agentCash(ctx, tx, r.Namespace, r.Agent)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	var owed float64
+	if len(cash) > 0 {
+		owed = cash[0].Owed
+	}
+	if r.Amount > owed {
+		return errors.E(op, fmt.Sprintf("agent only owes %.2f", owed), errors.KindBadRequest)
+	}
+
+	q = `
+		INSERT INTO cash_remittances (id, agent, namespace, amount, received_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+	err = tx.QueryRowContext(ctx, q, r.ID, r.Agent, r.Namespace, r.Amount, r.ReceivedBy).Scan(&r.CreatedAt)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errDuplicate:
+				return errors.E(op, "remittance already exists", errors.KindAlreadyExists)
+			case errInvalid, errTruncation, errCheck:
+				return errors.E(op, "invalid remittance entity", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	desc := fmt.Sprintf("cash remitted by %s", r.Agent)
+	entry := ledger.NewEntry(ledger.RemittanceEntry, r.ID, r.Namespace, desc).
+		Transfer(ledger.Bank(r.Namespace), ledger.Cash(r.Namespace), r.Amount)
+	if _, err := postEntry(ctx, tx, entry); err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *manualStore) AgentCash(ctx context.Context, namespace string) ([]manual.AgentCash, error) {
+	const op errors.Op = "store/postgres/manualStore.AgentCash"
+
+	cash, err := agentCash(ctx, repo, namespace, "")
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return cash, nil
+}
+
+// agentCash sums the approved cash payments of agents less what they
+// remitted, empty namespace or agent match everything.
+func agentCash(ctx context.Context, db querier, namespace, agent string) ([]manual.AgentCash, error) {
+	const op errors.Op = "store/postgres/agentCash"
+
+	q := `
+		SELECT
+			agent,
+			namespace,
+			SUM(collected),
+			SUM(remitted)
+		FROM (
+			SELECT recorded_by AS agent, namespace, amount AS collected, 0 AS remitted
+			FROM manual_payments
+			WHERE method=$3 AND status=$4
+			UNION ALL
+			SELECT agent, namespace, 0, amount
+			FROM cash_remittances
+		) AS cash
+		WHERE ($1 = '' OR namespace = $1) AND ($2 = '' OR agent = $2)
+		GROUP BY agent, namespace
+		ORDER BY namespace, agent
+	`
+
+	rows, err := db.QueryContext(ctx, q, namespace, agent, payment.Cash, manual.Approved)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var out = []manual.AgentCash{}
+
+	for rows.Next() {
+		var c manual.AgentCash
+		if err := rows.Scan(&c.Agent, &c.Namespace, &c.Collected, &c.Remitted); err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		c.Owed = c.Collected - c.Remitted
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// manualConditions builds the where clause of the manual payment filters
+func manualConditions(flts *manual.Filters) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if flts.Namespace != nil {
+		add("namespace = $%d", *flts.Namespace)
+	}
+	if flts.Status != nil {
+		add("status = $%d", *flts.Status)
+	}
+	if flts.RecordedBy != nil {
+		add("recorded_by = $%d", *flts.RecordedBy)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,164 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/manual"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveManualPayment(t *testing.T) {
+	repo := postgres.NewManualRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	cases := []struct {
+		desc   string
+		manual *manual.Payment
+		kind   int
+	}{
+		{
+			desc:   "save cash payment",
+			manual: newManualPayment(property.ID, property.Namespace, payment.Cash, ""),
+			kind:   0,
+		},
+		{
+			desc:   "save bank payment",
+			manual: newManualPayment(property.ID, property.Namespace, payment.Bank, "BK-001"),
+			kind:   0,
+		},
+		{
+			desc:   "save bank payment with an already recorded slip",
+			manual: newManualPayment(property.ID, property.Namespace, payment.Bank, "BK-001"),
+			kind:   errors.KindAlreadyExists,
+		},
+		{
+			desc:   "save payment of unknown property",
+			manual: newManualPayment(uuid.New().ID(), property.Namespace, payment.Cash, ""),
+			kind:   errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Save(context.Background(), tc.manual)
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+}
+
+func TestReviewManualPayment(t *testing.T) {
+	repo := postgres.NewManualRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	m := newManualPayment(property.ID, property.Namespace, payment.Cash, "")
+	err := repo.Save(context.Background(), m)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc   string
+		status manual.Status
+		kind   int
+	}{
+		{
+			desc:   "approve pending payment",
+			status: manual.Approved,
+			kind:   0,
+		},
+		{
+			desc:   "reject approved payment",
+			status: manual.Rejected,
+			kind:   errors.KindAlreadyExists,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Review(context.Background(), manual.Payment{ID: m.ID, Status: tc.status, ReviewedBy: "manager"})
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+
+	saved, err := repo.Find(context.Background(), m.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, manual.Approved, saved.Status, fmt.Sprintf("expected %s got %s", manual.Approved, saved.Status))
+	assert.Equal(t, "manager", saved.ReviewedBy, fmt.Sprintf("expected %s got %s", "manager", saved.ReviewedBy))
+}
+
+func TestRemitCash(t *testing.T) {
+	repo := postgres.NewManualRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	m := newManualPayment(property.ID, property.Namespace, payment.Cash, "")
+	err := repo.Save(context.Background(), m)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	m.Status = manual.Approved
+	err = repo.Review(context.Background(), *m)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc   string
+		amount float64
+		kind   int
+	}{
+		{
+			desc:   "remit part of the collected cash",
+			amount: 600,
+			kind:   0,
+		},
+		{
+			desc:   "remit more than the agent owes",
+			amount: 600,
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		r := &manual.Remittance{ID: uuid.New().ID(), Agent: m.RecordedBy, Namespace: property.Namespace, Amount: tc.amount, ReceivedBy: "manager"}
+		err := repo.Remit(context.Background(), r)
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+
+	cash, err := repo.AgentCash(context.Background(), property.Namespace)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	require.Len(t, cash, 1, fmt.Sprintf("expected 1 agent got %d", len(cash)))
+	assert.Equal(t, float64(400), cash[0].Owed, fmt.Sprintf("expected %v owed got %v", 400, cash[0].Owed))
+}
+
+func newManualPayment(property, namespace string, method payment.Method, slip string) *manual.Payment {
+	return &manual.Payment{
+		ID:         uuid.New().ID(),
+		Property:   property,
+		Namespace:  namespace,
+		Amount:     1000,
+		Months:     1,
+		Method:     method,
+		Slip:       slip,
+		Status:     manual.Pending,
+		RecordedBy: "agent",
+	}
+}
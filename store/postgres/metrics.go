@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/lib/pq"
 	"github.com/nshimiyimanaamani/paypack-backend/core/metrics"
@@ -26,16 +27,17 @@ func (repo *statsRepository) FindSectorRatio(ctx context.Context, sector string,
 			sector, 
 			pending_count, 
 			payed_count, 
-			expired_count
+			expired_count,
+			waived_count
 		from 
 			sector_payment_metrics 
 		where sector=$1 and extract(year from period)=$2 and extract(month from period)=$3;
 	`
 	var label string
 
-	var payed, pending, expired uint64
+	var payed, pending, expired, waived uint64
 
-	err := repo.QueryRowContext(ctx, q, sector, y, m).Scan(&label, &pending, &payed, &expired)
+	err := repo.QueryRowContext(ctx, q, sector, y, m).Scan(&label, &pending, &payed, &expired, &waived)
 	if err != nil {
 		empty := metrics.Chart{}
 		pqErr, ok := err.(*pq.Error)
@@ -47,7 +49,7 @@ func (repo *statsRepository) FindSectorRatio(ctx context.Context, sector string,
 
 	chart := metrics.Chart{
 		Label: label,
-		Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired},
+		Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired, "waived": waived},
 	}
 
 	return chart, nil
@@ -57,19 +59,20 @@ func (repo *statsRepository) FindCellRatio(ctx context.Context, cell string, y,
 
 	var label string
 
-	var payed, pending, expired uint64
+	var payed, pending, expired, waived uint64
 
 	q := `
 		select 
 			cell, 
 			pending_count, 
 			payed_count,
-			expired_count
+			expired_count,
+			waived_count
 		from  
 			cell_payment_metrics 
 		where cell=$1 and extract(year from period)=$2 and extract(month from period)=$3;
 	`
-	err := repo.QueryRowContext(ctx, q, cell, y, m).Scan(&label, &pending, &payed, &expired)
+	err := repo.QueryRowContext(ctx, q, cell, y, m).Scan(&label, &pending, &payed, &expired, &waived)
 	if err != nil {
 
 		empty := metrics.Chart{}
@@ -83,7 +86,7 @@ func (repo *statsRepository) FindCellRatio(ctx context.Context, cell string, y,
 
 	chart := metrics.Chart{
 		Label: label,
-		Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired},
+		Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired, "waived": waived},
 	}
 
 	return chart, nil
@@ -97,16 +100,17 @@ func (repo *statsRepository) FindVillageRatio(ctx context.Context, village strin
 			village, 
 			pending_count, 
 			payed_count,
-			expired_count 
+			expired_count,
+			waived_count
 		from  
 			village_payment_metrics 
 		where village=$1 and extract(year from period)=$2 and extract(month from period)=$3;
 	`
 	var label string
 
-	var payed, pending, expired uint64
+	var payed, pending, expired, waived uint64
 
-	err := repo.QueryRowContext(ctx, q, village, y, m).Scan(&label, &pending, &payed, &expired)
+	err := repo.QueryRowContext(ctx, q, village, y, m).Scan(&label, &pending, &payed, &expired, &waived)
 	if err != nil {
 		empty := metrics.Chart{}
 
@@ -118,7 +122,7 @@ func (repo *statsRepository) FindVillageRatio(ctx context.Context, village strin
 	}
 	chart := metrics.Chart{
 		Label: label,
-		Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired},
+		Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired, "waived": waived},
 	}
 	return chart, nil
 }
@@ -131,7 +135,8 @@ func (repo *statsRepository) ListSectorRatios(ctx context.Context, sector string
 			cell, 
 			pending_count, 
 			payed_count,
-			expired_count
+			expired_count,
+			waived_count
 		from 
 			cell_payment_metrics 
 		where sector=$1 and extract(year from period)=$2 and extract(month from period)=$3;
@@ -149,15 +154,15 @@ func (repo *statsRepository) ListSectorRatios(ctx context.Context, sector string
 	for rows.Next() {
 		var label string
 
-		var payed, pending, expired uint64
+		var payed, pending, expired, waived uint64
 
-		if err := rows.Scan(&label, &pending, &payed, &expired); err != nil {
+		if err := rows.Scan(&label, &pending, &payed, &expired, &waived); err != nil {
 			return nil, errors.E(op, err, errors.KindUnexpected)
 		}
 
 		chart := metrics.Chart{
 			Label: label,
-			Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired},
+			Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired, "waived": waived},
 		}
 
 		items = append(items, chart)
@@ -174,7 +179,8 @@ func (repo *statsRepository) ListCellRatios(ctx context.Context, cell string, y,
 			village, 
 			pending_count, 
 			payed_count,
-			expired_amount 
+			expired_count,
+			waived_count
 		from 
 			village_payment_metrics 
 		where cell=$1 and extract(year from period)=$2 and extract(month from period)=$3;
@@ -192,15 +198,15 @@ func (repo *statsRepository) ListCellRatios(ctx context.Context, cell string, y,
 	for rows.Next() {
 		var label string
 
-		var payed, pending, expired uint64
+		var payed, pending, expired, waived uint64
 
-		if err := rows.Scan(&label, &pending, &payed, &expired); err != nil {
+		if err := rows.Scan(&label, &pending, &payed, &expired, &waived); err != nil {
 			return nil, errors.E(op, err, errors.KindUnexpected)
 		}
 
 		chart := metrics.Chart{
 			Label: label,
-			Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired},
+			Data:  map[string]uint64{"payed": payed, "pending": pending, "expired": expired, "waived": waived},
 		}
 
 		items = append(items, chart)
@@ -216,7 +222,8 @@ func (repo *statsRepository) FindSectorBalance(ctx context.Context, sector strin
 			sector, 
 			pending_amount, 
 			payed_amount,
-			expired_amount
+			expired_amount,
+			waived_amount
 		from 
 			sector_payment_metrics 
 		where sector=$1 and extract(year from period)=$2 and extract(month from period)=$3;
@@ -224,9 +231,9 @@ func (repo *statsRepository) FindSectorBalance(ctx context.Context, sector strin
 
 	var label string
 
-	var payed, pending, expired float64
+	var payed, pending, expired, waived float64
 
-	err := repo.QueryRowContext(ctx, q, sector, y, m).Scan(&label, &pending, &payed, &expired)
+	err := repo.QueryRowContext(ctx, q, sector, y, m).Scan(&label, &pending, &payed, &expired, &waived)
 	if err != nil {
 		empty := metrics.Chart{}
 		pqErr, ok := err.(*pq.Error)
@@ -236,14 +243,21 @@ func (repo *statsRepository) FindSectorBalance(ctx context.Context, sector strin
 		return empty, errors.E(op, err, errors.KindUnexpected)
 	}
 
+	fees, err := repo.balanceFees(ctx, "sector", "sector", sector, y, m)
+	if err != nil {
+		return metrics.Chart{}, errors.E(op, err, errors.KindUnexpected)
+	}
+
 	chart := metrics.Chart{
 		Label: label,
 		Data: map[string]uint64{
 			"payed":   uint64(payed),
 			"pending": uint64(pending),
 			"expired": uint64(expired),
+			"waived":  uint64(waived),
 		},
 	}
+	fees[label].apply(chart, payed)
 
 	return chart, nil
 }
@@ -256,7 +270,8 @@ func (repo *statsRepository) FindCellBalance(ctx context.Context, cell string, y
 			cell, 
 			pending_amount, 
 			payed_amount,
-			expired_amount
+			expired_amount,
+			waived_amount
 		from 
 			cell_payment_metrics 
 		where cell=$1 and extract(year from period)=$2 and extract(month from period)=$3;
@@ -264,9 +279,9 @@ func (repo *statsRepository) FindCellBalance(ctx context.Context, cell string, y
 
 	var label string
 
-	var payed, pending, expired float64
+	var payed, pending, expired, waived float64
 
-	err := repo.QueryRowContext(ctx, q, cell, y, m).Scan(&label, &pending, &payed, &expired)
+	err := repo.QueryRowContext(ctx, q, cell, y, m).Scan(&label, &pending, &payed, &expired, &waived)
 	if err != nil {
 		empty := metrics.Chart{}
 		pqErr, ok := err.(*pq.Error)
@@ -276,14 +291,21 @@ func (repo *statsRepository) FindCellBalance(ctx context.Context, cell string, y
 		return empty, errors.E(op, err, errors.KindUnexpected)
 	}
 
+	fees, err := repo.balanceFees(ctx, "cell", "cell", cell, y, m)
+	if err != nil {
+		return metrics.Chart{}, errors.E(op, err, errors.KindUnexpected)
+	}
+
 	chart := metrics.Chart{
 		Label: label,
 		Data: map[string]uint64{
 			"payed":   uint64(payed),
 			"pending": uint64(pending),
 			"expired": uint64(expired),
+			"waived":  uint64(waived),
 		},
 	}
+	fees[label].apply(chart, payed)
 
 	return chart, nil
 }
@@ -296,7 +318,8 @@ func (repo *statsRepository) FindVillageBalance(ctx context.Context, cell string
 			village, 
 			pending_amount, 
 			payed_amount,
-			expired_amount
+			expired_amount,
+			waived_amount
 		from 
 			village_payment_metrics 
 		where village=$1 and extract(year from period)=$2 and extract(month from period)=$3;
@@ -304,9 +327,9 @@ func (repo *statsRepository) FindVillageBalance(ctx context.Context, cell string
 
 	var label string
 
-	var payed, pending, expired float64
+	var payed, pending, expired, waived float64
 
-	err := repo.QueryRowContext(ctx, q, cell, y, m).Scan(&label, &pending, &payed, &expired)
+	err := repo.QueryRowContext(ctx, q, cell, y, m).Scan(&label, &pending, &payed, &expired, &waived)
 	if err != nil {
 		empty := metrics.Chart{}
 		pqErr, ok := err.(*pq.Error)
@@ -316,14 +339,21 @@ func (repo *statsRepository) FindVillageBalance(ctx context.Context, cell string
 		return empty, errors.E(op, err, errors.KindUnexpected)
 	}
 
+	fees, err := repo.balanceFees(ctx, "village", "village", cell, y, m)
+	if err != nil {
+		return metrics.Chart{}, errors.E(op, err, errors.KindUnexpected)
+	}
+
 	chart := metrics.Chart{
 		Label: label,
 		Data: map[string]uint64{
 			"payed":   uint64(payed),
 			"pending": uint64(pending),
 			"expired": uint64(expired),
+			"waived":  uint64(waived),
 		},
 	}
+	fees[label].apply(chart, payed)
 
 	return chart, nil
 }
@@ -336,7 +366,8 @@ func (repo *statsRepository) ListSectorBalances(ctx context.Context, sector stri
 			cell, 
 			pending_amount, 
 			payed_amount,
-			expired_amount
+			expired_amount,
+			waived_amount
 		from 
 			cell_payment_metrics 
 		where sector=$1 and extract(year from period)=$2 and extract(month from period)=$3;
@@ -344,6 +375,11 @@ func (repo *statsRepository) ListSectorBalances(ctx context.Context, sector stri
 
 	items := []metrics.Chart{}
 
+	fees, err := repo.balanceFees(ctx, "cell", "sector", sector, y, m)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+
 	rows, err := repo.QueryContext(ctx, q, sector, y, m)
 	if err != nil {
 		return nil, errors.E(op, err, errors.KindUnexpected)
@@ -354,9 +390,9 @@ func (repo *statsRepository) ListSectorBalances(ctx context.Context, sector stri
 	for rows.Next() {
 		var label string
 
-		var payed, pending, expired float64
+		var payed, pending, expired, waived float64
 
-		if err := rows.Scan(&label, &pending, &payed, &expired); err != nil {
+		if err := rows.Scan(&label, &pending, &payed, &expired, &waived); err != nil {
 			return nil, errors.E(op, err, errors.KindUnexpected)
 		}
 
@@ -366,8 +402,10 @@ func (repo *statsRepository) ListSectorBalances(ctx context.Context, sector stri
 				"payed":   uint64(payed),
 				"pending": uint64(pending),
 				"expired": uint64(expired),
+				"waived":  uint64(waived),
 			},
 		}
+		fees[label].apply(chart, payed)
 
 		items = append(items, chart)
 	}
@@ -382,7 +420,8 @@ func (repo *statsRepository) ListCellBalances(ctx context.Context, cell string,
 			village, 
 			pending_amount, 
 			payed_amount, 
-			expired_amount
+			expired_amount,
+			waived_amount
 		from 
 			village_payment_metrics 
 		where cell=$1 and extract(year from period)=$2 and extract(month from period)=$3;
@@ -390,6 +429,11 @@ func (repo *statsRepository) ListCellBalances(ctx context.Context, cell string,
 
 	items := []metrics.Chart{}
 
+	fees, err := repo.balanceFees(ctx, "village", "cell", cell, y, m)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+
 	rows, err := repo.QueryContext(ctx, q, cell, y, m)
 	if err != nil {
 		return nil, errors.E(op, err, errors.KindUnexpected)
@@ -400,9 +444,9 @@ func (repo *statsRepository) ListCellBalances(ctx context.Context, cell string,
 	for rows.Next() {
 		var label string
 
-		var payed, pending, expired float64
+		var payed, pending, expired, waived float64
 
-		if err := rows.Scan(&label, &pending, &payed, &expired); err != nil {
+		if err := rows.Scan(&label, &pending, &payed, &expired, &waived); err != nil {
 			return nil, errors.E(op, err, errors.KindUnexpected)
 		}
 
@@ -412,10 +456,62 @@ func (repo *statsRepository) ListCellBalances(ctx context.Context, cell string,
 				"payed":   uint64(payed),
 				"pending": uint64(pending),
 				"expired": uint64(expired),
+				"waived":  uint64(waived),
 			},
 		}
+		fees[label].apply(chart, payed)
 
 		items = append(items, chart)
 	}
 	return items, nil
 }
+
+// balanceFees holds the fees charged on the money collected in an area
+type balanceFees struct {
+	total float64
+	payee float64
+}
+
+// apply adds the fees and the net collected amount to a balance chart
+func (f balanceFees) apply(chart metrics.Chart, payed float64) {
+	chart.Data["fees"] = uint64(f.total)
+	chart.Data["net"] = uint64(payed - f.payee)
+}
+
+// balanceFees sums the fees of the transactions that paid the invoices of
+// a month by area. Both columns are picked by the callers, never by users.
+func (repo *statsRepository) balanceFees(ctx context.Context, group, filter, value string, y, m uint) (map[string]balanceFees, error) {
+	q := fmt.Sprintf(`
+		select
+			properties.%s,
+			coalesce(sum(transactions.fee), 0),
+			coalesce(sum(transactions.fee) filter (where transactions.fee_bearer='payee'), 0)
+		from
+			transactions
+			join invoices on transactions.invoice=invoices.id
+			join properties on transactions.madefor=properties.id
+		where
+			properties.%s=$1 and transactions.status='successful'
+			and extract(year from invoices.created_at)=$2 and extract(month from invoices.created_at)=$3
+		group by properties.%s;
+	`, group, filter, group)
+
+	rows, err := repo.QueryContext(ctx, q, value, y, m)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fees := make(map[string]balanceFees)
+	for rows.Next() {
+		var (
+			label string
+			f     balanceFees
+		)
+		if err := rows.Scan(&label, &f.total, &f.payee); err != nil {
+			return nil, err
+		}
+		fees[label] = f
+	}
+	return fees, rows.Err()
+}
@@ -863,6 +863,1944 @@ func migrateDB(db *sql.DB) error {
 					`,
 				},
 			},
+			{
+				Id: "027_create_rejected_callbacks_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS rejected_callbacks(
+						id 			UUID DEFAULT uuid_generate_v4(),
+						route 		VARCHAR(254) NOT NULL,
+						reason 		TEXT NOT NULL,
+						payload 	TEXT NOT NULL DEFAULT '',
+						signature 	TEXT NOT NULL DEFAULT '',
+						timestamp 	VARCHAR(32) NOT NULL DEFAULT '',
+						created_at 	TIMESTAMP NOT NULL DEFAULT NOW(),
+						PRIMARY KEY(id)
+					)
+					`,
+				},
+			},
+			{
+				Id: "028_create_sms_outbox_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS sms_outbox(
+						id 				UUID DEFAULT uuid_generate_v4(),
+						sender			VARCHAR NOT NULL,
+						recipients 		TEXT[] NOT NULL,
+						message 		TEXT NOT NULL,
+						status 			VARCHAR(16) NOT NULL DEFAULT 'pending',
+						attempts 		INTEGER NOT NULL DEFAULT 0,
+						last_error 		TEXT NOT NULL DEFAULT '',
+						next_attempt 	TIMESTAMP NOT NULL DEFAULT NOW(),
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(sender) references accounts(id),
+						PRIMARY KEY(id)
+					)
+					`,
+
+					`CREATE INDEX IF NOT EXISTS sms_outbox_pending_idx ON sms_outbox(status, next_attempt);`,
+
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON sms_outbox
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+				},
+			},
+			{
+				Id: "029_create_payment_discrepancies_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS payment_discrepancies(
+						id 				UUID DEFAULT uuid_generate_v4(),
+						ref 			VARCHAR(254) NOT NULL,
+						reason 			TEXT NOT NULL,
+						local_status 	VARCHAR(16) NOT NULL DEFAULT '',
+						remote_status 	VARCHAR(16) NOT NULL DEFAULT '',
+						local_amount 	NUMERIC(9, 2) NOT NULL DEFAULT 0,
+						remote_amount 	NUMERIC(9, 2) NOT NULL DEFAULT 0,
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						UNIQUE(ref, reason),
+						PRIMARY KEY(id)
+					)
+					`,
+				},
+			},
+			{
+				Id: "030_partial_payments_and_property_balances",
+				Up: []string{
+					// the status column is widened for 'partially_paid', views
+					// depending on it have to be dropped and recreated.
+					`DROP VIEW IF EXISTS payment_metrics CASCADE;`,
+
+					`DROP MATERIALIZED VIEW IF EXISTS earliest_pending_invoices_view;`,
+
+					`
+					ALTER TABLE invoices 
+						ALTER COLUMN status TYPE VARCHAR(16),
+						DROP CONSTRAINT invoices_status_check,
+						ADD  CONSTRAINT invoices_status_check CHECK(status in ('pending', 'partially_paid', 'payed', 'expired')),
+						ADD  COLUMN paid NUMERIC (9, 2) NOT NULL DEFAULT (0);
+					`,
+
+					// the refresh triggers point to the dropped views
+					`
+					ALTER TABLE invoices DISABLE TRIGGER USER;
+					UPDATE invoices SET paid=amount WHERE status='payed';
+					ALTER TABLE invoices ENABLE TRIGGER USER;
+					`,
+
+					// payments no longer have to match the amount of their invoice
+					`
+					ALTER TABLE payments
+						DROP CONSTRAINT IF EXISTS payments_invoice_amount_fkey,
+						ADD  FOREIGN KEY(invoice) references invoices(id) ON DELETE CASCADE ON UPDATE CASCADE;
+					`,
+
+					`
+					ALTER TABLE transactions
+						DROP CONSTRAINT IF EXISTS transactions_invoice_amount_fkey,
+						DROP CONSTRAINT IF EXISTS transactions_invoice_key,
+						ADD  FOREIGN KEY(invoice) references invoices(id) ON DELETE CASCADE ON UPDATE CASCADE;
+					`,
+
+					`
+					CREATE TABLE IF NOT EXISTS property_balances(
+						property 	TEXT,
+						credit 		NUMERIC (9, 2) NOT NULL DEFAULT (0) CHECK(credit >= 0),
+						created_at 	TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 	TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(property) references properties(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(property)
+					)
+					`,
+
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON property_balances
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+
+					// successful transactions are allocated to the oldest open
+					// invoices first, the rest is credited to the property.
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_status()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						remaining NUMERIC(9, 2) := NEW.amount;
+						applied NUMERIC(9, 2);
+						rec RECORD;
+					BEGIN
+						IF NEW.status <> 'successful' THEN
+							RETURN NEW;
+						END IF;
+
+						FOR rec IN
+							SELECT 
+								id, amount, paid 
+							FROM 
+								invoices
+							WHERE 
+								property=NEW.madefor AND status IN ('pending', 'partially_paid')
+							ORDER BY created_at, id
+							FOR UPDATE
+						LOOP
+							EXIT WHEN remaining <= 0;
+
+							applied := LEAST(remaining, rec.amount - rec.paid);
+							UPDATE invoices SET 
+								paid=paid + applied,
+								status=CASE WHEN paid + applied >= amount THEN 'payed' ELSE 'partially_paid' END
+							WHERE id=rec.id;
+
+							remaining := remaining - applied;
+						END LOOP;
+
+						IF remaining > 0 THEN
+							INSERT INTO property_balances (property, credit) VALUES (NEW.madefor, remaining)
+							ON CONFLICT (property) DO UPDATE SET credit=property_balances.credit + EXCLUDED.credit;
+						END IF;
+
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// new invoices consume the credit of their property
+					`
+					CREATE  OR REPLACE FUNCTION audit_func(x INT, y INT) RETURNS INT AS
+					$$
+					DECLARE
+						count INT := 0;
+						rec RECORD;
+						inv INT;
+						available NUMERIC(9, 2);
+						applied NUMERIC(9, 2);
+					BEGIN
+						FOR rec IN
+							select 
+								id, due 
+							from 
+								one_month_old_properties_view
+							order by id offset x limit y
+						LOOP
+							inv := NULL;
+							INSERT INTO invoices (property, amount) VALUES (rec.id, rec.due) ON CONFLICT DO NOTHING RETURNING id INTO inv;
+							count:= count + 1;
+
+							IF inv IS NOT NULL THEN
+								SELECT credit INTO available FROM property_balances WHERE property=rec.id FOR UPDATE;
+
+								IF available > 0 THEN
+									applied := LEAST(available, rec.due);
+									UPDATE invoices SET 
+										paid=applied,
+										status=CASE WHEN applied >= amount THEN 'payed' ELSE 'partially_paid' END
+									WHERE id=inv;
+									UPDATE property_balances SET credit=credit - applied WHERE property=rec.id;
+								END IF;
+							END IF;
+						END LOOP;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					`
+					CREATE OR REPLACE VIEW payment_metrics AS
+						SELECT 
+							property,
+							properties.sector,
+							properties.cell,
+							properties.village,
+							date_trunc('month', invoices.created_at) AS period,
+							COUNT(*) filter (WHERE status IN ('pending', 'partially_paid')) AS pending,
+							COUNT(*) filter (WHERE status='payed') AS payed,
+							COALESCE(SUM(amount - paid) FILTER(WHERE status IN ('pending', 'partially_paid')), 0) AS pending_amount,
+							COALESCE(SUM(paid), 0) AS payed_amount,
+							COUNT(*) filter (WHERE status='expired') AS expired,
+							COALESCE(SUM(amount - paid) FILTER(WHERE status='expired'), 0) AS expired_amount
+						FROM invoices
+							JOIN properties on invoices.property=properties.id
+						GROUP BY 
+							property,
+							period,
+							properties.sector, 
+							properties.cell, 
+							properties.village
+						ORDER BY property; 
+					`,
+
+					`
+					CREATE MATERIALIZED VIEW IF NOT EXISTS sector_payment_metrics AS
+						SELECT
+							sector,
+							period,
+							SUM(pending) as pending_count,
+							SUM(payed) as payed_count,
+							SUM(expired) as expired_count,
+							COALESCE(sum(pending_amount),0) AS pending_amount,
+							COALESCE(sum(payed_amount),0) AS payed_amount,
+							COALESCE(sum(expired_amount),0) AS expired_amount
+						FROM payment_metrics GROUP BY sector, period;
+					`,
+
+					`create unique index on  sector_payment_metrics(sector, period);`,
+
+					`
+					CREATE MATERIALIZED VIEW IF NOT EXISTS cell_payment_metrics as
+						SELECT
+							cell,
+							sector,
+							period,
+							SUM(pending) as pending_count,
+							SUM(payed) as payed_count,
+							SUM(expired) as expired_count,
+							COALESCE(SUM(pending_amount),0) as pending_amount,
+							COALESCE(SUM(payed_amount),0) as payed_amount,
+							COALESCE(SUM(expired_amount),0) AS expired_amount
+						FROM payment_metrics GROUP by cell, sector, period;
+					`,
+
+					`CREATE unique index on cell_payment_metrics(cell, sector, period);`,
+
+					`
+					CREATE MATERIALIZED VIEW IF NOT EXISTS village_payment_metrics AS
+						SELECT
+							village,
+							cell,
+							period,
+							SUM(pending) as pending_count,
+							SUM(payed) as payed_count,
+							SUM(expired) as expired_count,
+							COALESCE(SUM(pending_amount),0) AS pending_amount,
+							COALESCE(SUM(payed_amount),0) AS payed_amount,
+							COALESCE(SUM(expired_amount),0) AS expired_amount
+						FROM payment_metrics GROUP BY village, cell, period;
+					`,
+
+					`CREATE unique index on village_payment_metrics(village, cell, period);`,
+
+					`CREATE MATERIALIZED VIEW earliest_pending_invoices_view AS
+						SELECT 
+							invoices.*
+						FROM
+							(
+								SELECT
+									property, MIN(created_at) as created_at 
+								FROM 
+									invoices WHERE status IN ('pending', 'partially_paid') GROUP BY property
+							) AS pending
+						INNER JOIN
+							invoices
+						ON 
+							invoices.property=pending.property AND invoices.created_at=pending.created_at 
+						ORDER BY 
+							invoices.id
+					`,
+
+					`CREATE unique index ON earliest_pending_invoices_view(property);`,
+				},
+			},
+			{
+				Id: "031_create_refunds_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS refunds(
+						id 				UUID,
+						ref 			VARCHAR(254) NOT NULL,
+						reversal 		VARCHAR(254) NOT NULL DEFAULT '',
+						property 		TEXT NOT NULL,
+						msisdn 			VARCHAR(15) NOT NULL,
+						method 			VARCHAR(254) NOT NULL,
+						amount 			NUMERIC (9, 2) NOT NULL DEFAULT (0),
+						reason 			TEXT NOT NULL,
+						status 			VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK(status in ('pending', 'approved', 'completed', 'rejected', 'failed')),
+						requested_by 	VARCHAR(254) NOT NULL DEFAULT '',
+						reviewed_by 	VARCHAR(254) NOT NULL DEFAULT '',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(property) references properties(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(id)
+					)
+					`,
+
+					// a payment can only be refunded once, closed requests don't count
+					`CREATE UNIQUE INDEX ON refunds(ref) WHERE status NOT IN ('rejected', 'failed');`,
+
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON refunds
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+
+					`
+					ALTER TABLE transactions 
+						DROP CONSTRAINT IF EXISTS transactions_status_check,
+						ADD  CONSTRAINT transactions_status_check CHECK(status in ('pending', 'successful', 'failed', 'reversed'));
+					`,
+				},
+			},
+			{
+				Id: "032_alter_payments_table_add_expiry",
+				Up: []string{
+					`
+					ALTER TABLE payments
+						ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '',
+						DROP CONSTRAINT IF EXISTS payments_status_check,
+						ADD  CONSTRAINT payments_status_check CHECK(status in ('pending', 'successful', 'failed', 'expired'));
+					`,
+				},
+			},
+			{
+				Id: "033_create_payment_events_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS payment_events (
+						id 				UUID NOT NULL DEFAULT uuid_generate_v4(),
+						ref 			TEXT NOT NULL,
+						kind 			VARCHAR(32) NOT NULL,
+						status 			VARCHAR(32) NOT NULL DEFAULT '',
+						payload 		JSONB NOT NULL DEFAULT '{}',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS payment_events_ref_idx ON payment_events(ref, created_at);`,
+				},
+			},
+			{
+				Id: "034_create_payouts_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS payouts (
+						id 				UUID NOT NULL DEFAULT uuid_generate_v4(),
+						ref 			VARCHAR(254) NOT NULL UNIQUE,
+						namespace 		TEXT NOT NULL DEFAULT '',
+						msisdn 			VARCHAR(15) NOT NULL,
+						method 			VARCHAR(254) NOT NULL,
+						amount 			NUMERIC (9, 2) NOT NULL DEFAULT (0),
+						status 			VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK(status in ('pending', 'successful', 'failed')),
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS payouts_namespace_idx ON payouts(namespace, created_at);`,
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON payouts
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+				},
+			},
+			{
+				Id: "035_create_fee_rules_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS fee_rules (
+						account 		VARCHAR(254) NOT NULL,
+						type 			VARCHAR(16) NOT NULL CHECK(type in ('flat', 'percentage', 'tiered')),
+						value 			NUMERIC (9, 4) NOT NULL DEFAULT (0),
+						tiers 			JSONB NOT NULL DEFAULT '[]',
+						bearer 			VARCHAR(8) NOT NULL DEFAULT 'payee' CHECK(bearer in ('payer', 'payee')),
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(account) references accounts(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(account)
+					)
+					`,
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON fee_rules
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+					`
+					ALTER TABLE transactions
+						ADD COLUMN IF NOT EXISTS fee NUMERIC (9, 2) NOT NULL DEFAULT (0),
+						ADD COLUMN IF NOT EXISTS gateway_fee NUMERIC (9, 2) NOT NULL DEFAULT (0),
+						ADD COLUMN IF NOT EXISTS fee_bearer VARCHAR(8) NOT NULL DEFAULT 'payee' CHECK(fee_bearer in ('payer', 'payee'));
+					`,
+
+					// existing transactions were always reported with the flat 5% commission
+					`UPDATE transactions SET fee = ROUND(amount * 0.05, 2);`,
+				},
+			},
+			{
+				Id: "036_create_ledger_tables",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS ledger_accounts (
+						code 			TEXT NOT NULL,
+						kind 			VARCHAR(16) NOT NULL CHECK(kind in ('asset', 'liability', 'revenue', 'expense')),
+						namespace 		TEXT NOT NULL,
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						PRIMARY KEY(code)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS ledger_accounts_namespace_idx ON ledger_accounts(namespace);`,
+					`
+					CREATE TABLE IF NOT EXISTS journal_entries (
+						id 				UUID NOT NULL DEFAULT uuid_generate_v4(),
+						kind 			VARCHAR(16) NOT NULL,
+						ref 			TEXT NOT NULL,
+						namespace 		TEXT NOT NULL,
+						description 	TEXT NOT NULL DEFAULT '',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						UNIQUE(kind, ref),
+						PRIMARY KEY(id)
+					)
+					`,
+					`
+					CREATE TABLE IF NOT EXISTS journal_lines (
+						id 				BIGSERIAL,
+						entry 			UUID NOT NULL,
+						account 		TEXT NOT NULL,
+						debit 			NUMERIC (12, 2) NOT NULL DEFAULT (0),
+						credit 			NUMERIC (12, 2) NOT NULL DEFAULT (0),
+						CHECK(debit >= 0 AND credit >= 0 AND (debit = 0) <> (credit = 0)),
+						FOREIGN KEY(entry) references journal_entries(id),
+						FOREIGN KEY(account) references ledger_accounts(code),
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS journal_lines_account_idx ON journal_lines(account);`,
+					`CREATE INDEX IF NOT EXISTS journal_lines_entry_idx ON journal_lines(entry);`,
+
+					// posted entries are never changed, mistakes are fixed with new entries
+					`
+					CREATE OR REPLACE FUNCTION trigger_forbid_journal_changes()
+					RETURNS TRIGGER AS $$
+					BEGIN
+						RAISE EXCEPTION 'journal entries are immutable';
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+					`
+					CREATE TRIGGER forbid_journal_changes
+					BEFORE UPDATE OR DELETE ON journal_entries
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_forbid_journal_changes();
+					`,
+					`
+					CREATE TRIGGER forbid_journal_changes
+					BEFORE UPDATE OR DELETE ON journal_lines
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_forbid_journal_changes();
+					`,
+
+					// entries are checked once all their lines are written
+					`
+					CREATE OR REPLACE FUNCTION trigger_check_journal_balance()
+					RETURNS TRIGGER AS $$
+					BEGIN
+						IF (SELECT SUM(debit - credit) FROM journal_lines WHERE entry = NEW.entry) <> 0 THEN
+							RAISE EXCEPTION 'journal entry % is not balanced', NEW.entry;
+						END IF;
+						RETURN NULL;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+					`
+					CREATE CONSTRAINT TRIGGER check_journal_balance
+					AFTER INSERT ON journal_lines
+					DEFERRABLE INITIALLY DEFERRED
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_check_journal_balance();
+					`,
+
+					// invoices are created by several database functions, posting
+					// them from a trigger covers every one of them
+					`
+					CREATE OR REPLACE FUNCTION trigger_post_invoice_entry()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						ns TEXT;
+						entry UUID;
+					BEGIN
+						IF NEW.amount <= 0 THEN
+							RETURN NEW;
+						END IF;
+
+						SELECT namespace INTO ns FROM properties WHERE id = NEW.property;
+
+						INSERT INTO ledger_accounts (code, kind, namespace) VALUES
+							('receivable:' || NEW.property, 'asset', ns),
+							('revenue:' || ns, 'revenue', ns)
+						ON CONFLICT (code) DO NOTHING;
+
+						INSERT INTO journal_entries (kind, ref, namespace, description)
+						VALUES ('invoice', NEW.id::TEXT, ns, 'invoice ' || NEW.id)
+						ON CONFLICT (kind, ref) DO NOTHING
+						RETURNING id INTO entry;
+
+						IF entry IS NOT NULL THEN
+							INSERT INTO journal_lines (entry, account, debit, credit) VALUES
+								(entry, 'receivable:' || NEW.property, NEW.amount, 0),
+								(entry, 'revenue:' || ns, 0, NEW.amount);
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+					`
+					CREATE TRIGGER post_invoice_entry
+					AFTER INSERT ON invoices
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_post_invoice_entry();
+					`,
+
+					// opening entries for the money that moved before the ledger existed
+					`
+					INSERT INTO ledger_accounts (code, kind, namespace)
+						SELECT 'receivable:' || p.id, 'asset', p.namespace FROM properties p
+						UNION SELECT 'revenue:' || a.id, 'revenue', a.id FROM accounts a
+						UNION SELECT 'clearing:' || a.id, 'asset', a.id FROM accounts a
+						UNION SELECT 'fees:' || a.id, 'expense', a.id FROM accounts a
+						UNION SELECT 'payouts:' || a.id, 'liability', a.id FROM accounts a
+					ON CONFLICT (code) DO NOTHING;
+					`,
+					`
+					WITH posted AS (
+						INSERT INTO journal_entries (kind, ref, namespace, description, created_at)
+							SELECT 'invoice', i.id::TEXT, p.namespace, 'invoice ' || i.id, i.created_at
+							FROM invoices i JOIN properties p ON i.property = p.id
+							WHERE i.amount > 0
+						ON CONFLICT (kind, ref) DO NOTHING
+						RETURNING id, ref, namespace
+					)
+					INSERT INTO journal_lines (entry, account, debit, credit)
+						SELECT posted.id, 'receivable:' || i.property, i.amount, 0
+						FROM posted JOIN invoices i ON i.id::TEXT = posted.ref
+						UNION ALL
+						SELECT posted.id, 'revenue:' || posted.namespace, 0, i.amount
+						FROM posted JOIN invoices i ON i.id::TEXT = posted.ref;
+					`,
+					`
+					WITH paid AS (
+						SELECT
+							ref::TEXT AS ref, namespace, madefor, MIN(created_at) AS created_at,
+							SUM(amount) AS amount,
+							SUM(CASE WHEN fee_bearer = 'payee' THEN fee ELSE 0 END) AS fee
+						FROM transactions
+						WHERE status = 'successful'
+						GROUP BY ref, namespace, madefor
+					), posted AS (
+						INSERT INTO journal_entries (kind, ref, namespace, description, created_at)
+							SELECT 'payment', ref, MIN(namespace), 'payment ' || ref, MIN(created_at) FROM paid GROUP BY ref
+						ON CONFLICT (kind, ref) DO NOTHING
+						RETURNING id, ref
+					)
+					INSERT INTO journal_lines (entry, account, debit, credit)
+						SELECT posted.id, 'clearing:' || paid.namespace, paid.amount, 0
+						FROM posted JOIN paid ON paid.ref = posted.ref
+						UNION ALL
+						SELECT posted.id, 'receivable:' || paid.madefor, 0, paid.amount
+						FROM posted JOIN paid ON paid.ref = posted.ref
+						UNION ALL
+						SELECT posted.id, 'fees:' || paid.namespace, paid.fee, 0
+						FROM posted JOIN paid ON paid.ref = posted.ref WHERE paid.fee > 0
+						UNION ALL
+						SELECT posted.id, 'clearing:' || paid.namespace, 0, paid.fee
+						FROM posted JOIN paid ON paid.ref = posted.ref WHERE paid.fee > 0;
+					`,
+					`
+					WITH posted AS (
+						INSERT INTO journal_entries (kind, ref, namespace, description, created_at)
+							SELECT 'refund', r.id::TEXT, p.namespace, 'refund of payment ' || r.ref, r.updated_at
+							FROM refunds r JOIN properties p ON r.property = p.id
+							WHERE r.status = 'completed' AND r.amount > 0
+						ON CONFLICT (kind, ref) DO NOTHING
+						RETURNING id, ref, namespace
+					)
+					INSERT INTO journal_lines (entry, account, debit, credit)
+						SELECT posted.id, 'receivable:' || r.property, r.amount, 0
+						FROM posted JOIN refunds r ON r.id::TEXT = posted.ref
+						UNION ALL
+						SELECT posted.id, 'payouts:' || posted.namespace, 0, r.amount
+						FROM posted JOIN refunds r ON r.id::TEXT = posted.ref;
+					`,
+					`
+					INSERT INTO ledger_accounts (code, kind, namespace)
+						SELECT DISTINCT 'payouts:' || namespace, 'liability', namespace FROM payouts WHERE status = 'successful'
+						UNION SELECT DISTINCT 'clearing:' || namespace, 'asset', namespace FROM payouts WHERE status = 'successful'
+					ON CONFLICT (code) DO NOTHING;
+					`,
+					`
+					WITH posted AS (
+						INSERT INTO journal_entries (kind, ref, namespace, description, created_at)
+							SELECT 'payout', ref, namespace, 'payout to ' || msisdn, updated_at
+							FROM payouts
+							WHERE status = 'successful' AND amount > 0
+						ON CONFLICT (kind, ref) DO NOTHING
+						RETURNING id, ref, namespace
+					)
+					INSERT INTO journal_lines (entry, account, debit, credit)
+						SELECT posted.id, 'payouts:' || posted.namespace, p.amount, 0
+						FROM posted JOIN payouts p ON p.ref = posted.ref
+						UNION ALL
+						SELECT posted.id, 'clearing:' || posted.namespace, 0, p.amount
+						FROM posted JOIN payouts p ON p.ref = posted.ref;
+					`,
+				},
+			},
+			{
+				Id: "037_create_manual_payments_tables",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS manual_payments (
+						id 				UUID NOT NULL,
+						property 		TEXT NOT NULL,
+						namespace 		TEXT NOT NULL,
+						amount 			NUMERIC (9, 2) NOT NULL CHECK(amount > 0),
+						months 			INTEGER NOT NULL DEFAULT 1 CHECK(months > 0),
+						method 			VARCHAR(8) NOT NULL CHECK(method in ('cash', 'bank')),
+						msisdn 			VARCHAR(15) NOT NULL DEFAULT '',
+						slip 			VARCHAR(254) NOT NULL DEFAULT '',
+						photo 			BYTEA,
+						status 			VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK(status in ('pending', 'approved', 'rejected', 'failed')),
+						reason 			TEXT NOT NULL DEFAULT '',
+						recorded_by 	VARCHAR(254) NOT NULL,
+						reviewed_by 	VARCHAR(254) NOT NULL DEFAULT '',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(property) references properties(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS manual_payments_namespace_idx ON manual_payments(namespace, created_at);`,
+					`CREATE INDEX IF NOT EXISTS manual_payments_agent_idx ON manual_payments(recorded_by, status);`,
+
+					// a deposit slip settles a single payment
+					`CREATE UNIQUE INDEX IF NOT EXISTS manual_payments_slip_idx ON manual_payments(method, slip) WHERE slip <> '' AND status <> 'rejected';`,
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON manual_payments
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+					`
+					CREATE TABLE IF NOT EXISTS cash_remittances (
+						id 				UUID NOT NULL,
+						agent 			VARCHAR(254) NOT NULL,
+						namespace 		TEXT NOT NULL,
+						amount 			NUMERIC (9, 2) NOT NULL CHECK(amount > 0),
+						received_by 	VARCHAR(254) NOT NULL,
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS cash_remittances_agent_idx ON cash_remittances(agent, namespace);`,
+				},
+			},
+			{
+				Id: "038_create_bank_statements_tables",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS bank_statements (
+						id 				UUID NOT NULL,
+						namespace 		TEXT NOT NULL,
+						filename 		VARCHAR(254) NOT NULL DEFAULT '',
+						uploaded_by 	VARCHAR(254) NOT NULL DEFAULT '',
+						lines 			INTEGER NOT NULL DEFAULT 0,
+						duplicates 		INTEGER NOT NULL DEFAULT 0,
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						PRIMARY KEY(id)
+					)
+					`,
+					`
+					CREATE TABLE IF NOT EXISTS statement_lines (
+						id 				UUID NOT NULL,
+						statement 		UUID NOT NULL,
+						namespace 		TEXT NOT NULL,
+						posted_at 		DATE,
+						reference 		VARCHAR(254) NOT NULL DEFAULT '',
+						narrative 		TEXT NOT NULL DEFAULT '',
+						amount 			NUMERIC (12, 2) NOT NULL CHECK(amount > 0),
+						status 			VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK(status in ('pending', 'matched', 'review', 'ignored')),
+						property 		TEXT NOT NULL DEFAULT '',
+						reason 			TEXT NOT NULL DEFAULT '',
+						reviewed_by 	VARCHAR(254) NOT NULL DEFAULT '',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(statement) references bank_statements(id) ON DELETE CASCADE,
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS statement_lines_status_idx ON statement_lines(status, created_at);`,
+					`CREATE INDEX IF NOT EXISTS statement_lines_namespace_idx ON statement_lines(namespace, created_at);`,
+
+					// overlapping statements list the same deposit more than once
+					`CREATE UNIQUE INDEX IF NOT EXISTS statement_lines_deposit_idx ON statement_lines(namespace, reference, posted_at, amount) WHERE reference <> '';`,
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON statement_lines
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+				},
+			},
+			{
+				Id: "039_create_settlements_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS settlements (
+						id 				UUID NOT NULL,
+						namespace 		TEXT NOT NULL,
+						day 			DATE NOT NULL,
+						payments 		INTEGER NOT NULL DEFAULT 0,
+						collected 		NUMERIC (12, 2) NOT NULL DEFAULT 0,
+						transactions 	INTEGER NOT NULL DEFAULT 0,
+						recorded 		NUMERIC (12, 2) NOT NULL DEFAULT 0,
+						fees 			NUMERIC (12, 2) NOT NULL DEFAULT 0,
+						gateway_fees 	NUMERIC (12, 2) NOT NULL DEFAULT 0,
+						payouts 		INTEGER NOT NULL DEFAULT 0,
+						paid_out 		NUMERIC (12, 2) NOT NULL DEFAULT 0,
+						net 			NUMERIC (12, 2) NOT NULL DEFAULT 0,
+						status 			VARCHAR(16) NOT NULL CHECK(status in ('balanced', 'unbalanced')),
+						signature 		TEXT NOT NULL,
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						UNIQUE(namespace, day),
+						PRIMARY KEY(id)
+					)
+					`,
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON settlements
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+				},
+			},
+			{
+				Id: "040_normalize_msisdn",
+				Up: []string{
+					`
+					CREATE OR REPLACE FUNCTION normalize_msisdn(phone TEXT)
+					RETURNS TEXT AS $$
+					DECLARE
+						digits TEXT := regexp_replace(phone, '[ ().-]', '', 'g');
+					BEGIN
+						digits := regexp_replace(digits, '^(\+|00)', '');
+						IF digits ~ '^(250|0)?7[2389][0-9]{7}$' THEN
+							RETURN '+250' || right(digits, 9);
+						END IF;
+						RETURN phone;
+					END;
+					$$ LANGUAGE plpgsql IMMUTABLE;
+					`,
+					// owners sharing a number written differently have to be merged
+					// by hand first, the migration stops and lists them
+					`
+					DO $$
+					DECLARE
+						collisions TEXT;
+					BEGIN
+						SELECT string_agg(number || ': ' || phones, '; ') INTO collisions
+						FROM (
+							SELECT normalize_msisdn(phone) AS number, string_agg(phone || ' (' || id || ')', ', ' ORDER BY phone) AS phones
+							FROM owners
+							GROUP BY normalize_msisdn(phone)
+							HAVING COUNT(*) > 1
+						) AS shared;
+
+						IF collisions IS NOT NULL THEN
+							RAISE EXCEPTION 'owners share phone numbers, merge them before normalizing: %', collisions;
+						END IF;
+					END;
+					$$;
+					`,
+					`UPDATE owners SET phone = normalize_msisdn(phone) WHERE phone <> normalize_msisdn(phone);`,
+					// the numbers change but the rows keep their update time, reports
+					// and settlements are computed from it
+					`
+					ALTER TABLE payments DISABLE TRIGGER set_timestamp;
+					UPDATE payments SET msisdn = normalize_msisdn(msisdn) WHERE msisdn <> normalize_msisdn(msisdn);
+					ALTER TABLE payments ENABLE TRIGGER set_timestamp;
+					`,
+					`
+					ALTER TABLE refunds DISABLE TRIGGER set_timestamp;
+					UPDATE refunds SET msisdn = normalize_msisdn(msisdn) WHERE msisdn <> normalize_msisdn(msisdn);
+					ALTER TABLE refunds ENABLE TRIGGER set_timestamp;
+					`,
+					`
+					ALTER TABLE payouts DISABLE TRIGGER set_timestamp;
+					UPDATE payouts SET msisdn = normalize_msisdn(msisdn) WHERE msisdn <> normalize_msisdn(msisdn);
+					ALTER TABLE payouts ENABLE TRIGGER set_timestamp;
+					`,
+					`
+					ALTER TABLE manual_payments DISABLE TRIGGER set_timestamp;
+					UPDATE manual_payments SET msisdn = normalize_msisdn(msisdn) WHERE msisdn <> normalize_msisdn(msisdn);
+					ALTER TABLE manual_payments ENABLE TRIGGER set_timestamp;
+					`,
+				},
+			},
+			{
+				Id: "041_billing_cycles",
+				Up: []string{
+					`
+					ALTER TABLE properties
+						ADD COLUMN IF NOT EXISTS billing_cycle VARCHAR(10) NOT NULL DEFAULT 'monthly' CHECK(billing_cycle in ('monthly', 'quarterly', 'yearly')),
+						ADD COLUMN IF NOT EXISTS billing_anchor SMALLINT NOT NULL DEFAULT 1 CHECK(billing_anchor BETWEEN 1 AND 28);
+					`,
+
+					`
+					CREATE OR REPLACE FUNCTION billing_interval(cycle VARCHAR)
+					RETURNS INTERVAL AS $$
+						SELECT CASE cycle
+							WHEN 'quarterly' THEN INTERVAL '3 months'
+							WHEN 'yearly' THEN INTERVAL '1 year'
+							ELSE INTERVAL '1 month'
+						END;
+					$$ LANGUAGE SQL IMMUTABLE;
+					`,
+
+					// periods start on the anchor day, quarters and years are
+					// aligned on the calendar
+					`
+					CREATE OR REPLACE FUNCTION billing_period_start(cycle VARCHAR, anchor INT, ts TIMESTAMP)
+					RETURNS DATE AS $$
+						SELECT (
+							DATE_TRUNC(
+								CASE cycle WHEN 'quarterly' THEN 'quarter' WHEN 'yearly' THEN 'year' ELSE 'month' END,
+								ts - INTERVAL '1 day' * (anchor - 1)
+							) + INTERVAL '1 day' * (anchor - 1)
+						)::date;
+					$$ LANGUAGE SQL IMMUTABLE;
+					`,
+
+					`
+					ALTER TABLE invoices
+						ADD COLUMN IF NOT EXISTS period_start DATE,
+						ADD COLUMN IF NOT EXISTS period_end DATE;
+					`,
+
+					// every invoice so far was monthly
+					`
+					ALTER TABLE invoices DISABLE TRIGGER USER;
+					UPDATE invoices SET 
+						period_start=DATE_TRUNC('month', created_at)::date,
+						period_end=(DATE_TRUNC('month', created_at) + INTERVAL '1 month')::date;
+					ALTER TABLE invoices ENABLE TRIGGER USER;
+					`,
+
+					`
+					ALTER TABLE invoices
+						ALTER COLUMN period_start SET NOT NULL,
+						ALTER COLUMN period_end SET NOT NULL;
+					`,
+
+					`DROP INDEX IF EXISTS single_invoice_per_property_per_month;`,
+
+					`CREATE UNIQUE INDEX IF NOT EXISTS single_invoice_per_property_per_period ON invoices(property, period_start);`,
+
+					// invoices inserted without a period get the one of their
+					// creation in the billing cycle of their property
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_period()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						cycle VARCHAR := 'monthly';
+						anchor INT := 1;
+					BEGIN
+						SELECT billing_cycle, billing_anchor INTO cycle, anchor FROM properties WHERE id=NEW.property;
+
+						IF NEW.period_start IS NULL THEN
+							NEW.period_start := billing_period_start(COALESCE(cycle, 'monthly'), COALESCE(anchor, 1), COALESCE(NEW.created_at, LOCALTIMESTAMP));
+						END IF;
+						IF NEW.period_end IS NULL THEN
+							NEW.period_end := (NEW.period_start + billing_interval(cycle))::date;
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					`
+					CREATE TRIGGER set_invoice_period
+					BEFORE INSERT ON invoices
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_invoice_period();
+					`,
+
+					// the materialized view went stale as soon as a month went by,
+					// properties due for an invoice are looked up when auditing
+					`DROP TRIGGER IF EXISTS trigger_refresh_one_month_old_properties_view ON properties;`,
+
+					`DROP FUNCTION IF EXISTS refresh_one_month_old_properties_view();`,
+
+					`DROP MATERIALIZED VIEW IF EXISTS one_month_old_properties_view;`,
+
+					`
+					CREATE OR REPLACE VIEW billable_properties_view AS
+						SELECT
+							id, due, created_at
+						FROM
+							properties
+						WHERE
+							created_at < billing_period_start(billing_cycle, billing_anchor, LOCALTIMESTAMP)
+					`,
+
+					`
+					CREATE  OR REPLACE FUNCTION audit_func(x INT, y INT) RETURNS INT AS
+					$$
+					DECLARE
+						count INT := 0;
+						rec RECORD;
+						inv INT;
+						available NUMERIC(9, 2);
+						applied NUMERIC(9, 2);
+					BEGIN
+						FOR rec IN
+							select 
+								id, due 
+							from 
+								billable_properties_view
+							order by id offset x limit y
+						LOOP
+							inv := NULL;
+							INSERT INTO invoices (property, amount) VALUES (rec.id, rec.due) ON CONFLICT DO NOTHING RETURNING id INTO inv;
+							count:= count + 1;
+
+							IF inv IS NOT NULL THEN
+								SELECT credit INTO available FROM property_balances WHERE property=rec.id FOR UPDATE;
+
+								IF available > 0 THEN
+									applied := LEAST(available, rec.due);
+									UPDATE invoices SET 
+										paid=applied,
+										status=CASE WHEN applied >= amount THEN 'payed' ELSE 'partially_paid' END
+									WHERE id=inv;
+									UPDATE property_balances SET credit=credit - applied WHERE property=rec.id;
+								END IF;
+							END IF;
+						END LOOP;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// invoices expire once their period is over
+					`
+					CREATE  OR REPLACE FUNCTION archive_func() 
+					RETURNS INT AS $$
+					
+					DECLARE count INT := 0;
+					BEGIN
+						UPDATE invoices SET status='expired' WHERE id IN(
+							select 
+								id
+							from 
+								invoices
+							where 
+								status='pending' 
+							AND 
+								period_end <= CURRENT_DATE
+							ORDER BY id OFFSET 0 LIMIT 50
+						);
+
+						GET DIAGNOSTICS count = ROW_COUNT;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+				},
+			},
+			{
+				Id: "042_create_waivers_table",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS waivers (
+						id 				UUID NOT NULL,
+						property 		TEXT NOT NULL,
+						namespace 		TEXT NOT NULL,
+						percent 		NUMERIC (5, 2) NOT NULL CHECK(percent > 0 AND percent <= 100),
+						starts_on 		DATE NOT NULL,
+						ends_on 		DATE NOT NULL,
+						reason 			TEXT NOT NULL,
+						status 			VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK(status in ('pending', 'approved', 'rejected')),
+						note 			TEXT NOT NULL DEFAULT '',
+						requested_by 	VARCHAR(254) NOT NULL,
+						reviewed_by 	VARCHAR(254) NOT NULL DEFAULT '',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						CHECK(starts_on <= ends_on),
+						FOREIGN KEY(property) references properties(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS waivers_namespace_idx ON waivers(namespace, created_at);`,
+					`CREATE INDEX IF NOT EXISTS waivers_property_idx ON waivers(property, status);`,
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON waivers
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+
+					// the metrics depend on the status column, they are recreated below
+					`DROP VIEW IF EXISTS payment_metrics CASCADE;`,
+
+					`
+					ALTER TABLE invoices
+						DROP CONSTRAINT invoices_status_check,
+						ADD  CONSTRAINT invoices_status_check CHECK(status in ('pending', 'partially_paid', 'payed', 'expired', 'waived')),
+						ADD  COLUMN IF NOT EXISTS waived NUMERIC (9, 2) NOT NULL DEFAULT (0) CHECK(waived >= 0);
+					`,
+
+					// new invoices are discounted by the approved waiver covering
+					// their period, it runs after set_invoice_period
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_waiver()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						pct NUMERIC(5, 2);
+					BEGIN
+						SELECT 
+							MAX(percent) INTO pct 
+						FROM 
+							waivers 
+						WHERE 
+							property=NEW.property AND status='approved' AND NEW.period_start BETWEEN starts_on AND ends_on;
+
+						IF pct IS NULL OR NEW.amount <= 0 THEN
+							RETURN NEW;
+						END IF;
+
+						NEW.waived := GREATEST(NEW.waived, LEAST(NEW.amount - NEW.paid, ROUND(NEW.amount * pct / 100, 2)));
+
+						IF NEW.status = 'pending' AND NEW.paid + NEW.waived >= NEW.amount THEN
+							NEW.status := 'waived';
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					`
+					CREATE TRIGGER set_invoice_waiver
+					BEFORE INSERT ON invoices
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_invoice_waiver();
+					`,
+
+					// the revenue given up is expensed, waived amounts only grow so
+					// every increase gets an entry of its own
+					`
+					CREATE OR REPLACE FUNCTION trigger_post_waiver_entry()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						ns TEXT;
+						entry UUID;
+						discount NUMERIC(9, 2) := NEW.waived;
+					BEGIN
+						IF TG_OP = 'UPDATE' THEN
+							discount := NEW.waived - OLD.waived;
+						END IF;
+
+						IF discount <= 0 THEN
+							RETURN NEW;
+						END IF;
+
+						SELECT namespace INTO ns FROM properties WHERE id = NEW.property;
+
+						INSERT INTO ledger_accounts (code, kind, namespace) VALUES
+							('receivable:' || NEW.property, 'asset', ns),
+							('waivers:' || ns, 'expense', ns)
+						ON CONFLICT (code) DO NOTHING;
+
+						INSERT INTO journal_entries (kind, ref, namespace, description)
+						VALUES ('waiver', NEW.id::TEXT || ':' || NEW.waived::TEXT, ns, 'waiver on invoice ' || NEW.id)
+						ON CONFLICT (kind, ref) DO NOTHING
+						RETURNING id INTO entry;
+
+						IF entry IS NOT NULL THEN
+							INSERT INTO journal_lines (entry, account, debit, credit) VALUES
+								(entry, 'waivers:' || ns, discount, 0),
+								(entry, 'receivable:' || NEW.property, 0, discount);
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					`
+					CREATE TRIGGER post_waiver_entry
+					AFTER INSERT OR UPDATE OF waived ON invoices
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_post_waiver_entry();
+					`,
+
+					// waived amounts are never owed
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_status()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						remaining NUMERIC(9, 2) := NEW.amount;
+						applied NUMERIC(9, 2);
+						rec RECORD;
+					BEGIN
+						IF NEW.status <> 'successful' THEN
+							RETURN NEW;
+						END IF;
+
+						FOR rec IN
+							SELECT 
+								id, amount, paid, waived 
+							FROM 
+								invoices
+							WHERE 
+								property=NEW.madefor AND status IN ('pending', 'partially_paid')
+							ORDER BY created_at, id
+							FOR UPDATE
+						LOOP
+							EXIT WHEN remaining <= 0;
+
+							applied := LEAST(remaining, rec.amount - rec.waived - rec.paid);
+							CONTINUE WHEN applied <= 0;
+
+							UPDATE invoices SET 
+								paid=paid + applied,
+								status=CASE WHEN paid + applied >= amount - waived THEN 'payed' ELSE 'partially_paid' END
+							WHERE id=rec.id;
+
+							remaining := remaining - applied;
+						END LOOP;
+
+						IF remaining > 0 THEN
+							INSERT INTO property_balances (property, credit) VALUES (NEW.madefor, remaining)
+							ON CONFLICT (property) DO UPDATE SET credit=property_balances.credit + EXCLUDED.credit;
+						END IF;
+
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					`
+					CREATE  OR REPLACE FUNCTION audit_func(x INT, y INT) RETURNS INT AS
+					$$
+					DECLARE
+						count INT := 0;
+						rec RECORD;
+						inv INT;
+						owed NUMERIC(9, 2);
+						available NUMERIC(9, 2);
+						applied NUMERIC(9, 2);
+					BEGIN
+						FOR rec IN
+							select 
+								id, due 
+							from 
+								billable_properties_view
+							order by id offset x limit y
+						LOOP
+							inv := NULL;
+							INSERT INTO invoices (property, amount) VALUES (rec.id, rec.due) ON CONFLICT DO NOTHING RETURNING id, amount - waived INTO inv, owed;
+							count:= count + 1;
+
+							IF inv IS NOT NULL AND owed > 0 THEN
+								SELECT credit INTO available FROM property_balances WHERE property=rec.id FOR UPDATE;
+
+								IF available > 0 THEN
+									applied := LEAST(available, owed);
+									UPDATE invoices SET 
+										paid=applied,
+										status=CASE WHEN applied >= amount - waived THEN 'payed' ELSE 'partially_paid' END
+									WHERE id=inv;
+									UPDATE property_balances SET credit=credit - applied WHERE property=rec.id;
+								END IF;
+							END IF;
+						END LOOP;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// waived invoices are reported on their own so that they
+					// don't count as unpaid
+					`
+					CREATE OR REPLACE VIEW payment_metrics AS
+						SELECT 
+							property,
+							properties.sector,
+							properties.cell,
+							properties.village,
+							date_trunc('month', invoices.created_at) AS period,
+							COUNT(*) filter (WHERE status IN ('pending', 'partially_paid')) AS pending,
+							COUNT(*) filter (WHERE status='payed') AS payed,
+							COALESCE(SUM(amount - waived - paid) FILTER(WHERE status IN ('pending', 'partially_paid')), 0) AS pending_amount,
+							COALESCE(SUM(paid), 0) AS payed_amount,
+							COUNT(*) filter (WHERE status='expired') AS expired,
+							COALESCE(SUM(amount - waived - paid) FILTER(WHERE status='expired'), 0) AS expired_amount,
+							COUNT(*) filter (WHERE status='waived') AS waived,
+							COALESCE(SUM(waived), 0) AS waived_amount
+						FROM invoices
+							JOIN properties on invoices.property=properties.id
+						GROUP BY 
+							property,
+							period,
+							properties.sector, 
+							properties.cell, 
+							properties.village
+						ORDER BY property; 
+					`,
+
+					`
+					CREATE MATERIALIZED VIEW IF NOT EXISTS sector_payment_metrics AS
+						SELECT
+							sector,
+							period,
+							SUM(pending) as pending_count,
+							SUM(payed) as payed_count,
+							SUM(expired) as expired_count,
+							SUM(waived) as waived_count,
+							COALESCE(sum(pending_amount),0) AS pending_amount,
+							COALESCE(sum(payed_amount),0) AS payed_amount,
+							COALESCE(sum(expired_amount),0) AS expired_amount,
+							COALESCE(sum(waived_amount),0) AS waived_amount
+						FROM payment_metrics GROUP BY sector, period;
+					`,
+
+					`create unique index on  sector_payment_metrics(sector, period);`,
+
+					`
+					CREATE MATERIALIZED VIEW IF NOT EXISTS cell_payment_metrics as
+						SELECT
+							cell,
+							sector,
+							period,
+							SUM(pending) as pending_count,
+							SUM(payed) as payed_count,
+							SUM(expired) as expired_count,
+							SUM(waived) as waived_count,
+							COALESCE(SUM(pending_amount),0) as pending_amount,
+							COALESCE(SUM(payed_amount),0) as payed_amount,
+							COALESCE(SUM(expired_amount),0) AS expired_amount,
+							COALESCE(SUM(waived_amount),0) AS waived_amount
+						FROM payment_metrics GROUP by cell, sector, period;
+					`,
+
+					`CREATE unique index on cell_payment_metrics(cell, sector, period);`,
+
+					`
+					CREATE MATERIALIZED VIEW IF NOT EXISTS village_payment_metrics AS
+						SELECT
+							village,
+							cell,
+							period,
+							SUM(pending) as pending_count,
+							SUM(payed) as payed_count,
+							SUM(expired) as expired_count,
+							SUM(waived) as waived_count,
+							COALESCE(SUM(pending_amount),0) AS pending_amount,
+							COALESCE(SUM(payed_amount),0) AS payed_amount,
+							COALESCE(SUM(expired_amount),0) AS expired_amount,
+							COALESCE(SUM(waived_amount),0) AS waived_amount
+						FROM payment_metrics GROUP BY village, cell, period;
+					`,
+
+					`CREATE unique index on village_payment_metrics(village, cell, period);`,
+				},
+			},
+			{
+				Id: "043_late_payment_penalties",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS penalty_policies (
+						account 		VARCHAR(254) NOT NULL,
+						type 			VARCHAR(16) NOT NULL CHECK(type in ('fixed', 'percentage')),
+						value 			NUMERIC (9, 4) NOT NULL DEFAULT (0) CHECK(value >= 0),
+						cap 			NUMERIC (9, 2) NOT NULL DEFAULT (0) CHECK(cap >= 0),
+						grace_days 		INT NOT NULL DEFAULT (0) CHECK(grace_days >= 0),
+						enabled 		BOOLEAN NOT NULL DEFAULT FALSE,
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						updated_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(account) references accounts(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(account)
+					)
+					`,
+					`
+					CREATE TRIGGER set_timestamp
+					BEFORE UPDATE ON penalty_policies
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_set_timestamp();
+					`,
+
+					// a penalty is an invoice of its own linked to the overdue one
+					`
+					ALTER TABLE invoices
+						ADD COLUMN IF NOT EXISTS penalty_of INT REFERENCES invoices(id) ON DELETE CASCADE;
+					`,
+
+					`CREATE UNIQUE INDEX IF NOT EXISTS single_penalty_per_invoice ON invoices(penalty_of);`,
+
+					`DROP INDEX IF EXISTS single_invoice_per_property_per_period;`,
+
+					`CREATE UNIQUE INDEX IF NOT EXISTS single_invoice_per_property_per_period ON invoices(property, period_start) WHERE penalty_of IS NULL;`,
+
+					// penalties don't charge the due of the property, the amount
+					// of the other invoices is checked and cascaded by triggers
+					`
+					ALTER TABLE invoices
+						DROP CONSTRAINT IF EXISTS invoices_property_amount_fkey,
+						ADD FOREIGN KEY(property) REFERENCES properties(id) ON UPDATE CASCADE ON DELETE CASCADE;
+					`,
+
+					`
+					CREATE OR REPLACE FUNCTION trigger_check_invoice_amount()
+					RETURNS TRIGGER AS $$
+					BEGIN
+						IF NEW.penalty_of IS NULL AND NOT EXISTS (
+							SELECT 1 FROM properties WHERE id=NEW.property AND due=NEW.amount
+						) THEN
+							RAISE foreign_key_violation USING MESSAGE = 'invoice amount must be the due of property ' || NEW.property;
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					`
+					CREATE TRIGGER check_invoice_amount
+					BEFORE INSERT OR UPDATE OF amount ON invoices
+					FOR EACH ROW
+					EXECUTE PROCEDURE trigger_check_invoice_amount();
+					`,
+
+					`
+					CREATE OR REPLACE FUNCTION trigger_cascade_property_due()
+					RETURNS TRIGGER AS $$
+					BEGIN
+						UPDATE invoices SET amount=NEW.due WHERE property=NEW.id AND amount=OLD.due AND penalty_of IS NULL;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					`
+					CREATE TRIGGER cascade_property_due
+					AFTER UPDATE OF due ON properties
+					FOR EACH ROW
+					WHEN (OLD.due IS DISTINCT FROM NEW.due)
+					EXECUTE PROCEDURE trigger_cascade_property_due();
+					`,
+
+					// waivers discount the due of a period, not the penalties
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_waiver()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						pct NUMERIC(5, 2);
+					BEGIN
+						IF NEW.penalty_of IS NOT NULL THEN
+							RETURN NEW;
+						END IF;
+
+						SELECT 
+							MAX(percent) INTO pct 
+						FROM 
+							waivers 
+						WHERE 
+							property=NEW.property AND status='approved' AND NEW.period_start BETWEEN starts_on AND ends_on;
+
+						IF pct IS NULL OR NEW.amount <= 0 THEN
+							RETURN NEW;
+						END IF;
+
+						NEW.waived := GREATEST(NEW.waived, LEAST(NEW.amount - NEW.paid, ROUND(NEW.amount * pct / 100, 2)));
+
+						IF NEW.status = 'pending' AND NEW.paid + NEW.waived >= NEW.amount THEN
+							NEW.status := 'waived';
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// penalties stay payable after the period they were charged for
+					`
+					CREATE  OR REPLACE FUNCTION archive_func() 
+					RETURNS INT AS $$
+					
+					DECLARE count INT := 0;
+					BEGIN
+						UPDATE invoices SET status='expired' WHERE id IN(
+							select 
+								id
+							from 
+								invoices
+							where 
+								status='pending' 
+							AND 
+								penalty_of IS NULL
+							AND 
+								period_end <= CURRENT_DATE
+							ORDER BY id OFFSET 0 LIMIT 50
+						);
+
+						GET DIAGNOSTICS count = ROW_COUNT;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// the oldest period is paid first, penalties right after the
+					// invoice they were charged on
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_status()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						remaining NUMERIC(9, 2) := NEW.amount;
+						applied NUMERIC(9, 2);
+						rec RECORD;
+					BEGIN
+						IF NEW.status <> 'successful' THEN
+							RETURN NEW;
+						END IF;
+
+						FOR rec IN
+							SELECT 
+								id, amount, paid, waived 
+							FROM 
+								invoices
+							WHERE 
+								property=NEW.madefor AND status IN ('pending', 'partially_paid')
+							ORDER BY period_start, created_at, id
+							FOR UPDATE
+						LOOP
+							EXIT WHEN remaining <= 0;
+
+							applied := LEAST(remaining, rec.amount - rec.waived - rec.paid);
+							CONTINUE WHEN applied <= 0;
+
+							UPDATE invoices SET 
+								paid=paid + applied,
+								status=CASE WHEN paid + applied >= amount - waived THEN 'payed' ELSE 'partially_paid' END
+							WHERE id=rec.id;
+
+							remaining := remaining - applied;
+						END LOOP;
+
+						IF remaining > 0 THEN
+							INSERT INTO property_balances (property, credit) VALUES (NEW.madefor, remaining)
+							ON CONFLICT (property) DO UPDATE SET credit=property_balances.credit + EXCLUDED.credit;
+						END IF;
+
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// invoices still owed once the grace period of their account
+					// is over get a single penalty. Penalties are not retroactive,
+					// grace periods that ended before the policy was saved are
+					// ignored.
+					`
+					CREATE  OR REPLACE FUNCTION penalty_func() 
+					RETURNS INT AS $$
+					
+					DECLARE count INT := 0;
+					BEGIN
+						WITH overdue AS (
+							SELECT
+								invoices.id,
+								invoices.property,
+								invoices.period_start,
+								invoices.period_end,
+								policy.cap,
+								CASE policy.type 
+									WHEN 'percentage' THEN (invoices.amount - invoices.waived - invoices.paid) * policy.value 
+									ELSE policy.value 
+								END AS penalty
+							FROM
+								invoices
+								JOIN properties ON properties.id=invoices.property
+								JOIN penalty_policies AS policy ON policy.account=properties.namespace
+							WHERE
+								policy.enabled
+							AND
+								invoices.penalty_of IS NULL
+							AND
+								invoices.status IN ('partially_paid', 'expired')
+							AND
+								invoices.amount - invoices.waived - invoices.paid > 0
+							AND
+								invoices.period_end + policy.grace_days <= CURRENT_DATE
+							AND
+								invoices.period_end + policy.grace_days >= policy.updated_at::date
+							AND NOT EXISTS (
+								SELECT 1 FROM invoices AS penalties WHERE penalties.penalty_of=invoices.id
+							)
+						), computed AS (
+							SELECT
+								id, property, period_start, period_end,
+								ROUND(CASE WHEN cap > 0 THEN LEAST(penalty, cap) ELSE penalty END, 2) AS amount
+							FROM
+								overdue
+						)
+						INSERT INTO invoices (property, amount, status, period_start, period_end, penalty_of)
+							SELECT 
+								property, amount, 'pending', period_start, period_end, id 
+							FROM 
+								computed
+							WHERE 
+								amount > 0
+							ORDER BY id LIMIT 50
+						ON CONFLICT DO NOTHING;
+
+						GET DIAGNOSTICS count = ROW_COUNT;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+				},
+			},
+			{
+				Id: "044_prorated_invoices",
+				Up: []string{
+					`
+					CREATE TABLE IF NOT EXISTS tariff_changes (
+						id 				UUID NOT NULL DEFAULT uuid_generate_v4(),
+						property 		TEXT NOT NULL,
+						previous 		NUMERIC (9, 2) NOT NULL,
+						due 			NUMERIC (9, 2) NOT NULL CHECK(due > 0),
+						effective_from 	DATE NOT NULL,
+						changed_by 		VARCHAR(254) NOT NULL DEFAULT '',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(property) references properties(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS tariff_changes_property_idx ON tariff_changes(property, created_at);`,
+
+					// every change of an invoice amount after it was issued
+					`
+					CREATE TABLE IF NOT EXISTS invoice_adjustments (
+						id 				UUID NOT NULL DEFAULT uuid_generate_v4(),
+						invoice 		INT NOT NULL,
+						property 		TEXT NOT NULL,
+						kind 			VARCHAR(16) NOT NULL CHECK(kind in ('proration', 'tariff')),
+						previous 		NUMERIC (9, 2) NOT NULL,
+						amount 			NUMERIC (9, 2) NOT NULL,
+						ref 			TEXT NOT NULL DEFAULT '',
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(invoice) references invoices(id) ON DELETE CASCADE,
+						FOREIGN KEY(property) references properties(id) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS invoice_adjustments_property_idx ON invoice_adjustments(property, created_at);`,
+
+					// invoice amounts follow proration and tariff changes, the due
+					// is checked where invoices are generated
+					`DROP TRIGGER IF EXISTS check_invoice_amount ON invoices;`,
+					`DROP FUNCTION IF EXISTS trigger_check_invoice_amount();`,
+					`DROP TRIGGER IF EXISTS cascade_property_due ON properties;`,
+					`DROP FUNCTION IF EXISTS trigger_cascade_property_due();`,
+
+					// the part of a due charged from since until the end of the period
+					`
+					CREATE OR REPLACE FUNCTION prorate(due NUMERIC, starts DATE, ends DATE, since DATE)
+					RETURNS NUMERIC AS $$
+						SELECT CASE
+							WHEN since <= starts THEN due
+							WHEN since >= ends THEN 0
+							ELSE ROUND(due * (ends - since) / (ends - starts), 2)
+						END;
+					$$ LANGUAGE SQL IMMUTABLE;
+					`,
+
+					// properties registered during a period pay for the days left
+					`
+					CREATE OR REPLACE FUNCTION trigger_initial_invoice()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						starts DATE := billing_period_start(NEW.billing_cycle, NEW.billing_anchor, NEW.created_at);
+						ends DATE;
+						charged NUMERIC(9, 2);
+						inv INT;
+					BEGIN
+						ends := (starts + billing_interval(NEW.billing_cycle))::date;
+						charged := prorate(NEW.due, starts, ends, NEW.created_at::date);
+
+						INSERT INTO invoices (
+							amount, property, period_start, period_end, created_at, updated_at
+						) VALUES (
+							charged, NEW.id, starts, ends, NEW.created_at, NEW.updated_at
+						) RETURNING id INTO inv;
+
+						IF charged <> NEW.due THEN
+							INSERT INTO invoice_adjustments (invoice, property, kind, previous, amount)
+							VALUES (inv, NEW.id, 'proration', NEW.due, charged);
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// open invoices are charged the new due for the days of their
+					// period from the effective day on. Amounts paid above the
+					// adjusted one become credit, the ledger gets an entry for
+					// every adjustment.
+					`
+					CREATE OR REPLACE FUNCTION apply_tariff_change(change UUID)
+					RETURNS INT AS $$
+					DECLARE
+						tc RECORD;
+						rec RECORD;
+						ns TEXT;
+						registered DATE;
+						since DATE;
+						adjusted NUMERIC(9, 2);
+						kept_waived NUMERIC(9, 2);
+						kept_paid NUMERIC(9, 2);
+						delta NUMERIC(9, 2);
+						excess NUMERIC(9, 2) := 0;
+						adjustment UUID;
+						entry UUID;
+						count INT := 0;
+					BEGIN
+						SELECT * INTO tc FROM tariff_changes WHERE id=change;
+						SELECT namespace, created_at::date INTO ns, registered FROM properties WHERE id=tc.property;
+
+						FOR rec IN
+							SELECT 
+								id, amount, paid, waived, period_start, period_end 
+							FROM 
+								invoices
+							WHERE 
+								property=tc.property AND penalty_of IS NULL AND status IN ('pending', 'partially_paid')
+							AND 
+								period_end > tc.effective_from
+							ORDER BY period_start
+							FOR UPDATE
+						LOOP
+							since := GREATEST(rec.period_start, tc.effective_from, registered);
+							CONTINUE WHEN since >= rec.period_end;
+
+							adjusted := GREATEST(0, rec.amount + ROUND((tc.due - tc.previous) * (rec.period_end - since) / (rec.period_end - rec.period_start), 2));
+							CONTINUE WHEN adjusted = rec.amount;
+
+							kept_waived := LEAST(rec.waived, adjusted);
+							kept_paid := LEAST(rec.paid, adjusted - kept_waived);
+							excess := excess + rec.paid - kept_paid;
+
+							UPDATE invoices SET
+								amount=adjusted,
+								waived=kept_waived,
+								paid=kept_paid,
+								status=CASE
+									WHEN kept_paid + kept_waived < adjusted AND kept_paid > 0 THEN 'partially_paid'
+									WHEN kept_paid + kept_waived < adjusted THEN 'pending'
+									WHEN kept_paid > 0 OR kept_waived = 0 THEN 'payed'
+									ELSE 'waived'
+								END
+							WHERE id=rec.id;
+
+							INSERT INTO invoice_adjustments (invoice, property, kind, previous, amount, ref)
+							VALUES (rec.id, tc.property, 'tariff', rec.amount, adjusted, change::TEXT)
+							RETURNING id INTO adjustment;
+
+							delta := adjusted - rec.amount;
+
+							INSERT INTO ledger_accounts (code, kind, namespace) VALUES
+								('receivable:' || tc.property, 'asset', ns),
+								('revenue:' || ns, 'revenue', ns),
+								('waivers:' || ns, 'expense', ns)
+							ON CONFLICT (code) DO NOTHING;
+
+							INSERT INTO journal_entries (kind, ref, namespace, description)
+							VALUES ('adjustment', adjustment::TEXT, ns, 'tariff change on invoice ' || rec.id)
+							RETURNING id INTO entry;
+
+							IF delta > 0 THEN
+								INSERT INTO journal_lines (entry, account, debit, credit) VALUES
+									(entry, 'receivable:' || tc.property, delta, 0),
+									(entry, 'revenue:' || ns, 0, delta);
+							ELSE
+								INSERT INTO journal_lines (entry, account, debit, credit) VALUES
+									(entry, 'revenue:' || ns, -delta, 0),
+									(entry, 'receivable:' || tc.property, 0, -delta);
+							END IF;
+
+							-- the part of the waiver above the adjusted amount is given back
+							IF kept_waived < rec.waived THEN
+								INSERT INTO journal_lines (entry, account, debit, credit) VALUES
+									(entry, 'receivable:' || tc.property, rec.waived - kept_waived, 0),
+									(entry, 'waivers:' || ns, 0, rec.waived - kept_waived);
+							END IF;
+
+							count := count + 1;
+						END LOOP;
+
+						IF excess > 0 THEN
+							INSERT INTO property_balances (property, credit) VALUES (tc.property, excess)
+							ON CONFLICT (property) DO UPDATE SET credit=property_balances.credit + EXCLUDED.credit;
+						END IF;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+				},
+			},
+			{
+				Id: "045_expire_partially_paid_invoices",
+				Up: []string{
+					// partially paid invoices expire with their period like pending ones
+					`
+					CREATE  OR REPLACE FUNCTION archive_func() 
+					RETURNS INT AS $$
+					
+					DECLARE count INT := 0;
+					BEGIN
+						UPDATE invoices SET status='expired' WHERE id IN(
+							select 
+								id
+							from 
+								invoices
+							where 
+								status IN ('pending', 'partially_paid') 
+							AND 
+								penalty_of IS NULL
+							AND 
+								period_end <= CURRENT_DATE
+							ORDER BY id OFFSET 0 LIMIT 50
+						);
+
+						GET DIAGNOSTICS count = ROW_COUNT;
+
+						RETURN count;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+
+					// an invoice settled by payments and a waiver together is payed
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_waiver()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						pct NUMERIC(5, 2);
+					BEGIN
+						IF NEW.penalty_of IS NOT NULL THEN
+							RETURN NEW;
+						END IF;
+
+						SELECT 
+							MAX(percent) INTO pct 
+						FROM 
+							waivers 
+						WHERE 
+							property=NEW.property AND status='approved' AND NEW.period_start BETWEEN starts_on AND ends_on;
+
+						IF pct IS NULL OR NEW.amount <= 0 THEN
+							RETURN NEW;
+						END IF;
+
+						NEW.waived := GREATEST(NEW.waived, LEAST(NEW.amount - NEW.paid, ROUND(NEW.amount * pct / 100, 2)));
+
+						IF NEW.status IN ('pending', 'partially_paid') AND NEW.paid + NEW.waived >= NEW.amount THEN
+							NEW.status := CASE WHEN NEW.paid > 0 THEN 'payed' ELSE 'waived' END;
+						END IF;
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+				},
+			},
+			{
+				Id: "046_create_payment_allocations_table",
+				Up: []string{
+					// what each transaction paid off, rows without an invoice went
+					// to the credit of the property
+					`
+					CREATE TABLE IF NOT EXISTS payment_allocations (
+						id 				SERIAL,
+						transaction 	UUID NOT NULL,
+						invoice 		INT,
+						amount 			NUMERIC (9, 2) NOT NULL CHECK(amount > 0),
+						created_at 		TIMESTAMP NOT NULL DEFAULT NOW(),
+						FOREIGN KEY(transaction) references transactions(id) ON DELETE CASCADE,
+						FOREIGN KEY(invoice) references invoices(id) ON DELETE CASCADE,
+						PRIMARY KEY(id)
+					)
+					`,
+					`CREATE INDEX IF NOT EXISTS payment_allocations_transaction_idx ON payment_allocations(transaction);`,
+
+					// earlier transactions are taken to have paid the invoice they were made for
+					`
+					INSERT INTO payment_allocations (transaction, invoice, amount)
+						SELECT 
+							t.id, t.invoice, t.amount 
+						FROM 
+							transactions t JOIN invoices i ON t.invoice=i.id 
+						WHERE 
+							t.status='successful' AND t.amount > 0;
+					`,
+
+					`
+					CREATE OR REPLACE FUNCTION trigger_set_invoice_status()
+					RETURNS TRIGGER AS $$
+					DECLARE
+						remaining NUMERIC(9, 2) := NEW.amount;
+						applied NUMERIC(9, 2);
+						rec RECORD;
+					BEGIN
+						IF NEW.status <> 'successful' THEN
+							RETURN NEW;
+						END IF;
+
+						FOR rec IN
+							SELECT 
+								id, amount, paid, waived 
+							FROM 
+								invoices
+							WHERE 
+								property=NEW.madefor AND status IN ('pending', 'partially_paid')
+							ORDER BY period_start, created_at, id
+							FOR UPDATE
+						LOOP
+							EXIT WHEN remaining <= 0;
+
+							applied := LEAST(remaining, rec.amount - rec.waived - rec.paid);
+							CONTINUE WHEN applied <= 0;
+
+							UPDATE invoices SET 
+								paid=paid + applied,
+								status=CASE WHEN paid + applied >= amount - waived THEN 'payed' ELSE 'partially_paid' END
+							WHERE id=rec.id;
+
+							INSERT INTO payment_allocations (transaction, invoice, amount) VALUES (NEW.id, rec.id, applied);
+
+							remaining := remaining - applied;
+						END LOOP;
+
+						IF remaining > 0 THEN
+							INSERT INTO property_balances (property, credit) VALUES (NEW.madefor, remaining)
+							ON CONFLICT (property) DO UPDATE SET credit=property_balances.credit + EXCLUDED.credit;
+
+							INSERT INTO payment_allocations (transaction, amount) VALUES (NEW.id, remaining);
+						END IF;
+
+						RETURN NEW;
+					END;
+					$$ LANGUAGE plpgsql;
+					`,
+				},
+			},
+			{
+				Id: "047_restrict_fee_bearer_to_payee",
+				Up: []string{
+					// payers were never charged on top of what they paid, so fees are
+					// always taken from the collected money. Past transactions keep
+					// the bearer they were recorded with.
+					`UPDATE fee_rules SET bearer = 'payee' WHERE bearer = 'payer';`,
+					`
+					ALTER TABLE fee_rules
+						DROP CONSTRAINT IF EXISTS fee_rules_bearer_check,
+						ADD CONSTRAINT fee_rules_bearer_check CHECK(bearer = 'payee');
+					`,
+				},
+			},
+			{
+				Id: "048_add_namespace_to_refunds",
+				Up: []string{
+					// refunds are listed and reviewed per sector
+					`ALTER TABLE refunds ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT '';`,
+					`
+					ALTER TABLE refunds DISABLE TRIGGER set_timestamp;
+					UPDATE refunds r SET namespace = p.namespace FROM properties p WHERE r.property = p.id;
+					ALTER TABLE refunds ENABLE TRIGGER set_timestamp;
+					`,
+					`CREATE INDEX IF NOT EXISTS refunds_namespace_idx ON refunds(namespace, created_at);`,
+				},
+			},
+			{
+				Id: "049_allow_payer_borne_fees",
+				Up: []string{
+					// payers bearing the fee are charged on top of the amount pulled
+					`
+					ALTER TABLE fee_rules
+						DROP CONSTRAINT IF EXISTS fee_rules_bearer_check,
+						ADD CONSTRAINT fee_rules_bearer_check CHECK(bearer in ('payer', 'payee'));
+					`,
+				},
+			},
 		},
 	}
 	_, err := migrate.Exec(db, "postgres", migrations, migrate.Up)
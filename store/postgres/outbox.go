@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (outbox.Repository) = (*outboxStore)(nil)
+
+type outboxStore struct {
+	*sql.DB
+}
+
+// NewOutboxRepository creates a postgres backed outbox.Repository
+func NewOutboxRepository(db *sql.DB) outbox.Repository {
+	return &outboxStore{db}
+}
+
+func (repo *outboxStore) Pending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	const op errors.Op = "store/postgres/outboxStore.Pending"
+
+	// due messages are claimed by pushing their next attempt back, rows
+	// locked by another dispatcher are skipped
+	q := `
+		WITH claimed AS (
+			UPDATE sms_outbox SET next_attempt = NOW() + make_interval(secs => $3)
+			WHERE id IN (
+				SELECT id FROM sms_outbox
+				WHERE status=$1 AND next_attempt <= NOW()
+				ORDER BY created_at LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING
+				id,
+				sender,
+				recipients,
+				message,
+				status,
+				attempts,
+				last_error,
+				next_attempt,
+				created_at,
+				updated_at
+		)
+		SELECT * FROM claimed ORDER BY created_at
+	`
+
+	rows, err := repo.QueryContext(ctx, q, outbox.Pending, limit, outbox.ClaimTimeout.Seconds())
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	out := []outbox.Message{}
+	for rows.Next() {
+		var msg outbox.Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.Sender,
+			pq.Array(&msg.Recipients),
+			&msg.Message,
+			&msg.Status,
+			&msg.Attempts,
+			&msg.LastError,
+			&msg.NextAttempt,
+			&msg.CreatedAt,
+			&msg.UpdatedAt,
+		)
+		if err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+func (repo *outboxStore) Update(ctx context.Context, msg outbox.Message) error {
+	const op errors.Op = "store/postgres/outboxStore.Update"
+
+	q := `
+		UPDATE sms_outbox SET
+			status=$1,
+			attempts=$2,
+			last_error=$3,
+			next_attempt=$4
+		WHERE id=$5
+	`
+
+	res, err := repo.ExecContext(ctx, q, msg.Status, msg.Attempts, msg.LastError, msg.NextAttempt, msg.ID)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code.Name() == errInvalid {
+			return errors.E(op, err, "message not found", errors.KindNotFound)
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if cnt == 0 {
+		return errors.E(op, "message not found", errors.KindNotFound)
+	}
+	return nil
+}
+
+// enqueueSMS writes a message to the outbox as part of an ongoing transaction
+func enqueueSMS(ctx context.Context, tx *sql.Tx, sender string, recipients []string, message string) error {
+	q := `INSERT INTO sms_outbox (sender, recipients, message) VALUES ($1, $2, $3)`
+
+	_, err := tx.ExecContext(ctx, q, sender, pq.Array(recipients), message)
+	return err
+}
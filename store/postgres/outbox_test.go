@@ -0,0 +1,113 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/outbox"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingOutboxMessages(t *testing.T) {
+	repo := postgres.NewOutboxRepository(db)
+	defer CleanDB(t, db)
+
+	account := accounts.Account{ID: "paypack.developers", Name: "remera", NumberOfSeats: 10, Type: accounts.Devs}
+	account = saveAccount(t, db, account)
+
+	n := 5
+	for i := 0; i < n; i++ {
+		saveOutboxMessage(t, db, outbox.Message{Sender: account.ID, Recipients: []string{"0788123501"}, Message: "hello"})
+	}
+
+	cases := []struct {
+		desc  string
+		limit int
+		size  int
+	}{
+		{
+			desc:  "retrieve all pending messages",
+			limit: n,
+			size:  n,
+		},
+		{
+			desc:  "retrieve a batch of pending messages",
+			limit: 2,
+			size:  2,
+		},
+	}
+
+	for _, tc := range cases {
+		msgs, err := repo.Pending(context.Background(), tc.limit)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.size, len(msgs), fmt.Sprintf("%s: expected %d messages got %d", tc.desc, tc.size, len(msgs)))
+	}
+}
+
+func TestUpdateOutboxMessage(t *testing.T) {
+	const op errors.Op = "store/postgres/outboxStore.Update"
+
+	repo := postgres.NewOutboxRepository(db)
+	defer CleanDB(t, db)
+
+	account := accounts.Account{ID: "paypack.developers", Name: "remera", NumberOfSeats: 10, Type: accounts.Devs}
+	account = saveAccount(t, db, account)
+
+	msg := saveOutboxMessage(t, db, outbox.Message{Sender: account.ID, Recipients: []string{"0788123501"}, Message: "hello"})
+
+	retried := msg
+	retried.Retry(fmt.Errorf("sms gateway unavailable"), time.Now())
+
+	cases := []struct {
+		desc string
+		msg  outbox.Message
+		err  error
+	}{
+		{
+			desc: "update message after failed attempt",
+			msg:  retried,
+			err:  nil,
+		},
+		{
+			desc: "update non-existing message",
+			msg:  outbox.Message{ID: uuid.NewV4().String(), Status: outbox.Sent},
+			err:  errors.E(op, "message not found", errors.KindNotFound),
+		},
+		{
+			desc: "update message with malformed id",
+			msg:  outbox.Message{ID: wrongValue, Status: outbox.Sent},
+			err:  errors.E(op, "message not found", errors.KindNotFound),
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Update(context.Background(), tc.msg)
+		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+	}
+
+	// the retried message is no longer due
+	msgs, err := repo.Pending(context.Background(), 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Empty(t, msgs, fmt.Sprintf("expected no pending messages got %d", len(msgs)))
+}
+
+func saveOutboxMessage(t *testing.T, db *sql.DB, msg outbox.Message) outbox.Message {
+	t.Helper()
+
+	q := `INSERT INTO sms_outbox (sender, recipients, message) VALUES ($1, $2, $3) RETURNING id`
+
+	err := db.QueryRow(q, msg.Sender, pq.Array(msg.Recipients), msg.Message).Scan(&msg.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return msg
+}
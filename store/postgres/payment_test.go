@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
 	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
@@ -19,7 +20,7 @@ import (
 
 func TestSavePayment(t *testing.T) {
 	const op errors.Op = "store/postgres/paymentStore.Save"
-	repo := postgres.NewPaymentRepository(db, nil)
+	repo := postgres.NewPaymentRepository(db, "", "")
 
 	defer CleanDB(t, db)
 
@@ -125,7 +126,7 @@ func TestSavePayment(t *testing.T) {
 func TestFindPayment(t *testing.T) {
 	const op errors.Op = "store/postgres/paymentStore.Find"
 
-	repo := postgres.NewPaymentRepository(db, nil)
+	repo := postgres.NewPaymentRepository(db, "", "")
 	defer CleanDB(t, db)
 
 	account := accounts.Account{ID: "paypack.developers", Name: "remera", NumberOfSeats: 10, Type: accounts.Devs}
@@ -217,7 +218,7 @@ func TestFindPayment(t *testing.T) {
 func TestUpdatePayment(t *testing.T) {
 	const op errors.Op = "store/postgres/paymentStore.Update"
 
-	repo := postgres.NewPaymentRepository(db, nil)
+	repo := postgres.NewPaymentRepository(db, "", "")
 	defer CleanDB(t, db)
 
 	account := accounts.Account{
@@ -319,3 +320,115 @@ func TestUpdatePayment(t *testing.T) {
 		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
 	}
 }
+
+func TestExpirePayment(t *testing.T) {
+	repo := postgres.NewPaymentRepository(db, "", "")
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	invoice := invoices.Invoice{Amount: property.Due, Property: property.ID, Status: invoices.Pending}
+	invoice = saveInvoice(t, db, invoice)
+
+	pmt := &payment.TxRequest{
+		ID:      uuid.New().ID(),
+		Code:    property.ID,
+		Ref:     uuid.New().ID(),
+		Amount:  invoice.Amount,
+		MSISDN:  "0784607135",
+		Method:  payment.MTN,
+		Invoice: invoice.ID,
+	}
+	err := repo.Save(context.Background(), pmt)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		before time.Time
+		refs   []string
+	}{
+		{
+			desc:   "expire recent payments",
+			before: time.Now().Add(-time.Hour),
+			refs:   []string{},
+		},
+		{
+			desc:   "expire stale payments",
+			before: time.Now().Add(time.Hour),
+			refs:   []string{pmt.Ref},
+		},
+		{
+			desc:   "expire already expired payments",
+			before: time.Now().Add(time.Hour),
+			refs:   []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		refs, err := repo.Expire(context.Background(), tc.before, payment.ExpiryReason)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.refs, refs, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.refs, refs))
+	}
+
+	payments, err := repo.Find(context.Background(), pmt.Ref)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, payment.ExpiredStatus, payments[0].Status)
+}
+
+func TestSettlePayment(t *testing.T) {
+	repo := postgres.NewPaymentRepository(db, "", "")
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	invoice := invoices.Invoice{Amount: property.Due, Property: property.ID, Status: invoices.Pending}
+	invoice = saveInvoice(t, db, invoice)
+
+	pmt := &payment.TxRequest{
+		ID:      uuid.New().ID(),
+		Code:    property.ID,
+		Ref:     uuid.New().ID(),
+		Amount:  invoice.Amount,
+		MSISDN:  "0784607135",
+		Method:  payment.Cash,
+		Invoice: invoice.ID,
+	}
+
+	cases := []struct {
+		desc     string
+		payments []*payment.TxRequest
+		kind     int
+	}{
+		{
+			desc:     "settle without payments",
+			payments: []*payment.TxRequest{},
+			kind:     errors.KindBadRequest,
+		},
+		{
+			desc:     "settle payment received in cash",
+			payments: []*payment.TxRequest{pmt},
+		},
+		{
+			desc:     "settle payment that already exists",
+			payments: []*payment.TxRequest{pmt},
+			kind:     errors.KindAlreadyExists,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Settle(context.Background(), tc.payments)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+	}
+
+	payments, err := repo.Find(context.Background(), pmt.Ref)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Len(t, payments, 1)
+	assert.Equal(t, string(payment.Successful), payments[0].Status)
+	assert.True(t, payments[0].Confirmed)
+}
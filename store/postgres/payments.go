@@ -9,25 +9,42 @@ import (
 	"time"
 
 	"github.com/lib/pq"
-	"github.com/nshimiyimanaamani/paypack-backend/core/notifs"
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
 	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
 	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/core/transactions"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/clock"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
-	"github.com/nshimiyimanaamani/paypack-backend/pkg/log"
-	"golang.org/x/sync/errgroup"
 )
 
 var _ (payment.Repository) = (*paymentStore)(nil)
 
 type paymentStore struct {
 	*sql.DB
-	sms notifs.Service
+	receipts receiptLinks
 }
 
-// NewPaymentRepository creates a new postgres backed payment.Repository
-func NewPaymentRepository(db *sql.DB, sms notifs.Service) payment.Repository {
-	return &paymentStore{db, sms}
+// NewPaymentRepository creates a new postgres backed payment.Repository,
+// receipts is the public api url receipts are linked from in payment sms
+// and secret signs the links, no link is sent when either is empty.
+func NewPaymentRepository(db *sql.DB, receipts, secret string) payment.Repository {
+	return &paymentStore{DB: db, receipts: receiptLinks{url: receipts, secret: secret}}
+}
+
+// receiptLinks builds the signed receipt links of payment sms
+type receiptLinks struct {
+	url    string
+	secret string
+}
+
+// link to the receipt of the transaction id, empty when receipts aren't
+// linked
+func (links receiptLinks) link(id string) string {
+	if links.url == "" || links.secret == "" {
+		return ""
+	}
+	return transactions.ReceiptURL(links.url, id, transactions.ReceiptToken(links.secret, id))
 }
 
 func (repo *paymentStore) Save(ctx context.Context, payment *payment.TxRequest) error {
@@ -80,6 +97,18 @@ func (repo *paymentStore) Find(ctx context.Context, id string) ([]*payment.TxReq
 	}
 	defer tx.Rollback()
 
+	out, err := findPayments(ctx, tx, id)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	return out, tx.Commit()
+}
+
+// findPayments reads the payments made under a ref along with the date of
+// the invoices they pay
+func findPayments(ctx context.Context, tx *sql.Tx, id string) ([]*payment.TxRequest, error) {
+	const op errors.Op = "store/postgres/findPayments"
+
 	query := `
 		SELECT 
 			p.id, 
@@ -132,7 +161,7 @@ func (repo *paymentStore) Find(ctx context.Context, id string) ([]*payment.TxReq
 		out = append(out, trns)
 	}
 
-	return out, tx.Commit()
+	return out, nil
 }
 
 func (repo *paymentStore) Update(ctx context.Context, status string, payments []*payment.TxRequest) error {
@@ -144,6 +173,17 @@ func (repo *paymentStore) Update(ctx context.Context, status string, payments []
 	}
 	defer tx.Rollback()
 
+	if err := updatePayments(ctx, tx, status, payments, repo.receipts); err != nil {
+		return errors.E(op, err)
+	}
+	return tx.Commit()
+}
+
+// updatePayments sets the status of the payments of a ref, successful ones
+// are recorded as transactions, posted to the ledger and get a receipt sms
+func updatePayments(ctx context.Context, tx *sql.Tx, status string, payments []*payment.TxRequest, receipts receiptLinks) error {
+	const op errors.Op = "store/postgres/updatePayments"
+
 	property := new(properties.Property)
 
 	// get property by property code
@@ -159,7 +199,7 @@ func (repo *paymentStore) Update(ctx context.Context, status string, payments []
 			properties INNER JOIN owners ON properties.owner = owners.id
 		WHERE properties.id=$1
 	`
-	if err = tx.QueryRowContext(ctx, q, payments[0].Code).Scan(
+	if err := tx.QueryRowContext(ctx, q, payments[0].Code).Scan(
 		&property.Owner.ID,
 		&property.Namespace,
 		&property.Address.Sector,
@@ -174,13 +214,32 @@ func (repo *paymentStore) Update(ctx context.Context, status string, payments []
 		return errors.E(op, err, errors.KindUnexpected)
 	}
 
+	// fees are computed with the rule in force when the money is received
+	rule, err := accountFeeRule(ctx, tx, property.Namespace)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
 	pos, args := []string{}, []interface{}{}
 
+	entry := ledger.NewEntry(ledger.PaymentEntry, payments[0].Ref, property.Namespace, "payment "+payments[0].Ref)
+
 	i := 0
 	for _, txns := range payments {
 		if status == "successful" {
+			fee := rule.Compute(txns.Amount)
+
+			received := receivedInto(property.Namespace, txns.Method)
+
+			// payers bearing the fee paid it on top of the amount, the
+			// account keeps the whole amount
+			entry.Transfer(received, ledger.Receivable(txns.Code), txns.Amount)
+			if rule.Bearer == accounts.Payee {
+				entry.Transfer(ledger.Fees(property.Namespace), received, fee)
+			}
+
 			pos = append(pos,
-				fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", i*9+1, i*9+2, i*9+3, i*9+4, i*9+5, i*9+6, i*9+7, i*9+8, i*9+9),
+				fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", i*12+1, i*12+2, i*12+3, i*12+4, i*12+5, i*12+6, i*12+7, i*12+8, i*12+9, i*12+10, i*12+11, i*12+12),
 			)
 			args = append(
 				args,
@@ -193,6 +252,9 @@ func (repo *paymentStore) Update(ctx context.Context, status string, payments []
 				txns.Method,
 				txns.Invoice,
 				property.Namespace,
+				fee,
+				txns.GatewayFee,
+				rule.Bearer,
 			)
 			i++
 		}
@@ -216,6 +278,12 @@ func (repo *paymentStore) Update(ctx context.Context, status string, payments []
 			}
 			return errors.E(op, err, errors.KindUnexpected)
 		}
+
+		if len(entry.Lines) > 0 {
+			if _, err := postEntry(ctx, tx, entry); err != nil {
+				return errors.E(op, err)
+			}
+		}
 	}
 
 	_, err = tx.ExecContext(ctx, updatePayQuery, true, status, payments[0].Ref)
@@ -230,32 +298,24 @@ func (repo *paymentStore) Update(ctx context.Context, status string, payments []
 		return errors.E(op, err, errors.KindUnexpected)
 	}
 
-	if status == "successful" {
-		g := errgroup.Group{}
-		g.Go(func() error {
-			_, err := repo.sms.Send(ctx,
-				notifs.Notification{
-					Sender:     property.Namespace,
-					Recipients: []string{property.Owner.Phone},
-					Message:    formMessage(payments, property)},
-			)
-
-			if err != nil {
-				return err
-			}
-
-			log.NoOpLogger().Infof("sms sent to %s successful", property.Owner.Phone)
-
-			return nil
-		})
-
-		if err := g.Wait(); err != nil {
+	kinds := map[string]payment.EventKind{"successful": payment.Confirmed, "failed": payment.Declined}
+	if kind, ok := kinds[status]; ok {
+		ev := payment.NewEvent(payments[0].Ref, kind, status, map[string]interface{}{"status": status, "payments": len(payments)})
+		if err := recordEvent(ctx, tx, ev); err != nil {
 			return errors.E(op, err, errors.KindUnexpected)
 		}
+	}
 
+	// the receipt is only queued here, the worker delivers it once the
+	// transaction is committed so a failing sms gateway can't undo a payment.
+	if status == "successful" {
+		recipients := []string{property.Owner.Phone}
+		if err := enqueueSMS(ctx, tx, property.Namespace, recipients, formMessage(payments, property, receipts)); err != nil {
+			return errors.E(op, err, errors.KindUnexpected)
+		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 func (repo *paymentStore) BulkSave(ctx context.Context, payments []*payment.TxRequest) error {
@@ -267,6 +327,46 @@ func (repo *paymentStore) BulkSave(ctx context.Context, payments []*payment.TxRe
 	}
 	defer tx.Rollback()
 
+	if err := savePayments(ctx, tx, payments); err != nil {
+		return errors.E(op, err)
+	}
+	return tx.Commit()
+}
+
+func (repo *paymentStore) Settle(ctx context.Context, payments []*payment.TxRequest) error {
+	const op errors.Op = "store/postgres/paymentStore.Settle"
+
+	if len(payments) == 0 {
+		return errors.E(op, "no payment to settle", errors.KindBadRequest)
+	}
+
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	if err := savePayments(ctx, tx, payments); err != nil {
+		return errors.E(op, err)
+	}
+
+	// the payments are read back like a gateway callback does so that they
+	// carry the invoice dates the receipt sms mentions
+	saved, err := findPayments(ctx, tx, payments[0].Ref)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := updatePayments(ctx, tx, string(payment.Successful), saved, repo.receipts); err != nil {
+		return errors.E(op, err)
+	}
+	return tx.Commit()
+}
+
+// savePayments inserts pending payments in a single statement
+func savePayments(ctx context.Context, tx *sql.Tx, payments []*payment.TxRequest) error {
+	const op errors.Op = "store/postgres/savePayments"
+
 	insertQuery := `
 	INSERT INTO payments(
 		id,
@@ -303,7 +403,7 @@ func (repo *paymentStore) BulkSave(ctx context.Context, payments []*payment.TxRe
 
 	var query = insertQuery + strings.Join(pos, ",")
 
-	_, err = tx.ExecContext(
+	_, err := tx.ExecContext(
 		ctx,
 		query,
 		args...,
@@ -320,7 +420,7 @@ func (repo *paymentStore) BulkSave(ctx context.Context, payments []*payment.TxRe
 		}
 		return errors.E(op, err, errors.KindUnexpected)
 	}
-	return tx.Commit()
+	return nil
 }
 
 func (repo *paymentStore) List(ctx context.Context, flts *payment.Filters) (payment.PaymentResponse, error) {
@@ -338,13 +438,16 @@ func (repo *paymentStore) List(ctx context.Context, flts *payment.Filters) (paym
 			o.lname,
 			o.phone,
 			i.property,
-			i.amount
+			i.amount,
+			f.fee,
+			i.amount - f.payee_fee
 		FROM 
 			owners o
 		JOIN properties p 
 			ON p.owner = o.id
 		JOIN invoices i ON 
 			i.property = p.id
+		` + invoiceFeesJoin + `
 		WHERE 1 = 1
 	`
 	// get creds
@@ -394,6 +497,8 @@ func (repo *paymentStore) List(ctx context.Context, flts *payment.Filters) (paym
 			&pmt.Phone,
 			&pmt.PropertyID,
 			&pmt.Amount,
+			&pmt.Fee,
+			&pmt.Net,
 		)
 		if err != nil {
 			return payment.PaymentResponse{}, errors.E(op, err, errors.KindUnexpected)
@@ -401,7 +506,8 @@ func (repo *paymentStore) List(ctx context.Context, flts *payment.Filters) (paym
 		payments = append(payments, pmt)
 	}
 
-	countQuery := `SELECT COUNT(*), COALESCE(SUM(i.amount), 0.0) FROM invoices i JOIN properties p ON i.property = p.id`
+	countQuery := `SELECT COUNT(*), COALESCE(SUM(i.amount), 0.0), COALESCE(SUM(f.fee), 0.0), COALESCE(SUM(i.amount - f.payee_fee), 0.0)
+		FROM invoices i JOIN properties p ON i.property = p.id ` + invoiceFeesJoin
 	countQuery += " WHERE 1 = 1"
 
 	if flts.Status != nil {
@@ -437,9 +543,11 @@ func (repo *paymentStore) List(ctx context.Context, flts *payment.Filters) (paym
 	var (
 		total  uint64
 		amount float64
+		fees   float64
+		net    float64
 	)
 
-	if err := repo.QueryRow(countQuery).Scan(&total, &amount); err != nil {
+	if err := repo.QueryRow(countQuery).Scan(&total, &amount, &fees, &net); err != nil {
 		return payment.PaymentResponse{}, errors.E(op, err, errors.KindUnexpected)
 	}
 
@@ -450,6 +558,8 @@ func (repo *paymentStore) List(ctx context.Context, flts *payment.Filters) (paym
 			Offset: *flts.Offset,
 			Limit:  *flts.Limit,
 			Amount: amount,
+			Fees:   fees,
+			Net:    net,
 		},
 	}
 	return page, nil
@@ -709,7 +819,7 @@ func (repo *paymentStore) UnpaidHouses(ctx context.Context, flts *payment.Metric
 			o.lname,
 			o.phone,
 			i.property,
-			i.amount,
+			i.amount - i.paid - i.waived,
 			p.sector,
 			p.village,
 			p.cell
@@ -720,7 +830,7 @@ func (repo *paymentStore) UnpaidHouses(ctx context.Context, flts *payment.Metric
 			ON p.owner = o.id
 		JOIN invoices i ON 
 			i.property = p.id
-		WHERE i.status = 'pending'
+		WHERE i.status IN ('pending', 'partially_paid')
 	`
 	if flts.Username != nil {
 		selectQuery += fmt.Sprintf(" AND p.recorded_by = '%s'", *flts.Username)
@@ -759,8 +869,9 @@ func (repo *paymentStore) UnpaidHouses(ctx context.Context, flts *payment.Metric
 		}
 		payments = append(payments, pmt)
 	}
-	countQuery := `SELECT COUNT(*), COALESCE(SUM(i.amount), 0.0) FROM invoices i JOIN properties p ON i.property = p.id`
-	countQuery += " WHERE i.status = 'pending'"
+	countQuery := `SELECT COUNT(*), COALESCE(SUM(i.amount - i.paid - i.waived), 0.0), COALESCE(SUM(f.fee), 0.0), COALESCE(SUM(i.amount - i.paid - i.waived - f.payee_fee), 0.0)
+		FROM invoices i JOIN properties p ON i.property = p.id ` + invoiceFeesJoin
+	countQuery += " WHERE i.status IN ('pending', 'partially_paid')"
 
 	// check on from date
 	if flts.Username != nil {
@@ -776,9 +887,11 @@ func (repo *paymentStore) UnpaidHouses(ctx context.Context, flts *payment.Metric
 	var (
 		total  uint64
 		amount float64
+		fees   float64
+		net    float64
 	)
 
-	if err := repo.QueryRow(countQuery).Scan(&total, &amount); err != nil {
+	if err := repo.QueryRow(countQuery).Scan(&total, &amount, &fees, &net); err != nil {
 		return payment.PaymentResponse{}, errors.E(op, err, errors.KindUnexpected)
 	}
 
@@ -789,12 +902,82 @@ func (repo *paymentStore) UnpaidHouses(ctx context.Context, flts *payment.Metric
 			Offset: *flts.Offset,
 			Limit:  *flts.Limit,
 			Amount: amount,
+			Fees:   fees,
+			Net:    net,
 		},
 	}
 	return page, nil
 
 }
-func formMessage(tx []*payment.TxRequest, prop *properties.Property) string {
+func (repo *paymentStore) Stale(ctx context.Context, before time.Time, offset, limit uint64) ([]string, error) {
+	const op errors.Op = "store/postgres/paymentStore.Stale"
+
+	q := `
+		SELECT
+			ref
+		FROM
+			payments
+		WHERE status='pending' AND created_at < $1
+		GROUP BY ref
+		ORDER BY MIN(created_at), ref OFFSET $2 LIMIT $3
+	`
+
+	rows, err := repo.QueryContext(ctx, q, before, offset, limit)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	refs := []string{}
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+func (repo *paymentStore) Expire(ctx context.Context, before time.Time, reason string) ([]string, error) {
+	const op errors.Op = "store/postgres/paymentStore.Expire"
+
+	q := `
+		WITH expired AS (
+			UPDATE payments SET status='expired', reason=$2
+			WHERE status='pending' AND created_at < $1
+			RETURNING ref
+		)
+		INSERT INTO payment_events (ref, kind, status, payload)
+		SELECT DISTINCT ref, 'expired', 'expired', json_build_object('reason', $2::text) FROM expired
+		RETURNING ref
+	`
+
+	rows, err := repo.QueryContext(ctx, q, before, reason)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	refs := []string{}
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	return refs, nil
+}
+
+func formMessage(tx []*payment.TxRequest, prop *properties.Property, receipts receiptLinks) string {
 
 	const header = "Murakoze kwishyura umusanzu w' isuku"
 	var (
@@ -826,6 +1009,9 @@ func formMessage(tx []*payment.TxRequest, prop *properties.Property) string {
 	buf.WriteString(fmt.Sprintf("Umubare w' amafaranga: %dRWF\n", amount))
 	buf.WriteString(fmt.Sprintf("Inzu yishyuriwe ni iya %s %s\n", prop.Owner.Fname, prop.Owner.Lname))
 	buf.WriteString(fmt.Sprintf("Code y' inzu ni: %s", tx[0].Code))
+	if link := receipts.link(tx[0].ID); link != "" {
+		buf.WriteString(fmt.Sprintf("\nInyemezabwishyu: %s", link))
+	}
 	return buf.String()
 }
 
@@ -846,10 +1032,25 @@ var insertTxQuery = `
 		amount,
 		method,
 		invoice,
-		namespace
+		namespace,
+		fee,
+		gateway_fee,
+		fee_bearer
 	) VALUES
 `
 
+// invoiceFeesJoin sums the fees of the transactions that paid an invoice,
+// payee_fee only counts the fees deducted from the collected money.
+var invoiceFeesJoin = `
+	LEFT JOIN LATERAL (
+		SELECT
+			COALESCE(SUM(t.fee), 0) AS fee,
+			COALESCE(SUM(t.fee) FILTER (WHERE t.fee_bearer = 'payee'), 0) AS payee_fee
+		FROM transactions t
+		WHERE t.invoice = i.id AND t.status = 'successful'
+	) f ON true
+`
+
 // update payments table
 var updatePayQuery = `
 UPDATE
@@ -858,3 +1059,16 @@ SET
 	confirmed=$1, status=$2 
 WHERE ref=$3
 `
+
+// receivedInto is the ledger account money paid with a method lands in,
+// agents hold cash until it's remitted and bank deposits skip the gateway.
+func receivedInto(namespace string, method payment.Method) string {
+	switch method {
+	case payment.Cash:
+		return ledger.Cash(namespace)
+	case payment.Bank:
+		return ledger.Bank(namespace)
+	default:
+		return ledger.Clearing(namespace)
+	}
+}
@@ -0,0 +1,305 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payouts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.PayoutRepository) = (*payoutStore)(nil)
+var _ (payouts.Repository) = (*payoutStore)(nil)
+
+type payoutStore struct {
+	*sql.DB
+}
+
+// NewPayoutRepository creates a postgres backed payment.PayoutRepository
+func NewPayoutRepository(db *sql.DB) payment.PayoutRepository {
+	return &payoutStore{db}
+}
+
+// NewPayoutReportRepository creates a postgres backed payouts.Repository
+func NewPayoutReportRepository(db *sql.DB) payouts.Repository {
+	return &payoutStore{db}
+}
+
+func (repo *payoutStore) Save(ctx context.Context, p *payment.Payout) error {
+	const op errors.Op = "store/postgres/payoutStore.Save"
+
+	q := `
+		INSERT INTO payouts (
+			ref,
+			namespace,
+			msisdn,
+			method,
+			amount,
+			status
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		p.Ref,
+		p.Namespace,
+		p.MSISDN,
+		p.Method,
+		p.Amount,
+		p.Status,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errDuplicate:
+				return errors.E(op, "payout already exists", errors.KindAlreadyExists)
+			case errInvalid, errTruncation:
+				return errors.E(op, "invalid payout entity", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *payoutStore) Find(ctx context.Context, ref string) (payment.Payout, error) {
+	const op errors.Op = "store/postgres/payoutStore.Find"
+
+	q := `
+		SELECT 
+			id, ref, namespace, msisdn, method, amount, status, created_at, updated_at
+		FROM 
+			payouts 
+		WHERE ref=$1
+	`
+
+	var p payment.Payout
+
+	err := repo.QueryRowContext(ctx, q, ref).Scan(
+		&p.ID,
+		&p.Ref,
+		&p.Namespace,
+		&p.MSISDN,
+		&p.Method,
+		&p.Amount,
+		&p.Status,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return payment.Payout{}, errors.E(op, "payout not found", errors.KindNotFound)
+		}
+		return payment.Payout{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return p, nil
+}
+
+func (repo *payoutStore) Settle(ctx context.Context, ref string, status payment.PayoutStatus) (payment.Payout, error) {
+	const op errors.Op = "store/postgres/payoutStore.Settle"
+
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return payment.Payout{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	q := `
+		UPDATE payouts SET status=$1 WHERE ref=$2 AND status=$3
+		RETURNING id, ref, namespace, msisdn, method, amount, status, created_at, updated_at
+	`
+
+	var p payment.Payout
+
+	err = tx.QueryRowContext(ctx, q, status, ref, payment.PayoutPending).Scan(
+		&p.ID,
+		&p.Ref,
+		&p.Namespace,
+		&p.MSISDN,
+		&p.Method,
+		&p.Amount,
+		&p.Status,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err == nil {
+		// payouts made outside of an account have no ledger to post to
+		if p.Status == payment.PayoutSuccessful && p.Namespace != "" {
+			entry := ledger.NewEntry(ledger.PayoutEntry, p.Ref, p.Namespace, "payout to "+p.MSISDN).
+				Transfer(ledger.Payouts(p.Namespace), ledger.Clearing(p.Namespace), p.Amount)
+
+			if _, err := postEntry(ctx, tx, entry); err != nil {
+				return payment.Payout{}, errors.E(op, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return payment.Payout{}, errors.E(op, err, errors.KindUnexpected)
+		}
+		return p, nil
+	}
+	if err != sql.ErrNoRows {
+		return payment.Payout{}, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	// nothing was updated, either the payout is unknown or it was settled already
+	var exists bool
+
+	q = `SELECT EXISTS(SELECT 1 FROM payouts WHERE ref=$1)`
+	if err := tx.QueryRowContext(ctx, q, ref).Scan(&exists); err != nil {
+		return payment.Payout{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	if !exists {
+		return payment.Payout{}, errors.E(op, "payout not found", errors.KindNotFound)
+	}
+	return payment.Payout{}, errors.E(op, "payout already settled", errors.KindAlreadyExists)
+}
+
+func (repo *payoutStore) List(ctx context.Context, flts *payouts.Filters) (payouts.Page, error) {
+	const op errors.Op = "store/postgres/payoutStore.List"
+
+	var empty payouts.Page
+
+	where, args := payoutConditions(flts)
+
+	q := fmt.Sprintf(`
+		SELECT
+			id,
+			ref,
+			namespace,
+			msisdn,
+			method,
+			amount,
+			status,
+			created_at,
+			updated_at
+		FROM
+			payouts
+		%s
+		ORDER BY created_at DESC OFFSET $%d LIMIT $%d
+	`, where, len(args)+1, len(args)+2)
+
+	rows, err := repo.QueryContext(ctx, q, append(args, flts.Offset, flts.Limit)...)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var items = []payment.Payout{}
+
+	for rows.Next() {
+		var p payment.Payout
+		if err := rows.Scan(
+			&p.ID,
+			&p.Ref,
+			&p.Namespace,
+			&p.MSISDN,
+			&p.Method,
+			&p.Amount,
+			&p.Status,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		items = append(items, p)
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(amount), 0.0) FROM payouts %s`, where)
+
+	var (
+		total  uint64
+		amount float64
+	)
+
+	if err := repo.QueryRowContext(ctx, q, args...).Scan(&total, &amount); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := payouts.Page{
+		Payouts: items,
+		PageMetadata: payouts.PageMetadata{
+			Total:  total,
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+			Amount: amount,
+		},
+	}
+	return page, nil
+}
+
+func (repo *payoutStore) Totals(ctx context.Context, flts *payouts.Filters) ([]payouts.Total, error) {
+	const op errors.Op = "store/postgres/payoutStore.Totals"
+
+	where, args := payoutConditions(flts)
+
+	q := fmt.Sprintf(`
+		SELECT
+			namespace,
+			status,
+			COUNT(*),
+			COALESCE(SUM(amount), 0.0)
+		FROM
+			payouts
+		%s
+		GROUP BY namespace, status
+		ORDER BY namespace, status
+	`, where)
+
+	rows, err := repo.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var totals = []payouts.Total{}
+
+	for rows.Next() {
+		var t payouts.Total
+		if err := rows.Scan(&t.Namespace, &t.Status, &t.Count, &t.Amount); err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		totals = append(totals, t)
+	}
+	return totals, nil
+}
+
+// payoutConditions builds the where clause of the payout filters
+func payoutConditions(flts *payouts.Filters) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if flts.Namespace != nil {
+		add("namespace = $%d", *flts.Namespace)
+	}
+	if flts.Status != nil {
+		add("status = $%d", *flts.Status)
+	}
+	if flts.MSISDN != nil {
+		add("msisdn = $%d", *flts.MSISDN)
+	}
+	if flts.From != nil {
+		add("created_at >= $%d", *flts.From)
+	}
+	if flts.To != nil {
+		add("created_at <= $%d", *flts.To)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,145 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payouts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettlePayout(t *testing.T) {
+	repo := postgres.NewPayoutRepository(db)
+
+	defer CleanDB(t, db)
+
+	p := payment.Payout{
+		Ref:       uuid.New().ID(),
+		Namespace: "kigali",
+		MSISDN:    "0784607135",
+		Method:    payment.MTN,
+		Amount:    1000,
+		Status:    payment.PayoutPending,
+	}
+
+	err := repo.Save(context.Background(), &p)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.NotEmpty(t, p.ID, "expected a payout id")
+
+	cases := []struct {
+		desc string
+		ref  string
+		kind int
+	}{
+		{
+			desc: "settle pending payout",
+			ref:  p.Ref,
+		},
+		{
+			desc: "settle settled payout",
+			ref:  p.Ref,
+			kind: errors.KindAlreadyExists,
+		},
+		{
+			desc: "settle unknown payout",
+			ref:  uuid.New().ID(),
+			kind: errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		settled, err := repo.Settle(context.Background(), tc.ref, payment.PayoutSuccessful)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, payment.PayoutSuccessful, settled.Status, tc.desc)
+	}
+}
+
+func TestFindPayout(t *testing.T) {
+	repo := postgres.NewPayoutRepository(db)
+
+	defer CleanDB(t, db)
+
+	p := payment.Payout{
+		Ref:       uuid.New().ID(),
+		Namespace: "kigali",
+		MSISDN:    "0784607135",
+		Method:    payment.MTN,
+		Amount:    1000,
+		Status:    payment.PayoutPending,
+	}
+
+	err := repo.Save(context.Background(), &p)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc string
+		ref  string
+		kind int
+	}{
+		{
+			desc: "find saved payout",
+			ref:  p.Ref,
+		},
+		{
+			desc: "find unknown payout",
+			ref:  uuid.New().ID(),
+			kind: errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		found, err := repo.Find(context.Background(), tc.ref)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, p.Namespace, found.Namespace, tc.desc)
+	}
+}
+
+func TestListPayouts(t *testing.T) {
+	repo := postgres.NewPayoutRepository(db)
+	reports := postgres.NewPayoutReportRepository(db)
+
+	defer CleanDB(t, db)
+
+	namespaces := []string{"kigali", "kigali", "kigali", "huye"}
+	for _, ns := range namespaces {
+		p := payment.Payout{
+			Ref:       uuid.New().ID(),
+			Namespace: ns,
+			MSISDN:    "0784607135",
+			Method:    payment.MTN,
+			Amount:    1000,
+			Status:    payment.PayoutPending,
+		}
+		err := repo.Save(context.Background(), &p)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	kigali := "kigali"
+
+	page, err := reports.List(context.Background(), &payouts.Filters{Namespace: &kigali, Offset: 0, Limit: 2})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Len(t, page.Payouts, 2)
+	assert.Equal(t, uint64(3), page.Total)
+	assert.Equal(t, float64(3000), page.Amount)
+
+	totals, err := reports.Totals(context.Background(), &payouts.Filters{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, []payouts.Total{
+		{Namespace: "huye", Status: payment.PayoutPending, Count: 1, Amount: 1000},
+		{Namespace: "kigali", Status: payment.PayoutPending, Count: 3, Amount: 3000},
+	}, totals)
+}
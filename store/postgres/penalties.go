@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (accounts.PenaltyRepository) = (*penaltyStore)(nil)
+
+type penaltyStore struct {
+	*sql.DB
+}
+
+// NewPenaltyRepository creates a postgres backed accounts.PenaltyRepository
+func NewPenaltyRepository(db *sql.DB) accounts.PenaltyRepository {
+	return &penaltyStore{db}
+}
+
+func (repo *penaltyStore) SavePenaltyPolicy(ctx context.Context, policy accounts.PenaltyPolicy) (accounts.PenaltyPolicy, error) {
+	const op errors.Op = "store/postgres/penaltyStore.SavePenaltyPolicy"
+
+	q := `
+		INSERT INTO penalty_policies (
+			account,
+			type,
+			value,
+			cap,
+			grace_days,
+			enabled
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (account) DO UPDATE SET
+			type=EXCLUDED.type,
+			value=EXCLUDED.value,
+			cap=EXCLUDED.cap,
+			grace_days=EXCLUDED.grace_days,
+			enabled=EXCLUDED.enabled
+		RETURNING created_at, updated_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		policy.Account,
+		policy.Type,
+		policy.Value,
+		policy.Cap,
+		policy.GraceDays,
+		policy.Enabled,
+	).Scan(&policy.CreatedAt, &policy.UpdatedAt)
+
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errFK:
+				return accounts.PenaltyPolicy{}, errors.E(op, "account not found", errors.KindNotFound)
+			case errInvalid, errTruncation, errCheck:
+				return accounts.PenaltyPolicy{}, errors.E(op, "invalid penalty policy", errors.KindBadRequest)
+			}
+		}
+		return accounts.PenaltyPolicy{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return policy, nil
+}
+
+func (repo *penaltyStore) RetrievePenaltyPolicy(ctx context.Context, account string) (accounts.PenaltyPolicy, error) {
+	const op errors.Op = "store/postgres/penaltyStore.RetrievePenaltyPolicy"
+
+	q := `
+		SELECT
+			account,
+			type,
+			value,
+			cap,
+			grace_days,
+			enabled,
+			created_at,
+			updated_at
+		FROM
+			penalty_policies
+		WHERE account=$1
+	`
+
+	var policy accounts.PenaltyPolicy
+
+	err := repo.QueryRowContext(ctx, q, account).Scan(
+		&policy.Account,
+		&policy.Type,
+		&policy.Value,
+		&policy.Cap,
+		&policy.GraceDays,
+		&policy.Enabled,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return accounts.PenaltyPolicy{}, errors.E(op, "penalty policy not found", errors.KindNotFound)
+		}
+		return accounts.PenaltyPolicy{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return policy, nil
+}
@@ -0,0 +1,59 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavePenaltyPolicy(t *testing.T) {
+	repo := postgres.NewPenaltyRepository(db)
+
+	defer CleanDB(t, db)
+
+	account := saveAccount(t, db, accounts.Account{ID: "paypack.developers", Name: "remera", NumberOfSeats: 10, Type: accounts.Devs})
+
+	_, err := repo.RetrievePenaltyPolicy(context.Background(), account.ID)
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err), "expected no penalty policy")
+
+	cases := []struct {
+		desc   string
+		policy accounts.PenaltyPolicy
+		kind   int
+	}{
+		{
+			desc:   "save penalty policy",
+			policy: accounts.PenaltyPolicy{Account: account.ID, Type: accounts.FixedPenalty, Value: 500, Enabled: true},
+		},
+		{
+			desc:   "replace penalty policy",
+			policy: accounts.PenaltyPolicy{Account: account.ID, Type: accounts.PercentagePenalty, Value: 0.1, Cap: 1000, GraceDays: 15, Enabled: true},
+		},
+		{
+			desc:   "save penalty policy of unknown account",
+			policy: accounts.PenaltyPolicy{Account: "invalid", Type: accounts.FixedPenalty, Value: 500, Enabled: true},
+			kind:   errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := repo.SavePenaltyPolicy(context.Background(), tc.policy)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected '%d' got '%d'", tc.desc, tc.kind, errors.Kind(err)))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+	}
+
+	policy, err := repo.RetrievePenaltyPolicy(context.Background(), account.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, accounts.PercentagePenalty, policy.Type)
+	assert.Equal(t, float64(1000), policy.Cap)
+	assert.Equal(t, 15, policy.GraceDays)
+}
@@ -36,8 +36,10 @@ func (repo *propertiesStore) Save(ctx context.Context, pro properties.Property)
 			village, 
 			recorded_by, 
 			occupied,
-			namespace
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING created_at, updated_at`
+			namespace,
+			billing_cycle,
+			billing_anchor
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING created_at, updated_at`
 
 	empty := properties.Property{}
 
@@ -51,6 +53,8 @@ func (repo *propertiesStore) Save(ctx context.Context, pro properties.Property)
 		pro.RecordedBy,
 		pro.Occupied,
 		pro.Namespace,
+		pro.Billing.Cycle,
+		pro.Billing.Anchor,
 	).Scan(&pro.CreatedAt, &pro.UpdatedAt)
 
 	if err != nil {
@@ -72,15 +76,27 @@ func (repo *propertiesStore) Save(ctx context.Context, pro properties.Property)
 func (repo *propertiesStore) Update(ctx context.Context, pro properties.Property) error {
 	const op errors.Op = "store/postgres/propertiesStore.Update"
 
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	// the previous due is read from the row locked by the update
 	q := `
 		UPDATE properties SET 
 			owner=$1, due=$2, sector=$3, 
 			cell=$4, village=$5, occupied=$6, 
-			for_rent=$7, namespace=$8
-		WHERE id=$9;
+			for_rent=$7, namespace=$8, billing_cycle=$9,
+			billing_anchor=$10
+		FROM (SELECT id, due FROM properties WHERE id=$11 FOR UPDATE) AS old
+		WHERE properties.id=old.id
+		RETURNING old.due;
 	`
 
-	res, err := repo.Exec(q,
+	var previous float64
+
+	err = tx.QueryRowContext(ctx, q,
 		pro.Owner.ID,
 		pro.Due,
 		pro.Address.Sector,
@@ -88,10 +104,16 @@ func (repo *propertiesStore) Update(ctx context.Context, pro properties.Property
 		pro.Address.Village,
 		pro.ForRent,
 		pro.Occupied,
-		pro.Namespace, pro.ID,
-	)
+		pro.Namespace,
+		pro.Billing.Cycle,
+		pro.Billing.Anchor,
+		pro.ID,
+	).Scan(&previous)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.E(op, "property not found", errors.KindNotFound)
+		}
 		pqErr, ok := err.(*pq.Error)
 		if ok {
 			switch pqErr.Code.Name() {
@@ -102,14 +124,101 @@ func (repo *propertiesStore) Update(ctx context.Context, pro properties.Property
 		return err
 	}
 
-	cnt, err := res.RowsAffected()
+	// a new due takes effect right away
+	if previous != pro.Due {
+		tc := &properties.TariffChange{Property: pro.ID, Previous: previous, Due: pro.Due}
+		if err := changeDue(ctx, tx, tc); err != nil {
+			return errors.E(op, err, errors.KindUnexpected)
+		}
+	}
+	return tx.Commit()
+}
+
+func (repo *propertiesStore) ChangeDue(ctx context.Context, tc *properties.TariffChange) error {
+	const op errors.Op = "store/postgres/propertiesStore.ChangeDue"
+
+	tx, err := repo.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return errors.E(op, err, errors.KindUnexpected)
 	}
-	if cnt == 0 {
-		return errors.E(op, "property not found", errors.KindNotFound)
+	defer tx.Rollback()
+
+	tc.Previous, err = lockDue(ctx, tx, tc.Property)
+	if err != nil {
+		return errors.E(op, err)
 	}
-	return nil
+
+	q := `UPDATE properties SET due=$1 WHERE id=$2`
+
+	if _, err := tx.ExecContext(ctx, q, tc.Due, tc.Property); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errInvalid, errTruncation, errCheck:
+				return errors.E(op, err, "invalid tariff change", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	if err := changeDue(ctx, tx, tc); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return tx.Commit()
+}
+
+// lockDue returns the due of a property, the property can't change until
+// the transaction is over
+func lockDue(ctx context.Context, tx *sql.Tx, id string) (float64, error) {
+	const op errors.Op = "store/postgres/lockDue"
+
+	var due float64
+
+	q := `SELECT due FROM properties WHERE id=$1 FOR UPDATE`
+
+	if err := tx.QueryRowContext(ctx, q, id).Scan(&due); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && errInvalid == pqErr.Code.Name() {
+			return 0, errors.E(op, "property not found", errors.KindNotFound)
+		}
+		return 0, errors.E(op, err, errors.KindUnexpected)
+	}
+	return due, nil
+}
+
+// changeDue records the tariff change of a property whose due was just
+// updated and adjusts its open invoices. A change without an effective day
+// takes effect on the current one.
+func changeDue(ctx context.Context, tx *sql.Tx, tc *properties.TariffChange) error {
+	var effective string
+	if !tc.EffectiveFrom.IsZero() {
+		effective = tc.EffectiveFrom.Format(properties.DayLayout)
+	}
+
+	q := `
+		INSERT INTO tariff_changes (
+			property,
+			previous,
+			due,
+			effective_from,
+			changed_by
+		) VALUES ($1, $2, $3, COALESCE(NULLIF($4::text, '')::date, CURRENT_DATE), $5)
+		RETURNING id, effective_from, created_at
+	`
+
+	err := tx.QueryRowContext(ctx, q,
+		tc.Property,
+		tc.Previous,
+		tc.Due,
+		effective,
+		tc.ChangedBy,
+	).Scan(&tc.ID, &tc.EffectiveFrom, &tc.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `SELECT apply_tariff_change($1)`, tc.ID)
+	return err
 }
 
 func (repo *propertiesStore) Delete(ctx context.Context, uid string) error {
@@ -141,6 +250,8 @@ func (repo *propertiesStore) RetrieveByID(ctx context.Context, id string) (prope
 			properties.cell,  
 			properties.village, 
 			properties.due, 
+			properties.billing_cycle,
+			properties.billing_anchor,
 			properties.recorded_by,
 			properties.occupied, 
 			properties.for_rent, 
@@ -166,6 +277,8 @@ func (repo *propertiesStore) RetrieveByID(ctx context.Context, id string) (prope
 		&prt.Address.Cell,
 		&prt.Address.Village,
 		&prt.Due,
+		&prt.Billing.Cycle,
+		&prt.Billing.Anchor,
 		&prt.RecordedBy,
 		&prt.Occupied,
 		&prt.ForRent,
@@ -199,6 +312,8 @@ func (repo *propertiesStore) RetrieveByOwner(ctx context.Context, owner string,
 			properties.cell, 
 			properties.village, 
 			properties.due, 
+			properties.billing_cycle,
+			properties.billing_anchor,
 			properties.recorded_by,
 			properties.occupied, 
 			properties.for_rent, 
@@ -238,6 +353,8 @@ func (repo *propertiesStore) RetrieveByOwner(ctx context.Context, owner string,
 			&row.Address.Cell,
 			&row.Address.Village,
 			&row.Due,
+			&row.Billing.Cycle,
+			&row.Billing.Anchor,
 			&row.RecordedBy,
 			&row.Occupied,
 			&row.ForRent,
@@ -292,6 +409,8 @@ func (repo *propertiesStore) RetrieveBySector(ctx context.Context, flts *propert
 			properties.cell, 
 			properties.village, 
 			properties.due, 
+			properties.billing_cycle,
+			properties.billing_anchor,
 			properties.recorded_by, 
 			properties.occupied, 
 			properties.for_rent, 
@@ -343,6 +462,8 @@ func (repo *propertiesStore) RetrieveBySector(ctx context.Context, flts *propert
 			&row.Address.Cell,
 			&row.Address.Village,
 			&row.Due,
+			&row.Billing.Cycle,
+			&row.Billing.Anchor,
 			&row.RecordedBy,
 			&row.Occupied,
 			&row.ForRent,
@@ -418,6 +539,8 @@ func (repo *propertiesStore) RetrieveByCell(ctx context.Context, cell string, of
 			properties.cell, 
 			properties.village, 
 			properties.due, 
+			properties.billing_cycle,
+			properties.billing_anchor,
 			properties.recorded_by, 
 			properties.occupied, 
 			properties.for_rent, 
@@ -457,6 +580,8 @@ func (repo *propertiesStore) RetrieveByCell(ctx context.Context, cell string, of
 			&row.Address.Cell,
 			&row.Address.Village,
 			&row.Due,
+			&row.Billing.Cycle,
+			&row.Billing.Anchor,
 			&row.RecordedBy,
 			&row.Occupied,
 			&row.ForRent,
@@ -511,6 +636,8 @@ func (repo *propertiesStore) RetrieveByVillage(ctx context.Context, village stri
 			properties.cell, 
 			properties.village, 
 			properties.due, 
+			properties.billing_cycle,
+			properties.billing_anchor,
 			properties.recorded_by, 
 			properties.occupied, 
 			properties.for_rent, 
@@ -553,6 +680,8 @@ func (repo *propertiesStore) RetrieveByVillage(ctx context.Context, village stri
 			&row.Address.Cell,
 			&row.Address.Village,
 			&row.Due,
+			&row.Billing.Cycle,
+			&row.Billing.Anchor,
 			&row.RecordedBy,
 			&row.Occupied,
 			&row.ForRent,
@@ -610,6 +739,8 @@ func (repo *propertiesStore) RetrieveByRecorder(ctx context.Context, user string
 			properties.cell, 
 			properties.village, 
 			properties.due, 
+			properties.billing_cycle,
+			properties.billing_anchor,
 			properties.recorded_by, 
 			properties.occupied, 
 			properties.for_rent, 
@@ -648,6 +779,8 @@ func (repo *propertiesStore) RetrieveByRecorder(ctx context.Context, user string
 			&row.Address.Cell,
 			&row.Address.Village,
 			&row.Due,
+			&row.Billing.Cycle,
+			&row.Billing.Anchor,
 			&row.RecordedBy,
 			&row.Occupied,
 			&row.ForRent,
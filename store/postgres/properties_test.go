@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
 	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
@@ -273,6 +274,62 @@ func TestDelete(t *testing.T) {
 
 }
 
+func TestChangeDue(t *testing.T) {
+	props := postgres.NewPropertyStore(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	var initial float64
+
+	q := `SELECT amount FROM invoices WHERE property=$1 AND penalty_of IS NULL`
+	err := db.QueryRow(q, property.ID).Scan(&initial)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	cases := []struct {
+		desc   string
+		change properties.TariffChange
+		amount float64
+		kind   int
+	}{
+		{
+			desc:   "double the due of an existing property",
+			change: properties.TariffChange{Property: property.ID, Due: property.Due * 2, EffectiveFrom: time.Now(), ChangedBy: "manager"},
+			amount: initial * 2,
+		},
+		{
+			desc:   "change the due of a non existant property",
+			change: properties.TariffChange{Property: nanoid.New(nil).ID(), Due: property.Due, EffectiveFrom: time.Now(), ChangedBy: "manager"},
+			kind:   errors.KindNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		err := props.ChangeDue(ctx, &tc.change)
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, property.Due, tc.change.Previous, fmt.Sprintf("%s: expected previous due %v got %v", tc.desc, property.Due, tc.change.Previous))
+
+		var amount, previous float64
+
+		q := `
+			SELECT invoices.amount, invoice_adjustments.previous
+			FROM invoices JOIN invoice_adjustments ON invoice_adjustments.invoice=invoices.id
+			WHERE invoice_adjustments.ref=$1
+		`
+		err = db.QueryRow(q, tc.change.ID).Scan(&amount, &previous)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		assert.InDelta(t, tc.amount, amount, 0.01, fmt.Sprintf("%s: expected amount %v got %v", tc.desc, tc.amount, amount))
+		assert.Equal(t, initial, previous, fmt.Sprintf("%s: expected previous amount %v got %v", tc.desc, initial, previous))
+	}
+}
+
 func TestRetrieveByID(t *testing.T) {
 	props := postgres.NewPropertyStore(db)
 
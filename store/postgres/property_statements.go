@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (statements.Repository) = (*propertyStatementStore)(nil)
+
+type propertyStatementStore struct {
+	*sql.DB
+}
+
+// NewPropertyStatementRepository creates a postgres backed statements.Repository,
+// statements are read from the receivable account of the property in the ledger
+func NewPropertyStatementRepository(db *sql.DB) statements.Repository {
+	return &propertyStatementStore{db}
+}
+
+func (repo *propertyStatementStore) Statement(ctx context.Context, property, namespace string, from, to time.Time) (statements.Statement, error) {
+	const op errors.Op = "store/postgres/propertyStatementStore.Statement"
+
+	var empty statements.Statement
+
+	q := `
+		SELECT
+			p.id,
+			o.fname || ' ' || o.lname,
+			p.namespace,
+			p.sector,
+			p.cell,
+			p.village,
+			p.due
+		FROM
+			properties p
+		JOIN owners o ON p.owner = o.id
+		WHERE p.id = $1 AND ($2 = '' OR p.namespace = $2)
+	`
+
+	st := statements.Statement{Items: []statements.Item{}}
+
+	err := repo.QueryRowContext(ctx, q, property, namespace).Scan(
+		&st.Property,
+		&st.Owner,
+		&st.Namespace,
+		&st.Sector,
+		&st.Cell,
+		&st.Village,
+		&st.Due,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return empty, errors.E(op, "property not found", errors.KindNotFound)
+		}
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	account := ledger.Receivable(st.Property)
+	first, last := from.Format(statements.DayLayout), to.Format(statements.DayLayout)
+
+	q = `
+		SELECT
+			COALESCE(SUM(l.debit - l.credit), 0)
+		FROM
+			journal_lines l
+		JOIN journal_entries e ON l.entry = e.id
+		WHERE l.account = $1 AND e.created_at < $2::date
+	`
+
+	if err := repo.QueryRowContext(ctx, q, account, first).Scan(&st.Opening); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	// penalties are posted as invoices, they are told apart by the invoice
+	// they penalize
+	q = `
+		SELECT
+			CASE WHEN i.penalty_of IS NOT NULL THEN 'penalty' ELSE e.kind END,
+			e.ref,
+			e.description,
+			l.debit,
+			l.credit,
+			e.created_at
+		FROM
+			journal_lines l
+		JOIN journal_entries e ON l.entry = e.id
+		LEFT JOIN invoices i ON e.kind = 'invoice' AND e.ref = i.id::TEXT
+		WHERE l.account = $1 AND e.created_at >= $2::date AND e.created_at < $3::date + 1
+		ORDER BY e.created_at, l.id
+	`
+
+	rows, err := repo.QueryContext(ctx, q, account, first, last)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item statements.Item
+		if err := rows.Scan(
+			&item.Kind,
+			&item.Ref,
+			&item.Description,
+			&item.Debit,
+			&item.Credit,
+			&item.CreatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		st.Items = append(st.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	return st, nil
+}
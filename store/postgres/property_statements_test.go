@@ -0,0 +1,83 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/statements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertyStatement(t *testing.T) {
+	repo := postgres.NewPropertyStatementRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	var charged float64
+
+	q := `SELECT amount FROM invoices WHERE property=$1`
+	err := db.QueryRow(q, property.ID).Scan(&charged)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	today := time.Now().UTC()
+
+	cases := []struct {
+		desc      string
+		property  string
+		namespace string
+		from      time.Time
+		items     int
+		opening   float64
+		kind      int
+	}{
+		{
+			desc:      "retrieve statement of the current day",
+			property:  property.ID,
+			namespace: property.Namespace,
+			from:      today,
+			items:     1,
+		},
+		{
+			desc:     "retrieve statement across namespaces",
+			property: property.ID,
+			from:     today,
+			items:    1,
+		},
+		{
+			desc:      "retrieve statement of another namespace",
+			property:  property.ID,
+			namespace: wrongValue,
+			from:      today,
+			kind:      errors.KindNotFound,
+		},
+		{
+			desc:     "retrieve statement of the days to come",
+			property: property.ID,
+			from:     today.AddDate(0, 0, 1),
+			opening:  charged,
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		st, err := repo.Statement(ctx, tc.property, tc.namespace, tc.from, tc.from.AddDate(0, 0, 7))
+		if tc.kind != 0 {
+			assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.opening, st.Opening, fmt.Sprintf("%s: expected opening balance %v got %v", tc.desc, tc.opening, st.Opening))
+		require.Len(t, st.Items, tc.items, fmt.Sprintf("%s: expected %d items got %d", tc.desc, tc.items, len(st.Items)))
+		for _, item := range st.Items {
+			assert.Equal(t, statements.Invoice, item.Kind, fmt.Sprintf("%s: expected %s got %s", tc.desc, statements.Invoice, item.Kind))
+			assert.Equal(t, charged, item.Debit, fmt.Sprintf("%s: expected debit %v got %v", tc.desc, charged, item.Debit))
+		}
+	}
+}
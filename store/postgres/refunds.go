@@ -0,0 +1,512 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/ledger"
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (refunds.Repository) = (*refundStore)(nil)
+
+type refundStore struct {
+	*sql.DB
+}
+
+// NewRefundRepository creates a postgres backed refunds.Repository
+func NewRefundRepository(db *sql.DB) refunds.Repository {
+	return &refundStore{db}
+}
+
+func (repo *refundStore) Save(ctx context.Context, r *refunds.Refund) error {
+	const op errors.Op = "store/postgres/refundStore.Save"
+
+	q := `
+		INSERT INTO refunds (
+			id,
+			ref,
+			property,
+			namespace,
+			msisdn,
+			method,
+			amount,
+			reason,
+			status,
+			requested_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		r.ID,
+		r.Ref,
+		r.Property,
+		r.Namespace,
+		r.MSISDN,
+		r.Method,
+		r.Amount,
+		r.Reason,
+		r.Status,
+		r.RequestedBy,
+	).Scan(&r.CreatedAt, &r.UpdatedAt)
+
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errDuplicate:
+				return errors.E(op, "payment already has a refund", errors.KindAlreadyExists)
+			case errInvalid, errTruncation:
+				return errors.E(op, "invalid refund entity", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *refundStore) Find(ctx context.Context, id string) (refunds.Refund, error) {
+	const op errors.Op = "store/postgres/refundStore.Find"
+
+	q := `
+		SELECT
+			id,
+			ref,
+			reversal,
+			property,
+			namespace,
+			msisdn,
+			method,
+			amount,
+			reason,
+			status,
+			requested_by,
+			reviewed_by,
+			created_at,
+			updated_at
+		FROM
+			refunds
+		WHERE id=$1
+	`
+
+	var r refunds.Refund
+
+	err := repo.QueryRowContext(ctx, q, id).Scan(
+		&r.ID,
+		&r.Ref,
+		&r.Reversal,
+		&r.Property,
+		&r.Namespace,
+		&r.MSISDN,
+		&r.Method,
+		&r.Amount,
+		&r.Reason,
+		&r.Status,
+		&r.RequestedBy,
+		&r.ReviewedBy,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && errInvalid == pqErr.Code.Name() {
+			return refunds.Refund{}, errors.E(op, "refund not found", errors.KindNotFound)
+		}
+		return refunds.Refund{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return r, nil
+}
+
+func (repo *refundStore) FindByReversal(ctx context.Context, reversal string) (refunds.Refund, error) {
+	const op errors.Op = "store/postgres/refundStore.FindByReversal"
+
+	q := `
+		SELECT
+			id,
+			ref,
+			reversal,
+			property,
+			namespace,
+			msisdn,
+			method,
+			amount,
+			reason,
+			status,
+			requested_by,
+			reviewed_by,
+			created_at,
+			updated_at
+		FROM
+			refunds
+		WHERE reversal=$1 AND reversal <> ''
+	`
+
+	var r refunds.Refund
+
+	err := repo.QueryRowContext(ctx, q, reversal).Scan(
+		&r.ID,
+		&r.Ref,
+		&r.Reversal,
+		&r.Property,
+		&r.Namespace,
+		&r.MSISDN,
+		&r.Method,
+		&r.Amount,
+		&r.Reason,
+		&r.Status,
+		&r.RequestedBy,
+		&r.ReviewedBy,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return refunds.Refund{}, errors.E(op, "refund not found", errors.KindNotFound)
+		}
+		return refunds.Refund{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return r, nil
+}
+
+func (repo *refundStore) List(ctx context.Context, flts *refunds.Filters) (refunds.Page, error) {
+	const op errors.Op = "store/postgres/refundStore.List"
+
+	where, args := refundConditions(flts)
+
+	q := fmt.Sprintf(`
+		SELECT
+			id,
+			ref,
+			reversal,
+			property,
+			namespace,
+			msisdn,
+			method,
+			amount,
+			reason,
+			status,
+			requested_by,
+			reviewed_by,
+			created_at,
+			updated_at
+		FROM
+			refunds
+		%s
+		ORDER BY created_at DESC OFFSET $%d LIMIT $%d
+	`, where, len(args)+1, len(args)+2)
+
+	var empty refunds.Page
+
+	rows, err := repo.QueryContext(ctx, q, append(args, flts.Offset, flts.Limit)...)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var items = []refunds.Refund{}
+
+	for rows.Next() {
+		var r refunds.Refund
+		if err := rows.Scan(
+			&r.ID,
+			&r.Ref,
+			&r.Reversal,
+			&r.Property,
+			&r.Namespace,
+			&r.MSISDN,
+			&r.Method,
+			&r.Amount,
+			&r.Reason,
+			&r.Status,
+			&r.RequestedBy,
+			&r.ReviewedBy,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		items = append(items, r)
+	}
+
+	q = fmt.Sprintf(`SELECT count(*) FROM refunds %s`, where)
+
+	var total uint64
+	if err := repo.QueryRowContext(ctx, q, args...).Scan(&total); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := refunds.Page{
+		Refunds: items,
+		PageMetadata: refunds.PageMetadata{
+			Total:  total,
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+		},
+	}
+	return page, nil
+}
+
+func (repo *refundStore) Review(ctx context.Context, r refunds.Refund) error {
+	const op errors.Op = "store/postgres/refundStore.Review"
+
+	q := `UPDATE refunds SET status=$1, reviewed_by=$2 WHERE id=$3 AND status=$4`
+
+	res, err := repo.ExecContext(ctx, q, r.Status, r.ReviewedBy, r.ID, refunds.Pending)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if n == 0 {
+		return errors.E(op, "refund was already reviewed", errors.KindAlreadyExists)
+	}
+	return nil
+}
+
+func (repo *refundStore) Update(ctx context.Context, r refunds.Refund) error {
+	const op errors.Op = "store/postgres/refundStore.Update"
+
+	q := `UPDATE refunds SET status=$1, reversal=$2 WHERE id=$3`
+
+	res, err := repo.ExecContext(ctx, q, r.Status, r.Reversal, r.ID)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if n == 0 {
+		return errors.E(op, "refund not found", errors.KindNotFound)
+	}
+	return nil
+}
+
+func (repo *refundStore) Reverse(ctx context.Context, r refunds.Refund) error {
+	const op errors.Op = "store/postgres/refundStore.Reverse"
+
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	// only approved refunds are completed, a replayed payout callback
+	// must not take the money back twice
+	q := `UPDATE refunds SET status=$1, reversal=$2 WHERE id=$3 AND status=$4`
+
+	res, err := tx.ExecContext(ctx, q, r.Status, r.Reversal, r.ID, refunds.Approved)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if n == 0 {
+		return errors.E(op, "refund not found or already completed", errors.KindNotFound)
+	}
+
+	// reversals mirror the original transactions with negated amounts so
+	// that transaction totals account for the refunded money.
+	q = `
+		INSERT INTO transactions (
+			id,
+			ref,
+			status,
+			madefor,
+			madeby,
+			amount,
+			method,
+			invoice,
+			namespace
+		)
+		SELECT
+			uuid_generate_v4(), $1, 'reversed', madefor, madeby, -amount, method, invoice, namespace
+		FROM
+			transactions
+		WHERE ref=$2 AND status='successful'
+	`
+	if _, err := tx.ExecContext(ctx, q, r.Reversal, r.Ref); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	// the invoices the payment was allocated to are owed again
+	q = `
+		UPDATE invoices SET
+			paid=GREATEST(invoices.paid - reversed.amount, 0),
+			status=CASE WHEN invoices.paid - reversed.amount > 0 THEN 'partially_paid' ELSE 'pending' END
+		FROM (
+			SELECT 
+				a.invoice, SUM(a.amount) AS amount 
+			FROM 
+				payment_allocations a JOIN transactions t ON a.transaction=t.id 
+			WHERE 
+				t.ref=$1 AND t.status='successful' AND a.invoice IS NOT NULL 
+			GROUP BY a.invoice
+		) AS reversed
+		WHERE invoices.id=reversed.invoice
+	`
+	if _, err := tx.ExecContext(ctx, q, r.Ref); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	if err := reverseCredit(ctx, tx, r); err != nil {
+		return errors.E(op, err)
+	}
+
+	// the household owes the invoices again until the money is sent back
+	var namespace, phone string
+
+	q = `SELECT p.namespace, o.phone FROM properties p JOIN owners o ON p.owner=o.id WHERE p.id=$1`
+	if err := tx.QueryRowContext(ctx, q, r.Property).Scan(&namespace, &phone); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	entry := ledger.NewEntry(ledger.RefundEntry, r.ID, namespace, "refund of payment "+r.Ref).
+		Transfer(ledger.Receivable(r.Property), ledger.Payouts(namespace), r.Amount)
+
+	if _, err := postEntry(ctx, tx, entry); err != nil {
+		return errors.E(op, err)
+	}
+
+	recipients := []string{phone, r.MSISDN}
+	if err := enqueueSMS(ctx, tx, namespace, recipients, refunds.FormatMessage(r, timestamp())); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	return tx.Commit()
+}
+
+// reverseCredit takes back the part of a refunded payment that went to the
+// credit of the property. Credit that was already spent on newer invoices
+// is taken back from them, newest first.
+func reverseCredit(ctx context.Context, tx *sql.Tx, r refunds.Refund) error {
+	const op errors.Op = "store/postgres/reverseCredit"
+
+	var credited float64
+
+	q := `
+		SELECT 
+			COALESCE(SUM(a.amount), 0) 
+		FROM 
+			payment_allocations a JOIN transactions t ON a.transaction=t.id 
+		WHERE 
+			t.ref=$1 AND t.status='successful' AND a.invoice IS NULL
+	`
+	if err := tx.QueryRowContext(ctx, q, r.Ref).Scan(&credited); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	if credited <= 0 {
+		return nil
+	}
+
+	var available float64
+
+	q = `SELECT credit FROM property_balances WHERE property=$1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, q, r.Property).Scan(&available); err != nil && err != sql.ErrNoRows {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	taken := math.Min(available, credited)
+	if taken > 0 {
+		q = `UPDATE property_balances SET credit=credit - $1 WHERE property=$2`
+		if _, err := tx.ExecContext(ctx, q, taken, r.Property); err != nil {
+			return errors.E(op, err, errors.KindUnexpected)
+		}
+	}
+
+	spent := credited - taken
+	if spent <= 0 {
+		return nil
+	}
+
+	q = `
+		SELECT 
+			id, paid 
+		FROM 
+			invoices 
+		WHERE 
+			property=$1 AND paid > 0 
+		ORDER BY period_start DESC, created_at DESC, id DESC 
+		FOR UPDATE
+	`
+
+	rows, err := tx.QueryContext(ctx, q, r.Property)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	type paid struct {
+		invoice uint64
+		amount  float64
+	}
+
+	var invoices []paid
+	for rows.Next() {
+		var p paid
+		if err := rows.Scan(&p.invoice, &p.amount); err != nil {
+			rows.Close()
+			return errors.E(op, err, errors.KindUnexpected)
+		}
+		invoices = append(invoices, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	q = `
+		UPDATE invoices SET
+			paid=paid - $1,
+			status=CASE WHEN paid - $1 > 0 THEN 'partially_paid' ELSE 'pending' END
+		WHERE id=$2
+	`
+
+	for _, p := range invoices {
+		if spent <= 0 {
+			break
+		}
+		applied := math.Min(spent, p.amount)
+		if _, err := tx.ExecContext(ctx, q, applied, p.invoice); err != nil {
+			return errors.E(op, err, errors.KindUnexpected)
+		}
+		spent -= applied
+	}
+	return nil
+}
+
+func refundConditions(flts *refunds.Filters) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if flts.Namespace != nil {
+		add("namespace = $%d", *flts.Namespace)
+	}
+	if flts.Status != nil {
+		add("status = $%d", *flts.Status)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,273 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
+	"github.com/nshimiyimanaamani/paypack-backend/core/nanoid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/properties"
+	"github.com/nshimiyimanaamani/paypack-backend/core/refunds"
+	"github.com/nshimiyimanaamani/paypack-backend/core/users"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/cast"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveRefund(t *testing.T) {
+	repo := postgres.NewRefundRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+	ref := uuid.New().ID()
+
+	cases := []struct {
+		desc   string
+		refund *refunds.Refund
+		kind   int
+	}{
+		{
+			desc:   "save refund",
+			refund: newRefund(property, ref),
+			kind:   0,
+		},
+		{
+			desc:   "save second refund of the same payment",
+			refund: newRefund(property, ref),
+			kind:   errors.KindAlreadyExists,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Save(context.Background(), tc.refund)
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+}
+
+func TestReviewRefund(t *testing.T) {
+	repo := postgres.NewRefundRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	refund := newRefund(property, uuid.New().ID())
+	err := repo.Save(context.Background(), refund)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	refund.Status = refunds.Rejected
+	refund.ReviewedBy = "admin"
+
+	cases := []struct {
+		desc string
+		kind int
+	}{
+		{
+			desc: "review pending refund",
+			kind: 0,
+		},
+		{
+			desc: "review already reviewed refund",
+			kind: errors.KindAlreadyExists,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Review(context.Background(), *refund)
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+
+	saved, err := repo.Find(context.Background(), refund.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, refunds.Rejected, saved.Status, fmt.Sprintf("expected %s got %s", refunds.Rejected, saved.Status))
+	assert.Equal(t, "admin", saved.ReviewedBy, fmt.Sprintf("expected %s got %s", "admin", saved.ReviewedBy))
+}
+
+func TestListRefunds(t *testing.T) {
+	repo := postgres.NewRefundRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	const n = 3
+	for i := 0; i < n; i++ {
+		err := repo.Save(context.Background(), newRefund(property, uuid.New().ID()))
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	cases := []struct {
+		desc  string
+		flts  *refunds.Filters
+		total uint64
+	}{
+		{
+			desc:  "list refunds of namespace",
+			flts:  &refunds.Filters{Namespace: &property.Namespace, Limit: n},
+			total: n,
+		},
+		{
+			desc:  "list refunds of another namespace",
+			flts:  &refunds.Filters{Namespace: cast.StringPointer("kigali.gasabo.kimironko"), Limit: n},
+			total: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.List(context.Background(), tc.flts)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d refunds got %d", tc.desc, tc.total, page.Total))
+		assert.Len(t, page.Refunds, int(tc.total), fmt.Sprintf("%s: expected %d refunds got %d", tc.desc, tc.total, len(page.Refunds)))
+	}
+}
+
+func TestReverseRefund(t *testing.T) {
+	repo := postgres.NewRefundRepository(db)
+	payments := postgres.NewPaymentRepository(db, "", "")
+
+	defer CleanDB(t, db)
+
+	ctx := context.Background()
+
+	property := savePayableProperty(t)
+
+	var invoice uint64
+
+	q := `SELECT id FROM invoices WHERE property=$1`
+	err := db.QueryRow(q, property.ID).Scan(&invoice)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	// the first payment settles the invoice, the second one is kept as credit
+	var refs []string
+	for i := 0; i < 2; i++ {
+		pmt := &payment.TxRequest{
+			ID:      uuid.New().ID(),
+			Ref:     uuid.New().ID(),
+			Code:    property.ID,
+			Amount:  property.Due,
+			Invoice: invoice,
+			MSISDN:  "0784607135",
+			Method:  payment.MTN,
+		}
+		err := payments.Update(ctx, "successful", []*payment.TxRequest{pmt})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+		refs = append(refs, pmt.Ref)
+	}
+
+	cases := []struct {
+		desc   string
+		ref    string
+		status string
+		credit float64
+	}{
+		{
+			desc:   "reverse payment kept as credit",
+			ref:    refs[1],
+			status: "payed",
+			credit: 0,
+		},
+		{
+			desc:   "reverse payment of an invoice",
+			ref:    refs[0],
+			status: "pending",
+			credit: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		refund := newRefund(property, tc.ref)
+		refund.Amount = property.Due
+		err := repo.Save(ctx, refund)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		refund.Status = refunds.Approved
+		refund.ReviewedBy = "admin"
+		err = repo.Review(ctx, *refund)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		refund.Status = refunds.Completed
+		refund.Reversal = uuid.New().ID()
+		err = repo.Reverse(ctx, *refund)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		var status string
+		err = db.QueryRow(`SELECT status FROM invoices WHERE id=$1`, invoice).Scan(&status)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.status, status, fmt.Sprintf("%s: expected invoice status %s got %s", tc.desc, tc.status, status))
+
+		var credit float64
+		err = db.QueryRow(`SELECT COALESCE(SUM(credit), 0) FROM property_balances WHERE property=$1`, property.ID).Scan(&credit)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.credit, credit, fmt.Sprintf("%s: expected credit %v got %v", tc.desc, tc.credit, credit))
+
+		err = repo.Reverse(ctx, *refund)
+		assert.Equal(t, errors.KindNotFound, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, errors.KindNotFound, err))
+	}
+}
+
+func newRefund(property properties.Property, ref string) *refunds.Refund {
+	return &refunds.Refund{
+		ID:          uuid.New().ID(),
+		Ref:         ref,
+		Property:    property.ID,
+		Namespace:   property.Namespace,
+		MSISDN:      "0784607135",
+		Method:      payment.MTN,
+		Amount:      1000,
+		Reason:      "paid twice",
+		Status:      refunds.Pending,
+		RequestedBy: "agent",
+	}
+}
+
+func savePayableProperty(t *testing.T) properties.Property {
+	t.Helper()
+
+	account := accounts.Account{ID: "paypack.developers", Name: "remera", NumberOfSeats: 10, Type: accounts.Devs}
+	account = saveAccount(t, db, account)
+
+	agent := users.Agent{
+		Telephone: random(15),
+		FirstName: "first",
+		LastName:  "last",
+		Password:  "password",
+		Cell:      "cell",
+		Sector:    "Sector",
+		Village:   "village",
+		Role:      users.Dev,
+		Account:   account.ID,
+	}
+	agent = saveAgent(t, db, agent)
+
+	owner := properties.Owner{ID: uuid.New().ID(), Fname: "rugwiro", Lname: "james", Phone: "0784677882"}
+	owner = saveOwner(t, db, owner)
+
+	property := properties.Property{
+		ID:    nanoid.New(nil).ID(),
+		Owner: properties.Owner{ID: owner.ID},
+		Address: properties.Address{
+			Sector:  "Remera",
+			Cell:    "Gishushu",
+			Village: "Ingabo",
+		},
+		Namespace:  account.ID,
+		Due:        float64(1000),
+		RecordedBy: agent.Telephone,
+		Occupied:   true,
+	}
+	return saveProperty(t, db, property)
+}
@@ -0,0 +1,331 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (settlements.Repository) = (*settlementStore)(nil)
+
+type settlementStore struct {
+	*sql.DB
+}
+
+// NewSettlementRepository creates a postgres backed settlements.Repository
+func NewSettlementRepository(db *sql.DB) settlements.Repository {
+	return &settlementStore{db}
+}
+
+func (repo *settlementStore) Totals(ctx context.Context, day time.Time) ([]settlements.Settlement, error) {
+	const op errors.Op = "store/postgres/settlementStore.Totals"
+
+	// cash and bank payments never reach the gateway
+	q := `
+		WITH collected AS (
+			SELECT
+				properties.namespace,
+				COUNT(*) AS count,
+				SUM(payments.amount) AS amount
+			FROM
+				payments INNER JOIN properties ON payments.property = properties.id
+			WHERE
+				payments.status = 'successful'
+			AND
+				payments.method NOT IN ($2, $3)
+			AND
+				DATE(payments.updated_at) = $1
+			GROUP BY properties.namespace
+		), recorded AS (
+			SELECT
+				namespace,
+				COUNT(*) AS count,
+				SUM(amount) AS amount,
+				SUM(CASE WHEN fee_bearer = 'payee' THEN fee ELSE 0 END) AS fee,
+				SUM(gateway_fee) AS gateway_fee
+			FROM
+				transactions
+			WHERE
+				status = 'successful'
+			AND
+				method NOT IN ($2, $3)
+			AND
+				DATE(created_at) = $1
+			GROUP BY namespace
+		), paid AS (
+			SELECT
+				namespace,
+				COUNT(*) AS count,
+				SUM(amount) AS amount
+			FROM
+				payouts
+			WHERE
+				status = 'successful'
+			AND
+				DATE(updated_at) = $1
+			GROUP BY namespace
+		), active AS (
+			SELECT namespace FROM collected
+			UNION
+			SELECT namespace FROM recorded
+			UNION
+			SELECT namespace FROM paid
+		)
+		SELECT
+			active.namespace,
+			COALESCE(collected.count, 0),
+			COALESCE(collected.amount, 0),
+			COALESCE(recorded.count, 0),
+			COALESCE(recorded.amount, 0),
+			COALESCE(recorded.fee, 0),
+			COALESCE(recorded.gateway_fee, 0),
+			COALESCE(paid.count, 0),
+			COALESCE(paid.amount, 0)
+		FROM
+			active
+			LEFT JOIN collected USING (namespace)
+			LEFT JOIN recorded USING (namespace)
+			LEFT JOIN paid USING (namespace)
+		ORDER BY active.namespace
+	`
+
+	rows, err := repo.QueryContext(ctx, q, day.Format(settlements.DayLayout), payment.Cash, payment.Bank)
+	if err != nil {
+		return nil, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var out = []settlements.Settlement{}
+
+	for rows.Next() {
+		s := settlements.Settlement{Day: day}
+		if err := rows.Scan(
+			&s.Namespace,
+			&s.Payments,
+			&s.Collected,
+			&s.Transactions,
+			&s.Recorded,
+			&s.Fees,
+			&s.GatewayFees,
+			&s.Payouts,
+			&s.PaidOut,
+		); err != nil {
+			return nil, errors.E(op, err, errors.KindUnexpected)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (repo *settlementStore) Save(ctx context.Context, s *settlements.Settlement) error {
+	const op errors.Op = "store/postgres/settlementStore.Save"
+
+	// a settlement computed again replaces the earlier one but keeps its id
+	q := `
+		INSERT INTO settlements (
+			id,
+			namespace,
+			day,
+			payments,
+			collected,
+			transactions,
+			recorded,
+			fees,
+			gateway_fees,
+			payouts,
+			paid_out,
+			net,
+			status,
+			signature
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (namespace, day) DO UPDATE SET
+			payments=EXCLUDED.payments,
+			collected=EXCLUDED.collected,
+			transactions=EXCLUDED.transactions,
+			recorded=EXCLUDED.recorded,
+			fees=EXCLUDED.fees,
+			gateway_fees=EXCLUDED.gateway_fees,
+			payouts=EXCLUDED.payouts,
+			paid_out=EXCLUDED.paid_out,
+			net=EXCLUDED.net,
+			status=EXCLUDED.status,
+			signature=EXCLUDED.signature
+		RETURNING id, created_at, updated_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		s.ID,
+		s.Namespace,
+		s.Day.Format(settlements.DayLayout),
+		s.Payments,
+		s.Collected,
+		s.Transactions,
+		s.Recorded,
+		s.Fees,
+		s.GatewayFees,
+		s.Payouts,
+		s.PaidOut,
+		s.Net,
+		s.Status,
+		s.Signature,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errInvalid, errTruncation, errCheck:
+				return errors.E(op, "invalid settlement entity", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *settlementStore) Find(ctx context.Context, id string) (settlements.Settlement, error) {
+	const op errors.Op = "store/postgres/settlementStore.Find"
+
+	q := fmt.Sprintf(`%s WHERE id=$1`, selectSettlements)
+
+	var s settlements.Settlement
+
+	err := repo.QueryRowContext(ctx, q, id).Scan(settlementFields(&s)...)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && errInvalid == pqErr.Code.Name() {
+			return settlements.Settlement{}, errors.E(op, "settlement not found", errors.KindNotFound)
+		}
+		return settlements.Settlement{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return s, nil
+}
+
+func (repo *settlementStore) List(ctx context.Context, flts *settlements.Filters) (settlements.Page, error) {
+	const op errors.Op = "store/postgres/settlementStore.List"
+
+	where, args := settlementConditions(flts)
+
+	q := fmt.Sprintf(`%s %s ORDER BY day DESC, namespace OFFSET $%d LIMIT $%d`, selectSettlements, where, len(args)+1, len(args)+2)
+
+	var empty settlements.Page
+
+	rows, err := repo.QueryContext(ctx, q, append(args, flts.Offset, flts.Limit)...)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var items = []settlements.Settlement{}
+
+	for rows.Next() {
+		var s settlements.Settlement
+		if err := rows.Scan(settlementFields(&s)...); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		items = append(items, s)
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(net), 0.0) FROM settlements %s`, where)
+
+	var (
+		total  uint64
+		amount float64
+	)
+
+	if err := repo.QueryRowContext(ctx, q, args...).Scan(&total, &amount); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := settlements.Page{
+		Settlements: items,
+		PageMetadata: settlements.PageMetadata{
+			Total:  total,
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+			Amount: amount,
+		},
+	}
+	return page, nil
+}
+
+const selectSettlements = `
+	SELECT
+		id,
+		namespace,
+		day,
+		payments,
+		collected,
+		transactions,
+		recorded,
+		fees,
+		gateway_fees,
+		payouts,
+		paid_out,
+		net,
+		status,
+		signature,
+		created_at,
+		updated_at
+	FROM
+		settlements
+`
+
+func settlementFields(s *settlements.Settlement) []interface{} {
+	return []interface{}{
+		&s.ID,
+		&s.Namespace,
+		&s.Day,
+		&s.Payments,
+		&s.Collected,
+		&s.Transactions,
+		&s.Recorded,
+		&s.Fees,
+		&s.GatewayFees,
+		&s.Payouts,
+		&s.PaidOut,
+		&s.Net,
+		&s.Status,
+		&s.Signature,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	}
+}
+
+// settlementConditions builds the where clause of the settlement filters
+func settlementConditions(flts *settlements.Filters) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if flts.Namespace != nil {
+		add("namespace = $%d", *flts.Namespace)
+	}
+	if flts.Status != nil {
+		add("status = $%d", *flts.Status)
+	}
+	if flts.From != nil {
+		add("day >= $%d::date", *flts.From)
+	}
+	if flts.To != nil {
+		add("day <= $%d::date", *flts.To)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
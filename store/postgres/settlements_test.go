@@ -0,0 +1,100 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/settlements"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveSettlement(t *testing.T) {
+	repo := postgres.NewSettlementRepository(db)
+
+	defer CleanDB(t, db)
+
+	day := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	first := newSettlement("kigali", day)
+	err := repo.Save(context.Background(), &first)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	// the same day computed again keeps the first id
+	second := newSettlement("kigali", day)
+	second.Collected = 3000
+	err = repo.Save(context.Background(), &second)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, first.ID, second.ID, fmt.Sprintf("expected %s got %s", first.ID, second.ID))
+
+	saved, err := repo.Find(context.Background(), first.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, 3000.0, saved.Collected, fmt.Sprintf("expected %v got %v", 3000.0, saved.Collected))
+
+	invalid := newSettlement("kigali", day.AddDate(0, 0, 1))
+	invalid.Status = "unknown"
+	err = repo.Save(context.Background(), &invalid)
+	assert.Equal(t, errors.KindBadRequest, errors.Kind(err), fmt.Sprintf("expected kind %d got err: '%v'", errors.KindBadRequest, err))
+
+	_, err = repo.Find(context.Background(), uuid.New().ID())
+	assert.Equal(t, errors.KindNotFound, errors.Kind(err), fmt.Sprintf("expected kind %d got err: '%v'", errors.KindNotFound, err))
+}
+
+func TestListSettlements(t *testing.T) {
+	repo := postgres.NewSettlementRepository(db)
+
+	defer CleanDB(t, db)
+
+	day := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		s := newSettlement("kigali", day.AddDate(0, 0, i))
+		err := repo.Save(context.Background(), &s)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	}
+
+	from := "2020-10-02"
+
+	cases := []struct {
+		desc  string
+		flts  *settlements.Filters
+		total uint64
+	}{
+		{
+			desc:  "list every settlement",
+			flts:  &settlements.Filters{Limit: 10},
+			total: 3,
+		},
+		{
+			desc:  "list settlements from a day",
+			flts:  &settlements.Filters{From: &from, Limit: 10},
+			total: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.List(context.Background(), tc.flts)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d got %d", tc.desc, tc.total, page.Total))
+	}
+}
+
+func newSettlement(namespace string, day time.Time) settlements.Settlement {
+	s := settlements.Settlement{
+		ID:           uuid.New().ID(),
+		Namespace:    namespace,
+		Day:          day,
+		Payments:     1,
+		Collected:    1000,
+		Transactions: 1,
+		Recorded:     1000,
+	}
+	s.Balance()
+	s.Sign("secret")
+	return s
+}
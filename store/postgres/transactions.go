@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/accounts"
 	"github.com/nshimiyimanaamani/paypack-backend/core/auth"
 	"github.com/nshimiyimanaamani/paypack-backend/core/transactions"
 	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
@@ -74,6 +75,9 @@ func (repo *transactionsStore) RetrieveByID(ctx context.Context, id string) (tra
 			transactions.madefor,
 			transactions.invoice,
 			transactions.created_at, 
+			transactions.fee,
+			transactions.gateway_fee,
+			transactions.fee_bearer,
 			properties.sector, 
 			properties.cell, 
 			properties.village, 
@@ -99,6 +103,9 @@ func (repo *transactionsStore) RetrieveByID(ctx context.Context, id string) (tra
 		&tx.Invoice,
 		// &tx.Namespace,
 		&tx.DateRecorded,
+		&tx.Fees,
+		&tx.GatewayFee,
+		&tx.FeeBearer,
 		&tx.Sector,
 		&tx.Cell,
 		&tx.Village,
@@ -116,6 +123,7 @@ func (repo *transactionsStore) RetrieveByID(ctx context.Context, id string) (tra
 		}
 		return empty, errors.E(op, err, errors.KindUnexpected)
 	}
+	tx.Net = accounts.Net(tx.Amount, tx.Fees, accounts.FeeBearer(tx.FeeBearer))
 	return tx, nil
 }
 func (repo *transactionsStore) RetrieveAll(ctx context.Context, offset uint64, limit uint64) (transactions.TransactionPage, error) {
@@ -130,7 +138,8 @@ func (repo *transactionsStore) RetrieveAll(ctx context.Context, offset uint64, l
 	selectQuery := `
 	SELECT 
 		transactions.id, transactions.amount,transactions.method, transactions.madefor,
-		transactions.invoice, transactions.created_at, properties.sector, properties.cell, 
+		transactions.invoice, transactions.created_at, transactions.fee, transactions.gateway_fee,
+		transactions.fee_bearer, properties.sector, properties.cell, 
 		properties.village, owners.id, owners.fname, owners.lname
 	FROM 
 		transactions
@@ -180,6 +189,9 @@ func (repo *transactionsStore) RetrieveAll(ctx context.Context, offset uint64, l
 			&tx.MadeFor,
 			&tx.Invoice,
 			&tx.DateRecorded,
+			&tx.Fees,
+			&tx.GatewayFee,
+			&tx.FeeBearer,
 			&tx.Sector,
 			&tx.Cell,
 			&tx.Village,
@@ -190,7 +202,7 @@ func (repo *transactionsStore) RetrieveAll(ctx context.Context, offset uint64, l
 		if err != nil {
 			return transactions.TransactionPage{}, errors.E(op, err, errors.KindUnexpected)
 		}
-		tx.Fees = tx.Amount * transactions.RateFee
+		tx.Net = accounts.Net(tx.Amount, tx.Fees, accounts.FeeBearer(tx.FeeBearer))
 		out = append(out, tx)
 	}
 
@@ -452,3 +464,92 @@ func (repo *transactionsStore) RetrieveByPropertyR(ctx context.Context, property
 	}
 	return page, nil
 }
+
+func (repo *transactionsStore) RetrieveReceipt(ctx context.Context, id string) (transactions.Receipt, error) {
+	const op errors.Op = "store/postgres/transactionsRepository.RetrieveReceipt"
+
+	q := `
+		SELECT
+			transactions.id,
+			transactions.ref,
+			transactions.madefor,
+			transactions.method,
+			transactions.namespace,
+			transactions.created_at,
+			properties.sector,
+			properties.cell,
+			properties.village,
+			owners.fname,
+			owners.lname,
+			COALESCE((SELECT msisdn FROM payments WHERE payments.ref = transactions.ref LIMIT 1), '')
+		FROM
+			transactions
+		INNER JOIN
+			properties ON transactions.madefor=properties.id
+		INNER JOIN
+			owners ON transactions.madeby=owners.id
+		WHERE transactions.id = $1
+	`
+
+	var (
+		receipt      transactions.Receipt
+		fname, lname string
+	)
+
+	err := repo.QueryRowContext(ctx, q, id).Scan(
+		&receipt.ID,
+		&receipt.Ref,
+		&receipt.Property,
+		&receipt.Method,
+		&receipt.Namespace,
+		&receipt.PaidAt,
+		&receipt.Sector,
+		&receipt.Cell,
+		&receipt.Village,
+		&fname,
+		&lname,
+		&receipt.MSISDN,
+	)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && errInvalid == pqErr.Code.Name() {
+			return transactions.Receipt{}, errors.E(op, err, "transaction not found", errors.KindNotFound)
+		}
+		return transactions.Receipt{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	receipt.Owner = fmt.Sprintf("%s %s", fname, lname)
+
+	// a payment covering several months is recorded as one transaction
+	// per invoice sharing the payment ref
+	q = `
+		SELECT
+			transactions.invoice,
+			COALESCE(invoices.created_at, transactions.created_at),
+			transactions.amount
+		FROM
+			transactions
+		LEFT JOIN
+			invoices ON transactions.invoice=invoices.id
+		WHERE transactions.ref = $1 AND transactions.madefor = $2
+		ORDER BY 2, 1
+	`
+
+	rows, err := repo.QueryContext(ctx, q, receipt.Ref, receipt.Property)
+	if err != nil {
+		return transactions.Receipt{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item transactions.ReceiptItem
+		if err := rows.Scan(&item.Invoice, &item.Month, &item.Amount); err != nil {
+			return transactions.Receipt{}, errors.E(op, err, errors.KindUnexpected)
+		}
+		receipt.Amount += item.Amount
+		receipt.Items = append(receipt.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return transactions.Receipt{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return receipt, nil
+}
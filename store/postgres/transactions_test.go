@@ -579,3 +579,100 @@ func TestRetrieveByMethod(t *testing.T) {
 		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
 	}
 }
+
+func TestRetrieveReceipt(t *testing.T) {
+	repo := postgres.NewTransactionRepository(db)
+
+	defer CleanDB(t, db)
+
+	account := accounts.Account{
+		ID:            "paypack.developers",
+		Name:          "remera",
+		NumberOfSeats: 10,
+		Type:          accounts.Devs,
+	}
+	account = saveAccount(t, db, account)
+
+	agent := users.Agent{
+		Telephone: random(15),
+		FirstName: "first",
+		LastName:  "last",
+		Password:  "password",
+		Cell:      "cell",
+		Sector:    "Sector",
+		Village:   "village",
+		Role:      users.Dev,
+		Account:   account.ID,
+	}
+
+	agent = saveAgent(t, db, agent)
+
+	owner := properties.Owner{
+		ID:    uuid.New().ID(),
+		Fname: "rugwiro",
+		Lname: "james",
+		Phone: "0784677882",
+	}
+	owner = saveOwner(t, db, owner)
+
+	property := properties.Property{
+		ID:         nanoid.New(nil).ID(),
+		Owner:      properties.Owner{ID: owner.ID},
+		Due:        float64(1000),
+		Namespace:  account.ID,
+		RecordedBy: agent.Telephone,
+		Occupied:   true,
+	}
+	property = saveProperty(t, db, property)
+
+	invoice := retrieveInvoice(t, db, property.ID)
+
+	method := "kcb"
+
+	transaction := transactions.Transaction{
+		ID:        uuid.New().ID(),
+		OwnerID:   owner.ID,
+		MadeFor:   property.ID,
+		Amount:    invoice.Amount,
+		Method:    method,
+		Invoice:   invoice.ID,
+		Namespace: account.ID,
+	}
+	saveTx(t, db, transaction)
+
+	const op errors.Op = "store/postgres/transactionsRepository.RetrieveReceipt"
+
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "retrieve receipt of existing transaction",
+			id:   transaction.ID,
+			err:  nil,
+		},
+		{
+			desc: "retrieve receipt of non existing transaction",
+			id:   uuid.New().ID(),
+			err:  errors.E(op, "transaction not found", errors.KindNotFound),
+		},
+		{
+			desc: "retrieve receipt with malformed id",
+			id:   wrongValue,
+			err:  errors.E(op, "transaction not found", errors.KindNotFound),
+		},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		receipt, err := repo.RetrieveReceipt(ctx, tc.id)
+		assert.True(t, errors.Match(tc.err, err), fmt.Sprintf("%s: expected err: '%v' got err: '%v'", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, property.ID, receipt.Property, tc.desc)
+			assert.Equal(t, "rugwiro james", receipt.Owner, tc.desc)
+			assert.Equal(t, invoice.Amount, receipt.Amount, tc.desc)
+			assert.Len(t, receipt.Items, 1, tc.desc)
+		}
+	}
+}
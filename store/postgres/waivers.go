@@ -0,0 +1,286 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (waivers.Repository) = (*waiverStore)(nil)
+
+type waiverStore struct {
+	*sql.DB
+}
+
+// NewWaiverRepository creates a postgres backed waivers.Repository
+func NewWaiverRepository(db *sql.DB) waivers.Repository {
+	return &waiverStore{db}
+}
+
+func (repo *waiverStore) Save(ctx context.Context, w *waivers.Waiver) error {
+	const op errors.Op = "store/postgres/waiverStore.Save"
+
+	q := `
+		INSERT INTO waivers (
+			id,
+			property,
+			namespace,
+			percent,
+			starts_on,
+			ends_on,
+			reason,
+			status,
+			requested_by
+		) VALUES ($1, $2, $3, $4, $5::date, $6::date, $7, $8, $9)
+		RETURNING created_at, updated_at
+	`
+
+	err := repo.QueryRowContext(ctx, q,
+		w.ID,
+		w.Property,
+		w.Namespace,
+		w.Percent,
+		w.From.Format(waivers.DayLayout),
+		w.To.Format(waivers.DayLayout),
+		w.Reason,
+		w.Status,
+		w.RequestedBy,
+	).Scan(&w.CreatedAt, &w.UpdatedAt)
+
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errDuplicate:
+				return errors.E(op, "waiver already exists", errors.KindAlreadyExists)
+			case errFK:
+				return errors.E(op, "property not found", errors.KindNotFound)
+			case errInvalid, errTruncation, errCheck:
+				return errors.E(op, "invalid waiver entity", errors.KindBadRequest)
+			}
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (repo *waiverStore) Find(ctx context.Context, id string) (waivers.Waiver, error) {
+	const op errors.Op = "store/postgres/waiverStore.Find"
+
+	q := `
+		SELECT
+			id,
+			property,
+			namespace,
+			percent,
+			starts_on,
+			ends_on,
+			reason,
+			status,
+			note,
+			requested_by,
+			reviewed_by,
+			created_at,
+			updated_at
+		FROM
+			waivers
+		WHERE id=$1
+	`
+
+	var w waivers.Waiver
+
+	err := repo.QueryRowContext(ctx, q, id).Scan(
+		&w.ID,
+		&w.Property,
+		&w.Namespace,
+		&w.Percent,
+		&w.From,
+		&w.To,
+		&w.Reason,
+		&w.Status,
+		&w.Note,
+		&w.RequestedBy,
+		&w.ReviewedBy,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && errInvalid == pqErr.Code.Name() {
+			return waivers.Waiver{}, errors.E(op, "waiver not found", errors.KindNotFound)
+		}
+		return waivers.Waiver{}, errors.E(op, err, errors.KindUnexpected)
+	}
+	return w, nil
+}
+
+func (repo *waiverStore) List(ctx context.Context, flts *waivers.Filters) (waivers.Page, error) {
+	const op errors.Op = "store/postgres/waiverStore.List"
+
+	where, args := waiverConditions(flts)
+
+	q := fmt.Sprintf(`
+		SELECT
+			id,
+			property,
+			namespace,
+			percent,
+			starts_on,
+			ends_on,
+			reason,
+			status,
+			note,
+			requested_by,
+			reviewed_by,
+			created_at,
+			updated_at
+		FROM
+			waivers
+		%s
+		ORDER BY created_at DESC OFFSET $%d LIMIT $%d
+	`, where, len(args)+1, len(args)+2)
+
+	var empty waivers.Page
+
+	rows, err := repo.QueryContext(ctx, q, append(args, flts.Offset, flts.Limit)...)
+	if err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+	defer rows.Close()
+
+	var items = []waivers.Waiver{}
+
+	for rows.Next() {
+		var w waivers.Waiver
+		if err := rows.Scan(
+			&w.ID,
+			&w.Property,
+			&w.Namespace,
+			&w.Percent,
+			&w.From,
+			&w.To,
+			&w.Reason,
+			&w.Status,
+			&w.Note,
+			&w.RequestedBy,
+			&w.ReviewedBy,
+			&w.CreatedAt,
+			&w.UpdatedAt,
+		); err != nil {
+			return empty, errors.E(op, err, errors.KindUnexpected)
+		}
+		items = append(items, w)
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*) FROM waivers %s`, where)
+
+	var total uint64
+
+	if err := repo.QueryRowContext(ctx, q, args...).Scan(&total); err != nil {
+		return empty, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	page := waivers.Page{
+		Waivers: items,
+		PageMetadata: waivers.PageMetadata{
+			Total:  total,
+			Offset: flts.Offset,
+			Limit:  flts.Limit,
+		},
+	}
+	return page, nil
+}
+
+func (repo *waiverStore) Review(ctx context.Context, w waivers.Waiver) error {
+	const op errors.Op = "store/postgres/waiverStore.Review"
+
+	tx, err := repo.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	defer tx.Rollback()
+
+	q := `
+		UPDATE waivers SET status=$1, reviewed_by=$2, note=$3 WHERE id=$4 AND status=$5
+		RETURNING property, percent, starts_on, ends_on
+	`
+
+	err = tx.QueryRowContext(ctx, q, w.Status, w.ReviewedBy, w.Note, w.ID, waivers.Pending).
+		Scan(&w.Property, &w.Percent, &w.From, &w.To)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.E(op, "waiver was already reviewed", errors.KindAlreadyExists)
+		}
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+
+	if w.Status != waivers.Approved {
+		return tx.Commit()
+	}
+
+	// invoices that already exist are discounted now, the larger waiver
+	// wins when several cover the same period
+	q = `
+		UPDATE invoices SET
+			waived=discounted.waived,
+			status=CASE
+				WHEN invoices.paid + discounted.waived < invoices.amount THEN invoices.status
+				WHEN invoices.paid > 0 THEN 'payed'
+				ELSE 'waived'
+			END
+		FROM (
+			SELECT
+				id, GREATEST(waived, LEAST(amount - paid, ROUND(amount * $2 / 100, 2))) AS waived
+			FROM
+				invoices
+			WHERE
+				property=$1 AND status IN ('pending', 'partially_paid', 'expired') AND penalty_of IS NULL
+			AND
+				period_start BETWEEN $3::date AND $4::date
+		) AS discounted
+		WHERE invoices.id=discounted.id AND discounted.waived > invoices.waived
+	`
+
+	_, err = tx.ExecContext(ctx, q,
+		w.Property,
+		w.Percent,
+		w.From.Format(waivers.DayLayout),
+		w.To.Format(waivers.DayLayout),
+	)
+	if err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return tx.Commit()
+}
+
+func waiverConditions(flts *waivers.Filters) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if flts.Namespace != nil {
+		add("namespace = $%d", *flts.Namespace)
+	}
+	if flts.Property != nil {
+		add("property = $%d", *flts.Property)
+	}
+	if flts.Status != nil {
+		add("status = $%d", *flts.Status)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,137 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/invoices"
+	"github.com/nshimiyimanaamani/paypack-backend/core/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/waivers"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+	"github.com/nshimiyimanaamani/paypack-backend/store/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWaiver(property, namespace string, percent float64) *waivers.Waiver {
+	now := time.Now()
+	return &waivers.Waiver{
+		ID:          uuid.New().ID(),
+		Property:    property,
+		Namespace:   namespace,
+		Percent:     percent,
+		From:        now.AddDate(0, -1, 0),
+		To:          now.AddDate(0, 1, 0),
+		Reason:      "ubudehe category 1",
+		Status:      waivers.Pending,
+		RequestedBy: "agent",
+	}
+}
+
+func TestSaveWaiver(t *testing.T) {
+	repo := postgres.NewWaiverRepository(db)
+
+	defer CleanDB(t, db)
+
+	property := savePayableProperty(t)
+
+	cases := []struct {
+		desc   string
+		waiver *waivers.Waiver
+		kind   int
+	}{
+		{
+			desc:   "save waiver",
+			waiver: newWaiver(property.ID, property.Namespace, 100),
+		},
+		{
+			desc:   "save waiver of unknown property",
+			waiver: newWaiver(uuid.New().ID(), property.Namespace, 100),
+			kind:   errors.KindNotFound,
+		},
+		{
+			desc:   "save waiver above 100 percent",
+			waiver: newWaiver(property.ID, property.Namespace, 150),
+			kind:   errors.KindBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Save(context.Background(), tc.waiver)
+		if tc.kind == 0 {
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+			continue
+		}
+		assert.Equal(t, tc.kind, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, tc.kind, err))
+	}
+}
+
+func TestReviewWaiver(t *testing.T) {
+	repo := postgres.NewWaiverRepository(db)
+
+	defer CleanDB(t, db)
+
+	ctx := context.Background()
+
+	exempted := savePayableProperty(t)
+	discounted := savePayableProperty(t)
+
+	// invoices of the current period exist before the waivers are approved
+	for _, property := range []string{exempted.ID, discounted.ID} {
+		saveInvoice(t, db, invoices.Invoice{Amount: 1000, Property: property, Status: invoices.Pending, CreatedAt: time.Now(), UpdatedAt: time.Now()})
+	}
+
+	cases := []struct {
+		desc    string
+		waiver  *waivers.Waiver
+		status  invoices.Status
+		waived  float64
+		balance float64
+	}{
+		{
+			desc:    "approve exemption",
+			waiver:  newWaiver(exempted.ID, exempted.Namespace, 100),
+			status:  invoices.Waived,
+			waived:  1000,
+			balance: 0,
+		},
+		{
+			desc:    "approve partial waiver",
+			waiver:  newWaiver(discounted.ID, discounted.Namespace, 25),
+			status:  invoices.Pending,
+			waived:  250,
+			balance: 750,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Save(ctx, tc.waiver)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		tc.waiver.Status = waivers.Approved
+		tc.waiver.ReviewedBy = "manager"
+
+		err = repo.Review(ctx, *tc.waiver)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		err = repo.Review(ctx, *tc.waiver)
+		assert.Equal(t, errors.KindAlreadyExists, errors.Kind(err), fmt.Sprintf("%s: expected kind %d got err: '%v'", tc.desc, errors.KindAlreadyExists, err))
+
+		var inv invoices.Invoice
+		q := `SELECT amount, paid, waived, status FROM invoices WHERE property=$1`
+		err = db.QueryRow(q, tc.waiver.Property).Scan(&inv.Amount, &inv.Paid, &inv.Waived, &inv.Status)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+
+		assert.Equal(t, tc.status, inv.Status, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.status, inv.Status))
+		assert.Equal(t, tc.waived, inv.Waived, fmt.Sprintf("%s: expected %f got %f", tc.desc, tc.waived, inv.Waived))
+		assert.Equal(t, tc.balance, inv.Balance(), fmt.Sprintf("%s: expected %f got %f", tc.desc, tc.balance, inv.Balance()))
+	}
+
+	approved := string(waivers.Approved)
+
+	page, err := repo.List(ctx, &waivers.Filters{Status: &approved, Limit: 10})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.Equal(t, uint64(2), page.Total, fmt.Sprintf("expected %d waivers got %d", 2, page.Total))
+}
@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/encoding"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.IdempotencyStore) = (*idempotencyStore)(nil)
+
+const idempotencyPrefix = "idempotency:"
+
+type idempotencyStore struct {
+	cli *redis.Client
+}
+
+// NewIdempotencyStore initialises a redis backed payment.IdempotencyStore
+func NewIdempotencyStore(client *redis.Client) payment.IdempotencyStore {
+	return &idempotencyStore{client}
+}
+
+func (store *idempotencyStore) Reserve(ctx context.Context, key string, rec payment.IdempotentRecord) (payment.IdempotentRecord, bool, error) {
+	const op errors.Op = "idempotencyStore.Reserve"
+
+	empty := payment.IdempotentRecord{}
+
+	b, err := encoding.Encode(ctx, rec)
+	if err != nil {
+		return empty, false, errors.E(op, err)
+	}
+
+	ok, err := store.cli.SetNX(idempotencyPrefix+key, b, payment.IdempotencyExpiration).Result()
+	if err != nil {
+		return empty, false, errors.E(op, err, errors.KindUnexpected)
+	}
+	if ok {
+		return rec, true, nil
+	}
+
+	res, err := store.cli.Get(idempotencyPrefix + key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return empty, false, errors.E(op, "idempotency key expired while reserving", errors.KindUnexpected)
+		}
+		return empty, false, errors.E(op, err, errors.KindUnexpected)
+	}
+
+	var prev payment.IdempotentRecord
+	if err := encoding.Decode(ctx, []byte(res), &prev); err != nil {
+		return empty, false, errors.E(op, err, "unable to deserialize idempotent record", errors.KindUnexpected)
+	}
+	return prev, false, nil
+}
+
+func (store *idempotencyStore) Save(ctx context.Context, key string, rec payment.IdempotentRecord) error {
+	const op errors.Op = "idempotencyStore.Save"
+
+	b, err := encoding.Encode(ctx, rec)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if _, err := store.cli.Set(idempotencyPrefix+key, b, payment.IdempotencyExpiration).Result(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
+
+func (store *idempotencyStore) Release(ctx context.Context, key string) error {
+	const op errors.Op = "idempotencyStore.Release"
+
+	if _, err := store.cli.Del(idempotencyPrefix + key).Result(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/store/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyReserve(t *testing.T) {
+	store := redis.NewIdempotencyStore(redisClient)
+
+	key := uuid.New().ID()
+	rec := payment.IdempotentRecord{Fingerprint: "fingerprint"}
+
+	cases := []struct {
+		desc     string
+		key      string
+		reserved bool
+	}{
+		{
+			desc:     "reserve new key",
+			key:      key,
+			reserved: true,
+		},
+		{
+			desc:     "reserve already reserved key",
+			key:      key,
+			reserved: false,
+		},
+	}
+
+	for _, tc := range cases {
+		prev, reserved, err := store.Reserve(context.Background(), tc.key, rec)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.reserved, reserved, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.reserved, reserved))
+		assert.Equal(t, rec, prev, fmt.Sprintf("%s: expected %v got %v", tc.desc, rec, prev))
+	}
+}
+
+func TestIdempotencySave(t *testing.T) {
+	store := redis.NewIdempotencyStore(redisClient)
+
+	key := uuid.New().ID()
+	rec := payment.IdempotentRecord{Fingerprint: "fingerprint"}
+
+	_, _, err := store.Reserve(context.Background(), key, rec)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	rec.Response = &payment.TxResponse{TxID: uuid.New().ID(), TxState: "processing"}
+	err = store.Save(context.Background(), key, rec)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	prev, reserved, err := store.Reserve(context.Background(), key, payment.IdempotentRecord{})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.False(t, reserved, "saved key should not be reserved again")
+	assert.Equal(t, rec, prev, fmt.Sprintf("expected %v got %v", rec, prev))
+}
+
+func TestIdempotencyRelease(t *testing.T) {
+	store := redis.NewIdempotencyStore(redisClient)
+
+	key := uuid.New().ID()
+	rec := payment.IdempotentRecord{Fingerprint: "fingerprint"}
+
+	_, _, err := store.Reserve(context.Background(), key, rec)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	err = store.Release(context.Background(), key)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	_, reserved, err := store.Reserve(context.Background(), key, rec)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.True(t, reserved, "released key should be reservable again")
+}
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/nshimiyimanaamani/paypack-backend/core/payment"
+	"github.com/nshimiyimanaamani/paypack-backend/pkg/errors"
+)
+
+var _ (payment.ReplayCache) = (*replayCache)(nil)
+
+const replayPrefix = "callbacks:"
+
+type replayCache struct {
+	cli *redis.Client
+}
+
+// NewReplayCache initialises a redis backed payment.ReplayCache
+func NewReplayCache(client *redis.Client) payment.ReplayCache {
+	return &replayCache{client}
+}
+
+func (cache *replayCache) Claim(ctx context.Context, event string, ttl time.Duration) (bool, error) {
+	const op errors.Op = "replayCache.Claim"
+
+	ok, err := cache.cli.SetNX(replayPrefix+event, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, errors.E(op, err, errors.KindUnexpected)
+	}
+	return ok, nil
+}
+
+func (cache *replayCache) Release(ctx context.Context, event string) error {
+	const op errors.Op = "replayCache.Release"
+
+	if _, err := cache.cli.Del(replayPrefix + event).Result(); err != nil {
+		return errors.E(op, err, errors.KindUnexpected)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nshimiyimanaamani/paypack-backend/core/identity/uuid"
+	"github.com/nshimiyimanaamani/paypack-backend/store/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayClaim(t *testing.T) {
+	cache := redis.NewReplayCache(redisClient)
+
+	event := uuid.New().ID()
+
+	cases := []struct {
+		desc    string
+		event   string
+		claimed bool
+	}{
+		{
+			desc:    "claim new event",
+			event:   event,
+			claimed: true,
+		},
+		{
+			desc:    "claim already claimed event",
+			event:   event,
+			claimed: false,
+		},
+	}
+
+	for _, tc := range cases {
+		claimed, err := cache.Claim(context.Background(), tc.event, time.Minute)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: '%v'", tc.desc, err))
+		assert.Equal(t, tc.claimed, claimed, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.claimed, claimed))
+	}
+}
+
+func TestReplayRelease(t *testing.T) {
+	cache := redis.NewReplayCache(redisClient)
+
+	event := uuid.New().ID()
+
+	_, err := cache.Claim(context.Background(), event, time.Minute)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	err = cache.Release(context.Background(), event)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+
+	claimed, err := cache.Claim(context.Background(), event, time.Minute)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: '%v'", err))
+	assert.True(t, claimed, "released event should be claimable again")
+}